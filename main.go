@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -14,6 +15,7 @@ import (
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/felixge/fgprof"
@@ -24,6 +26,7 @@ import (
 
 	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/cache/simple"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/catalog"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/cleantemp"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/config"
@@ -32,6 +35,7 @@ import (
 	"github.com/trufflesecurity/trufflehog/v3/pkg/engine"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/engine/defaults"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/feature"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/gitstate"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/log"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/output"
@@ -54,6 +58,9 @@ var (
 	jsonOut             = cli.Flag("json", "以 JSON 格式输出。").Short('j').Bool()
 	jsonLegacy          = cli.Flag("json-legacy", "使用预 v3.0 的 JSON 格式。仅适用于 git、gitlab 和 github 来源。").Bool()
 	gitHubActionsFormat = cli.Flag("github-actions", "以 GitHub Actions 格式输出。").Bool()
+	redact              = cli.Flag("redact", "在所有输出格式中用遮蔽后的值替换秘密，并从额外数据和原始数据中清除秘密。与 --hash-secrets 不兼容。").Bool()
+	hashSecrets         = cli.Flag("hash-secrets", "包含 HMAC 密钥的文件路径。在所有输出格式中用带密钥的 HMAC 替换秘密，以便跨运行关联结果。与 --redact 不兼容。").PlaceHolder("HMAC-KEY-FILE").ExistingFile()
+	summaryFormat       = cli.Flag("summary", "扫描结束后打印汇总：按检测器、来源单元和解码器统计已验证、未验证和未知结果，列出结果最多的文件并按类型汇总扫描错误。可选值：table、json。").Enum("table", "json")
 	concurrency         = cli.Flag("concurrency", "并发工作线程数。").Default(strconv.Itoa(runtime.NumCPU())).Int()
 	noVerification      = cli.Flag("no-verification", "不验证结果。").Bool()
 	onlyVerified        = cli.Flag("only-verified", "仅输出已验证的结果。").Hidden().Bool()
@@ -81,6 +88,17 @@ var (
 
 	noVerificationCache = cli.Flag("no-verification-cache", "禁用验证缓存").Bool()
 
+	webhookURLs          = cli.Flag("webhook", "在扫描过程中将结果 POST 到的 HTTP 端点。可重复指定。").PlaceHolder("URL").Strings()
+	webhookHeaders       = cli.Flag("webhook-header", "发送到 webhook 端点的请求头，格式为 'Key: Value'。可重复指定。").Strings()
+	webhookFormat        = cli.Flag("webhook-format", "webhook 请求体格式：generic（JSON）、chat（聊天 webhook）或 ticket（工单）。").Default(engine.HTTPFormatGeneric).Enum(engine.HTTPFormatGeneric, engine.HTTPFormatChat, engine.HTTPFormatTicket)
+	webhookTemplate      = cli.Flag("webhook-template", "自定义 webhook 请求体的 Go 模板文件路径，优先于 --webhook-format。").ExistingFile()
+	webhookBatchSize     = cli.Flag("webhook-batch-size", "每个 webhook 请求包含的最大结果数。").Default("1").Int()
+	webhookFlushInterval = cli.Flag("webhook-flush-interval", "批处理时结果等待发送的最长时间。").Default("5s").Duration()
+	webhookMaxRetries    = cli.Flag("webhook-max-retries", "webhook 请求失败时的最大重试次数。").Default("3").Int()
+	webhookSpoolFile     = cli.Flag("webhook-spool-file", "保存无法投递的 webhook 请求的文件路径，下次运行时将重新发送。").String()
+	webhookResults       = cli.Flag("webhook-results", "发送到 webhook 的结果类型：verified、unknown、unverified。默认发送所有类型。").String()
+	webhookDetectors     = cli.Flag("webhook-detectors", "发送到 webhook 的检测器类型列表，逗号分隔，语法与 --include-detectors 相同。默认发送所有检测器的结果。").String()
+
 	// 添加功能标志
 	forceSkipBinaries  = cli.Flag("force-skip-binaries", "强制跳过二进制文件。").Bool()
 	forceSkipArchives  = cli.Flag("force-skip-archives", "强制跳过归档文件。").Bool()
@@ -96,6 +114,8 @@ var (
 	gitScanBranch       = gitScan.Flag("branch", "扫描指定分支。").String()
 	gitScanMaxDepth     = gitScan.Flag("max-depth", "扫描的最大提交深度。").Int()
 	gitScanBare         = gitScan.Flag("bare", "扫描裸仓库（例如，适用于 pre-receive 钩子时使用）。").Bool()
+	gitScanUnreachable  = gitScan.Flag("scan-unreachable", "同时扫描储藏(stash)、reflog、refs/notes、refs/pull/* 等隐藏引用以及悬空对象。").Bool()
+	gitScanStateFile    = gitScan.Flag("state-file", "记录每个仓库和引用上次扫描的提交的状态文件。再次扫描时只扫描新的提交。").String()
 	_                   = gitScan.Flag("allow", "无操作标志，仅为向后兼容。").Bool()
 	_                   = gitScan.Flag("entropy", "无操作标志，仅为向后兼容。").Bool()
 	_                   = gitScan.Flag("regex", "无操作标志，仅为向后兼容。").Bool()
@@ -115,6 +135,9 @@ var (
 	githubScanPRComments        = githubScan.Flag("pr-comments", "在扫描中包括拉取请求描述和评论。").Bool()
 	githubScanGistComments      = githubScan.Flag("gist-comments", "在扫描中包括gist评论。").Bool()
 	githubCommentsTimeframeDays = githubScan.Flag("comments-timeframe", "在扫描问题、PR和gist评论时回顾的天数。").Uint32()
+	githubScanActionsLogs       = githubScan.Flag("include-actions-logs", "在扫描中包括GitHub Actions工作流运行的日志。").Bool()
+	githubScanArtifacts         = githubScan.Flag("include-artifacts", "在扫描中包括GitHub Actions工作流运行的未过期产物。").Bool()
+	githubScanStateFile         = githubScan.Flag("state-file", "记录每个仓库和引用上次扫描的提交的状态文件。再次扫描时只扫描新的提交。").String()
 	
 	// GitHub跨分支对象引用实验特性
 	githubExperimentalScan = cli.Command("github-experimental", "运行一个实验性的GitHub扫描。必须至少指定一个实验性子模块进行扫描：object-discovery。")
@@ -135,6 +158,147 @@ var (
 	gitlabScanExcludePaths = gitlabScan.Flag("exclude-paths", "排除要扫描的文件的正则表达式的路径，每个正则表达式一行。").Short('x').String()
 	gitlabScanIncludeRepos = gitlabScan.Flag("include-repos", `在组织扫描中包含的仓库。也可以是一个glob模式。你可以多次使用这个标志。必须使用Gitlab仓库的完整名称。示例： "trufflesecurity/trufflehog", "trufflesecurity/t*"`).Strings()
 	gitlabScanExcludeRepos = gitlabScan.Flag("exclude-repos", `在组织扫描中排除的仓库。也可以是一个glob模式。你可以多次使用这个标志。必须使用Gitlab仓库的完整名称。示例： "trufflesecurity/driftwood", "trufflesecurity/d*"`).Strings()
+	gitlabScanStateFile    = gitlabScan.Flag("state-file", "记录每个仓库和引用上次扫描的提交的状态文件。再次扫描时只扫描新的提交。").String()
+	
+	bitbucketScan             = cli.Command("bitbucket", "在Bitbucket Cloud或Bitbucket Data Center仓库中查找凭据。")
+	bitbucketScanEndpoint     = bitbucketScan.Flag("endpoint", "Bitbucket Data Center端点。留空以扫描Bitbucket Cloud。").String()
+	bitbucketScanToken        = bitbucketScan.Flag("token", "Bitbucket访问令牌。可以通过环境变量BITBUCKET_TOKEN提供。").Envar("BITBUCKET_TOKEN").String()
+	bitbucketScanOAuthToken   = bitbucketScan.Flag("oauth-token", "Bitbucket OAuth访问令牌。可以通过环境变量BITBUCKET_OAUTH_TOKEN提供。").Envar("BITBUCKET_OAUTH_TOKEN").String()
+	bitbucketScanUsername     = bitbucketScan.Flag("username", "用于基本认证的Bitbucket用户名。可以通过环境变量BITBUCKET_USERNAME提供。").Envar("BITBUCKET_USERNAME").String()
+	bitbucketScanPassword     = bitbucketScan.Flag("password", "用于基本认证的Bitbucket密码或应用密码。可以通过环境变量BITBUCKET_PASSWORD提供。").Envar("BITBUCKET_PASSWORD").String()
+	bitbucketScanRepos        = bitbucketScan.Flag("repo", "Bitbucket仓库url。你可以多次使用这个标志。留空以扫描提供凭证的所有仓库。示例： https://bitbucket.org/workspace/repo.git").Strings()
+	bitbucketScanWorkspaces   = bitbucketScan.Flag("workspace", "要扫描的Bitbucket Cloud工作区或Data Center项目键。你可以多次使用这个标志。留空以扫描所有可访问的工作区。").Strings()
+	bitbucketScanProjects     = bitbucketScan.Flag("project", "仅扫描这些项目键中的仓库。你可以多次使用这个标志。").Strings()
+	bitbucketScanIncludeRepos = bitbucketScan.Flag("include-repos", `在扫描中包含的仓库。也可以是一个glob模式。你可以多次使用这个标志。必须使用仓库的完整名称。示例： "workspace/repo", "workspace/r*"`).Strings()
+	bitbucketScanExcludeRepos = bitbucketScan.Flag("exclude-repos", `在扫描中排除的仓库。也可以是一个glob模式。你可以多次使用这个标志。必须使用仓库的完整名称。示例： "workspace/legacy", "PROJ/l*"`).Strings()
+	bitbucketScanIncludePaths = bitbucketScan.Flag("include-paths", "包含要扫描的文件的正则表达式的路径，每个正则表达式一行。").Short('i').String()
+	bitbucketScanExcludePaths = bitbucketScan.Flag("exclude-paths", "排除要扫描的文件的正则表达式的路径，每个正则表达式一行。").Short('x').String()
+	bitbucketScanPRComments   = bitbucketScan.Flag("pr-comments", "在扫描中包括拉取请求描述和评论。").Bool()
+	
+	azureReposScan                = cli.Command("azure-repos", "在Azure DevOps仓库中查找凭据。")
+	azureReposScanEndpoint        = azureReposScan.Flag("endpoint", "Azure DevOps Server端点。留空以扫描Azure DevOps Services。").String()
+	azureReposScanToken           = azureReposScan.Flag("token", "Azure DevOps个人访问令牌。可以通过环境变量AZURE_DEVOPS_TOKEN提供。").Envar("AZURE_DEVOPS_TOKEN").String()
+	azureReposScanOAuthToken      = azureReposScan.Flag("oauth-token", "Azure DevOps OAuth访问令牌。可以通过环境变量AZURE_DEVOPS_OAUTH_TOKEN提供。").Envar("AZURE_DEVOPS_OAUTH_TOKEN").String()
+	azureReposScanRepos           = azureReposScan.Flag("repo", "Azure Repos仓库url。你可以多次使用这个标志。示例： https://dev.azure.com/org/project/_git/repo").Strings()
+	azureReposScanOrgs            = azureReposScan.Flag("org", "要扫描的Azure DevOps组织（或Azure DevOps Server集合）。你可以多次使用这个标志。留空以扫描所有可访问的组织。").Strings()
+	azureReposScanProjects        = azureReposScan.Flag("project", "要扫描的项目。你可以多次使用这个标志。留空以扫描组织中的所有项目。").Strings()
+	azureReposScanIncludeForks    = azureReposScan.Flag("include-forks", "在扫描中包含分支仓库。").Bool()
+	azureReposScanIncludeRepos    = azureReposScan.Flag("include-repos", `在扫描中包含的仓库。也可以是一个glob模式。你可以多次使用这个标志。必须使用"项目/仓库"格式。示例： "Platform/api", "Platform/a*"`).Strings()
+	azureReposScanExcludeRepos    = azureReposScan.Flag("exclude-repos", `在扫描中排除的仓库。也可以是一个glob模式。你可以多次使用这个标志。必须使用"项目/仓库"格式。示例： "Platform/legacy", "*/l*"`).Strings()
+	azureReposScanIncludeProjects = azureReposScan.Flag("include-projects", "在扫描中包含的项目。也可以是一个glob模式。你可以多次使用这个标志。").Strings()
+	azureReposScanExcludeProjects = azureReposScan.Flag("exclude-projects", "在扫描中排除的项目。也可以是一个glob模式。你可以多次使用这个标志。").Strings()
+	azureReposScanIncludePaths    = azureReposScan.Flag("include-paths", "包含要扫描的文件的正则表达式的路径，每个正则表达式一行。").Short('i').String()
+	azureReposScanExcludePaths    = azureReposScan.Flag("exclude-paths", "排除要扫描的文件的正则表达式的路径，每个正则表达式一行。").Short('x').String()
+	azureReposScanPRThreads       = azureReposScan.Flag("pr-comments", "在扫描中包括拉取请求描述和讨论线程。").Bool()
+	azureReposScanWikis           = azureReposScan.Flag("include-wikis", "在扫描中包含项目wiki仓库。").Bool()
+
+	gerritScan             = cli.Command("gerrit", "在Gerrit项目、补丁集和代码审查评论中查找凭据。")
+	gerritScanEndpoint     = gerritScan.Flag("endpoint", "Gerrit服务器端点。").Required().String()
+	gerritScanUsername     = gerritScan.Flag("username", "Gerrit用户名。可以通过环境变量GERRIT_USERNAME提供。留空以匿名扫描。").Envar("GERRIT_USERNAME").String()
+	gerritScanPassword     = gerritScan.Flag("password", "Gerrit HTTP密码。可以通过环境变量GERRIT_PASSWORD提供。").Envar("GERRIT_PASSWORD").String()
+	gerritScanProjects     = gerritScan.Flag("project", "要扫描的Gerrit项目。你可以多次使用这个标志。留空以扫描所有可访问的项目。").Strings()
+	gerritScanIncludePaths = gerritScan.Flag("include-paths", "包含要扫描的文件的正则表达式的路径，每个正则表达式一行。").Short('i').String()
+	gerritScanExcludePaths = gerritScan.Flag("exclude-paths", "排除要扫描的文件的正则表达式的路径，每个正则表达式一行。").Short('x').String()
+
+	jiraScan                      = cli.Command("jira", "在Jira问题、评论、变更历史和附件中查找凭据。")
+	jiraScanEndpoint              = jiraScan.Flag("endpoint", "Jira Cloud或Jira Data Center端点。").Required().String()
+	jiraScanUsername              = jiraScan.Flag("username", "与--token一起用于基本身份验证的Jira账户邮箱（Cloud）或用户名（Data Center）。可以通过环境变量JIRA_USERNAME提供。").Envar("JIRA_USERNAME").String()
+	jiraScanToken                 = jiraScan.Flag("token", "Jira API令牌；不带--username时作为个人访问令牌使用。可以通过环境变量JIRA_TOKEN提供。").Envar("JIRA_TOKEN").String()
+	jiraScanOAuthToken            = jiraScan.Flag("oauth-token", "Jira OAuth访问令牌。可以通过环境变量JIRA_OAUTH_TOKEN提供。").Envar("JIRA_OAUTH_TOKEN").String()
+	jiraScanProjects              = jiraScan.Flag("project", "要扫描的项目键。也可以是一个glob模式。你可以多次使用这个标志。留空以扫描所有可访问的项目。").Strings()
+	jiraScanIgnoreProjects        = jiraScan.Flag("ignore-project", "在扫描中排除的项目键。也可以是一个glob模式。你可以多次使用这个标志。").Strings()
+	jiraScanSince                 = jiraScan.Flag("since", "仅扫描在此时间之后更新的问题。格式为RFC3339或YYYY-MM-DD。").String()
+	jiraScanInsecureSkipVerifyTLS = jiraScan.Flag("insecure-skip-verify-tls", "跳过TLS证书验证。").Bool()
+
+	confluenceScan                      = cli.Command("confluence", "在Confluence页面、页面历史版本和附件中查找凭据。")
+	confluenceScanEndpoint              = confluenceScan.Flag("endpoint", "Confluence Data Center端点，或Confluence Cloud站点的/wiki地址。").Required().String()
+	confluenceScanUsername              = confluenceScan.Flag("username", "与--token一起用于基本身份验证的Confluence账户邮箱（Cloud）或用户名（Data Center）。可以通过环境变量CONFLUENCE_USERNAME提供。").Envar("CONFLUENCE_USERNAME").String()
+	confluenceScanToken                 = confluenceScan.Flag("token", "Confluence API令牌；不带--username时作为个人访问令牌使用。可以通过环境变量CONFLUENCE_TOKEN提供。").Envar("CONFLUENCE_TOKEN").String()
+	confluenceScanSpacesScope           = confluenceScan.Flag("spaces-scope", "未指定--space时要扫描的空间类型。").Default("all").Enum("all", "global", "personal")
+	confluenceScanSpaces                = confluenceScan.Flag("space", "要扫描的空间键。你可以多次使用这个标志。留空以扫描--spaces-scope中的所有空间。").Strings()
+	confluenceScanIgnoreSpaces          = confluenceScan.Flag("ignore-space", "在扫描中排除的空间键。也可以是一个glob模式。你可以多次使用这个标志。").Strings()
+	confluenceScanIncludeAttachments    = confluenceScan.Flag("include-attachments", "在扫描中包含页面附件。").Bool()
+	confluenceScanSkipHistory           = confluenceScan.Flag("skip-history", "仅扫描页面的当前版本。").Bool()
+	confluenceScanInsecureSkipVerifyTLS = confluenceScan.Flag("insecure-skip-verify-tls", "跳过TLS证书验证。").Bool()
+
+	slackScan               = cli.Command("slack", "在Slack频道消息、线程回复、文件和画布中查找凭据。")
+	slackScanEndpoint       = slackScan.Flag("endpoint", "Slack Web API端点。留空以使用https://slack.com/api。").String()
+	slackScanToken          = slackScan.Flag("token", "Slack用户或机器人令牌。可以通过环境变量SLACK_TOKEN提供。").Envar("SLACK_TOKEN").String()
+	slackScanChannels       = slackScan.Flag("channel", "要扫描的频道名称或ID。你可以多次使用这个标志。留空以扫描所有可访问的频道。").Strings()
+	slackScanIgnoreChannels = slackScan.Flag("ignore-channel", "在扫描中排除的频道名称或ID。你可以多次使用这个标志。").Strings()
+	slackScanExport         = slackScan.Flag("export", "要扫描的Slack导出ZIP文件的路径。无需令牌。").String()
+
+	npmScan           = cli.Command("npm", "在已发布的npm软件包中查找凭据。")
+	npmScanRegistry   = npmScan.Flag("registry", "npm注册表端点，例如私有的Verdaccio实例。").Default("https://registry.npmjs.org").String()
+	npmScanPackages   = npmScan.Flag("package", "要扫描的软件包名称。你可以多次使用这个标志。").Strings()
+	npmScanScopes     = npmScan.Flag("scope", "扫描该作用域下的所有软件包。你可以多次使用这个标志。示例： @acme").Strings()
+	npmScanMaintainer = npmScan.Flag("maintainer", "扫描该npm用户维护的所有软件包。").String()
+	npmScanVersions   = npmScan.Flag("package-version", "要扫描的版本或dist-tag。你可以多次使用这个标志。留空以扫描所有已发布的版本。").Strings()
+
+	pypiScan         = cli.Command("pypi", "在已发布的Python软件包（sdist和wheel）中查找凭据。")
+	pypiScanIndexURL = pypiScan.Flag("index-url", "软件包索引的Simple API根地址，与pip --index-url相同，例如devpi索引。").Default("https://pypi.org/simple").String()
+	pypiScanProjects = pypiScan.Flag("project", "要扫描的项目名称。你可以多次使用这个标志。").Required().Strings()
+	pypiScanVersions = pypiScan.Flag("project-version", "要扫描的版本。你可以多次使用这个标志。留空以扫描所有版本。").Strings()
+
+	artifactoryScan              = cli.Command("artifactory", "在JFrog Artifactory仓库的制品中查找凭据。")
+	artifactoryScanEndpoint      = artifactoryScan.Flag("endpoint", "Artifactory端点，包含/artifactory上下文路径。").Required().String()
+	artifactoryScanUsername      = artifactoryScan.Flag("username", "用于基本身份验证的Artifactory用户名。可以通过环境变量ARTIFACTORY_USERNAME提供。").Envar("ARTIFACTORY_USERNAME").String()
+	artifactoryScanPassword      = artifactoryScan.Flag("password", "用于基本身份验证的Artifactory密码或API密钥。可以通过环境变量ARTIFACTORY_PASSWORD提供。").Envar("ARTIFACTORY_PASSWORD").String()
+	artifactoryScanAccessToken   = artifactoryScan.Flag("access-token", "Artifactory访问令牌。可以通过环境变量ARTIFACTORY_ACCESS_TOKEN提供。").Envar("ARTIFACTORY_ACCESS_TOKEN").String()
+	artifactoryScanRepos         = artifactoryScan.Flag("repo", "要扫描的仓库键。也可以是一个glob模式。你可以多次使用这个标志。留空以扫描所有本地仓库和远程仓库缓存。").Strings()
+	artifactoryScanIncludePaths  = artifactoryScan.Flag("include-paths", "要包含在扫描中的制品路径glob模式。你可以多次使用这个标志。").Strings()
+	artifactoryScanExcludePaths  = artifactoryScan.Flag("exclude-paths", "要在扫描中排除的制品路径glob模式。你可以多次使用这个标志。").Strings()
+	artifactoryScanMaxSize       = artifactoryScan.Flag("max-artifact-size", "要扫描的制品的最大大小。大于此大小的制品将被跳过。（字节单位，例如512B，2KB，4MB）").Default("250MB").Bytes()
+	artifactoryScanModifiedSince = artifactoryScan.Flag("since", "仅扫描在此时间之后修改的制品。格式为RFC3339或YYYY-MM-DD。").String()
+
+	azureStorageScan                  = cli.Command("azure-storage", "在Azure Blob存储容器中查找凭据。")
+	azureStorageScanConnectionString  = azureStorageScan.Flag("connection-string", "存储账户连接字符串。可以通过环境变量AZURE_STORAGE_CONNECTION_STRING提供。").Envar("AZURE_STORAGE_CONNECTION_STRING").String()
+	azureStorageScanAccount           = azureStorageScan.Flag("account", "存储账户名称。可以通过环境变量AZURE_STORAGE_ACCOUNT提供。").Envar("AZURE_STORAGE_ACCOUNT").String()
+	azureStorageScanAccountKey        = azureStorageScan.Flag("account-key", "存储账户密钥。可以通过环境变量AZURE_STORAGE_KEY提供。").Envar("AZURE_STORAGE_KEY").String()
+	azureStorageScanTenantID          = azureStorageScan.Flag("tenant-id", "用于客户端证书身份验证的租户ID。可以通过环境变量AZURE_TENANT_ID提供。").Envar("AZURE_TENANT_ID").String()
+	azureStorageScanClientID          = azureStorageScan.Flag("client-id", "用于客户端证书身份验证的应用程序（客户端）ID。可以通过环境变量AZURE_CLIENT_ID提供。").Envar("AZURE_CLIENT_ID").String()
+	azureStorageScanClientCertificate = azureStorageScan.Flag("client-certificate", "包含客户端证书及其私钥的PEM文件路径。可以通过环境变量AZURE_CLIENT_CERTIFICATE_PATH提供。").Envar("AZURE_CLIENT_CERTIFICATE_PATH").String()
+	azureStorageScanEndpoint          = azureStorageScan.Flag("endpoint", "Blob服务端点，例如Azurite的http://127.0.0.1:10000/devstoreaccount1。").String()
+	azureStorageScanContainers        = azureStorageScan.Flag("container", "要扫描的容器。你可以多次使用这个标志。留空以扫描所有容器。").Strings()
+	azureStorageScanIgnoreContainers  = azureStorageScan.Flag("ignore-container", "在扫描中排除的容器。也可以是一个glob模式。你可以多次使用这个标志。").Strings()
+	azureStorageScanPrefix            = azureStorageScan.Flag("prefix", "仅扫描名称以此前缀开头的blob。").String()
+	azureStorageScanIncludeBlobs      = azureStorageScan.Flag("include-blobs", "要扫描的blob名称glob模式。你可以多次使用这个标志。").Strings()
+	azureStorageScanExcludeBlobs      = azureStorageScan.Flag("exclude-blobs", "要在扫描中排除的blob名称glob模式。你可以多次使用这个标志。").Strings()
+	azureStorageScanMaxObjectSize     = azureStorageScan.Flag("max-object-size", "要扫描的blob的最大大小。大于此大小的blob将被跳过。（字节单位，例如512B，2KB，4MB）").Default("250MB").Bytes()
+	azureStorageScanIncludeSnapshots  = azureStorageScan.Flag("include-snapshots", "同时扫描blob快照。").Bool()
+	azureStorageScanIncludeVersions   = azureStorageScan.Flag("include-versions", "同时扫描blob的历史版本。").Bool()
+
+	kubernetesScan               = cli.Command("kubernetes", "在Kubernetes集群中查找凭据。")
+	kubernetesScanKubeconfig     = kubernetesScan.Flag("kubeconfig", "kubeconfig文件路径。默认为~/.kube/config。可以通过环境变量KUBECONFIG提供。").Envar("KUBECONFIG").String()
+	kubernetesScanContext        = kubernetesScan.Flag("context", "要使用的kubeconfig上下文。默认为当前上下文。").String()
+	kubernetesScanInCluster      = kubernetesScan.Flag("in-cluster", "使用trufflehog所在pod的服务账户，而不是kubeconfig。").Bool()
+	kubernetesScanNamespaces     = kubernetesScan.Flag("namespace", "要扫描的命名空间。你可以多次使用这个标志。留空以扫描所有命名空间。").Strings()
+	kubernetesScanIncludeSecrets = kubernetesScan.Flag("include-secrets", "同时扫描Secret解码后的数据。Helm发布始终会被扫描。").Bool()
+	kubernetesScanResources      = kubernetesScan.Flag("resource", "要扫描的自定义资源，格式为group/version/resource，例如monitoring.coreos.com/v1/alertmanagers。你可以多次使用这个标志。").Strings()
+
+	databaseScan                 = cli.Command("database", "在关系型数据库的表内容中查找凭据。")
+	databaseScanDriver           = databaseScan.Flag("driver", "数据库类型：postgres或mysql。").Required().Enum("postgres", "mysql")
+	databaseScanConnectionString = databaseScan.Flag("connection-string", "postgres:// URL或MySQL DSN（例如user:pass@tcp(host:3306)/db）。可以通过环境变量DATABASE_URL提供。").Envar("DATABASE_URL").Required().String()
+	databaseScanIncludeTables    = databaseScan.Flag("include-tables", "要扫描的表，格式为schema.table的glob模式，例如public.*。你可以多次使用这个标志。").Strings()
+	databaseScanExcludeTables    = databaseScan.Flag("exclude-tables", "要在扫描中排除的表，格式为schema.table的glob模式。你可以多次使用这个标志。").Strings()
+	databaseScanSampleRows       = databaseScan.Flag("sample-rows", "每个表最多扫描的行数。0表示扫描所有行。").Int64()
+	databaseScanBatchSize        = databaseScan.Flag("batch-size", "每次查询读取的行数。").Default("1000").Int64()
+	databaseScanUpdatedColumn    = databaseScan.Flag("updated-column", "记录行最后修改时间的列，例如updated_at。与--since一起使用以进行增量扫描。").String()
+	databaseScanSince            = databaseScan.Flag("since", "仅扫描--updated-column在此时间之后的行。没有该列的表会被完整扫描。格式为RFC3339或YYYY-MM-DD。").String()
+
+	buildkiteScan                = cli.Command("buildkite", "在Buildkite流水线配置、构建环境变量、元数据、作业日志和构建产物中查找凭据。")
+	buildkiteScanToken           = buildkiteScan.Flag("token", "具有read_organizations、read_pipelines、read_builds、read_build_logs和read_artifacts权限的Buildkite API访问令牌。可以通过环境变量BUILDKITE_TOKEN提供。").Envar("BUILDKITE_TOKEN").Required().String()
+	buildkiteScanOrganizations   = buildkiteScan.Flag("org", "要扫描的组织slug。你可以多次使用这个标志。留空以扫描令牌可访问的所有组织。").Strings()
+	buildkiteScanPipelines       = buildkiteScan.Flag("pipeline", "要扫描的流水线，格式为org/pipeline的glob模式。你可以多次使用这个标志。").Strings()
+	buildkiteScanIgnorePipelines = buildkiteScan.Flag("ignore-pipeline", "在扫描中排除的流水线，格式为org/pipeline的glob模式。你可以多次使用这个标志。").Strings()
+	buildkiteScanSince           = buildkiteScan.Flag("since", "仅扫描在此时间之后创建的构建。格式为RFC3339或YYYY-MM-DD。").String()
+	buildkiteScanSkipArtifacts   = buildkiteScan.Flag("skip-artifacts", "不下载和扫描构建产物。").Bool()
+
+	sentryScan                      = cli.Command("sentry", "在Sentry事件的面包屑、请求头和请求体、附加上下文以及堆栈帧局部变量中查找凭据。")
+	sentryScanEndpoint              = sentryScan.Flag("endpoint", "Sentry端点。自托管Sentry请使用其URL。").Default("https://sentry.io").String()
+	sentryScanAuthToken             = sentryScan.Flag("auth-token", "具有event:read、project:read和org:read权限的Sentry认证令牌。可以通过环境变量SENTRY_AUTH_TOKEN提供。").Envar("SENTRY_AUTH_TOKEN").Required().String()
+	sentryScanProjects              = sentryScan.Flag("project", "要扫描的项目slug，或org/project。你可以多次使用这个标志。留空以扫描所有可访问的项目。").Strings()
+	sentryScanInsecureSkipVerifyTLS = sentryScan.Flag("insecure-skip-verify-tls", "跳过TLS证书验证。").Bool()
 	
 	filesystemScan  = cli.Command("filesystem", "在文件系统中查找凭据。")
 	filesystemPaths = filesystemScan.Arg("path", "要扫描的文件或目录的路径。").Strings()
@@ -176,9 +340,18 @@ var (
 	circleCiScan      = cli.Command("circleci", "扫描 CircleCI")
 	circleCiScanToken = circleCiScan.Flag("token", "CircleCI token。也可以通过环境变量提供").Envar("CIRCLECI_TOKEN").Required().String()
 	
-	dockerScan       = cli.Command("docker", "扫描 Docker 镜像")
-	dockerScanImages = dockerScan.Flag("image", "要扫描的 Docker 镜像。使用 file:// 前缀来指向本地 tarball，否则假定为镜像仓库。").Required().Strings()
-	dockerScanToken  = dockerScan.Flag("token", "Docker bearer token。也可以通过环境变量提供").Envar("DOCKER_TOKEN").String()
+	dockerScan                    = cli.Command("docker", "扫描 Docker 镜像")
+	dockerScanImages              = dockerScan.Flag("image", "要扫描的 Docker 镜像。使用 file:// 前缀来指向本地 tarball，docker-daemon:// 指向本地 Docker 引擎中的镜像，oci-layout:// 指向 OCI 镜像布局目录（可加 :tag），containerd:// 指向 containerd 内容存储中的镜像，否则假定为镜像仓库。使用 --registry 时可省略。").Strings()
+	dockerScanToken               = dockerScan.Flag("token", "Docker bearer token。也可以通过环境变量提供").Envar("DOCKER_TOKEN").String()
+	dockerScanPlatform            = dockerScan.Flag("platform", "从多平台镜像中选择要扫描的平台，例如 linux/arm64。默认为 linux/amd64。设置后，为其他平台构建的单平台镜像将被拒绝。").String()
+	dockerScanContainerdRoot      = dockerScan.Flag("containerd-root", "containerd 的根目录，用于 containerd:// 镜像。").Default("/var/lib/containerd").String()
+	dockerScanContainerdNamespace = dockerScan.Flag("containerd-namespace", "containerd:// 镜像所在的 containerd 命名空间，例如 Kubernetes 使用的 k8s.io。").Default("default").String()
+	dockerScanRegistry            = dockerScan.Flag("registry", "通过 /v2/_catalog 接口枚举并扫描该镜像仓库中的所有镜像，例如 registry.example.com。各镜像共享的层只扫描一次。").String()
+	dockerScanIncludeRepos        = dockerScan.Flag("include-repos", "使用 --registry 时只扫描匹配这些 glob 的仓库，例如 team/*。").Strings()
+	dockerScanExcludeRepos        = dockerScan.Flag("exclude-repos", "使用 --registry 时跳过匹配这些 glob 的仓库。").Strings()
+	dockerScanIncludeTags         = dockerScan.Flag("include-tags", "使用 --registry 时只扫描匹配这些 glob 的标签，例如 v*。").Strings()
+	dockerScanExcludeTags         = dockerScan.Flag("exclude-tags", "使用 --registry 时跳过匹配这些 glob 的标签。").Strings()
+	dockerScanMaxTags             = dockerScan.Flag("max-tags", "使用 --registry 时每个仓库只扫描最近创建的 N 个标签。0 表示全部。").Int()
 	
 	travisCiScan      = cli.Command("travisci", "扫描 TravisCI")
 	travisCiScanToken = travisCiScan.Flag("token", "TravisCI token。也可以通过环境变量提供").Envar("TRAVISCI_TOKEN").Required().String()
@@ -249,6 +422,10 @@ var (
 	huggingfaceIncludeDiscussions = huggingfaceScan.Flag("include-discussions", "在扫描中包含讨论。").Bool()
 	huggingfaceIncludePrs         = huggingfaceScan.Flag("include-prs", "在扫描中包含拉取请求（PR）。").Bool()
 	
+	detectorsCmd        = cli.Command("detectors", "查看可用的检测器。")
+	detectorsListCmd    = detectorsCmd.Command("list", "列出默认检测器以及通过 --config 加载的自定义检测器及其元数据。")
+	detectorsListFormat = detectorsListCmd.Flag("format", "输出格式：table 或 json。").Default("table").Enum("table", "json")
+
 	analyzeCmd = analyzer.Command(cli)
 	usingTUI   = false	
 )
@@ -396,12 +573,10 @@ func run(state overseer.State) {
 		os.Setenv("GITHUB_TOKEN", *githubScanToken)
 	}
 
-	// When setting a base commit, chunks must be scanned in order.
-	if *gitScanSinceCommit != "" {
-		*concurrency = 1
-		if !isValidCommit(*gitScanSinceCommit) {
-			logger.Info("警告:提供的提交哈希似乎无效。")
-		}
+	// A base commit bounds the commits the git source reads; as with the
+	// per-ref base commits of --state-file, chunks need not be scanned in order.
+	if *gitScanSinceCommit != "" && !isValidCommit(*gitScanSinceCommit) {
+		logger.Info("警告:提供的提交哈希似乎无效。")
 	}
 
 	if *profile {
@@ -447,6 +622,13 @@ func run(state overseer.State) {
 		}
 	}
 
+	if cmd == detectorsListCmd.FullCommand() {
+		if err := listDetectors(conf); err != nil {
+			logFatal(err, "列出检测器时出错")
+		}
+		return
+	}
+
 	if *detectorTimeout != 0 {
 		logger.Info("设置检测超时", "timeout", detectorTimeout.String())
 		engine.SetDetectorTimeout(*detectorTimeout)
@@ -475,6 +657,20 @@ func run(state overseer.State) {
 		printer = new(output.PlainPrinter)
 	}
 
+	sanitizer, err := outputSanitizer()
+	if err != nil {
+		logFatal(err, "配置输出脱敏失败")
+	}
+	if sanitizer != nil {
+		printer = output.NewSanitizingPrinter(printer, sanitizer)
+	}
+
+	var summary *output.SummaryPrinter
+	if *summaryFormat != "" {
+		summary = output.NewSummaryPrinter(printer)
+		printer = summary
+	}
+
 	if !*jsonLegacy && !*jsonOut {
 		fmt.Fprintf(os.Stderr, "🐷🔑🐷 TruffleHog。挖掘你的秘密. 🐷🔑🐷\n\n")
 	}
@@ -489,6 +685,15 @@ func run(state overseer.State) {
 		logFatal(err, "配置结果标志失败")
 	}
 
+	var dispatcher engine.ResultsDispatcher = engine.NewPrinterDispatcher(printer)
+	if len(*webhookURLs) > 0 {
+		httpDispatcher, err := newHTTPDispatcher(ctx, sanitizer)
+		if err != nil {
+			logFatal(err, "配置 webhook 失败")
+		}
+		dispatcher = engine.NewMultiDispatcher(dispatcher, httpDispatcher)
+	}
+
 	verificationCacheMetrics := verificationcache.InMemoryMetrics{}
 
 	engConf := engine.Config{
@@ -503,7 +708,7 @@ func run(state overseer.State) {
 		ExcludeDetectors:         *excludeDetectors,
 		CustomVerifiersOnly:      *customVerifiersOnly,
 		VerifierEndpoints:        *verifiers,
-		Dispatcher:               engine.NewPrinterDispatcher(printer),
+		Dispatcher:               dispatcher,
 		FilterUnverified:         *filterUnverified,
 		FilterEntropy:            *filterEntropy,
 		VerificationOverlap:      *allowVerificationOverlap,
@@ -554,6 +759,12 @@ func run(state overseer.State) {
 		"verification_caching", verificationCacheMetricsSnapshot,
 	)
 
+	if summary != nil {
+		if err := printSummary(summary.Report(metrics.scanErrors, 0)); err != nil {
+			logger.Error(err, "打印扫描汇总失败")
+		}
+	}
+
 	if metrics.hasFoundResults && *fail {
 		logger.V(2).Info("退出代码 183 因为已经存在文件")
 		os.Exit(183)
@@ -611,6 +822,7 @@ func compareMetrics(customMetrics, entireMetrics engine.Metrics) error {
 type metrics struct {
 	engine.Metrics
 	hasFoundResults bool
+	scanErrors      []error
 }
 
 func runSingleScan(ctx context.Context, cmd string, cfg engine.Config) (metrics, error) {
@@ -679,9 +891,17 @@ func runSingleScan(ctx context.Context, cmd string, cfg engine.Config) (metrics,
 		}
 	}()
 
-	var ref sources.JobProgressRef
+	var (
+		ref sources.JobProgressRef
+		// gitState records the commits scanned by incremental git scans. It
+		// is saved once every chunk has been processed.
+		gitState *gitstate.Store
+	)
 	switch cmd {
 	case gitScan.FullCommand():
+		if gitState, err = loadGitState(*gitScanStateFile); err != nil {
+			return scanMetrics, err
+		}
 		gitCfg := sources.GitConfig{
 			URI:              *gitScanURI,
 			IncludePathsFile: *gitScanIncludePaths,
@@ -691,6 +911,8 @@ func runSingleScan(ctx context.Context, cmd string, cfg engine.Config) (metrics,
 			MaxDepth:         *gitScanMaxDepth,
 			Bare:             *gitScanBare,
 			ExcludeGlobs:     *gitScanExcludeGlobs,
+			ScanUnreachable:  *gitScanUnreachable,
+			State:            gitState,
 		}
 		if ref, err = eng.ScanGit(ctx, gitCfg); err != nil {
 			return scanMetrics, fmt.Errorf("failed to scan Git: %v", err)
@@ -703,6 +925,9 @@ func runSingleScan(ctx context.Context, cmd string, cfg engine.Config) (metrics,
 		if len(*githubScanOrgs) == 0 && len(*githubScanRepos) == 0 {
 			return scanMetrics, fmt.Errorf("invalid config: you must specify at least one organization or repository")
 		}
+		if gitState, err = loadGitState(*githubScanStateFile); err != nil {
+			return scanMetrics, err
+		}
 
 		cfg := sources.GithubConfig{
 			Endpoint:                   *githubScanEndpoint,
@@ -719,7 +944,10 @@ func runSingleScan(ctx context.Context, cmd string, cfg engine.Config) (metrics,
 			IncludePullRequestComments: *githubScanPRComments,
 			IncludeGistComments:        *githubScanGistComments,
 			CommentsTimeframeDays:      *githubCommentsTimeframeDays,
+			IncludeActionsLogs:         *githubScanActionsLogs,
+			IncludeArtifacts:           *githubScanArtifacts,
 			Filter:                     filter,
+			State:                      gitState,
 		}
 		if ref, err = eng.ScanGitHub(ctx, cfg); err != nil {
 			return scanMetrics, fmt.Errorf("failed to scan Github: %v", err)
@@ -740,6 +968,9 @@ func runSingleScan(ctx context.Context, cmd string, cfg engine.Config) (metrics,
 		if err != nil {
 			return scanMetrics, fmt.Errorf("could not create filter: %v", err)
 		}
+		if gitState, err = loadGitState(*gitlabScanStateFile); err != nil {
+			return scanMetrics, err
+		}
 
 		cfg := sources.GitlabConfig{
 			Endpoint:     *gitlabScanEndpoint,
@@ -748,10 +979,246 @@ func runSingleScan(ctx context.Context, cmd string, cfg engine.Config) (metrics,
 			IncludeRepos: *gitlabScanIncludeRepos,
 			ExcludeRepos: *gitlabScanExcludeRepos,
 			Filter:       filter,
+			State:        gitState,
 		}
 		if ref, err = eng.ScanGitLab(ctx, cfg); err != nil {
 			return scanMetrics, fmt.Errorf("failed to scan GitLab: %v", err)
 		}
+	case bitbucketScan.FullCommand():
+		filter, err := common.FilterFromFiles(*bitbucketScanIncludePaths, *bitbucketScanExcludePaths)
+		if err != nil {
+			return scanMetrics, fmt.Errorf("could not create filter: %v", err)
+		}
+
+		cfg := sources.BitbucketConfig{
+			Endpoint:          *bitbucketScanEndpoint,
+			Token:             *bitbucketScanToken,
+			OAuthToken:        *bitbucketScanOAuthToken,
+			Username:          *bitbucketScanUsername,
+			Password:          *bitbucketScanPassword,
+			Repos:             *bitbucketScanRepos,
+			Workspaces:        *bitbucketScanWorkspaces,
+			Projects:          *bitbucketScanProjects,
+			IncludeRepos:      *bitbucketScanIncludeRepos,
+			ExcludeRepos:      *bitbucketScanExcludeRepos,
+			IncludePRComments: *bitbucketScanPRComments,
+			Filter:            filter,
+		}
+		if ref, err = eng.ScanBitbucket(ctx, cfg); err != nil {
+			return scanMetrics, fmt.Errorf("failed to scan Bitbucket: %v", err)
+		}
+	case azureReposScan.FullCommand():
+		filter, err := common.FilterFromFiles(*azureReposScanIncludePaths, *azureReposScanExcludePaths)
+		if err != nil {
+			return scanMetrics, fmt.Errorf("could not create filter: %v", err)
+		}
+
+		cfg := sources.AzureReposConfig{
+			Endpoint:         *azureReposScanEndpoint,
+			Token:            *azureReposScanToken,
+			OAuthToken:       *azureReposScanOAuthToken,
+			Repos:            *azureReposScanRepos,
+			Organizations:    *azureReposScanOrgs,
+			Projects:         *azureReposScanProjects,
+			IncludeForks:     *azureReposScanIncludeForks,
+			IncludeRepos:     *azureReposScanIncludeRepos,
+			ExcludeRepos:     *azureReposScanExcludeRepos,
+			IncludeProjects:  *azureReposScanIncludeProjects,
+			ExcludeProjects:  *azureReposScanExcludeProjects,
+			IncludePRThreads: *azureReposScanPRThreads,
+			IncludeWikis:     *azureReposScanWikis,
+			Filter:           filter,
+		}
+		if ref, err = eng.ScanAzureRepos(ctx, cfg); err != nil {
+			return scanMetrics, fmt.Errorf("failed to scan Azure Repos: %v", err)
+		}
+	case gerritScan.FullCommand():
+		filter, err := common.FilterFromFiles(*gerritScanIncludePaths, *gerritScanExcludePaths)
+		if err != nil {
+			return scanMetrics, fmt.Errorf("could not create filter: %v", err)
+		}
+
+		cfg := sources.GerritConfig{
+			Endpoint: *gerritScanEndpoint,
+			Username: *gerritScanUsername,
+			Password: *gerritScanPassword,
+			Projects: *gerritScanProjects,
+			Filter:   filter,
+		}
+		if ref, err = eng.ScanGerrit(ctx, cfg); err != nil {
+			return scanMetrics, fmt.Errorf("failed to scan Gerrit: %v", err)
+		}
+	case jiraScan.FullCommand():
+		since, err := parseSince(*jiraScanSince)
+		if err != nil {
+			return scanMetrics, err
+		}
+
+		cfg := sources.JiraConfig{
+			Endpoint:              *jiraScanEndpoint,
+			Username:              *jiraScanUsername,
+			Token:                 *jiraScanToken,
+			OAuthToken:            *jiraScanOAuthToken,
+			Projects:              *jiraScanProjects,
+			IgnoreProjects:        *jiraScanIgnoreProjects,
+			UpdatedSince:          since,
+			InsecureSkipVerifyTLS: *jiraScanInsecureSkipVerifyTLS,
+		}
+		if ref, err = eng.ScanJira(ctx, cfg); err != nil {
+			return scanMetrics, fmt.Errorf("failed to scan Jira: %v", err)
+		}
+	case confluenceScan.FullCommand():
+		cfg := sources.ConfluenceConfig{
+			Endpoint:              *confluenceScanEndpoint,
+			Username:              *confluenceScanUsername,
+			Token:                 *confluenceScanToken,
+			SpacesScope:           *confluenceScanSpacesScope,
+			Spaces:                *confluenceScanSpaces,
+			IgnoreSpaces:          *confluenceScanIgnoreSpaces,
+			IncludeAttachments:    *confluenceScanIncludeAttachments,
+			SkipHistory:           *confluenceScanSkipHistory,
+			InsecureSkipVerifyTLS: *confluenceScanInsecureSkipVerifyTLS,
+		}
+		if ref, err = eng.ScanConfluence(ctx, cfg); err != nil {
+			return scanMetrics, fmt.Errorf("failed to scan Confluence: %v", err)
+		}
+	case slackScan.FullCommand():
+		if *slackScanToken == "" && *slackScanExport == "" {
+			return scanMetrics, fmt.Errorf("must provide --token or --export")
+		}
+
+		cfg := sources.SlackConfig{
+			Endpoint:       *slackScanEndpoint,
+			Token:          *slackScanToken,
+			Channels:       *slackScanChannels,
+			IgnoreChannels: *slackScanIgnoreChannels,
+			ExportPath:     *slackScanExport,
+		}
+		if ref, err = eng.ScanSlack(ctx, cfg); err != nil {
+			return scanMetrics, fmt.Errorf("failed to scan Slack: %v", err)
+		}
+	case npmScan.FullCommand():
+		if len(*npmScanPackages) == 0 && len(*npmScanScopes) == 0 && *npmScanMaintainer == "" {
+			return scanMetrics, fmt.Errorf("must provide --package, --scope or --maintainer")
+		}
+
+		cfg := sources.NPMConfig{
+			Registry:   *npmScanRegistry,
+			Packages:   *npmScanPackages,
+			Scopes:     *npmScanScopes,
+			Maintainer: *npmScanMaintainer,
+			Versions:   *npmScanVersions,
+		}
+		if ref, err = eng.ScanNPM(ctx, cfg); err != nil {
+			return scanMetrics, fmt.Errorf("failed to scan npm: %v", err)
+		}
+	case pypiScan.FullCommand():
+		cfg := sources.PyPIConfig{
+			IndexURL: *pypiScanIndexURL,
+			Projects: *pypiScanProjects,
+			Versions: *pypiScanVersions,
+		}
+		if ref, err = eng.ScanPyPI(ctx, cfg); err != nil {
+			return scanMetrics, fmt.Errorf("failed to scan PyPI: %v", err)
+		}
+	case artifactoryScan.FullCommand():
+		since, err := parseSince(*artifactoryScanModifiedSince)
+		if err != nil {
+			return scanMetrics, err
+		}
+
+		cfg := sources.ArtifactoryConfig{
+			Endpoint:      *artifactoryScanEndpoint,
+			Username:      *artifactoryScanUsername,
+			Password:      *artifactoryScanPassword,
+			AccessToken:   *artifactoryScanAccessToken,
+			Repositories:  *artifactoryScanRepos,
+			IncludePaths:  *artifactoryScanIncludePaths,
+			ExcludePaths:  *artifactoryScanExcludePaths,
+			MaxSize:       int64(*artifactoryScanMaxSize),
+			ModifiedSince: since,
+		}
+		if ref, err = eng.ScanArtifactory(ctx, cfg); err != nil {
+			return scanMetrics, fmt.Errorf("failed to scan Artifactory: %v", err)
+		}
+	case azureStorageScan.FullCommand():
+		cfg := sources.AzureStorageConfig{
+			ConnectionString:  *azureStorageScanConnectionString,
+			Account:           *azureStorageScanAccount,
+			AccountKey:        *azureStorageScanAccountKey,
+			TenantID:          *azureStorageScanTenantID,
+			ClientID:          *azureStorageScanClientID,
+			ClientCertificate: *azureStorageScanClientCertificate,
+			Endpoint:          *azureStorageScanEndpoint,
+			Containers:        *azureStorageScanContainers,
+			IgnoreContainers:  *azureStorageScanIgnoreContainers,
+			Prefix:            *azureStorageScanPrefix,
+			IncludeBlobs:      *azureStorageScanIncludeBlobs,
+			ExcludeBlobs:      *azureStorageScanExcludeBlobs,
+			MaxObjectSize:     int64(*azureStorageScanMaxObjectSize),
+			Concurrency:       *concurrency,
+			IncludeSnapshots:  *azureStorageScanIncludeSnapshots,
+			IncludeVersions:   *azureStorageScanIncludeVersions,
+		}
+		if ref, err = eng.ScanAzureStorage(ctx, cfg); err != nil {
+			return scanMetrics, fmt.Errorf("failed to scan Azure Storage: %v", err)
+		}
+	case kubernetesScan.FullCommand():
+		cfg := sources.KubernetesConfig{
+			Kubeconfig:     *kubernetesScanKubeconfig,
+			Context:        *kubernetesScanContext,
+			InCluster:      *kubernetesScanInCluster,
+			Namespaces:     *kubernetesScanNamespaces,
+			IncludeSecrets: *kubernetesScanIncludeSecrets,
+			Resources:      *kubernetesScanResources,
+		}
+		if ref, err = eng.ScanKubernetes(ctx, cfg); err != nil {
+			return scanMetrics, fmt.Errorf("failed to scan Kubernetes: %v", err)
+		}
+	case databaseScan.FullCommand():
+		since, err := parseSince(*databaseScanSince)
+		if err != nil {
+			return scanMetrics, err
+		}
+		cfg := sources.DatabaseConfig{
+			Driver:           *databaseScanDriver,
+			ConnectionString: *databaseScanConnectionString,
+			IncludeTables:    *databaseScanIncludeTables,
+			ExcludeTables:    *databaseScanExcludeTables,
+			SampleRows:       *databaseScanSampleRows,
+			BatchSize:        *databaseScanBatchSize,
+			UpdatedColumn:    *databaseScanUpdatedColumn,
+			UpdatedSince:     since,
+		}
+		if ref, err = eng.ScanDatabase(ctx, cfg); err != nil {
+			return scanMetrics, fmt.Errorf("failed to scan database: %v", err)
+		}
+	case buildkiteScan.FullCommand():
+		since, err := parseSince(*buildkiteScanSince)
+		if err != nil {
+			return scanMetrics, err
+		}
+		cfg := sources.BuildkiteConfig{
+			Token:           *buildkiteScanToken,
+			Organizations:   *buildkiteScanOrganizations,
+			Pipelines:       *buildkiteScanPipelines,
+			IgnorePipelines: *buildkiteScanIgnorePipelines,
+			CreatedSince:    since,
+			SkipArtifacts:   *buildkiteScanSkipArtifacts,
+		}
+		if ref, err = eng.ScanBuildkite(ctx, cfg); err != nil {
+			return scanMetrics, fmt.Errorf("failed to scan Buildkite: %v", err)
+		}
+	case sentryScan.FullCommand():
+		cfg := sources.SentryConfig{
+			Endpoint:              *sentryScanEndpoint,
+			AuthToken:             *sentryScanAuthToken,
+			Projects:              *sentryScanProjects,
+			InsecureSkipVerifyTLS: *sentryScanInsecureSkipVerifyTLS,
+		}
+		if ref, err = eng.ScanSentry(ctx, cfg); err != nil {
+			return scanMetrics, fmt.Errorf("failed to scan Sentry: %v", err)
+		}
 	case filesystemScan.FullCommand():
 		if len(*filesystemDirectories) > 0 {
 			ctx.Logger().Info("--directory flag is deprecated, please pass directories as arguments")
@@ -819,10 +1286,22 @@ func runSingleScan(ctx context.Context, cmd string, cfg engine.Config) (metrics,
 			return scanMetrics, fmt.Errorf("failed to scan GCS: %v", err)
 		}
 	case dockerScan.FullCommand():
+		if len(*dockerScanImages) == 0 && *dockerScanRegistry == "" {
+			return scanMetrics, fmt.Errorf("must provide --image or --registry")
+		}
 		cfg := sources.DockerConfig{
-			BearerToken:       *dockerScanToken,
-			Images:            *dockerScanImages,
-			UseDockerKeychain: *dockerScanToken == "",
+			BearerToken:         *dockerScanToken,
+			Images:              *dockerScanImages,
+			UseDockerKeychain:   *dockerScanToken == "",
+			Platform:            *dockerScanPlatform,
+			ContainerdRoot:      *dockerScanContainerdRoot,
+			ContainerdNamespace: *dockerScanContainerdNamespace,
+			Registry:            *dockerScanRegistry,
+			IncludeRepos:        *dockerScanIncludeRepos,
+			ExcludeRepos:        *dockerScanExcludeRepos,
+			IncludeTags:         *dockerScanIncludeTags,
+			ExcludeTags:         *dockerScanExcludeTags,
+			MaxTags:             *dockerScanMaxTags,
 		}
 		if ref, err = eng.ScanDocker(ctx, cfg); err != nil {
 			return scanMetrics, fmt.Errorf("failed to scan Docker: %v", err)
@@ -932,8 +1411,15 @@ func runSingleScan(ctx context.Context, cmd string, cfg engine.Config) (metrics,
 		return scanMetrics, fmt.Errorf("engine failed to finish execution: %v", err)
 	}
 
+	if gitState != nil {
+		if err := gitState.Save(); err != nil {
+			return scanMetrics, fmt.Errorf("failed to save state file: %v", err)
+		}
+	}
+
 	// Print any errors reported during the scan.
-	if errs := ref.Snapshot().Errors; len(errs) > 0 {
+	errs := ref.Snapshot().Errors
+	if len(errs) > 0 {
 		errMsgs := make([]string, len(errs))
 		for i := 0; i < len(errs); i++ {
 			errMsgs[i] = errs[i].Error()
@@ -945,7 +1431,30 @@ func runSingleScan(ctx context.Context, cmd string, cfg engine.Config) (metrics,
 		printAverageDetectorTime(eng)
 	}
 
-	return metrics{Metrics: eng.GetMetrics(), hasFoundResults: eng.HasFoundResults()}, nil
+	return metrics{Metrics: eng.GetMetrics(), hasFoundResults: eng.HasFoundResults(), scanErrors: errs}, nil
+}
+
+// loadGitState loads the state file of incremental git scans, if one is set.
+func loadGitState(path string) (*gitstate.Store, error) {
+	if path == "" {
+		return nil, nil
+	}
+	state, err := gitstate.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load state file: %v", err)
+	}
+	return state, nil
+}
+
+// printSummary writes the end-of-scan summary to stderr, so that it doesn't
+// interfere with results printed to stdout.
+func printSummary(report output.SummaryReport) error {
+	if *summaryFormat == "json" {
+		return output.WriteSummaryJSON(os.Stderr, report)
+	}
+	fmt.Fprintln(os.Stderr)
+	output.WriteSummaryTable(os.Stderr, report)
+	return nil
 }
 
 // parseResults ensures that users provide valid CSV input to `--results`.
@@ -999,6 +1508,100 @@ func commaSeparatedToSlice(s []string) []string {
 	return result
 }
 
+// parseSince parses the value of a --since flag, which is either an RFC3339
+// timestamp or a date. An empty value is the zero time.
+func parseSince(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since value %q: expected RFC3339 or YYYY-MM-DD", value)
+	}
+	return t, nil
+}
+
+// outputSanitizer returns the Sanitizer selected by the --redact or
+// --hash-secrets flags, or nil if secrets should be output as found.
+func outputSanitizer() (*output.Sanitizer, error) {
+	switch {
+	case *redact && *hashSecrets != "":
+		return nil, fmt.Errorf("--redact and --hash-secrets cannot be used together")
+	case *redact:
+		return output.NewRedactor(), nil
+	case *hashSecrets != "":
+		key, err := os.ReadFile(*hashSecrets)
+		if err != nil {
+			return nil, fmt.Errorf("could not read HMAC key file: %w", err)
+		}
+		return output.NewHasher(bytes.TrimSpace(key))
+	default:
+		return nil, nil
+	}
+}
+
+// newHTTPDispatcher creates the dispatcher that posts results to the
+// endpoints configured with the --webhook flags.
+func newHTTPDispatcher(ctx context.Context, sanitizer *output.Sanitizer) (*engine.HTTPDispatcher, error) {
+	headers := make(map[string]string, len(*webhookHeaders))
+	for _, h := range *webhookHeaders {
+		key, value, ok := strings.Cut(h, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid webhook header %q, expected 'Key: Value'", h)
+		}
+		headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	cfg := engine.HTTPDispatcherConfig{
+		Format:        *webhookFormat,
+		BatchSize:     *webhookBatchSize,
+		FlushInterval: *webhookFlushInterval,
+		MaxRetries:    webhookMaxRetries,
+		SpoolFile:     *webhookSpoolFile,
+	}
+	for _, u := range *webhookURLs {
+		cfg.Endpoints = append(cfg.Endpoints, engine.HTTPEndpoint{URL: u, Headers: headers})
+	}
+	if *webhookTemplate != "" {
+		tmpl, err := os.ReadFile(*webhookTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("could not read webhook template: %w", err)
+		}
+		cfg.Template = string(tmpl)
+	}
+
+	results, err := parseResults(webhookResults)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Results = results
+
+	if *webhookDetectors != "" {
+		if cfg.Detectors, err = config.ParseDetectors(*webhookDetectors); err != nil {
+			return nil, err
+		}
+	}
+	if sanitizer != nil {
+		cfg.Sanitize = sanitizer.Sanitize
+	}
+
+	return engine.NewHTTPDispatcher(ctx, cfg)
+}
+
+// listDetectors prints metadata about the default detectors and any custom
+// detectors loaded from the configuration file.
+func listDetectors(conf *config.Config) error {
+	infos := catalog.Describe(defaults.DefaultDetectors(), conf.Detectors)
+	if *detectorsListFormat == "json" || *jsonOut {
+		return catalog.WriteJSON(os.Stdout, infos)
+	}
+	catalog.WriteTable(os.Stdout, infos)
+	return nil
+}
+
 func printAverageDetectorTime(e *engine.Engine) {
 	fmt.Fprintln(
 		os.Stderr,
@@ -0,0 +1,54 @@
+package analyzer
+
+import (
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+)
+
+// detectorAnalyzers maps detector types to the analyzer that can be run on
+// the credentials they find. Detectors without an entry have no analyzer.
+var detectorAnalyzers = map[detectorspb.DetectorType]analyzers.AnalyzerType{
+	detectorspb.DetectorType_AirbrakeUserKey:             analyzers.AnalyzerTypeAirbrake,
+	detectorspb.DetectorType_AirtableOAuth:               analyzers.AnalyzerTypeAirtable,
+	detectorspb.DetectorType_AirtablePersonalAccessToken: analyzers.AnalyzerTypeAirtable,
+	detectorspb.DetectorType_Anthropic:                   analyzers.AnalyzerAnthropic,
+	detectorspb.DetectorType_AsanaOauth:                  analyzers.AnalyzerTypeAsana,
+	detectorspb.DetectorType_AsanaPersonalAccessToken:    analyzers.AnalyzerTypeAsana,
+	detectorspb.DetectorType_DigitalOceanToken:           analyzers.AnalyzerTypeDigitalOcean,
+	detectorspb.DetectorType_DigitalOceanV2:              analyzers.AnalyzerTypeDigitalOcean,
+	detectorspb.DetectorType_Dockerhub:                   analyzers.AnalyzerTypeDockerHub,
+	detectorspb.DetectorType_ElevenLabs:                  analyzers.AnalyzerTypeElevenLabs,
+	detectorspb.DetectorType_Github:                      analyzers.AnalyzerTypeGitHub,
+	detectorspb.DetectorType_Gitlab:                      analyzers.AnalyzerTypeGitLab,
+	detectorspb.DetectorType_HuggingFace:                 analyzers.AnalyzerTypeHuggingFace,
+	detectorspb.DetectorType_Mailchimp:                   analyzers.AnalyzerTypeMailchimp,
+	detectorspb.DetectorType_Mailgun:                     analyzers.AnalyzerTypeMailgun,
+	detectorspb.DetectorType_Notion:                      analyzers.AnalyzerTypeNotion,
+	detectorspb.DetectorType_OpenAI:                      analyzers.AnalyzerTypeOpenAI,
+	detectorspb.DetectorType_Opsgenie:                    analyzers.AnalyzerTypeOpsgenie,
+	detectorspb.DetectorType_Postgres:                    analyzers.AnalyzerTypePostgres,
+	detectorspb.DetectorType_Postman:                     analyzers.AnalyzerTypePostman,
+	detectorspb.DetectorType_PrivateKey:                  analyzers.AnalyzerTypePrivateKey,
+	detectorspb.DetectorType_SendGrid:                    analyzers.AnalyzerTypeSendgrid,
+	detectorspb.DetectorType_Shopify:                     analyzers.AnalyzerTypeShopify,
+	detectorspb.DetectorType_Slack:                       analyzers.AnalyzerTypeSlack,
+	detectorspb.DetectorType_Sourcegraph:                 analyzers.AnalyzerTypeSourcegraph,
+	detectorspb.DetectorType_Square:                      analyzers.AnalyzerTypeSquare,
+	detectorspb.DetectorType_Stripe:                      analyzers.AnalyzerTypeStripe,
+	detectorspb.DetectorType_Twilio:                      analyzers.AnalyzerTypeTwilio,
+	detectorspb.DetectorType_TwilioApiKey:                analyzers.AnalyzerTypeTwilio,
+}
+
+// analyzersWithoutDetector lists analyzers that no detector type maps to, with
+// the reason. Every analyzer must appear either here or in detectorAnalyzers.
+var analyzersWithoutDetector = map[analyzers.AnalyzerType]string{
+	analyzers.AnalyzerTypeBitbucket: "there is no Bitbucket detector",
+	analyzers.AnalyzerTypeMySQL:     "the JDBC detector also matches postgres, sqlserver, oracle and other subprotocols",
+}
+
+// AnalyzerForDetector returns the analyzer that supports credentials found by
+// the given detector type, if one exists.
+func AnalyzerForDetector(detectorType detectorspb.DetectorType) (analyzers.AnalyzerType, bool) {
+	analyzerType, ok := detectorAnalyzers[detectorType]
+	return analyzerType, ok
+}
@@ -0,0 +1,28 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers"
+)
+
+func TestDetectorAnalyzersCoverAllAnalyzers(t *testing.T) {
+	mapped := make(map[string]bool)
+	for _, analyzerType := range detectorAnalyzers {
+		mapped[analyzerType.String()] = true
+	}
+	unmapped := make(map[string]bool)
+	for analyzerType := range analyzersWithoutDetector {
+		unmapped[analyzerType.String()] = true
+	}
+
+	for _, name := range analyzers.AvailableAnalyzers() {
+		if unmapped[name] {
+			assert.False(t, mapped[name], "analyzer %s is mapped but listed as having no detector", name)
+			continue
+		}
+		assert.True(t, mapped[name], "analyzer %s is not mapped to any detector type", name)
+	}
+}
@@ -0,0 +1,179 @@
+// Package catalog describes the detectors known to the engine in a
+// machine-readable form, including which optional detector interfaces they
+// implement and whether an analyzer exists for the credentials they find.
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+)
+
+// Names of the optional detector interfaces reported in DetectorInfo.Interfaces.
+const (
+	InterfaceVersioner                   = "Versioner"
+	InterfaceEndpointCustomizer          = "EndpointCustomizer"
+	InterfaceCloudProvider               = "CloudProvider"
+	InterfaceMultiPartCredentialProvider = "MultiPartCredentialProvider"
+	InterfaceCustomFalsePositiveChecker  = "CustomFalsePositiveChecker"
+	InterfaceMaxSecretSizeProvider       = "MaxSecretSizeProvider"
+	InterfaceStartOffsetProvider         = "StartOffsetProvider"
+	InterfaceCustomResultsCleaner        = "CustomResultsCleaner"
+)
+
+// DetectorInfo is the metadata reported for a single detector.
+type DetectorInfo struct {
+	// Type is the string name of the detector's DetectorType.
+	Type string `json:"type"`
+	// ID is the numeric value of the detector's DetectorType.
+	ID int32 `json:"id"`
+	// Version is the detector version, or 0 if the detector is not a Versioner.
+	Version int `json:"version"`
+	// Name is the user supplied name of a custom detector.
+	Name string `json:"name,omitempty"`
+	// Custom indicates the detector was loaded from a configuration file.
+	Custom      bool     `json:"custom"`
+	Keywords    []string `json:"keywords"`
+	Description string   `json:"description"`
+	// Interfaces lists the optional detector interfaces implemented by the detector.
+	Interfaces []string `json:"interfaces"`
+	// Analyzer is the name of the analyzer that supports the detector's
+	// credentials, if one exists.
+	Analyzer string `json:"analyzer,omitempty"`
+}
+
+// Implements reports whether the detector implements the named optional interface.
+func (d DetectorInfo) Implements(iface string) bool {
+	for _, i := range d.Interfaces {
+		if i == iface {
+			return true
+		}
+	}
+	return false
+}
+
+// Describe builds a DetectorInfo for each provided detector, sorted by type
+// and version. Detectors in custom are flagged as user supplied.
+func Describe(builtin, custom []detectors.Detector) []DetectorInfo {
+	infos := make([]DetectorInfo, 0, len(builtin)+len(custom))
+	for _, d := range builtin {
+		infos = append(infos, describe(d, false))
+	}
+	for _, d := range custom {
+		infos = append(infos, describe(d, true))
+	}
+
+	sort.SliceStable(infos, func(i, j int) bool {
+		if infos[i].Type != infos[j].Type {
+			return infos[i].Type < infos[j].Type
+		}
+		if infos[i].Version != infos[j].Version {
+			return infos[i].Version < infos[j].Version
+		}
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+func describe(d detectors.Detector, custom bool) DetectorInfo {
+	info := DetectorInfo{
+		Type:        d.Type().String(),
+		ID:          int32(d.Type()),
+		Custom:      custom,
+		Keywords:    d.Keywords(),
+		Description: d.Description(),
+		Interfaces:  implementedInterfaces(d),
+	}
+	if info.Keywords == nil {
+		info.Keywords = []string{}
+	}
+	if v, ok := d.(detectors.Versioner); ok {
+		info.Version = v.Version()
+	}
+	if named, ok := d.(interface{ GetName() string }); ok {
+		info.Name = named.GetName()
+	}
+	if analyzerType, ok := analyzer.AnalyzerForDetector(d.Type()); ok {
+		info.Analyzer = analyzerType.String()
+	}
+	return info
+}
+
+func implementedInterfaces(d detectors.Detector) []string {
+	checks := []struct {
+		name string
+		ok   bool
+	}{
+		{InterfaceVersioner, is[detectors.Versioner](d)},
+		{InterfaceEndpointCustomizer, is[detectors.EndpointCustomizer](d)},
+		{InterfaceCloudProvider, is[detectors.CloudProvider](d)},
+		{InterfaceMultiPartCredentialProvider, is[detectors.MultiPartCredentialProvider](d)},
+		{InterfaceCustomFalsePositiveChecker, is[detectors.CustomFalsePositiveChecker](d)},
+		{InterfaceMaxSecretSizeProvider, is[detectors.MaxSecretSizeProvider](d)},
+		{InterfaceStartOffsetProvider, is[detectors.StartOffsetProvider](d)},
+		{InterfaceCustomResultsCleaner, is[detectors.CustomResultsCleaner](d)},
+	}
+
+	ifaces := []string{}
+	for _, c := range checks {
+		if c.ok {
+			ifaces = append(ifaces, c.name)
+		}
+	}
+	return ifaces
+}
+
+func is[T any](d detectors.Detector) bool {
+	_, ok := d.(T)
+	return ok
+}
+
+// WriteJSON writes the detector metadata to w as a JSON array.
+func WriteJSON(w io.Writer, infos []DetectorInfo) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(infos); err != nil {
+		return fmt.Errorf("could not marshal detectors: %w", err)
+	}
+	return nil
+}
+
+// WriteTable writes the detector metadata to w as a human-readable table.
+func WriteTable(w io.Writer, infos []DetectorInfo) {
+	t := table.NewWriter()
+	t.SetOutputMirror(w)
+	t.AppendHeader(table.Row{"Type", "ID", "Version", "Keywords", "Interfaces", "Analyzer", "Description"})
+	t.SetColumnConfigs([]table.ColumnConfig{
+		{Name: "Keywords", WidthMax: 40},
+		{Name: "Interfaces", WidthMax: 40},
+		{Name: "Description", WidthMax: 60},
+	})
+	for _, info := range infos {
+		name := info.Type
+		if info.Custom && info.Name != "" {
+			name = fmt.Sprintf("%s (%s)", info.Type, info.Name)
+		}
+		version := ""
+		if info.Version != 0 {
+			version = strconv.Itoa(info.Version)
+		}
+		t.AppendRow(table.Row{
+			name,
+			info.ID,
+			version,
+			strings.Join(info.Keywords, ", "),
+			strings.Join(info.Interfaces, ", "),
+			info.Analyzer,
+			info.Description,
+		})
+	}
+	t.Render()
+}
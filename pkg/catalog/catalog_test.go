@@ -0,0 +1,59 @@
+package catalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/custom_detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors/github/v2"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors/jdbc"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/custom_detectorspb"
+)
+
+func TestDescribe(t *testing.T) {
+	custom, err := custom_detectors.NewWebhookCustomRegex(&custom_detectorspb.CustomRegex{
+		Name:     "internal-token",
+		Keywords: []string{"itok"},
+		Regex:    map[string]string{"token": "itok_[a-z0-9]{16}"},
+	})
+	require.NoError(t, err)
+
+	infos := Describe(
+		[]detectors.Detector{&jdbc.Scanner{}, &github.Scanner{}},
+		[]detectors.Detector{custom},
+	)
+	require.Len(t, infos, 3)
+
+	assert.Equal(t, "CustomRegex", infos[0].Type)
+	assert.True(t, infos[0].Custom)
+	assert.Equal(t, "internal-token", infos[0].Name)
+	assert.Equal(t, []string{"itok"}, infos[0].Keywords)
+	assert.True(t, infos[0].Implements(InterfaceCustomFalsePositiveChecker))
+	assert.True(t, infos[0].Implements(InterfaceMaxSecretSizeProvider))
+	assert.Empty(t, infos[0].Analyzer)
+
+	assert.Equal(t, "Github", infos[1].Type)
+	assert.Equal(t, 2, infos[1].Version)
+	assert.True(t, infos[1].Implements(InterfaceVersioner))
+	assert.Equal(t, "GitHub", infos[1].Analyzer)
+
+	assert.Equal(t, "JDBC", infos[2].Type)
+	assert.False(t, infos[2].Custom)
+	assert.Empty(t, infos[2].Analyzer)
+}
+
+func TestWriteJSON(t *testing.T) {
+	infos := Describe([]detectors.Detector{&jdbc.Scanner{}}, nil)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteJSON(&buf, infos))
+
+	var got []DetectorInfo
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, infos, got)
+}
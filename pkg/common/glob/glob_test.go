@@ -136,3 +136,25 @@ func TestGlobDefaultFilters(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildIgnorer(t *testing.T) {
+	var badPatterns []string
+	onCompileErr := func(_ error, pattern string) { badPatterns = append(badPatterns, pattern) }
+
+	ignore := BuildIgnorer([]string{"team/*", "["}, []string{"team/old-*"}, onCompileErr)
+	assert.Equal(t, []string{"["}, badPatterns)
+	assert.False(t, ignore("team/api"))
+	assert.True(t, ignore("team/old-api"))
+	assert.True(t, ignore("other/api"))
+	// Without separators, "*" matches "/".
+	assert.False(t, ignore("team/api/v2"))
+
+	ignore = BuildIgnorer(nil, []string{"libs/*"}, onCompileErr, WithSeparators('/'))
+	assert.True(t, ignore("libs/a.jar"))
+	assert.False(t, ignore("libs/com/a.jar"))
+	assert.False(t, ignore("anything"))
+
+	ignore = BuildIgnorer([]string{"SUP*"}, nil, onCompileErr, IgnoreCase())
+	assert.False(t, ignore("support"))
+	assert.True(t, ignore("ops"))
+}
@@ -0,0 +1,73 @@
+package glob
+
+import (
+	"strings"
+
+	"github.com/gobwas/glob"
+)
+
+type ignorerConfig struct {
+	separators []rune
+	ignoreCase bool
+}
+
+// IgnorerOption configures an ignorer built by BuildIgnorer.
+type IgnorerOption func(*ignorerConfig)
+
+// WithSeparators makes "*" stop at the separators, such as '/' for paths;
+// "**" still matches them.
+func WithSeparators(separators ...rune) IgnorerOption {
+	return func(c *ignorerConfig) { c.separators = append(c.separators, separators...) }
+}
+
+// IgnoreCase matches names case-insensitively.
+func IgnoreCase() IgnorerOption {
+	return func(c *ignorerConfig) { c.ignoreCase = true }
+}
+
+// BuildIgnorer returns a function that reports whether a name is filtered
+// out: when include globs are given, names that match none of them are, and
+// names that match an exclude glob always are. Globs that do not compile are
+// passed to onCompileErr and left out.
+func BuildIgnorer(include, exclude []string, onCompileErr func(err error, pattern string), opts ...IgnorerOption) func(name string) bool {
+	var config ignorerConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+	normalize := func(s string) string {
+		if config.ignoreCase {
+			return strings.ToLower(s)
+		}
+		return s
+	}
+
+	compile := func(patterns []string) []glob.Glob {
+		globs := make([]glob.Glob, 0, len(patterns))
+		for _, p := range patterns {
+			g, err := glob.Compile(normalize(p), config.separators...)
+			if err != nil {
+				onCompileErr(err, p)
+				continue
+			}
+			globs = append(globs, g)
+		}
+		return globs
+	}
+	includeGlobs, excludeGlobs := compile(include), compile(exclude)
+
+	matchesAny := func(globs []glob.Glob, name string) bool {
+		for _, g := range globs {
+			if g.Match(name) {
+				return true
+			}
+		}
+		return false
+	}
+	return func(name string) bool {
+		name = normalize(name)
+		if len(includeGlobs) > 0 && !matchesAny(includeGlobs, name) {
+			return true
+		}
+		return matchesAny(excludeGlobs, name)
+	}
+}
@@ -141,6 +141,12 @@ func WithTimeout(timeout time.Duration) ClientOption {
 	return func(c *retryablehttp.Client) { c.HTTPClient.Timeout = timeout }
 }
 
+// WithTransport allows setting a custom transport for the requests the client
+// retries.
+func WithTransport(transport http.RoundTripper) ClientOption {
+	return func(c *retryablehttp.Client) { c.HTTPClient.Transport = transport }
+}
+
 // WithMaxRetries allows setting a custom maximum number of retries.
 func WithMaxRetries(retries int) ClientOption {
 	return func(c *retryablehttp.Client) { c.RetryMax = retries }
@@ -71,6 +71,40 @@ func (d *Base64) FromChunk(chunk *sources.Chunk) *DecodableChunk {
 	return nil
 }
 
+// Locate finds the base64 text that decodes to decoded within data. The
+// returned range covers every base64 quantum that contributes to decoded, so
+// it may include up to two characters on either side.
+func (d *Base64) Locate(data, decoded []byte) (int, int, bool) {
+	if len(decoded) == 0 {
+		return 0, 0, false
+	}
+	cursor := 0
+	for _, str := range getSubstringsOfCharacterSet(data, 20, b64CharsetMapping, b64EndChars) {
+		pos := bytes.Index(data[cursor:], []byte(str))
+		if pos == -1 {
+			continue
+		}
+		pos += cursor
+		cursor = pos + len(str)
+
+		for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawURLEncoding} {
+			dec, err := enc.DecodeString(str)
+			if err != nil {
+				continue
+			}
+			idx := bytes.Index(dec, decoded)
+			if idx == -1 {
+				continue
+			}
+			// Every 3 decoded bytes are encoded as 4 characters.
+			start := idx / 3 * 4
+			end := min((idx+len(decoded)+2)/3*4, len(str))
+			return pos + start, pos + end, true
+		}
+	}
+	return 0, 0, false
+}
+
 func isASCII(b []byte) bool {
 	for i := 0; i < len(b); i++ {
 		if b[i] > unicode.MaxASCII {
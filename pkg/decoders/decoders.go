@@ -27,6 +27,14 @@ type Decoder interface {
 	Type() detectorspb.DecoderType
 }
 
+// Locator is an optional interface that a decoder can implement to find the
+// encoded form of decoded data within the data it was decoded from.
+type Locator interface {
+	// Locate returns the byte range, with an exclusive end, of the encoded
+	// form of decoded within data.
+	Locate(data, decoded []byte) (start, end int, ok bool)
+}
+
 // Fuzz is an entrypoint for go-fuzz, which is an AFL-style fuzzing tool.
 // This one attempts to uncover any panics during decoding.
 func Fuzz(data []byte) int {
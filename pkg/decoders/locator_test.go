@@ -0,0 +1,83 @@
+package decoders
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocate(t *testing.T) {
+	tests := []struct {
+		name      string
+		decoder   Locator
+		data      string
+		decoded   string
+		wantStart int
+		wantEnd   int
+		wantOk    bool
+	}{
+		{
+			name:      "plain",
+			decoder:   &UTF8{},
+			data:      "key = secret-value\n",
+			decoded:   "secret-value",
+			wantStart: 6,
+			wantEnd:   18,
+			wantOk:    true,
+		},
+		{
+			name:    "plain not found",
+			decoder: &UTF8{},
+			data:    "key = secret-value\n",
+			decoded: "other",
+		},
+		{
+			name:      "base64 aligned",
+			decoder:   &Base64{},
+			data:      "token: bG9uZ2VyLWVuY29kZWQtc2VjcmV0LXRlc3Q=",
+			decoded:   "longer-encoded-secret-test",
+			wantStart: 7,
+			wantEnd:   43,
+			wantOk:    true,
+		},
+		{
+			// "encoded-secret" starts at decoded offset 7, within the third quantum.
+			name:      "base64 unaligned",
+			decoder:   &Base64{},
+			data:      "token: bG9uZ2VyLWVuY29kZWQtc2VjcmV0LXRlc3Q=",
+			decoded:   "encoded-secret",
+			wantStart: 15,
+			wantEnd:   35,
+			wantOk:    true,
+		},
+		{
+			name:      "utf16 little endian",
+			decoder:   &UTF16{},
+			data:      "\xff\xfek\x00e\x00y\x00=\x00a\x00b\x00c\x00",
+			decoded:   "abc",
+			wantStart: 10,
+			wantEnd:   16,
+			wantOk:    true,
+		},
+		{
+			name:      "utf16 big endian",
+			decoder:   &UTF16{},
+			data:      "\x00k\x00=\x00a\x00b\x00c",
+			decoded:   "abc",
+			wantStart: 4,
+			wantEnd:   10,
+			wantOk:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, ok := tt.decoder.Locate([]byte(tt.data), []byte(tt.decoded))
+			assert.Equal(t, tt.wantOk, ok)
+			if !tt.wantOk {
+				return
+			}
+			assert.Equal(t, tt.wantStart, start)
+			assert.Equal(t, tt.wantEnd, end)
+		})
+	}
+}
@@ -32,6 +32,26 @@ func (d *UTF16) FromChunk(chunk *sources.Chunk) *DecodableChunk {
 	return nil
 }
 
+// Locate finds the UTF-16 encoding of decoded within data, trying
+// little-endian first to match the order in which FromChunk decodes.
+func (d *UTF16) Locate(data, decoded []byte) (int, int, bool) {
+	if len(decoded) == 0 {
+		return 0, 0, false
+	}
+	le := make([]byte, 0, len(decoded)*2)
+	be := make([]byte, 0, len(decoded)*2)
+	for _, b := range decoded {
+		le = append(le, b, 0)
+		be = append(be, 0, b)
+	}
+	for _, encoded := range [][]byte{le, be} {
+		if start := bytes.Index(data, encoded); start != -1 {
+			return start, start + len(encoded), true
+		}
+	}
+	return 0, 0, false
+}
+
 // utf16ToUTF8 converts a byte slice containing UTF-16 encoded data to a UTF-8 encoded byte slice.
 func utf16ToUTF8(b []byte) ([]byte, error) {
 	var bufBE, bufLE bytes.Buffer
@@ -1,6 +1,7 @@
 package decoders
 
 import (
+	"bytes"
 	"unicode/utf8"
 
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
@@ -28,6 +29,16 @@ func (d *UTF8) FromChunk(chunk *sources.Chunk) *DecodableChunk {
 	return decodableChunk
 }
 
+// Locate finds decoded within data. Printable text is left unchanged by the
+// decoder, so the decoded bytes appear as-is in the original data.
+func (d *UTF8) Locate(data, decoded []byte) (int, int, bool) {
+	start := bytes.Index(data, decoded)
+	if start == -1 || len(decoded) == 0 {
+		return 0, 0, false
+	}
+	return start, start + len(decoded), true
+}
+
 // utf8ReplacementBytes holds the UTF-8 encoded form of the Unicode replacement character (U+FFFD).
 // This is pre-computed since it's used frequently when replacing invalid UTF-8 sequences
 // and control characters.
@@ -112,6 +112,31 @@ type Result struct {
 	// analysis to run. The keys of the map are analyzer specific and
 	// should match what is expected in the corresponding analyzer.
 	AnalysisInfo map[string]string
+
+	// Position is the location of Raw within the scanned data. It is set by
+	// the engine, and is nil if Raw could not be located.
+	Position *Position
+}
+
+// Position locates a result within the data it was found in. Lines and
+// columns are 1-based, and columns count bytes. End columns and offsets are
+// exclusive.
+type Position struct {
+	StartLine   int64
+	StartColumn int64
+	EndLine     int64
+	EndColumn   int64
+	// Bytes is the byte range of the secret within the original, pre-decoding
+	// file. It is nil if the source doesn't report where its chunks were read
+	// from. Sources that scan git diffs, such as git, GitHub and GitLab, never
+	// report it, because a diff doesn't say where its lines are in the file.
+	Bytes *ByteRange `json:",omitempty"`
+}
+
+// ByteRange is a range of byte offsets. End is exclusive.
+type ByteRange struct {
+	Start int64
+	End   int64
 }
 
 // CopyVerificationInfo clones verification info (status and error) from another Result struct. This is used when
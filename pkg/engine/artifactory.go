@@ -0,0 +1,53 @@
+package engine
+
+import (
+	"runtime"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/artifactory"
+)
+
+// ScanArtifactory scans JFrog Artifactory repositories with the provided configuration.
+func (e *Engine) ScanArtifactory(ctx context.Context, c sources.ArtifactoryConfig) (sources.JobProgressRef, error) {
+	connection := &sourcespb.Artifactory{
+		Endpoint:     c.Endpoint,
+		Repositories: c.Repositories,
+		IncludePaths: c.IncludePaths,
+		IgnorePaths:  c.ExcludePaths,
+	}
+
+	switch {
+	case len(c.Username) > 0:
+		connection.Credential = &sourcespb.Artifactory_BasicAuth{
+			BasicAuth: &credentialspb.BasicAuth{Username: c.Username, Password: c.Password},
+		}
+	case len(c.AccessToken) > 0:
+		connection.Credential = &sourcespb.Artifactory_AccessToken{AccessToken: c.AccessToken}
+	default:
+		connection.Credential = &sourcespb.Artifactory_Unauthenticated{Unauthenticated: &credentialspb.Unauthenticated{}}
+	}
+
+	var conn anypb.Any
+	err := anypb.MarshalFrom(&conn, connection, proto.MarshalOptions{})
+	if err != nil {
+		ctx.Logger().Error(err, "failed to marshal artifactory connection")
+		return sources.JobProgressRef{}, err
+	}
+
+	sourceName := "trufflehog - artifactory"
+	sourceID, jobID, _ := e.sourceManager.GetIDs(ctx, sourceName, artifactory.SourceType)
+
+	artifactorySource := &artifactory.Source{}
+	if err := artifactorySource.Init(ctx, sourceName, jobID, sourceID, true, &conn, runtime.NumCPU()); err != nil {
+		return sources.JobProgressRef{}, err
+	}
+	artifactorySource.WithMaxSize(c.MaxSize)
+	artifactorySource.WithModifiedSince(c.ModifiedSince)
+	return e.sourceManager.EnumerateAndScan(ctx, sourceName, artifactorySource)
+}
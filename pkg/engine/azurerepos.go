@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"fmt"
+	"runtime"
+
+	gogit "github.com/go-git/go-git/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/azurerepos"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/git"
+)
+
+// ScanAzureRepos scans Azure DevOps repositories with the provided configuration.
+func (e *Engine) ScanAzureRepos(ctx context.Context, c sources.AzureReposConfig) (sources.JobProgressRef, error) {
+	logOptions := &gogit.LogOptions{}
+	opts := []git.ScanOption{
+		git.ScanOptionFilter(c.Filter),
+		git.ScanOptionLogOptions(logOptions),
+	}
+	scanOptions := git.NewScanOptions(opts...)
+
+	connection := &sourcespb.AzureRepos{
+		Endpoint:        c.Endpoint,
+		Repositories:    c.Repos,
+		Organizations:   c.Organizations,
+		Projects:        c.Projects,
+		IncludeForks:    c.IncludeForks,
+		IncludeRepos:    c.IncludeRepos,
+		IgnoreRepos:     c.ExcludeRepos,
+		IncludeProjects: c.IncludeProjects,
+		IgnoreProjects:  c.ExcludeProjects,
+		SkipBinaries:    c.SkipBinaries,
+	}
+
+	switch {
+	case len(c.Token) > 0:
+		connection.Credential = &sourcespb.AzureRepos_Token{Token: c.Token}
+	case len(c.OAuthToken) > 0:
+		connection.Credential = &sourcespb.AzureRepos_Oauth{
+			Oauth: &credentialspb.Oauth2{AccessToken: c.OAuthToken},
+		}
+	default:
+		return sources.JobProgressRef{}, fmt.Errorf("must provide a personal access token or an OAuth token")
+	}
+
+	var conn anypb.Any
+	err := anypb.MarshalFrom(&conn, connection, proto.MarshalOptions{})
+	if err != nil {
+		ctx.Logger().Error(err, "failed to marshal azure repos connection")
+		return sources.JobProgressRef{}, err
+	}
+
+	sourceName := "trufflehog - azure repos"
+	sourceID, jobID, _ := e.sourceManager.GetIDs(ctx, sourceName, azurerepos.SourceType)
+
+	azureSource := &azurerepos.Source{}
+	if err := azureSource.Init(ctx, sourceName, jobID, sourceID, true, &conn, runtime.NumCPU()); err != nil {
+		return sources.JobProgressRef{}, err
+	}
+	azureSource.WithScanOptions(scanOptions)
+	if c.IncludePRThreads {
+		azureSource.WithPullRequestThreads()
+	}
+	if c.IncludeWikis {
+		azureSource.WithWikis()
+	}
+	return e.sourceManager.EnumerateAndScan(ctx, sourceName, azureSource)
+}
@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"fmt"
+	"runtime"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/azurestorage"
+)
+
+// ScanAzureStorage scans Azure Blob Storage containers with the provided configuration.
+func (e *Engine) ScanAzureStorage(ctx context.Context, c sources.AzureStorageConfig) (sources.JobProgressRef, error) {
+	connection := &sourcespb.AzureStorage{StorageContainers: c.Containers}
+
+	endpoint := c.Endpoint
+	switch {
+	case len(c.ConnectionString) > 0:
+		connection.Credential = &sourcespb.AzureStorage_ConnectionString{ConnectionString: c.ConnectionString}
+	case len(c.Account) > 0 && len(c.AccountKey) > 0:
+		connection.Credential = &sourcespb.AzureStorage_BasicAuth{
+			BasicAuth: &credentialspb.BasicAuth{Username: c.Account, Password: c.AccountKey},
+		}
+	case len(c.ClientCertificate) > 0:
+		connection.Credential = &sourcespb.AzureStorage_ClientCertificate{ClientCertificate: c.ClientCertificate}
+	default:
+		connection.Credential = &sourcespb.AzureStorage_Unauthenticated{Unauthenticated: &credentialspb.Unauthenticated{}}
+	}
+	if endpoint == "" && len(c.ConnectionString) == 0 {
+		if c.Account == "" {
+			return sources.JobProgressRef{}, fmt.Errorf("a storage account or endpoint is required")
+		}
+		endpoint = fmt.Sprintf("https://%s.blob.core.windows.net", c.Account)
+	}
+
+	var conn anypb.Any
+	err := anypb.MarshalFrom(&conn, connection, proto.MarshalOptions{})
+	if err != nil {
+		ctx.Logger().Error(err, "failed to marshal azure storage connection")
+		return sources.JobProgressRef{}, err
+	}
+
+	sourceName := "trufflehog - azure storage"
+	sourceID, jobID, _ := e.sourceManager.GetIDs(ctx, sourceName, azurestorage.SourceType)
+
+	concurrency := c.Concurrency
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+	azureSource := &azurestorage.Source{}
+	if err := azureSource.Init(ctx, sourceName, jobID, sourceID, true, &conn, concurrency); err != nil {
+		return sources.JobProgressRef{}, err
+	}
+	azureSource.WithEndpoint(endpoint)
+	azureSource.WithServicePrincipal(c.TenantID, c.ClientID)
+	azureSource.WithPrefix(c.Prefix)
+	if err := azureSource.WithIgnoreContainers(c.IgnoreContainers...); err != nil {
+		return sources.JobProgressRef{}, err
+	}
+	if err := azureSource.WithBlobGlobs(c.IncludeBlobs, c.ExcludeBlobs); err != nil {
+		return sources.JobProgressRef{}, err
+	}
+	azureSource.WithMaxObjectSize(c.MaxObjectSize)
+	azureSource.WithSnapshots(c.IncludeSnapshots)
+	azureSource.WithVersions(c.IncludeVersions)
+	return e.sourceManager.EnumerateAndScan(ctx, sourceName, azureSource)
+}
@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"runtime"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/buildkite"
+)
+
+// ScanBuildkite scans Buildkite pipelines and builds with the provided configuration.
+func (e *Engine) ScanBuildkite(ctx context.Context, c sources.BuildkiteConfig) (sources.JobProgressRef, error) {
+	connection := &sourcespb.Buildkite{
+		Credential:      &sourcespb.Buildkite_Token{Token: c.Token},
+		Organizations:   c.Organizations,
+		Pipelines:       c.Pipelines,
+		IgnorePipelines: c.IgnorePipelines,
+		SkipArtifacts:   c.SkipArtifacts,
+	}
+	if !c.CreatedSince.IsZero() {
+		connection.CreatedSince = timestamppb.New(c.CreatedSince)
+	}
+
+	var conn anypb.Any
+	err := anypb.MarshalFrom(&conn, connection, proto.MarshalOptions{})
+	if err != nil {
+		ctx.Logger().Error(err, "failed to marshal buildkite connection")
+		return sources.JobProgressRef{}, err
+	}
+
+	sourceName := "trufflehog - buildkite"
+	sourceID, jobID, _ := e.sourceManager.GetIDs(ctx, sourceName, buildkite.SourceType)
+
+	buildkiteSource := &buildkite.Source{}
+	if err := buildkiteSource.Init(ctx, sourceName, jobID, sourceID, true, &conn, runtime.NumCPU()); err != nil {
+		return sources.JobProgressRef{}, err
+	}
+	return e.sourceManager.EnumerateAndScan(ctx, sourceName, buildkiteSource)
+}
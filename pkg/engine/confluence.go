@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"runtime"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/confluence"
+)
+
+// ScanConfluence scans Confluence spaces with the provided configuration.
+func (e *Engine) ScanConfluence(ctx context.Context, c sources.ConfluenceConfig) (sources.JobProgressRef, error) {
+	connection := &sourcespb.Confluence{
+		Endpoint:              c.Endpoint,
+		SpacesScope:           sourcespb.Confluence_GetAllSpacesScope(sourcespb.Confluence_GetAllSpacesScope_value[strings.ToUpper(c.SpacesScope)]),
+		Spaces:                c.Spaces,
+		IgnoreSpaces:          c.IgnoreSpaces,
+		IncludeAttachments:    c.IncludeAttachments,
+		SkipHistory:           c.SkipHistory,
+		InsecureSkipVerifyTls: c.InsecureSkipVerifyTLS,
+	}
+
+	switch {
+	case len(c.Username) > 0:
+		connection.Credential = &sourcespb.Confluence_BasicAuth{
+			BasicAuth: &credentialspb.BasicAuth{Username: c.Username, Password: c.Token},
+		}
+	case len(c.Token) > 0:
+		connection.Credential = &sourcespb.Confluence_Token{Token: c.Token}
+	default:
+		connection.Credential = &sourcespb.Confluence_Unauthenticated{Unauthenticated: &credentialspb.Unauthenticated{}}
+	}
+
+	var conn anypb.Any
+	err := anypb.MarshalFrom(&conn, connection, proto.MarshalOptions{})
+	if err != nil {
+		ctx.Logger().Error(err, "failed to marshal confluence connection")
+		return sources.JobProgressRef{}, err
+	}
+
+	sourceName := "trufflehog - confluence"
+	sourceID, jobID, _ := e.sourceManager.GetIDs(ctx, sourceName, confluence.SourceType)
+
+	confluenceSource := &confluence.Source{}
+	if err := confluenceSource.Init(ctx, sourceName, jobID, sourceID, true, &conn, runtime.NumCPU()); err != nil {
+		return sources.JobProgressRef{}, err
+	}
+	return e.sourceManager.EnumerateAndScan(ctx, sourceName, confluenceSource)
+}
@@ -0,0 +1,46 @@
+package engine
+
+import (
+	"runtime"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/database"
+)
+
+// ScanDatabase scans the tables of a relational database with the provided configuration.
+func (e *Engine) ScanDatabase(ctx context.Context, c sources.DatabaseConfig) (sources.JobProgressRef, error) {
+	connection := &sourcespb.Database{
+		Driver:           c.Driver,
+		ConnectionString: c.ConnectionString,
+		IncludeTables:    c.IncludeTables,
+		ExcludeTables:    c.ExcludeTables,
+		SampleRows:       c.SampleRows,
+		BatchSize:        c.BatchSize,
+		UpdatedColumn:    c.UpdatedColumn,
+	}
+	if !c.UpdatedSince.IsZero() {
+		connection.UpdatedSince = timestamppb.New(c.UpdatedSince)
+	}
+
+	var conn anypb.Any
+	err := anypb.MarshalFrom(&conn, connection, proto.MarshalOptions{})
+	if err != nil {
+		ctx.Logger().Error(err, "failed to marshal database connection")
+		return sources.JobProgressRef{}, err
+	}
+
+	sourceName := "trufflehog - database"
+	sourceID, jobID, _ := e.sourceManager.GetIDs(ctx, sourceName, database.SourceType)
+
+	databaseSource := &database.Source{}
+	if err := databaseSource.Init(ctx, sourceName, jobID, sourceID, true, &conn, runtime.NumCPU()); err != nil {
+		return sources.JobProgressRef{}, err
+	}
+	return e.sourceManager.EnumerateAndScan(ctx, sourceName, databaseSource)
+}
@@ -39,5 +39,24 @@ func (e *Engine) ScanDocker(ctx context.Context, c sources.DockerConfig) (source
 	if err := dockerSource.Init(ctx, sourceName, jobID, sourceID, true, &conn, runtime.NumCPU()); err != nil {
 		return sources.JobProgressRef{}, err
 	}
+	if c.Platform != "" {
+		if err := dockerSource.WithPlatform(c.Platform); err != nil {
+			return sources.JobProgressRef{}, err
+		}
+	}
+	dockerSource.WithContainerdRoot(c.ContainerdRoot)
+	dockerSource.WithContainerdNamespace(c.ContainerdNamespace)
+	if c.Registry != "" {
+		if err := dockerSource.WithRegistry(c.Registry); err != nil {
+			return sources.JobProgressRef{}, err
+		}
+		if err := dockerSource.WithRepositoryGlobs(c.IncludeRepos, c.ExcludeRepos); err != nil {
+			return sources.JobProgressRef{}, err
+		}
+		if err := dockerSource.WithTagGlobs(c.IncludeTags, c.ExcludeTags); err != nil {
+			return sources.JobProgressRef{}, err
+		}
+		dockerSource.WithMaxTags(c.MaxTags)
+	}
 	return e.sourceManager.EnumerateAndScan(ctx, sourceName, dockerSource)
 }
@@ -90,6 +90,47 @@ func (p *PrinterDispatcher) Dispatch(ctx context.Context, result detectors.Resul
 	return p.printer.Print(ctx, &result)
 }
 
+// FlushingDispatcher is a ResultsDispatcher that buffers results. Flush is
+// called by Engine.Finish once every result has been dispatched.
+type FlushingDispatcher interface {
+	ResultsDispatcher
+	Flush(ctx context.Context) error
+}
+
+// MultiDispatcher sends every result to each of its dispatchers in order.
+type MultiDispatcher struct{ dispatchers []ResultsDispatcher }
+
+var _ FlushingDispatcher = (*MultiDispatcher)(nil)
+
+// NewMultiDispatcher creates a new MultiDispatcher instance with the provided dispatchers.
+func NewMultiDispatcher(dispatchers ...ResultsDispatcher) *MultiDispatcher {
+	return &MultiDispatcher{dispatchers}
+}
+
+// Dispatch sends the result to every dispatcher, even if some of them fail.
+func (m *MultiDispatcher) Dispatch(ctx context.Context, result detectors.ResultWithMetadata) error {
+	var errs []error
+	for _, d := range m.dispatchers {
+		if err := d.Dispatch(ctx, result); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Flush flushes every dispatcher that buffers results.
+func (m *MultiDispatcher) Flush(ctx context.Context) error {
+	var errs []error
+	for _, d := range m.dispatchers {
+		if f, ok := d.(FlushingDispatcher); ok {
+			if err := f.Flush(ctx); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // Config used to configure the engine.
 type Config struct {
 	// Number of concurrent scanner workers,
@@ -733,6 +774,12 @@ func (e *Engine) Finish(ctx context.Context) error {
 	close(e.results)    // Detector workers are done, close the results channel and call it a day.
 	e.WgNotifier.Wait() // Wait for the notifier workers to finish notifying results.
 
+	if f, ok := e.dispatcher.(FlushingDispatcher); ok {
+		if flushErr := f.Flush(ctx); flushErr != nil {
+			ctx.Logger().Error(flushErr, "error flushing results")
+		}
+	}
+
 	e.metrics.ScanDuration = time.Since(e.metrics.scanStartTime)
 
 	return err
@@ -757,6 +804,8 @@ func (e *Engine) ScanChunk(chunk *sources.Chunk) {
 type detectableChunk struct {
 	detector *ahocorasick.DetectorMatch
 	chunk    sources.Chunk
+	// original is the chunk's data before it was decoded.
+	original []byte
 	decoder  detectorspb.DecoderType
 	wgDoneFn func()
 }
@@ -766,6 +815,7 @@ type detectableChunk struct {
 // enabled if the same secret was not found by multiple detectors.
 type verificationOverlapChunk struct {
 	chunk                       sources.Chunk
+	original                    []byte
 	decoder                     detectorspb.DecoderType
 	detectors                   []*ahocorasick.DetectorMatch
 	verificationOverlapWgDoneFn func()
@@ -778,6 +828,9 @@ func (e *Engine) scannerWorker(ctx context.Context) {
 	for chunk := range e.ChunksChan() {
 		startTime := time.Now()
 		sourceVerify := chunk.Verify
+		// Decoders replace the chunk's data, so keep the original around to
+		// locate results within it.
+		original := chunk.Data
 		for _, decoder := range e.decoders {
 			decodeStart := time.Now()
 			decoded := decoder.FromChunk(chunk)
@@ -794,6 +847,7 @@ func (e *Engine) scannerWorker(ctx context.Context) {
 				wgVerificationOverlap.Add(1)
 				e.verificationOverlapChunksChan <- verificationOverlapChunk{
 					chunk:                       *decoded.Chunk,
+					original:                    original,
 					detectors:                   matchingDetectors,
 					decoder:                     decoded.DecoderType,
 					verificationOverlapWgDoneFn: wgVerificationOverlap.Done,
@@ -806,6 +860,7 @@ func (e *Engine) scannerWorker(ctx context.Context) {
 				wgDetect.Add(1)
 				e.detectableChunksChan <- detectableChunk{
 					chunk:    *decoded.Chunk,
+					original: original,
 					detector: detector,
 					decoder:  decoded.DecoderType,
 					wgDoneFn: wgDetect.Done,
@@ -987,6 +1042,7 @@ func (e *Engine) verificationOverlapWorker(ctx context.Context) {
 							ctx,
 							detectableChunk{
 								chunk:    chunk.chunk,
+								original: chunk.original,
 								detector: detector,
 								decoder:  chunk.decoder,
 								wgDoneFn: wgDetect.Done,
@@ -1010,6 +1066,7 @@ func (e *Engine) verificationOverlapWorker(ctx context.Context) {
 			chunk.chunk.Verify = e.shouldVerifyChunk(chunk.chunk.Verify, detector, e.detectorVerificationOverrides)
 			e.detectableChunksChan <- detectableChunk{
 				chunk:    chunk.chunk,
+				original: chunk.original,
 				detector: detector,
 				decoder:  chunk.decoder,
 				wgDoneFn: wgDetect.Done,
@@ -1152,6 +1209,7 @@ func (e *Engine) processResult(
 	res detectors.Result,
 	isFalsePositive func(detectors.Result) (bool, string),
 ) {
+	firstLine := int64(1)
 	ignoreLinePresent := false
 	if SupportsLineNumbers(data.chunk.SourceType) {
 		copyChunk := data.chunk
@@ -1160,6 +1218,7 @@ func (e *Engine) processResult(
 			copyChunk.SourceMetadata = copyMetaData
 		}
 		fragStart, mdLine, link := FragmentFirstLineAndLink(&copyChunk)
+		firstLine = fragStart
 		ignoreLinePresent = SetResultLineNumber(&copyChunk, &res, fragStart, mdLine)
 		if err := UpdateLink(ctx, copyChunk.SourceMetadata, link, *mdLine); err != nil {
 			ctx.Logger().Error(err, "error setting link")
@@ -1171,6 +1230,12 @@ func (e *Engine) processResult(
 		return
 	}
 
+	res.Position = ResultPosition(&data.chunk, data.original, e.locator(data.decoder), &res, firstLine)
+	if md := data.chunk.SourceMetadata; md.GetFileOffset() != nil {
+		// The offset is reported through the result's position instead.
+		data.chunk.SourceMetadata = &source_metadatapb.MetaData{Data: md.Data}
+	}
+
 	secret := detectors.CopyMetadata(&data.chunk, res)
 	secret.DecoderType = data.decoder
 	secret.DetectorDescription = data.detector.Detector.Description()
@@ -1317,6 +1382,7 @@ func FragmentFirstLineAndLink(chunk *sources.Chunk) (int64, *int64, string) {
 // SetResultLineNumber sets the line number in the provided result.
 func SetResultLineNumber(chunk *sources.Chunk, result *detectors.Result, fragStart int64, mdLine *int64) bool {
 	offset, skip := FragmentLineOffset(chunk, result)
+	offset += chunk.SourceMetadata.GetFileOffset().GetLines()
 	*mdLine = fragStart + offset
 	return skip
 }
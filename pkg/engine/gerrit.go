@@ -0,0 +1,56 @@
+package engine
+
+import (
+	"runtime"
+
+	gogit "github.com/go-git/go-git/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/gerrit"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/git"
+)
+
+// ScanGerrit scans Gerrit projects with the provided configuration.
+func (e *Engine) ScanGerrit(ctx context.Context, c sources.GerritConfig) (sources.JobProgressRef, error) {
+	logOptions := &gogit.LogOptions{}
+	opts := []git.ScanOption{
+		git.ScanOptionFilter(c.Filter),
+		git.ScanOptionLogOptions(logOptions),
+	}
+	scanOptions := git.NewScanOptions(opts...)
+
+	connection := &sourcespb.Gerrit{
+		Endpoint:     c.Endpoint,
+		Projects:     c.Projects,
+		SkipBinaries: c.SkipBinaries,
+	}
+	if len(c.Username) > 0 {
+		connection.Credential = &sourcespb.Gerrit_BasicAuth{
+			BasicAuth: &credentialspb.BasicAuth{Username: c.Username, Password: c.Password},
+		}
+	} else {
+		connection.Credential = &sourcespb.Gerrit_Unauthenticated{Unauthenticated: &credentialspb.Unauthenticated{}}
+	}
+
+	var conn anypb.Any
+	err := anypb.MarshalFrom(&conn, connection, proto.MarshalOptions{})
+	if err != nil {
+		ctx.Logger().Error(err, "failed to marshal gerrit connection")
+		return sources.JobProgressRef{}, err
+	}
+
+	sourceName := "trufflehog - gerrit"
+	sourceID, jobID, _ := e.sourceManager.GetIDs(ctx, sourceName, gerrit.SourceType)
+
+	gerritSource := &gerrit.Source{}
+	if err := gerritSource.Init(ctx, sourceName, jobID, sourceID, true, &conn, runtime.NumCPU()); err != nil {
+		return sources.JobProgressRef{}, err
+	}
+	gerritSource.WithScanOptions(scanOptions)
+	return e.sourceManager.EnumerateAndScan(ctx, sourceName, gerritSource)
+}
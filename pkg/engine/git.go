@@ -24,6 +24,7 @@ func (e *Engine) ScanGit(ctx context.Context, c sources.GitConfig) (sources.JobP
 		ExcludePathsFile: c.ExcludePathsFile,
 		MaxDepth:         int64(c.MaxDepth),
 		SkipBinaries:     c.SkipBinaries,
+		ScanUnreachable:  c.ScanUnreachable,
 	}
 	var conn anypb.Any
 	if err := anypb.MarshalFrom(&conn, connection, proto.MarshalOptions{}); err != nil {
@@ -38,6 +39,7 @@ func (e *Engine) ScanGit(ctx context.Context, c sources.GitConfig) (sources.JobP
 	if err := gitSource.Init(ctx, sourceName, jobID, sourceID, true, &conn, runtime.NumCPU()); err != nil {
 		return sources.JobProgressRef{}, err
 	}
+	gitSource.WithState(c.State)
 
 	return e.sourceManager.EnumerateAndScan(ctx, sourceName, gitSource)
 }
@@ -0,0 +1,606 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/config"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/output"
+)
+
+// Payload formats supported by the HTTPDispatcher.
+const (
+	// HTTPFormatGeneric posts a JSON object with a "findings" array.
+	HTTPFormatGeneric = "generic"
+	// HTTPFormatChat posts a {"text": ...} message understood by most chat
+	// incoming webhooks.
+	HTTPFormatChat = "chat"
+	// HTTPFormatTicket posts one ticket-like object per finding, with a title,
+	// description, severity and labels.
+	HTTPFormatTicket = "ticket"
+)
+
+const (
+	defaultHTTPFlushInterval = 5 * time.Second
+	defaultHTTPMaxRetries    = 3
+)
+
+var httpPayloadTemplates = map[string]string{
+	HTTPFormatGeneric: `{"findings": {{ json .Findings }}}`,
+	HTTPFormatChat:    `{"text": {{ json (chatText .Findings) }}}`,
+	HTTPFormatTicket: `{{ with index .Findings 0 -}}
+{"title": {{ json (printf "%s secret found in %s" .DetectorName (location .)) }},
+"description": {{ json (ticketText .) }},
+"severity": {{ if .Verified }}"critical"{{ else }}"medium"{{ end }},
+"labels": ["trufflehog", {{ json .DetectorName }}, {{ json .Status }}],
+"finding": {{ json . }}}
+{{- end }}`,
+}
+
+// HTTPEndpoint is a destination that findings are posted to.
+type HTTPEndpoint struct {
+	URL     string
+	Headers map[string]string
+}
+
+// HTTPDispatcherConfig configures an HTTPDispatcher.
+type HTTPDispatcherConfig struct {
+	Endpoints []HTTPEndpoint
+	// Format selects one of the built-in payload templates. It is ignored
+	// when Template is set.
+	Format string
+	// Template is a custom text/template used to render request bodies. It
+	// is executed with a value holding a Findings slice.
+	Template string
+	// ContentType of the request bodies. Defaults to application/json.
+	ContentType string
+	// BatchSize is the maximum number of findings sent in a single request.
+	// Ticket payloads are always sent one finding at a time.
+	BatchSize int
+	// FlushInterval is the longest a finding waits in a partial batch.
+	FlushInterval time.Duration
+	// MaxRetries is the number of times a failed request is retried. Nil
+	// uses the default, and 0 disables retries.
+	MaxRetries *int
+	// SpoolFile, if set, stores requests that could not be delivered so that
+	// they are retried the next time a dispatcher is created.
+	SpoolFile string
+	// Results limits the dispatched findings by verification status, using
+	// the same values as the engine's Results option. Empty means all.
+	Results map[string]struct{}
+	// Detectors limits the dispatched findings to those of the given
+	// detectors. Empty means all.
+	Detectors []config.DetectorID
+	// Sanitize, if set, is applied to every result before it is rendered.
+	Sanitize func(*detectors.ResultWithMetadata) *detectors.ResultWithMetadata
+	// Client overrides the HTTP client used to send requests.
+	Client *http.Client
+}
+
+// HTTPFinding is the representation of a result that is passed to payload
+// templates and included in the generic payload.
+type HTTPFinding struct {
+	DetectorName        string
+	DetectorDescription string
+	DecoderName         string
+	Verified            bool
+	VerificationError   string `json:",omitempty"`
+	// Status is one of "verified", "unverified" or "unknown".
+	Status         string
+	Raw            string
+	RawV2          string `json:",omitempty"`
+	Redacted       string `json:",omitempty"`
+	SourceName     string
+	SourceType     string
+	SourceMetadata any
+	Unit           string              `json:",omitempty"`
+	File           string              `json:",omitempty"`
+	Line           int64               `json:",omitempty"`
+	Link           string              `json:",omitempty"`
+	Position       *detectors.Position `json:",omitempty"`
+	ExtraData      map[string]string   `json:",omitempty"`
+}
+
+// httpPayload is the value payload templates are executed with.
+type httpPayload struct{ Findings []HTTPFinding }
+
+// spooledRequest is a single undelivered request stored in the spool file.
+type spooledRequest struct {
+	URL  string `json:"url"`
+	Body string `json:"body"`
+}
+
+// HTTPDispatcher is a ResultsDispatcher that posts findings to one or more
+// HTTP endpoints, optionally in batches. Requests that fail after all retries
+// are appended to a spool file, if one is configured.
+type HTTPDispatcher struct {
+	cfg       HTTPDispatcherConfig
+	client    *http.Client
+	tmpl      *template.Template
+	batchSize int
+	detectors map[config.DetectorID]struct{}
+
+	mu      sync.Mutex
+	pending []HTTPFinding
+	spoolMu sync.Mutex
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+var _ FlushingDispatcher = (*HTTPDispatcher)(nil)
+
+// NewHTTPDispatcher creates an HTTPDispatcher. Any requests left in the spool
+// file by a previous run are re-sent before it returns.
+func NewHTTPDispatcher(ctx context.Context, cfg HTTPDispatcherConfig) (*HTTPDispatcher, error) {
+	if len(cfg.Endpoints) == 0 {
+		return nil, errors.New("at least one endpoint is required")
+	}
+	for _, e := range cfg.Endpoints {
+		if !strings.HasPrefix(e.URL, "http://") && !strings.HasPrefix(e.URL, "https://") {
+			return nil, fmt.Errorf("invalid endpoint URL %q", e.URL)
+		}
+	}
+
+	if cfg.Format == "" {
+		cfg.Format = HTTPFormatGeneric
+	}
+	text := cfg.Template
+	if text == "" {
+		var ok bool
+		if text, ok = httpPayloadTemplates[cfg.Format]; !ok {
+			return nil, fmt.Errorf("unknown payload format %q", cfg.Format)
+		}
+	}
+	tmpl, err := template.New("payload").Funcs(httpTemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse payload template: %w", err)
+	}
+
+	if cfg.ContentType == "" {
+		cfg.ContentType = "application/json"
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaultHTTPFlushInterval
+	}
+	maxRetries := defaultHTTPMaxRetries
+	if cfg.MaxRetries != nil {
+		if *cfg.MaxRetries < 0 {
+			return nil, fmt.Errorf("invalid max retries %d", *cfg.MaxRetries)
+		}
+		maxRetries = *cfg.MaxRetries
+	}
+
+	d := &HTTPDispatcher{
+		cfg:       cfg,
+		client:    cfg.Client,
+		tmpl:      tmpl,
+		batchSize: cfg.BatchSize,
+		detectors: make(map[config.DetectorID]struct{}, len(cfg.Detectors)),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	if d.client == nil {
+		d.client = common.RetryableHTTPClient(common.WithMaxRetries(maxRetries), common.WithTimeout(30*time.Second))
+	}
+	if d.batchSize <= 0 || (cfg.Template == "" && cfg.Format == HTTPFormatTicket) {
+		d.batchSize = 1
+	}
+	for _, id := range cfg.Detectors {
+		d.detectors[id] = struct{}{}
+	}
+
+	if err := d.replaySpool(ctx); err != nil {
+		return nil, err
+	}
+
+	if d.batchSize > 1 {
+		go d.flushPeriodically(ctx)
+	} else {
+		close(d.done)
+	}
+	return d, nil
+}
+
+// Dispatch queues the result to be posted to the configured endpoints. The
+// request is sent immediately unless batching is enabled.
+func (d *HTTPDispatcher) Dispatch(ctx context.Context, result detectors.ResultWithMetadata) error {
+	if !d.wants(&result) {
+		return nil
+	}
+	r := &result
+	if d.cfg.Sanitize != nil {
+		r = d.cfg.Sanitize(r)
+	}
+
+	d.mu.Lock()
+	d.pending = append(d.pending, newHTTPFinding(r))
+	var batch []HTTPFinding
+	if len(d.pending) >= d.batchSize {
+		batch, d.pending = d.pending, nil
+	}
+	d.mu.Unlock()
+
+	return d.send(ctx, batch)
+}
+
+// Flush sends any findings waiting in a partial batch and stops the periodic
+// flush. It must be called once all results have been dispatched.
+func (d *HTTPDispatcher) Flush(ctx context.Context) error {
+	select {
+	case <-d.stop:
+	default:
+		close(d.stop)
+	}
+	<-d.done
+
+	d.mu.Lock()
+	batch := d.pending
+	d.pending = nil
+	d.mu.Unlock()
+
+	return d.send(ctx, batch)
+}
+
+func (d *HTTPDispatcher) flushPeriodically(ctx context.Context) {
+	defer close(d.done)
+	ticker := time.NewTicker(d.cfg.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+			d.mu.Lock()
+			batch := d.pending
+			d.pending = nil
+			d.mu.Unlock()
+			if err := d.send(ctx, batch); err != nil {
+				ctx.Logger().Error(err, "error sending findings")
+			}
+		}
+	}
+}
+
+// wants reports whether the result passes the status and detector filters.
+func (d *HTTPDispatcher) wants(r *detectors.ResultWithMetadata) bool {
+	if len(d.cfg.Results) > 0 {
+		if _, ok := d.cfg.Results[resultStatus(r)]; !ok {
+			return false
+		}
+	}
+	if len(d.detectors) == 0 {
+		return true
+	}
+	if _, ok := d.detectors[config.DetectorID{ID: r.DetectorType}]; ok {
+		return true
+	}
+	var version int
+	if v, ok := r.ExtraData["version"]; ok {
+		_, _ = fmt.Sscan(v, &version)
+	}
+	_, ok := d.detectors[config.DetectorID{ID: r.DetectorType, Version: version}]
+	return ok
+}
+
+func resultStatus(r *detectors.ResultWithMetadata) string {
+	switch {
+	case r.Verified:
+		return "verified"
+	case r.VerificationError() != nil:
+		return "unknown"
+	default:
+		return "unverified"
+	}
+}
+
+func newHTTPFinding(r *detectors.ResultWithMetadata) HTTPFinding {
+	loc := output.ResultLocation(r)
+	f := HTTPFinding{
+		DetectorName:        r.DetectorType.String(),
+		DetectorDescription: r.DetectorDescription,
+		DecoderName:         r.DecoderType.String(),
+		Verified:            r.Verified,
+		Status:              resultStatus(r),
+		Raw:                 string(r.Raw),
+		RawV2:               string(r.RawV2),
+		Redacted:            r.Redacted,
+		SourceName:          r.SourceName,
+		SourceType:          r.SourceType.String(),
+		Unit:                loc.Unit,
+		File:                loc.File,
+		Line:                loc.Line,
+		Link:                loc.Link,
+		Position:            r.Position,
+		ExtraData:           r.ExtraData,
+	}
+	if r.DetectorName != "" {
+		f.DetectorName = r.DetectorName
+	}
+	if err := r.VerificationError(); err != nil {
+		f.VerificationError = err.Error()
+	}
+	if r.SourceMetadata != nil {
+		f.SourceMetadata = r.SourceMetadata.Data
+	}
+	return f
+}
+
+// send renders the batch and posts it to every endpoint.
+func (d *HTTPDispatcher) send(ctx context.Context, batch []HTTPFinding) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := d.tmpl.Execute(&buf, httpPayload{Findings: batch}); err != nil {
+		return fmt.Errorf("could not render payload: %w", err)
+	}
+
+	var errs []error
+	for _, endpoint := range d.cfg.Endpoints {
+		err := d.post(ctx, endpoint, buf.String())
+		if err == nil {
+			continue
+		}
+		var permanent *permanentHTTPError
+		if !errors.As(err, &permanent) {
+			if spoolErr := d.spool(spooledRequest{URL: endpoint.URL, Body: buf.String()}); spoolErr != nil {
+				err = errors.Join(err, spoolErr)
+			}
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// permanentHTTPError is returned for responses that won't succeed if the
+// request is retried, and so aren't spooled.
+type permanentHTTPError struct {
+	url    string
+	status int
+}
+
+func (e *permanentHTTPError) Error() string {
+	return fmt.Sprintf("%s responded with status %d", e.url, e.status)
+}
+
+func (d *HTTPDispatcher) post(ctx context.Context, endpoint HTTPEndpoint, body string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", d.cfg.ContentType)
+	for k, v := range endpoint.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not post findings to %s: %w", endpoint.URL, err)
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("%s responded with status %d", endpoint.URL, resp.StatusCode)
+	default:
+		return &permanentHTTPError{url: endpoint.URL, status: resp.StatusCode}
+	}
+}
+
+func (d *HTTPDispatcher) spool(req spooledRequest) error {
+	if d.cfg.SpoolFile == "" {
+		return nil
+	}
+	line, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	d.spoolMu.Lock()
+	defer d.spoolMu.Unlock()
+	f, err := os.OpenFile(d.cfg.SpoolFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("could not open spool file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("could not write to spool file: %w", err)
+	}
+	return nil
+}
+
+// replaySpool re-sends requests stored in the spool file. Requests that still
+// can't be delivered are kept for the next run. The spool file is only
+// replaced once every request has been tried, so an interrupted replay never
+// loses findings, although it may deliver some of them twice.
+func (d *HTTPDispatcher) replaySpool(ctx context.Context) error {
+	if d.cfg.SpoolFile == "" {
+		return nil
+	}
+	f, err := os.Open(d.cfg.SpoolFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not open spool file: %w", err)
+	}
+
+	var spooled []spooledRequest
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var req spooledRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			ctx.Logger().Error(err, "skipping malformed spool entry")
+			continue
+		}
+		spooled = append(spooled, req)
+	}
+	_ = f.Close()
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("could not read spool file: %w", err)
+	}
+
+	var (
+		delivered   int
+		undelivered []spooledRequest
+	)
+	for _, req := range spooled {
+		err := d.post(ctx, HTTPEndpoint{URL: req.URL, Headers: d.headersFor(req.URL)}, req.Body)
+		var permanent *permanentHTTPError
+		switch {
+		case err == nil:
+			delivered++
+		case errors.As(err, &permanent):
+			ctx.Logger().Error(err, "dropping spooled findings")
+		default:
+			undelivered = append(undelivered, req)
+		}
+	}
+	if err := d.rewriteSpool(undelivered); err != nil {
+		return err
+	}
+	if len(spooled) > 0 {
+		ctx.Logger().Info("replayed spooled findings", "delivered", delivered, "total", len(spooled))
+	}
+	return nil
+}
+
+// rewriteSpool atomically replaces the spool file with the given requests,
+// removing it if there are none.
+func (d *HTTPDispatcher) rewriteSpool(reqs []spooledRequest) error {
+	d.spoolMu.Lock()
+	defer d.spoolMu.Unlock()
+
+	if len(reqs) == 0 {
+		if err := os.Remove(d.cfg.SpoolFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("could not remove spool file: %w", err)
+		}
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(d.cfg.SpoolFile), filepath.Base(d.cfg.SpoolFile)+".*")
+	if err != nil {
+		return fmt.Errorf("could not create spool file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	for _, req := range reqs {
+		line, err := json.Marshal(req)
+		if err != nil {
+			_ = tmp.Close()
+			return err
+		}
+		_, _ = w.Write(append(line, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("could not write to spool file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("could not write to spool file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), d.cfg.SpoolFile); err != nil {
+		return fmt.Errorf("could not replace spool file: %w", err)
+	}
+	return nil
+}
+
+// headersFor returns the headers configured for the endpoint with the given
+// URL, so that spooled requests are replayed with current credentials.
+func (d *HTTPDispatcher) headersFor(url string) map[string]string {
+	for _, e := range d.cfg.Endpoints {
+		if e.URL == url {
+			return e.Headers
+		}
+	}
+	return nil
+}
+
+var httpTemplateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"location":   findingLocation,
+	"chatText":   chatText,
+	"ticketText": ticketText,
+}
+
+func findingLocation(f HTTPFinding) string {
+	switch {
+	case f.Link != "":
+		return f.Link
+	case f.File != "" && f.Line > 0:
+		return fmt.Sprintf("%s:%d", f.File, f.Line)
+	case f.File != "":
+		return f.File
+	case f.Unit != "":
+		return f.Unit
+	default:
+		return f.SourceName
+	}
+}
+
+func findingSecret(f HTTPFinding) string {
+	if f.Redacted != "" {
+		return f.Redacted
+	}
+	return f.Raw
+}
+
+func chatText(findings []HTTPFinding) string {
+	var sb strings.Builder
+	for i, f := range findings {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "%s %s secret found in %s (%s)", statusEmoji(f), f.DetectorName, findingLocation(f), findingSecret(f))
+	}
+	return sb.String()
+}
+
+func ticketText(f HTTPFinding) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "TruffleHog found a %s %s secret.\n\n", f.Status, f.DetectorName)
+	fmt.Fprintf(&sb, "Secret: %s\n", findingSecret(f))
+	fmt.Fprintf(&sb, "Source: %s (%s)\n", f.SourceName, f.SourceType)
+	fmt.Fprintf(&sb, "Location: %s\n", findingLocation(f))
+	fmt.Fprintf(&sb, "Decoder: %s\n", f.DecoderName)
+	if f.VerificationError != "" {
+		fmt.Fprintf(&sb, "Verification error: %s\n", f.VerificationError)
+	}
+	return sb.String()
+}
+
+func statusEmoji(f HTTPFinding) string {
+	switch f.Status {
+	case "verified":
+		return "✅"
+	case "unknown":
+		return "⚠️"
+	default:
+		return "❓"
+	}
+}
@@ -0,0 +1,281 @@
+package engine
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/config"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+)
+
+type recordingServer struct {
+	*httptest.Server
+	mu     sync.Mutex
+	bodies []string
+	status int
+}
+
+func newRecordingServer(t *testing.T) *recordingServer {
+	t.Helper()
+	s := &recordingServer{status: http.StatusOK}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.status == http.StatusOK {
+			s.bodies = append(s.bodies, string(body))
+		}
+		w.WriteHeader(s.status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *recordingServer) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.bodies...)
+}
+
+func (s *recordingServer) setStatus(status int) {
+	s.mu.Lock()
+	s.status = status
+	s.mu.Unlock()
+}
+
+func testHTTPResult(detector detectorspb.DetectorType, verified bool) detectors.ResultWithMetadata {
+	return detectors.ResultWithMetadata{
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Filesystem{Filesystem: &source_metadatapb.Filesystem{File: "config.yaml", Line: 3}},
+		},
+		SourceName: "test",
+		Result: detectors.Result{
+			DetectorType: detector,
+			Verified:     verified,
+			Raw:          []byte("secret-" + detector.String()),
+			Redacted:     "redacted-" + detector.String(),
+		},
+	}
+}
+
+func TestHTTPDispatcher_Generic(t *testing.T) {
+	ctx := context.Background()
+	srv := newRecordingServer(t)
+
+	d, err := NewHTTPDispatcher(ctx, HTTPDispatcherConfig{
+		Endpoints: []HTTPEndpoint{{URL: srv.URL}},
+		BatchSize: 2,
+		Results:   map[string]struct{}{"verified": {}},
+		Detectors: []config.DetectorID{{ID: detectorspb.DetectorType_AWS}, {ID: detectorspb.DetectorType_Github}},
+		Client:    http.DefaultClient,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, d.Dispatch(ctx, testHTTPResult(detectorspb.DetectorType_AWS, true)))
+	require.NoError(t, d.Dispatch(ctx, testHTTPResult(detectorspb.DetectorType_AWS, false)))
+	require.NoError(t, d.Dispatch(ctx, testHTTPResult(detectorspb.DetectorType_Stripe, true)))
+	assert.Empty(t, srv.received(), "a partial batch should not be sent")
+
+	require.NoError(t, d.Dispatch(ctx, testHTTPResult(detectorspb.DetectorType_Github, true)))
+	require.NoError(t, d.Dispatch(ctx, testHTTPResult(detectorspb.DetectorType_Github, true)))
+	require.NoError(t, d.Flush(ctx))
+
+	bodies := srv.received()
+	require.Len(t, bodies, 2)
+
+	var payload struct{ Findings []HTTPFinding }
+	require.NoError(t, json.Unmarshal([]byte(bodies[0]), &payload))
+	require.Len(t, payload.Findings, 2)
+	assert.Equal(t, "AWS", payload.Findings[0].DetectorName)
+	assert.Equal(t, "verified", payload.Findings[0].Status)
+	assert.Equal(t, "config.yaml", payload.Findings[0].File)
+	assert.Equal(t, int64(3), payload.Findings[0].Line)
+
+	require.NoError(t, json.Unmarshal([]byte(bodies[1]), &payload))
+	assert.Len(t, payload.Findings, 1)
+}
+
+func TestHTTPDispatcher_Formats(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		format string
+		check  func(t *testing.T, body map[string]any)
+	}{
+		{
+			format: HTTPFormatChat,
+			check: func(t *testing.T, body map[string]any) {
+				assert.Equal(t, "✅ AWS secret found in config.yaml:3 (redacted-AWS)", body["text"])
+			},
+		},
+		{
+			format: HTTPFormatTicket,
+			check: func(t *testing.T, body map[string]any) {
+				assert.Equal(t, "AWS secret found in config.yaml:3", body["title"])
+				assert.Equal(t, "critical", body["severity"])
+				assert.Contains(t, body["description"], "Secret: redacted-AWS")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			srv := newRecordingServer(t)
+			d, err := NewHTTPDispatcher(ctx, HTTPDispatcherConfig{
+				Endpoints: []HTTPEndpoint{{URL: srv.URL}},
+				Format:    tt.format,
+				Client:    http.DefaultClient,
+			})
+			require.NoError(t, err)
+			require.NoError(t, d.Dispatch(ctx, testHTTPResult(detectorspb.DetectorType_AWS, true)))
+			require.NoError(t, d.Flush(ctx))
+
+			bodies := srv.received()
+			require.Len(t, bodies, 1)
+			var body map[string]any
+			require.NoError(t, json.Unmarshal([]byte(bodies[0]), &body))
+			tt.check(t, body)
+		})
+	}
+}
+
+func TestHTTPDispatcher_Spool(t *testing.T) {
+	ctx := context.Background()
+	srv := newRecordingServer(t)
+	srv.setStatus(http.StatusServiceUnavailable)
+	spool := filepath.Join(t.TempDir(), "spool.jsonl")
+
+	cfg := HTTPDispatcherConfig{
+		Endpoints: []HTTPEndpoint{{URL: srv.URL}},
+		SpoolFile: spool,
+		Client:    http.DefaultClient,
+	}
+	d, err := NewHTTPDispatcher(ctx, cfg)
+	require.NoError(t, err)
+	assert.Error(t, d.Dispatch(ctx, testHTTPResult(detectorspb.DetectorType_AWS, true)))
+	require.NoError(t, d.Flush(ctx))
+	assert.FileExists(t, spool)
+
+	// The spooled request is delivered once the endpoint recovers.
+	srv.setStatus(http.StatusOK)
+	d, err = NewHTTPDispatcher(ctx, cfg)
+	require.NoError(t, err)
+	require.NoError(t, d.Flush(ctx))
+
+	assert.Len(t, srv.received(), 1)
+	_, err = os.Stat(spool)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestHTTPDispatcher_SpoolKeptOnInterruptedReplay(t *testing.T) {
+	ctx := context.Background()
+	srv := newRecordingServer(t)
+	srv.setStatus(http.StatusServiceUnavailable)
+	spool := filepath.Join(t.TempDir(), "spool.jsonl")
+
+	cfg := HTTPDispatcherConfig{
+		Endpoints: []HTTPEndpoint{{URL: srv.URL}},
+		SpoolFile: spool,
+		Client:    http.DefaultClient,
+	}
+	d, err := NewHTTPDispatcher(ctx, cfg)
+	require.NoError(t, err)
+	assert.Error(t, d.Dispatch(ctx, testHTTPResult(detectorspb.DetectorType_AWS, true)))
+	assert.Error(t, d.Dispatch(ctx, testHTTPResult(detectorspb.DetectorType_Github, false)))
+	require.NoError(t, d.Flush(ctx))
+	before, err := os.ReadFile(spool)
+	require.NoError(t, err)
+
+	// A replay that is canceled before anything is delivered keeps every
+	// spooled request.
+	srv.setStatus(http.StatusOK)
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = NewHTTPDispatcher(canceled, cfg)
+	require.NoError(t, err)
+	after, err := os.ReadFile(spool)
+	require.NoError(t, err)
+	assert.Equal(t, string(before), string(after))
+	assert.Empty(t, srv.received())
+
+	d, err = NewHTTPDispatcher(ctx, cfg)
+	require.NoError(t, err)
+	require.NoError(t, d.Flush(ctx))
+	assert.Len(t, srv.received(), 2)
+	_, err = os.Stat(spool)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestHTTPDispatcher_SpoolKeepsUndelivered(t *testing.T) {
+	ctx := context.Background()
+	up := newRecordingServer(t)
+	down := newRecordingServer(t)
+	down.setStatus(http.StatusServiceUnavailable)
+	spool := filepath.Join(t.TempDir(), "spool.jsonl")
+
+	// Both endpoints fail at first, so both requests are spooled.
+	up.setStatus(http.StatusServiceUnavailable)
+	cfg := HTTPDispatcherConfig{
+		Endpoints: []HTTPEndpoint{{URL: up.URL}, {URL: down.URL}},
+		SpoolFile: spool,
+		Client:    http.DefaultClient,
+	}
+	d, err := NewHTTPDispatcher(ctx, cfg)
+	require.NoError(t, err)
+	assert.Error(t, d.Dispatch(ctx, testHTTPResult(detectorspb.DetectorType_AWS, true)))
+
+	up.setStatus(http.StatusOK)
+	_, err = NewHTTPDispatcher(ctx, cfg)
+	require.NoError(t, err)
+	assert.Len(t, up.received(), 1)
+
+	spooled, err := os.ReadFile(spool)
+	require.NoError(t, err)
+	var req spooledRequest
+	require.NoError(t, json.Unmarshal(spooled, &req))
+	assert.Equal(t, down.URL, req.URL)
+}
+
+func TestHTTPDispatcher_MaxRetries(t *testing.T) {
+	ctx := context.Background()
+	var (
+		mu       sync.Mutex
+		attempts int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts++
+		mu.Unlock()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+
+	noRetries := 0
+	d, err := NewHTTPDispatcher(ctx, HTTPDispatcherConfig{
+		Endpoints:  []HTTPEndpoint{{URL: srv.URL}},
+		MaxRetries: &noRetries,
+	})
+	require.NoError(t, err)
+	assert.Error(t, d.Dispatch(ctx, testHTTPResult(detectorspb.DetectorType_AWS, true)))
+	mu.Lock()
+	assert.Equal(t, 1, attempts)
+	mu.Unlock()
+
+	negative := -1
+	_, err = NewHTTPDispatcher(ctx, HTTPDispatcherConfig{
+		Endpoints:  []HTTPEndpoint{{URL: srv.URL}},
+		MaxRetries: &negative,
+	})
+	assert.Error(t, err)
+}
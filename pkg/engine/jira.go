@@ -0,0 +1,56 @@
+package engine
+
+import (
+	"runtime"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/jira"
+)
+
+// ScanJira scans Jira issues with the provided configuration.
+func (e *Engine) ScanJira(ctx context.Context, c sources.JiraConfig) (sources.JobProgressRef, error) {
+	connection := &sourcespb.JIRA{
+		Endpoint:              c.Endpoint,
+		Projects:              c.Projects,
+		IgnoreProjects:        c.IgnoreProjects,
+		InsecureSkipVerifyTls: c.InsecureSkipVerifyTLS,
+	}
+
+	switch {
+	case len(c.Username) > 0:
+		connection.Credential = &sourcespb.JIRA_BasicAuth{
+			BasicAuth: &credentialspb.BasicAuth{Username: c.Username, Password: c.Token},
+		}
+	case len(c.Token) > 0:
+		connection.Credential = &sourcespb.JIRA_Token{Token: c.Token}
+	case len(c.OAuthToken) > 0:
+		connection.Credential = &sourcespb.JIRA_Oauth{
+			Oauth: &credentialspb.Oauth2{AccessToken: c.OAuthToken},
+		}
+	default:
+		connection.Credential = &sourcespb.JIRA_Unauthenticated{Unauthenticated: &credentialspb.Unauthenticated{}}
+	}
+
+	var conn anypb.Any
+	err := anypb.MarshalFrom(&conn, connection, proto.MarshalOptions{})
+	if err != nil {
+		ctx.Logger().Error(err, "failed to marshal jira connection")
+		return sources.JobProgressRef{}, err
+	}
+
+	sourceName := "trufflehog - jira"
+	sourceID, jobID, _ := e.sourceManager.GetIDs(ctx, sourceName, jira.SourceType)
+
+	jiraSource := &jira.Source{}
+	if err := jiraSource.Init(ctx, sourceName, jobID, sourceID, true, &conn, runtime.NumCPU()); err != nil {
+		return sources.JobProgressRef{}, err
+	}
+	jiraSource.WithUpdatedSince(c.UpdatedSince)
+	return e.sourceManager.EnumerateAndScan(ctx, sourceName, jiraSource)
+}
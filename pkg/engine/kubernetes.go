@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/kubernetes"
+)
+
+// ScanKubernetes scans the namespaces of a Kubernetes cluster with the provided configuration.
+func (e *Engine) ScanKubernetes(ctx context.Context, c sources.KubernetesConfig) (sources.JobProgressRef, error) {
+	connection := &sourcespb.Kubernetes{
+		Context:        c.Context,
+		Namespaces:     c.Namespaces,
+		IncludeSecrets: c.IncludeSecrets,
+		Resources:      c.Resources,
+	}
+
+	switch {
+	case c.InCluster:
+		connection.Credential = &sourcespb.Kubernetes_InCluster{InCluster: true}
+	default:
+		// KUBECONFIG may list several files. Only the first one is read.
+		path, _, _ := strings.Cut(c.Kubeconfig, string(os.PathListSeparator))
+		if path == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return sources.JobProgressRef{}, fmt.Errorf("could not find the default kubeconfig: %w", err)
+			}
+			path = filepath.Join(home, ".kube", "config")
+		}
+		kubeconfig, err := os.ReadFile(path)
+		if err != nil {
+			return sources.JobProgressRef{}, fmt.Errorf("could not read kubeconfig: %w", err)
+		}
+		connection.Credential = &sourcespb.Kubernetes_Kubeconfig{Kubeconfig: string(kubeconfig)}
+	}
+
+	var conn anypb.Any
+	err := anypb.MarshalFrom(&conn, connection, proto.MarshalOptions{})
+	if err != nil {
+		ctx.Logger().Error(err, "failed to marshal kubernetes connection")
+		return sources.JobProgressRef{}, err
+	}
+
+	sourceName := "trufflehog - kubernetes"
+	sourceID, jobID, _ := e.sourceManager.GetIDs(ctx, sourceName, kubernetes.SourceType)
+
+	kubernetesSource := &kubernetes.Source{}
+	if err := kubernetesSource.Init(ctx, sourceName, jobID, sourceID, true, &conn, runtime.NumCPU()); err != nil {
+		return sources.JobProgressRef{}, err
+	}
+	return e.sourceManager.EnumerateAndScan(ctx, sourceName, kubernetesSource)
+}
@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"bytes"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/decoders"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// ResultPosition locates the result's raw secret within the chunk. original is
+// the chunk's data before decoding; when the decoder can locate the secret in
+// it, the position refers to the encoded secret in the original data.
+// Otherwise it refers to the secret in the decoded data, and no byte range is
+// reported. firstLine is the line number the chunk's data starts on, not
+// counting the file offset in the chunk's metadata. Nil is returned if the secret can't be
+// found.
+func ResultPosition(
+	chunk *sources.Chunk,
+	original []byte,
+	locator decoders.Locator,
+	result *detectors.Result,
+	firstLine int64,
+) *detectors.Position {
+	if len(result.Raw) == 0 {
+		return nil
+	}
+
+	var (
+		data       = chunk.Data
+		start, end int
+		mapped     bool
+	)
+	if locator != nil && original != nil {
+		start, end, mapped = locator.Locate(original, result.Raw)
+	}
+	if mapped {
+		data = original
+	} else {
+		start = bytes.Index(chunk.Data, result.Raw)
+		if start == -1 {
+			return nil
+		}
+		end = start + len(result.Raw)
+	}
+
+	fileOffset := chunk.SourceMetadata.GetFileOffset()
+	firstLine += fileOffset.GetLines()
+	column := fileOffset.GetColumn()
+
+	pos := new(detectors.Position)
+	pos.StartLine, pos.StartColumn = lineAndColumn(data, start, firstLine, column)
+	pos.EndLine, pos.EndColumn = lineAndColumn(data, end, firstLine, column)
+	if mapped && fileOffset != nil {
+		pos.Bytes = &detectors.ByteRange{
+			Start: fileOffset.GetByte() + int64(start),
+			End:   fileOffset.GetByte() + int64(end),
+		}
+	}
+	return pos
+}
+
+// lineAndColumn returns the 1-based line and column of offset within data.
+// firstColumn is the number of bytes that precede data on its first line.
+func lineAndColumn(data []byte, offset int, firstLine, firstColumn int64) (int64, int64) {
+	before := data[:offset]
+	line := firstLine + int64(bytes.Count(before, []byte("\n")))
+	if idx := bytes.LastIndexByte(before, '\n'); idx != -1 {
+		return line, int64(offset-idx-1) + 1
+	}
+	return line, firstColumn + int64(offset) + 1
+}
+
+// locator returns the engine's decoder of the given type if it can locate
+// decoded data within the original data.
+func (e *Engine) locator(decoderType detectorspb.DecoderType) decoders.Locator {
+	for _, d := range e.decoders {
+		if d.Type() != decoderType {
+			continue
+		}
+		if l, ok := d.(decoders.Locator); ok {
+			return l
+		}
+		return nil
+	}
+	return nil
+}
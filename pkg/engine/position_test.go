@@ -0,0 +1,143 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/decoders"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+func TestResultPosition(t *testing.T) {
+	tests := []struct {
+		name      string
+		chunk     *sources.Chunk
+		original  string
+		locator   decoders.Locator
+		raw       string
+		firstLine int64
+		want      *detectors.Position
+	}{
+		{
+			name:      "plain text",
+			chunk:     &sources.Chunk{Data: []byte("first\nkey = secret\n")},
+			original:  "first\nkey = secret\n",
+			locator:   &decoders.UTF8{},
+			raw:       "secret",
+			firstLine: 1,
+			want:      &detectors.Position{StartLine: 2, StartColumn: 7, EndLine: 2, EndColumn: 13},
+		},
+		{
+			name: "file offset",
+			chunk: &sources.Chunk{
+				Data:           []byte("ne\nkey = secret\n"),
+				SourceMetadata: withOffset(sources.FileOffset{Byte: 100, Lines: 9, Column: 3}),
+			},
+			original:  "ne\nkey = secret\n",
+			locator:   &decoders.UTF8{},
+			raw:       "ne",
+			firstLine: 1,
+			want: &detectors.Position{
+				StartLine: 10, StartColumn: 4, EndLine: 10, EndColumn: 6,
+				Bytes: &detectors.ByteRange{Start: 100, End: 102},
+			},
+		},
+		{
+			name:      "base64 mapped to encoded text",
+			chunk:     &sources.Chunk{Data: []byte("token: longer-encoded-secret-test"), SourceMetadata: withOffset(sources.FileOffset{})},
+			original:  "token: bG9uZ2VyLWVuY29kZWQtc2VjcmV0LXRlc3Q=",
+			locator:   &decoders.Base64{},
+			raw:       "longer-encoded-secret-test",
+			firstLine: 1,
+			want: &detectors.Position{
+				StartLine: 1, StartColumn: 8, EndLine: 1, EndColumn: 44,
+				Bytes: &detectors.ByteRange{Start: 7, End: 43},
+			},
+		},
+		{
+			name:      "unmapped decoder uses decoded data",
+			chunk:     &sources.Chunk{Data: []byte("a\nb secret"), SourceMetadata: withOffset(sources.FileOffset{})},
+			original:  `a\u000ab secret`,
+			raw:       "secret",
+			firstLine: 5,
+			want:      &detectors.Position{StartLine: 6, StartColumn: 3, EndLine: 6, EndColumn: 9},
+		},
+		{
+			name:      "not found",
+			chunk:     &sources.Chunk{Data: []byte("nothing here")},
+			original:  "nothing here",
+			locator:   &decoders.UTF8{},
+			raw:       "secret",
+			firstLine: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ResultPosition(tt.chunk, []byte(tt.original), tt.locator, &detectors.Result{Raw: []byte(tt.raw)}, tt.firstLine)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func withOffset(offset sources.FileOffset) *source_metadatapb.MetaData {
+	return sources.WithFileOffset(&source_metadatapb.MetaData{}, offset)
+}
+
+type positionCaptureDispatcher struct {
+	positions []*detectors.Position
+	metadata  []*source_metadatapb.MetaData
+}
+
+func (d *positionCaptureDispatcher) Dispatch(_ context.Context, result detectors.ResultWithMetadata) error {
+	d.positions = append(d.positions, result.Position)
+	d.metadata = append(d.metadata, result.SourceMetadata)
+	return nil
+}
+
+func TestEngine_ResultPositionAcrossChunks(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Place the secret past the first chunk and its peek.
+	filler := strings.Repeat("a\n", 7000)
+	prefix := "xx " + fakeDetectorKeyword + " "
+	path := filepath.Join(t.TempDir(), "creds.txt")
+	require.NoError(t, os.WriteFile(path, []byte(filler+prefix+"fake secret v1\n"), 0o600))
+
+	capturer := new(positionCaptureDispatcher)
+	conf := Config{
+		Concurrency:   1,
+		Decoders:      decoders.DefaultDecoders(),
+		Detectors:     []detectors.Detector{fakeDetectorV1{}},
+		SourceManager: sources.NewManager(sources.WithSourceUnits(), sources.WithBufferedOutput(64)),
+		Dispatcher:    capturer,
+	}
+	eng, err := NewEngine(ctx, &conf)
+	require.NoError(t, err)
+	eng.Start(ctx)
+
+	_, err = eng.ScanFileSystem(ctx, sources.FilesystemConfig{Paths: []string{path}})
+	require.NoError(t, err)
+	require.NoError(t, eng.Finish(ctx))
+
+	start := int64(len(filler) + len(prefix))
+	want := &detectors.Position{
+		StartLine: 7001, StartColumn: int64(len(prefix)) + 1,
+		EndLine: 7001, EndColumn: int64(len(prefix)) + 15,
+		Bytes: &detectors.ByteRange{Start: start, End: start + 14},
+	}
+	require.Len(t, capturer.positions, 1)
+	assert.Equal(t, want, capturer.positions[0])
+	// The offset is only reported through the position.
+	assert.Nil(t, capturer.metadata[0].GetFileOffset())
+	assert.Equal(t, int64(7001), capturer.metadata[0].GetFilesystem().GetLine())
+}
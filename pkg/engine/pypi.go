@@ -0,0 +1,42 @@
+package engine
+
+import (
+	"runtime"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/pypi"
+)
+
+// ScanPyPI scans Python package releases with the provided configuration.
+func (e *Engine) ScanPyPI(ctx context.Context, c sources.PyPIConfig) (sources.JobProgressRef, error) {
+	connection := &sourcespb.PyPIUnauthenticatedPackage{
+		Credential: &sourcespb.PyPIUnauthenticatedPackage_Unauthenticated{Unauthenticated: &credentialspb.Unauthenticated{}},
+	}
+
+	var conn anypb.Any
+	err := anypb.MarshalFrom(&conn, connection, proto.MarshalOptions{})
+	if err != nil {
+		ctx.Logger().Error(err, "failed to marshal pypi connection")
+		return sources.JobProgressRef{}, err
+	}
+
+	sourceName := "trufflehog - pypi"
+	sourceID, jobID, _ := e.sourceManager.GetIDs(ctx, sourceName, pypi.SourceType)
+
+	pypiSource := &pypi.Source{}
+	if err := pypiSource.Init(ctx, sourceName, jobID, sourceID, true, &conn, runtime.NumCPU()); err != nil {
+		return sources.JobProgressRef{}, err
+	}
+	pypiSource.WithIndexURL(c.IndexURL)
+	pypiSource.WithProjects(c.Projects...)
+	if len(c.Versions) > 0 {
+		pypiSource.WithVersions(c.Versions...)
+	}
+	return e.sourceManager.EnumerateAndScan(ctx, sourceName, pypiSource)
+}
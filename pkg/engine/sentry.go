@@ -0,0 +1,40 @@
+package engine
+
+import (
+	"runtime"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/sentry"
+)
+
+// ScanSentry scans Sentry events with the provided configuration.
+func (e *Engine) ScanSentry(ctx context.Context, c sources.SentryConfig) (sources.JobProgressRef, error) {
+	connection := &sourcespb.Sentry{
+		Endpoint:              c.Endpoint,
+		Credential:            &sourcespb.Sentry_AuthToken{AuthToken: c.AuthToken},
+		Projects:              strings.Join(c.Projects, ","),
+		InsecureSkipVerifyTls: c.InsecureSkipVerifyTLS,
+	}
+
+	var conn anypb.Any
+	err := anypb.MarshalFrom(&conn, connection, proto.MarshalOptions{})
+	if err != nil {
+		ctx.Logger().Error(err, "failed to marshal sentry connection")
+		return sources.JobProgressRef{}, err
+	}
+
+	sourceName := "trufflehog - sentry"
+	sourceID, jobID, _ := e.sourceManager.GetIDs(ctx, sourceName, sentry.SourceType)
+
+	sentrySource := &sentry.Source{}
+	if err := sentrySource.Init(ctx, sourceName, jobID, sourceID, true, &conn, runtime.NumCPU()); err != nil {
+		return sources.JobProgressRef{}, err
+	}
+	return e.sourceManager.EnumerateAndScan(ctx, sourceName, sentrySource)
+}
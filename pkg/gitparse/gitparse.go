@@ -230,6 +230,49 @@ func (c *Parser) RepoPath(
 	isBare bool,
 	additionalArgs ...string,
 ) (chan *Diff, error) {
+	revisions := []string{"--all"}
+	if head != "" {
+		revisions = []string{head}
+	}
+	return c.Log(ctx, source, revisions, abbreviatedLog, excludedGlobs, isBare, additionalArgs...)
+}
+
+// Log parses the output of the `git log` command for the `source` path over
+// the given revisions, which may include options such as --branches or --not.
+// The Diff chan will return diffs in the order they are parsed from the log.
+func (c *Parser) Log(
+	ctx context.Context,
+	source string,
+	revisions []string,
+	abbreviatedLog bool,
+	excludedGlobs []string,
+	isBare bool,
+	additionalArgs ...string,
+) (chan *Diff, error) {
+	cmd := logCommand(source, revisions, abbreviatedLog, excludedGlobs, isBare, additionalArgs)
+	return c.executeCommand(ctx, cmd, false)
+}
+
+// LogStdin is Log with the commits fed to `git log --stdin`, so that there may
+// be more of them than fit on a command line. A commit prefixed with ^ is
+// excluded along with its ancestors. Options such as --not apply to the
+// revisions in additionalArgs only.
+func (c *Parser) LogStdin(
+	ctx context.Context,
+	source string,
+	commits []string,
+	abbreviatedLog bool,
+	excludedGlobs []string,
+	isBare bool,
+	additionalArgs ...string,
+) (chan *Diff, error) {
+	cmd := logCommand(source, []string{"--stdin"}, abbreviatedLog, excludedGlobs, isBare, additionalArgs)
+	cmd.Stdin = strings.NewReader(strings.Join(commits, "\n") + "\n")
+	return c.executeCommand(ctx, cmd, false)
+}
+
+// logCommand returns the `git log` command of Log.
+func logCommand(source string, revisions []string, abbreviatedLog bool, excludedGlobs []string, isBare bool, additionalArgs []string) *exec.Cmd {
 	args := []string{
 		"-C", source,
 		"log",
@@ -242,11 +285,7 @@ func (c *Parser) RepoPath(
 	if abbreviatedLog {
 		args = append(args, "--diff-filter=AM")
 	}
-	if head != "" {
-		args = append(args, head)
-	} else {
-		args = append(args, "--all")
-	}
+	args = append(args, revisions...)
 	args = append(args, additionalArgs...) // These need to come before --
 	for _, glob := range excludedGlobs {
 		args = append(args, "--", ".", ":(exclude)"+glob)
@@ -271,8 +310,7 @@ func (c *Parser) RepoPath(
 			}
 		}
 	}
-
-	return c.executeCommand(ctx, cmd, false)
+	return cmd
 }
 
 // Staged parses the output of the `git diff` command for the `source` path.
@@ -0,0 +1,96 @@
+// Package gitstate persists, per repository and ref, the last commit scanned
+// by incremental git scans.
+package gitstate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Store holds the commit each ref of each repository pointed to when the
+// repository was last scanned successfully. It is safe for concurrent use.
+type Store struct {
+	path string
+
+	mu    sync.Mutex
+	repos map[string]map[string]string
+}
+
+// stateFile is the JSON layout of a state file.
+type stateFile struct {
+	Repositories map[string]map[string]string `json:"repositories"`
+}
+
+// Load reads the state file at path. A missing file yields an empty store,
+// which is created on Save.
+func Load(path string) (*Store, error) {
+	s := &Store{path: path, repos: make(map[string]map[string]string)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading state file: %w", err)
+	}
+
+	var f stateFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("error parsing state file %q: %w", path, err)
+	}
+	if f.Repositories != nil {
+		s.repos = f.Repositories
+	}
+	return s, nil
+}
+
+// Refs returns the commit of each ref of the repository recorded by its last
+// scan, by ref name. It is empty if the repository was never scanned.
+func (s *Store) Refs(repo string) map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return maps.Clone(s.repos[repo])
+}
+
+// Set records the refs of a repository after a successful scan, replacing the
+// ones previously recorded.
+func (s *Store) Set(repo string, refs map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.repos[repo] = maps.Clone(refs)
+}
+
+// Save writes the store to its state file. The file is replaced atomically,
+// so an interrupted save leaves the previous state intact.
+func (s *Store) Save() error {
+	s.mu.Lock()
+	data, err := json.MarshalIndent(stateFile{Repositories: s.repos}, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("error encoding state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error creating state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("error writing state file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package gitstate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	s, err := Load(path)
+	require.NoError(t, err)
+	assert.Empty(t, s.Refs("https://github.com/acme/api.git"))
+
+	refs := map[string]string{"HEAD": "aaa", "refs/heads/main": "aaa", "refs/tags/v1": "bbb"}
+	s.Set("https://github.com/acme/api.git", refs)
+	refs["HEAD"] = "changed"
+	require.NoError(t, s.Save())
+
+	s, err = Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"HEAD": "aaa", "refs/heads/main": "aaa", "refs/tags/v1": "bbb"}, s.Refs("https://github.com/acme/api.git"))
+
+	s.Set("https://github.com/acme/api.git", map[string]string{"HEAD": "ccc"})
+	require.NoError(t, s.Save())
+	s, err = Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"HEAD": "ccc"}, s.Refs("https://github.com/acme/api.git"))
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o644))
+
+	_, err := Load(path)
+	assert.Error(t, err)
+}
@@ -18,6 +18,10 @@ const (
 	providerGitlab    provider = "Gitlab"
 	providerBitbucket provider = "Bitbucket"
 	providerAzure     provider = "Azure"
+	// providerBitbucketDataCenter is a self-hosted Bitbucket Data Center or
+	// Server instance. It is recognized by the path of its web links, since
+	// it has no well-known host.
+	providerBitbucketDataCenter provider = "BitbucketDataCenter"
 
 	urlGithub    = "github.com/"
 	urlGitlab    = "gitlab.com/"
@@ -35,11 +39,17 @@ func determineProvider(repo string) provider {
 		return providerBitbucket
 	case strings.Contains(repo, urlAzure):
 		return providerAzure
+	case bitbucketDataCenterPattern.MatchString(repo):
+		return providerBitbucketDataCenter
 	default:
 		return ""
 	}
 }
 
+// bitbucketDataCenterPattern matches the path of Bitbucket Data Center web
+// links to a repository, which may be hosted under a context path.
+var bitbucketDataCenterPattern = regexp.MustCompile(`/(projects|users)/[^/]+/repos/[^/]+(/|$)`)
+
 func NormalizeBitbucketRepo(repoURL string) (string, error) {
 	if !strings.HasPrefix(repoURL, "https") {
 		return "", errors.New("Bitbucket requires https repo urls: e.g. https://bitbucket.org/org/repo.git")
@@ -192,6 +202,14 @@ func UpdateLinkLineNumber(ctx context.Context, link string, newLine int64) strin
 		// So we don't need to change anything.
 		return link
 
+	case providerBitbucketDataCenter:
+		// Data Center links to a line of a file with .../browse/<file>?at=<commit>#<number>.
+		// Links to commits and pull request comments don't point to a line.
+		if !strings.Contains(parsedURL.Path, "/browse/") {
+			return link
+		}
+		parsedURL.Fragment = strconv.FormatInt(newLine, 10)
+
 	case providerAzure:
 		// For Azure, line numbers are appended as ?line=<number>.
 		query := parsedURL.Query()
@@ -257,6 +257,22 @@ func TestUpdateLinkLineNumber(t *testing.T) {
 			},
 			want: "https://dev.azure.com/org/project/_git/repo/commit/abcdef/main.go?line=40",
 		},
+		{
+			name: "Update bitbucket data center file link with line",
+			args: args{
+				link:    "https://bitbucket.example.com/projects/PROJ/repos/repo/browse/main.go?at=abcdef#12",
+				newLine: int64(40),
+			},
+			want: "https://bitbucket.example.com/projects/PROJ/repos/repo/browse/main.go?at=abcdef#40",
+		},
+		{
+			name: "Bitbucket data center pull request comment link has no line",
+			args: args{
+				link:    "https://bitbucket.example.com/projects/PROJ/repos/repo/pull-requests/3?commentId=17",
+				newLine: int64(40),
+			},
+			want: "https://bitbucket.example.com/projects/PROJ/repos/repo/pull-requests/3?commentId=17",
+		},
 		{
 			name: "Add line to github link without line",
 			args: args{
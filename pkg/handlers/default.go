@@ -44,7 +44,7 @@ func (h *defaultHandler) HandleFile(ctx logContext.Context, input fileReader) ch
 		defer close(dataOrErrChan)
 
 		start := time.Now()
-		err := h.handleNonArchiveContent(ctx, newMimeTypeReaderFromFileReader(input), dataOrErrChan)
+		err := h.handleContent(ctx, newMimeTypeReaderFromFileReader(input), dataOrErrChan, true)
 		if err == nil {
 			h.metrics.incFilesProcessed()
 		}
@@ -95,6 +95,19 @@ func (h *defaultHandler) handleNonArchiveContent(
 	ctx logContext.Context,
 	reader mimeTypeReader,
 	dataOrErrChan chan DataOrErr,
+) error {
+	return h.handleContent(ctx, reader, dataOrErrChan, false)
+}
+
+// handleContent implements handleNonArchiveContent. When withOffsets is set,
+// the reader is the top-level file and each chunk is sent along with its
+// location in that file. Content extracted from archives doesn't have a
+// meaningful location in the scanned file, so its chunks don't carry one.
+func (h *defaultHandler) handleContent(
+	ctx logContext.Context,
+	reader mimeTypeReader,
+	dataOrErrChan chan DataOrErr,
+	withOffsets bool,
 ) error {
 	mimeExt := reader.mimeExt
 
@@ -119,6 +132,10 @@ func (h *defaultHandler) handleNonArchiveContent(
 		}
 
 		dataOrErr.Data = data.Bytes()
+		if withOffsets {
+			offset := data.Offset()
+			dataOrErr.Offset = &offset
+		}
 		if err := common.CancellableWrite(ctx, dataOrErrChan, dataOrErr); err != nil {
 			return err
 		}
@@ -184,6 +184,8 @@ func newFileReader(ctx context.Context, r io.Reader, options ...readerOption) (f
 type DataOrErr struct {
 	Data []byte
 	Err  error
+	// Offset is the location of Data within the handled file, if known.
+	Offset *sources.FileOffset
 }
 
 // FileHandler represents a handler for files.
@@ -423,6 +425,9 @@ func handleChunksWithError(
 			if len(dataOrErr.Data) > 0 {
 				chunk := *chunkSkel
 				chunk.Data = dataOrErr.Data
+				if dataOrErr.Offset != nil {
+					chunk.SourceMetadata = sources.WithFileOffset(chunkSkel.SourceMetadata, *dataOrErr.Offset)
+				}
 				if err := reporter.ChunkOk(ctx, chunk); err != nil {
 					return fmt.Errorf("error reporting chunk: %w", err)
 				}
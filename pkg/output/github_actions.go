@@ -65,6 +65,12 @@ func (p *GitHubActionsPrinter) Print(_ context.Context, r *detectors.ResultWithM
 		message = fmt.Sprintf("Found %s %s result with %s encoding 🐷🔑\n", verifiedStatus, out.DetectorType, out.DecoderType)
 	}
 
+	if pos := r.Result.Position; pos != nil && pos.StartLine == out.StartLine {
+		fmt.Printf("::warning file=%s,line=%d,endLine=%d,col=%d,endColumn=%d::%s",
+			out.Filename, pos.StartLine, pos.EndLine, pos.StartColumn, pos.EndColumn, message)
+		return nil
+	}
+
 	fmt.Printf("::warning file=%s,line=%d,endLine=%d::%s",
 		out.Filename, out.StartLine, out.StartLine, message)
 
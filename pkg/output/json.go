@@ -54,6 +54,8 @@ func (p *JSONPrinter) Print(_ context.Context, r *detectors.ResultWithMetadata)
 		Redacted       string
 		ExtraData      map[string]string
 		StructuredData *detectorspb.StructuredData
+		// Position locates the secret within the scanned data.
+		Position *detectors.Position `json:",omitempty"`
 	}{
 		SourceMetadata:        r.SourceMetadata,
 		SourceID:              r.SourceID,
@@ -71,6 +73,7 @@ func (p *JSONPrinter) Print(_ context.Context, r *detectors.ResultWithMetadata)
 		Redacted:              r.Redacted,
 		ExtraData:             r.ExtraData,
 		StructuredData:        r.StructuredData,
+		Position:              r.Position,
 	}
 	out, err := json.Marshal(v)
 	if err != nil {
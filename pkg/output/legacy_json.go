@@ -25,6 +25,12 @@ import (
 type LegacyJSONPrinter struct{ mu sync.Mutex }
 
 func (p *LegacyJSONPrinter) Print(ctx context.Context, r *detectors.ResultWithMetadata) error {
+	return p.printScrubbed(ctx, r, func(s string) string { return s })
+}
+
+// printScrubbed prints the result after passing any secret-bearing output,
+// including the diff read back from the repository, through scrub.
+func (p *LegacyJSONPrinter) printScrubbed(ctx context.Context, r *detectors.ResultWithMetadata, scrub func(string) string) error {
 	var repo string
 	switch r.SourceType {
 	case sourcespb.SourceType_SOURCE_TYPE_GIT:
@@ -46,7 +52,7 @@ func (p *LegacyJSONPrinter) Print(ctx context.Context, r *detectors.ResultWithMe
 		defer os.RemoveAll(repoPath)
 	}
 
-	legacy, err := convertToLegacyJSON(r, repoPath)
+	legacy, err := convertToLegacyJSON(r, repoPath, scrub)
 	if err != nil {
 		return fmt.Errorf("could not convert to legacy JSON: %w", err)
 	}
@@ -61,7 +67,7 @@ func (p *LegacyJSONPrinter) Print(ctx context.Context, r *detectors.ResultWithMe
 	return nil
 }
 
-func convertToLegacyJSON(r *detectors.ResultWithMetadata, repoPath string) (*LegacyJSONOutput, error) {
+func convertToLegacyJSON(r *detectors.ResultWithMetadata, repoPath string, scrub func(string) string) (*LegacyJSONOutput, error) {
 	var source LegacyJSONCompatibleSource
 	switch r.SourceType {
 	case sourcespb.SourceType_SOURCE_TYPE_GIT:
@@ -93,9 +99,9 @@ func convertToLegacyJSON(r *detectors.ResultWithMetadata, repoPath string) (*Leg
 		log.Fatal(err)
 	}
 
-	diff := GenerateDiff(commit, fileName)
+	diff := scrub(GenerateDiff(commit, fileName))
 
-	foundString := string(r.Result.Raw)
+	foundString := scrub(string(r.Result.Raw))
 
 	// Add highlighting to the offending bit of string.
 	printableDiff := strings.ReplaceAll(diff, foundString, fmt.Sprintf("\u001b[93m%s\u001b[0m", foundString))
@@ -103,7 +109,7 @@ func convertToLegacyJSON(r *detectors.ResultWithMetadata, repoPath string) (*Leg
 	// Load up the struct to match the old JSON format
 	output := &LegacyJSONOutput{
 		Branch:       FindBranch(commit, repo),
-		Commit:       commit.Message,
+		Commit:       scrub(commit.Message),
 		CommitHash:   commitHash.String(),
 		Date:         commit.Committer.When.Format("2006-01-02 15:04:05"),
 		Diff:         diff,
@@ -0,0 +1,56 @@
+package output
+
+import (
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+)
+
+// Location identifies where within a source a result was found, as far as
+// the result's source metadata allows.
+type Location struct {
+	// Unit is the source unit, such as a repository, bucket or image.
+	Unit string
+	File string
+	Line int64
+	Link string
+}
+
+// locationUnitKeys are the source metadata fields that identify the source
+// unit a result was found in, in order of preference.
+var locationUnitKeys = []string{
+	"repository", "bucket", "image", "project", "project_name", "workspace_name",
+	"index", "organization", "channel_name", "space", "package", "repo",
+}
+
+// locationFileKeys are the source metadata fields that identify the file a
+// result was found in, in order of preference.
+var locationFileKeys = []string{"file", "filename", "path"}
+
+// ResultLocation extracts the location of a result from its source metadata.
+func ResultLocation(r *detectors.ResultWithMetadata) Location {
+	var loc Location
+	if r.SourceMetadata == nil {
+		return loc
+	}
+	meta, err := structToMap(r.SourceMetadata.Data)
+	if err != nil {
+		return loc
+	}
+	for _, data := range meta {
+		loc.Unit = firstString(data, locationUnitKeys)
+		loc.File = firstString(data, locationFileKeys)
+		loc.Link = firstString(data, []string{"link"})
+		if line, ok := data["line"].(float64); ok {
+			loc.Line = int64(line)
+		}
+	}
+	return loc
+}
+
+func firstString(data map[string]any, keys []string) string {
+	for _, k := range keys {
+		if v, ok := data[k].(string); ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
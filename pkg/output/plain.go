@@ -100,6 +100,12 @@ func (p *PlainPrinter) Print(_ context.Context, r *detectors.ResultWithMetadata)
 	for _, k := range aggregateDataKeys {
 		printer.Printf("%s: %v\n", cases.Title(language.AmericanEnglish).String(k), aggregateData[k])
 	}
+	if pos := r.Result.Position; pos != nil {
+		printer.Printf("Position: %d:%d-%d:%d\n", pos.StartLine, pos.StartColumn, pos.EndLine, pos.EndColumn)
+		if pos.Bytes != nil {
+			printer.Printf("Byte Range: %d-%d\n", pos.Bytes.Start, pos.Bytes.End)
+		}
+	}
 	fmt.Println("")
 	return nil
 }
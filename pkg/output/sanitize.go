@@ -0,0 +1,261 @@
+package output
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+)
+
+type sanitizeMode int
+
+const (
+	sanitizeRedact sanitizeMode = iota
+	sanitizeHash
+)
+
+// hashPrefix identifies secret values that were replaced with a keyed HMAC.
+const hashPrefix = "hmac-sha256:"
+
+// Sanitizer removes live secret values from results before they are output.
+// In redact mode secrets are replaced with the detector's redacted form or a
+// masked value. In hash mode secrets are replaced with a keyed HMAC, so the
+// same secret produces the same value across runs that share a key.
+type Sanitizer struct {
+	mode sanitizeMode
+	key  []byte
+}
+
+// NewRedactor creates a Sanitizer that masks secret values.
+func NewRedactor() *Sanitizer { return &Sanitizer{mode: sanitizeRedact} }
+
+// NewHasher creates a Sanitizer that replaces secret values with an
+// HMAC-SHA256 computed using the provided key.
+func NewHasher(key []byte) (*Sanitizer, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("HMAC key must not be empty")
+	}
+	return &Sanitizer{mode: sanitizeHash, key: key}, nil
+}
+
+// Sanitize returns a copy of r with its secret values replaced. Raw and RawV2
+// are replaced outright, and any occurrence of them or of their components in
+// ExtraData, StructuredData and Data is scrubbed. The original result is not
+// modified.
+func (s *Sanitizer) Sanitize(r *detectors.ResultWithMetadata) *detectors.ResultWithMetadata {
+	scrub := s.Scrubber(r)
+
+	out := *r
+	out.Raw = s.replaceRaw(r, r.Raw)
+	out.RawV2 = s.replaceRaw(r, r.RawV2)
+	out.Data = []byte(scrub(string(r.Data)))
+
+	if r.ExtraData != nil {
+		out.ExtraData = make(map[string]string, len(r.ExtraData))
+		for k, v := range r.ExtraData {
+			out.ExtraData[k] = scrub(v)
+		}
+	}
+
+	if r.StructuredData != nil {
+		structured := proto.Clone(r.StructuredData).(*detectorspb.StructuredData)
+		scrubMessage(structured.ProtoReflect(), scrub)
+		out.StructuredData = structured
+	}
+
+	// AnalysisInfo holds credentials for analyzers and is never output.
+	out.AnalysisInfo = nil
+
+	return &out
+}
+
+// Scrubber returns a function that replaces every occurrence of the secret
+// values of r, and of each of their components, within a string. Printers
+// that build their output themselves use it so that every output format
+// shows the same replacement for the same finding.
+func (s *Sanitizer) Scrubber(r *detectors.ResultWithMetadata) func(string) string {
+	secrets := secretParts(r)
+	if len(secrets) == 0 {
+		return func(in string) string { return in }
+	}
+
+	oldNew := make([]string, 0, len(secrets)*2)
+	for _, secret := range secrets {
+		oldNew = append(oldNew, secret, s.replacementFor(r, secret))
+	}
+	replacer := strings.NewReplacer(oldNew...)
+	return replacer.Replace
+}
+
+// minSecretPartLen is the shortest component of a multi-part secret that is
+// scrubbed on its own. Shorter fragments are too likely to match unrelated
+// text.
+const minSecretPartLen = 4
+
+// secretParts returns Raw, RawV2 and the components they are made of, longest
+// first. Multi-part detectors build RawV2 by concatenating the parts of a
+// credential, sometimes with a separator, so the secret part of RawV2 can
+// appear on its own in ExtraData or Data.
+func secretParts(r *detectors.ResultWithMetadata) []string {
+	raw, rawV2 := string(r.Raw), string(r.RawV2)
+
+	seen := make(map[string]struct{})
+	var parts []string
+	add := func(part string) {
+		if part == "" {
+			return
+		}
+		if _, ok := seen[part]; ok {
+			return
+		}
+		seen[part] = struct{}{}
+		parts = append(parts, part)
+	}
+	addComponents := func(value string) {
+		for _, field := range strings.FieldsFunc(value, isSecretSeparator) {
+			if len(field) >= minSecretPartLen {
+				add(field)
+			}
+		}
+	}
+
+	add(raw)
+	add(rawV2)
+	addComponents(raw)
+	addComponents(rawV2)
+	if raw != "" && raw != rawV2 && strings.Contains(rawV2, raw) {
+		// RawV2 is Raw concatenated with the other parts of the credential.
+		for _, rest := range strings.Split(rawV2, raw) {
+			addComponents(rest)
+		}
+	}
+
+	// Longer secrets are replaced first, because they frequently contain the
+	// shorter ones.
+	sort.SliceStable(parts, func(i, j int) bool { return len(parts[i]) > len(parts[j]) })
+	return parts
+}
+
+func isSecretSeparator(r rune) bool {
+	switch r {
+	case ':', ';', '|', ',', ' ', '\t', '\r', '\n':
+		return true
+	}
+	return false
+}
+
+func (s *Sanitizer) replaceRaw(r *detectors.ResultWithMetadata, raw []byte) []byte {
+	if len(raw) == 0 {
+		return raw
+	}
+	return []byte(s.replacementFor(r, string(raw)))
+}
+
+// replacementFor returns the value that replaces secret, which is Raw, RawV2
+// or one of their components, when r is output. In redact mode Raw and RawV2
+// are replaced with the detector's redacted form if it has one.
+func (s *Sanitizer) replacementFor(r *detectors.ResultWithMetadata, secret string) string {
+	if s.mode == sanitizeRedact && r.Redacted != "" &&
+		(secret == string(r.Raw) || secret == string(r.RawV2)) {
+		return r.Redacted
+	}
+	return s.replacement(secret)
+}
+
+func (s *Sanitizer) replacement(secret string) string {
+	if s.mode == sanitizeHash {
+		mac := hmac.New(sha256.New, s.key)
+		mac.Write([]byte(secret))
+		return hashPrefix + hex.EncodeToString(mac.Sum(nil))
+	}
+	return mask(secret)
+}
+
+// mask keeps a short prefix of the secret so that results remain
+// recognizable, and hides the rest.
+func mask(secret string) string {
+	const maskLen = 8
+	visible := len(secret) / 4
+	if visible > 4 {
+		visible = 4
+	}
+	return secret[:visible] + strings.Repeat("*", maskLen)
+}
+
+// scrubMessage applies scrub to every string field of a protobuf message,
+// recursing into nested messages, lists and maps.
+func scrubMessage(m protoreflect.Message, scrub func(string) string) {
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsList():
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				switch fd.Kind() {
+				case protoreflect.StringKind:
+					list.Set(i, protoreflect.ValueOfString(scrub(list.Get(i).String())))
+				case protoreflect.MessageKind, protoreflect.GroupKind:
+					scrubMessage(list.Get(i).Message(), scrub)
+				}
+			}
+		case fd.IsMap():
+			mapVal := v.Map()
+			valDesc := fd.MapValue()
+			mapVal.Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+				switch valDesc.Kind() {
+				case protoreflect.StringKind:
+					mapVal.Set(k, protoreflect.ValueOfString(scrub(mv.String())))
+				case protoreflect.MessageKind, protoreflect.GroupKind:
+					scrubMessage(mv.Message(), scrub)
+				}
+				return true
+			})
+		case fd.Kind() == protoreflect.StringKind:
+			m.Set(fd, protoreflect.ValueOfString(scrub(v.String())))
+		case fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind:
+			scrubMessage(v.Message(), scrub)
+		}
+		return true
+	})
+}
+
+// resultPrinter matches the engine's Printer interface, which can't be
+// imported here without creating an import cycle.
+type resultPrinter interface {
+	Print(ctx context.Context, r *detectors.ResultWithMetadata) error
+}
+
+// scrubbingPrinter is implemented by printers that output data which doesn't
+// come from the result itself, such as a diff re-read from a git repository.
+// Those printers receive the original result and scrub their own output.
+type scrubbingPrinter interface {
+	printScrubbed(ctx context.Context, r *detectors.ResultWithMetadata, scrub func(string) string) error
+}
+
+// SanitizingPrinter wraps another printer and removes secret values from
+// every result before it is printed.
+type SanitizingPrinter struct {
+	printer   resultPrinter
+	sanitizer *Sanitizer
+}
+
+// NewSanitizingPrinter creates a SanitizingPrinter that sanitizes results
+// with sanitizer before passing them to printer.
+func NewSanitizingPrinter(printer resultPrinter, sanitizer *Sanitizer) *SanitizingPrinter {
+	return &SanitizingPrinter{printer: printer, sanitizer: sanitizer}
+}
+
+func (p *SanitizingPrinter) Print(ctx context.Context, r *detectors.ResultWithMetadata) error {
+	if sp, ok := p.printer.(scrubbingPrinter); ok {
+		return sp.printScrubbed(ctx, r, p.sanitizer.Scrubber(r))
+	}
+	return p.printer.Print(ctx, p.sanitizer.Sanitize(r))
+}
@@ -0,0 +1,309 @@
+package output
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+
+	logContext "github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// defaultSummaryTopFiles is the number of files listed in the summary's
+// top offending files.
+const defaultSummaryTopFiles = 10
+
+// SummaryCounts holds the number of results of each verification status.
+type SummaryCounts struct {
+	Verified   uint64 `json:"verified"`
+	Unverified uint64 `json:"unverified"`
+	Unknown    uint64 `json:"unknown"`
+}
+
+// Total returns the number of results regardless of status.
+func (c SummaryCounts) Total() uint64 { return c.Verified + c.Unverified + c.Unknown }
+
+func (c *SummaryCounts) add(r *detectors.ResultWithMetadata) {
+	switch {
+	case r.Verified:
+		c.Verified++
+	case r.VerificationError() != nil:
+		c.Unknown++
+	default:
+		c.Unverified++
+	}
+}
+
+// SummaryGroup is the result count for a single detector, source unit or decoder.
+type SummaryGroup struct {
+	Name string `json:"name"`
+	SummaryCounts
+}
+
+// SummaryFile is the result count for a single file within a source unit.
+type SummaryFile struct {
+	Unit string `json:"unit,omitempty"`
+	File string `json:"file"`
+	SummaryCounts
+}
+
+// SummaryError is the number of scan errors of a given type.
+type SummaryError struct {
+	Type    string `json:"type"`
+	Count   int    `json:"count"`
+	Example string `json:"example"`
+}
+
+// SummaryReport is the aggregated breakdown of a scan's results.
+type SummaryReport struct {
+	Totals    SummaryCounts  `json:"totals"`
+	Detectors []SummaryGroup `json:"detectors"`
+	Units     []SummaryGroup `json:"units"`
+	Decoders  []SummaryGroup `json:"decoders"`
+	TopFiles  []SummaryFile  `json:"top_files"`
+	Errors    []SummaryError `json:"errors"`
+}
+
+type summaryFileKey struct{ unit, file string }
+
+// SummaryPrinter wraps another printer and tallies every result it prints so
+// that an aggregated report can be produced once the scan is finished.
+type SummaryPrinter struct {
+	printer resultPrinter
+
+	mu        sync.Mutex
+	totals    SummaryCounts
+	detectors map[string]*SummaryCounts
+	units     map[string]*SummaryCounts
+	decoders  map[string]*SummaryCounts
+	files     map[summaryFileKey]*SummaryCounts
+}
+
+// NewSummaryPrinter creates a SummaryPrinter that forwards results to printer.
+func NewSummaryPrinter(printer resultPrinter) *SummaryPrinter {
+	return &SummaryPrinter{
+		printer:   printer,
+		detectors: make(map[string]*SummaryCounts),
+		units:     make(map[string]*SummaryCounts),
+		decoders:  make(map[string]*SummaryCounts),
+		files:     make(map[summaryFileKey]*SummaryCounts),
+	}
+}
+
+func (p *SummaryPrinter) Print(ctx logContext.Context, r *detectors.ResultWithMetadata) error {
+	p.collect(r)
+	return p.printer.Print(ctx, r)
+}
+
+func (p *SummaryPrinter) collect(r *detectors.ResultWithMetadata) {
+	detector := r.DetectorType.String()
+	if r.DetectorName != "" {
+		detector = r.DetectorName
+	}
+	loc := ResultLocation(r)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.totals.add(r)
+	countFor(p.detectors, detector).add(r)
+	countFor(p.decoders, r.DecoderType.String()).add(r)
+	if loc.Unit != "" {
+		countFor(p.units, loc.Unit).add(r)
+	}
+	if loc.File != "" {
+		countFor(p.files, summaryFileKey{unit: loc.Unit, file: loc.File}).add(r)
+	}
+}
+
+func countFor[K comparable](m map[K]*SummaryCounts, key K) *SummaryCounts {
+	c, ok := m[key]
+	if !ok {
+		c = new(SummaryCounts)
+		m[key] = c
+	}
+	return c
+}
+
+// Report builds the aggregated summary of all results printed so far, along
+// with a breakdown of the provided scan errors. At most topFiles files are
+// included; a value of 0 uses the default.
+func (p *SummaryPrinter) Report(scanErrs []error, topFiles int) SummaryReport {
+	if topFiles <= 0 {
+		topFiles = defaultSummaryTopFiles
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	report := SummaryReport{
+		Totals:    p.totals,
+		Detectors: sortedGroups(p.detectors),
+		Units:     sortedGroups(p.units),
+		Decoders:  sortedGroups(p.decoders),
+		Errors:    summarizeErrors(scanErrs),
+	}
+
+	files := make([]SummaryFile, 0, len(p.files))
+	for k, c := range p.files {
+		files = append(files, SummaryFile{Unit: k.unit, File: k.file, SummaryCounts: *c})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].Total() != files[j].Total() {
+			return files[i].Total() > files[j].Total()
+		}
+		if files[i].Verified != files[j].Verified {
+			return files[i].Verified > files[j].Verified
+		}
+		if files[i].Unit != files[j].Unit {
+			return files[i].Unit < files[j].Unit
+		}
+		return files[i].File < files[j].File
+	})
+	if len(files) > topFiles {
+		files = files[:topFiles]
+	}
+	report.TopFiles = files
+
+	return report
+}
+
+// sortedGroups orders groups by verified count, then total count, then name.
+func sortedGroups(m map[string]*SummaryCounts) []SummaryGroup {
+	groups := make([]SummaryGroup, 0, len(m))
+	for name, c := range m {
+		groups = append(groups, SummaryGroup{Name: name, SummaryCounts: *c})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Verified != groups[j].Verified {
+			return groups[i].Verified > groups[j].Verified
+		}
+		if groups[i].Total() != groups[j].Total() {
+			return groups[i].Total() > groups[j].Total()
+		}
+		return groups[i].Name < groups[j].Name
+	})
+	return groups
+}
+
+// summarizeErrors groups scan errors by their type.
+func summarizeErrors(errs []error) []SummaryError {
+	byType := make(map[string]*SummaryError)
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		typ := errorType(err)
+		s, ok := byType[typ]
+		if !ok {
+			s = &SummaryError{Type: typ, Example: err.Error()}
+			byType[typ] = s
+		}
+		s.Count++
+	}
+
+	out := make([]SummaryError, 0, len(byType))
+	for _, s := range byType {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Type < out[j].Type
+	})
+	return out
+}
+
+func errorType(err error) string {
+	var (
+		fatalErr     sources.Fatal
+		chunkErr     sources.ChunkError
+		enumerateErr sources.EnumerateError
+		targetedErr  sources.TargetedScanError
+	)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.As(err, &fatalErr):
+		return "fatal"
+	case errors.As(err, &targetedErr):
+		return "targeted scan"
+	case errors.As(err, &chunkErr):
+		if chunkErr.Unit != nil {
+			_, kind := chunkErr.Unit.SourceUnitID()
+			return fmt.Sprintf("chunking %s", kind)
+		}
+		return "chunking"
+	case errors.As(err, &enumerateErr):
+		return "enumeration"
+	default:
+		return "other"
+	}
+}
+
+// WriteSummaryJSON writes the summary report to w as JSON.
+func WriteSummaryJSON(w io.Writer, report SummaryReport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return fmt.Errorf("could not marshal summary: %w", err)
+	}
+	return nil
+}
+
+// WriteSummaryTable writes the summary report to w as a set of tables.
+func WriteSummaryTable(w io.Writer, report SummaryReport) {
+	fmt.Fprintf(w, "Scan summary: %d verified, %d unverified, %d unknown\n",
+		report.Totals.Verified, report.Totals.Unverified, report.Totals.Unknown)
+
+	writeGroupTable(w, "Detector", report.Detectors)
+	writeGroupTable(w, "Source Unit", report.Units)
+	writeGroupTable(w, "Decoder", report.Decoders)
+
+	if len(report.TopFiles) > 0 {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Top Files")
+		t.AppendHeader(table.Row{"Source Unit", "File", "Verified", "Unverified", "Unknown", "Total"})
+		t.SetColumnConfigs([]table.ColumnConfig{{Name: "File", WidthMax: 80}})
+		for _, f := range report.TopFiles {
+			t.AppendRow(table.Row{f.Unit, f.File, f.Verified, f.Unverified, f.Unknown, f.Total()})
+		}
+		t.Render()
+	}
+
+	if len(report.Errors) > 0 {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Scan Errors")
+		t.AppendHeader(table.Row{"Type", "Count", "Example"})
+		t.SetColumnConfigs([]table.ColumnConfig{{Name: "Example", WidthMax: 100}})
+		for _, e := range report.Errors {
+			t.AppendRow(table.Row{e.Type, e.Count, e.Example})
+		}
+		t.Render()
+	}
+}
+
+func writeGroupTable(w io.Writer, title string, groups []SummaryGroup) {
+	if len(groups) == 0 {
+		return
+	}
+	t := table.NewWriter()
+	t.SetOutputMirror(w)
+	t.AppendHeader(table.Row{title, "Verified", "Unverified", "Unknown", "Total"})
+	for _, g := range groups {
+		t.AppendRow(table.Row{g.Name, g.Verified, g.Unverified, g.Unknown, g.Total()})
+	}
+	t.Render()
+}
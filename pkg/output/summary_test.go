@@ -0,0 +1,109 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	logContext "github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+type discardPrinter struct{ printed int }
+
+func (p *discardPrinter) Print(logContext.Context, *detectors.ResultWithMetadata) error {
+	p.printed++
+	return nil
+}
+
+func gitResult(repo, file string, detector detectorspb.DetectorType, verified bool, verifyErr error) *detectors.ResultWithMetadata {
+	r := &detectors.ResultWithMetadata{
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Git{Git: &source_metadatapb.Git{Repository: repo, File: file}},
+		},
+		Result:      detectors.Result{DetectorType: detector, Verified: verified},
+		DecoderType: detectorspb.DecoderType_PLAIN,
+	}
+	if verifyErr != nil {
+		r.SetVerificationError(verifyErr)
+	}
+	return r
+}
+
+func TestSummaryPrinter_Report(t *testing.T) {
+	ctx := logContext.Background()
+	inner := new(discardPrinter)
+	p := NewSummaryPrinter(inner)
+
+	results := []*detectors.ResultWithMetadata{
+		gitResult("repo-a", "config.yaml", detectorspb.DetectorType_AWS, true, nil),
+		gitResult("repo-a", "config.yaml", detectorspb.DetectorType_AWS, false, nil),
+		gitResult("repo-a", "main.go", detectorspb.DetectorType_Github, false, errors.New("timeout")),
+		gitResult("repo-b", "config.yaml", detectorspb.DetectorType_AWS, false, nil),
+	}
+	results[3].DecoderType = detectorspb.DecoderType_BASE64
+	for _, r := range results {
+		require.NoError(t, p.Print(ctx, r))
+	}
+	assert.Equal(t, len(results), inner.printed)
+
+	scanErrs := []error{
+		sources.ChunkError{Err: errors.New("bad credentials")},
+		sources.EnumerateError{Err: errors.New("could not list repos")},
+		sources.EnumerateError{Err: errors.New("could not list orgs")},
+		errors.New("could not decode chunk"),
+		fmt.Errorf("wrapped: %w", context.DeadlineExceeded),
+	}
+	report := p.Report(scanErrs, 2)
+
+	assert.Equal(t, SummaryCounts{Verified: 1, Unverified: 2, Unknown: 1}, report.Totals)
+	assert.Equal(t, []SummaryGroup{
+		{Name: "AWS", SummaryCounts: SummaryCounts{Verified: 1, Unverified: 2}},
+		{Name: "Github", SummaryCounts: SummaryCounts{Unknown: 1}},
+	}, report.Detectors)
+	assert.Equal(t, []SummaryGroup{
+		{Name: "repo-a", SummaryCounts: SummaryCounts{Verified: 1, Unverified: 1, Unknown: 1}},
+		{Name: "repo-b", SummaryCounts: SummaryCounts{Unverified: 1}},
+	}, report.Units)
+	assert.Equal(t, []SummaryGroup{
+		{Name: "PLAIN", SummaryCounts: SummaryCounts{Verified: 1, Unverified: 1, Unknown: 1}},
+		{Name: "BASE64", SummaryCounts: SummaryCounts{Unverified: 1}},
+	}, report.Decoders)
+	assert.Equal(t, []SummaryFile{
+		{Unit: "repo-a", File: "config.yaml", SummaryCounts: SummaryCounts{Verified: 1, Unverified: 1}},
+		{Unit: "repo-a", File: "main.go", SummaryCounts: SummaryCounts{Unknown: 1}},
+	}, report.TopFiles)
+	assert.Equal(t, []SummaryError{
+		{Type: "enumeration", Count: 2, Example: "could not list repos"},
+		{Type: "chunking", Count: 1, Example: "error chunking unit \"<nil>\": bad credentials"},
+		{Type: "other", Count: 1, Example: "could not decode chunk"},
+		{Type: "timeout", Count: 1, Example: "wrapped: context deadline exceeded"},
+	}, report.Errors)
+}
+
+func TestSummaryReport_Write(t *testing.T) {
+	p := NewSummaryPrinter(new(discardPrinter))
+	require.NoError(t, p.Print(logContext.Background(), gitResult("repo-a", "main.go", detectorspb.DetectorType_AWS, true, nil)))
+	report := p.Report(nil, 0)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteSummaryJSON(&buf, report))
+	var decoded SummaryReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, report, decoded)
+
+	buf.Reset()
+	WriteSummaryTable(&buf, report)
+	assert.Contains(t, buf.String(), "1 verified, 0 unverified, 0 unknown")
+	assert.Contains(t, buf.String(), "repo-a")
+	assert.Contains(t, buf.String(), "main.go")
+}
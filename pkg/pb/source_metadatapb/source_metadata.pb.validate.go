@@ -296,6 +296,10 @@ func (m *Buildkite) validate(all bool) error {
 
 	// no validation rules for Timestamp
 
+	// no validation rules for Job
+
+	// no validation rules for Location
+
 	if len(errors) > 0 {
 		return BuildkiteMultiError(errors)
 	}
@@ -741,6 +745,8 @@ func (m *Docker) validate(all bool) error {
 
 	// no validation rules for Tag
 
+	// no validation rules for HistoryOnly
+
 	if len(errors) > 0 {
 		return DockerMultiError(errors)
 	}
@@ -1070,6 +1076,8 @@ func (m *Git) validate(all bool) error {
 
 	// no validation rules for Line
 
+	// no validation rules for Reached
+
 	if len(errors) > 0 {
 		return GitMultiError(errors)
 	}
@@ -1186,6 +1194,16 @@ func (m *Github) validate(all bool) error {
 
 	// no validation rules for Visibility
 
+	// no validation rules for Workflow
+
+	// no validation rules for RunId
+
+	// no validation rules for Job
+
+	// no validation rules for Step
+
+	// no validation rules for Artifact
+
 	if len(errors) > 0 {
 		return GithubMultiError(errors)
 	}
@@ -4011,6 +4029,222 @@ var _ interface {
 	ErrorName() string
 } = SentryValidationError{}
 
+// Validate checks the field values on Kubernetes with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *Kubernetes) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on Kubernetes with the rules defined in
+// the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in KubernetesMultiError, or
+// nil if none found.
+func (m *Kubernetes) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *Kubernetes) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Cluster
+
+	// no validation rules for Namespace
+
+	// no validation rules for Kind
+
+	// no validation rules for Name
+
+	// no validation rules for Field
+
+	if len(errors) > 0 {
+		return KubernetesMultiError(errors)
+	}
+
+	return nil
+}
+
+// KubernetesMultiError is an error wrapping multiple validation errors
+// returned by Kubernetes.ValidateAll() if the designated constraints aren't met.
+type KubernetesMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m KubernetesMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m KubernetesMultiError) AllErrors() []error { return m }
+
+// KubernetesValidationError is the validation error returned by
+// Kubernetes.Validate if the designated constraints aren't met.
+type KubernetesValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e KubernetesValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e KubernetesValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e KubernetesValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e KubernetesValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e KubernetesValidationError) ErrorName() string { return "KubernetesValidationError" }
+
+// Error satisfies the builtin error interface
+func (e KubernetesValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sKubernetes.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = KubernetesValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = KubernetesValidationError{}
+
+// Validate checks the field values on Database with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *Database) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on Database with the rules defined in
+// the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in DatabaseMultiError, or nil
+// if none found.
+func (m *Database) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *Database) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Database
+
+	// no validation rules for Table
+
+	// no validation rules for PrimaryKey
+
+	// no validation rules for Column
+
+	if len(errors) > 0 {
+		return DatabaseMultiError(errors)
+	}
+
+	return nil
+}
+
+// DatabaseMultiError is an error wrapping multiple validation errors returned
+// by Database.ValidateAll() if the designated constraints aren't met.
+type DatabaseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m DatabaseMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m DatabaseMultiError) AllErrors() []error { return m }
+
+// DatabaseValidationError is the validation error returned by
+// Database.Validate if the designated constraints aren't met.
+type DatabaseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e DatabaseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e DatabaseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e DatabaseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e DatabaseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e DatabaseValidationError) ErrorName() string { return "DatabaseValidationError" }
+
+// Error satisfies the builtin error interface
+func (e DatabaseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sDatabase.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = DatabaseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = DatabaseValidationError{}
+
 // Validate checks the field values on MetaData with the rules defined in the
 // proto definition for this message. If any rules are violated, the first
 // error encountered is returned, or nil if there are no violations.
@@ -4033,6 +4267,35 @@ func (m *MetaData) validate(all bool) error {
 
 	var errors []error
 
+	if all {
+		switch v := interface{}(m.GetFileOffset()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, MetaDataValidationError{
+					field:  "FileOffset",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, MetaDataValidationError{
+					field:  "FileOffset",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetFileOffset()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return MetaDataValidationError{
+				field:  "FileOffset",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
 	switch v := m.Data.(type) {
 	case *MetaData_Azure:
 		if v == nil {
@@ -5387,6 +5650,88 @@ func (m *MetaData) validate(all bool) error {
 			}
 		}
 
+	case *MetaData_Kubernetes:
+		if v == nil {
+			err := MetaDataValidationError{
+				field:  "Data",
+				reason: "oneof value cannot be a typed-nil",
+			}
+			if !all {
+				return err
+			}
+			errors = append(errors, err)
+		}
+
+		if all {
+			switch v := interface{}(m.GetKubernetes()).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, MetaDataValidationError{
+						field:  "Kubernetes",
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, MetaDataValidationError{
+						field:  "Kubernetes",
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(m.GetKubernetes()).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return MetaDataValidationError{
+					field:  "Kubernetes",
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	case *MetaData_Database:
+		if v == nil {
+			err := MetaDataValidationError{
+				field:  "Data",
+				reason: "oneof value cannot be a typed-nil",
+			}
+			if !all {
+				return err
+			}
+			errors = append(errors, err)
+		}
+
+		if all {
+			switch v := interface{}(m.GetDatabase()).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, MetaDataValidationError{
+						field:  "Database",
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, MetaDataValidationError{
+						field:  "Database",
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(m.GetDatabase()).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return MetaDataValidationError{
+					field:  "Database",
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
 	default:
 		_ = v // ensures v is used
 	}
@@ -5467,3 +5812,108 @@ var _ interface {
 	Cause() error
 	ErrorName() string
 } = MetaDataValidationError{}
+
+// Validate checks the field values on FileOffset with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *FileOffset) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on FileOffset with the rules defined in
+// the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in FileOffsetMultiError, or nil
+// if none found.
+func (m *FileOffset) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *FileOffset) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Byte
+
+	// no validation rules for Lines
+
+	// no validation rules for Column
+
+	if len(errors) > 0 {
+		return FileOffsetMultiError(errors)
+	}
+
+	return nil
+}
+
+// FileOffsetMultiError is an error wrapping multiple validation errors returned
+// by FileOffset.ValidateAll() if the designated constraints aren't met.
+type FileOffsetMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m FileOffsetMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m FileOffsetMultiError) AllErrors() []error { return m }
+
+// FileOffsetValidationError is the validation error returned by
+// FileOffset.Validate if the designated constraints aren't met.
+type FileOffsetValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e FileOffsetValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e FileOffsetValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e FileOffsetValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e FileOffsetValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e FileOffsetValidationError) ErrorName() string { return "FileOffsetValidationError" }
+
+// Error satisfies the builtin error interface
+func (e FileOffsetValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sFileOffset.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = FileOffsetValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = FileOffsetValidationError{}
@@ -2071,6 +2071,8 @@ func (m *Git) validate(all bool) error {
 
 	// no validation rules for SkipArchives
 
+	// no validation rules for ScanUnreachable
+
 	switch v := m.Credential.(type) {
 	case *Git_BasicAuth:
 		if v == nil {
@@ -2546,6 +2548,10 @@ func (m *GitHub) validate(all bool) error {
 
 	// no validation rules for CommentsTimeframeDays
 
+	// no validation rules for IncludeActionsLogs
+
+	// no validation rules for IncludeArtifacts
+
 	switch v := m.Credential.(type) {
 	case *GitHub_GithubApp:
 		if v == nil {
@@ -4548,6 +4554,37 @@ func (m *Buildkite) validate(all bool) error {
 
 	var errors []error
 
+	if all {
+		switch v := interface{}(m.GetCreatedSince()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, BuildkiteValidationError{
+					field:  "CreatedSince",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, BuildkiteValidationError{
+					field:  "CreatedSince",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetCreatedSince()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return BuildkiteValidationError{
+				field:  "CreatedSince",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	// no validation rules for SkipArtifacts
+
 	switch v := m.Credential.(type) {
 	case *Buildkite_Token:
 		if v == nil {
@@ -6647,3 +6684,273 @@ var _ interface {
 	Cause() error
 	ErrorName() string
 } = SentryValidationError{}
+
+// Validate checks the field values on Kubernetes with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *Kubernetes) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on Kubernetes with the rules defined in
+// the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in KubernetesMultiError, or
+// nil if none found.
+func (m *Kubernetes) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *Kubernetes) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Context
+
+	// no validation rules for IncludeSecrets
+
+	switch v := m.Credential.(type) {
+	case *Kubernetes_Kubeconfig:
+		if v == nil {
+			err := KubernetesValidationError{
+				field:  "Credential",
+				reason: "oneof value cannot be a typed-nil",
+			}
+			if !all {
+				return err
+			}
+			errors = append(errors, err)
+		}
+		// no validation rules for Kubeconfig
+	case *Kubernetes_InCluster:
+		if v == nil {
+			err := KubernetesValidationError{
+				field:  "Credential",
+				reason: "oneof value cannot be a typed-nil",
+			}
+			if !all {
+				return err
+			}
+			errors = append(errors, err)
+		}
+		// no validation rules for InCluster
+	default:
+		_ = v // ensures v is used
+	}
+
+	if len(errors) > 0 {
+		return KubernetesMultiError(errors)
+	}
+
+	return nil
+}
+
+// KubernetesMultiError is an error wrapping multiple validation errors
+// returned by Kubernetes.ValidateAll() if the designated constraints aren't met.
+type KubernetesMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m KubernetesMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m KubernetesMultiError) AllErrors() []error { return m }
+
+// KubernetesValidationError is the validation error returned by
+// Kubernetes.Validate if the designated constraints aren't met.
+type KubernetesValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e KubernetesValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e KubernetesValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e KubernetesValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e KubernetesValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e KubernetesValidationError) ErrorName() string { return "KubernetesValidationError" }
+
+// Error satisfies the builtin error interface
+func (e KubernetesValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sKubernetes.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = KubernetesValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = KubernetesValidationError{}
+
+// Validate checks the field values on Database with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *Database) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on Database with the rules defined in
+// the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in DatabaseMultiError, or nil
+// if none found.
+func (m *Database) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *Database) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Driver
+
+	// no validation rules for ConnectionString
+
+	// no validation rules for SampleRows
+
+	// no validation rules for BatchSize
+
+	// no validation rules for UpdatedColumn
+
+	if all {
+		switch v := interface{}(m.GetUpdatedSince()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, DatabaseValidationError{
+					field:  "UpdatedSince",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, DatabaseValidationError{
+					field:  "UpdatedSince",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetUpdatedSince()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return DatabaseValidationError{
+				field:  "UpdatedSince",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return DatabaseMultiError(errors)
+	}
+
+	return nil
+}
+
+// DatabaseMultiError is an error wrapping multiple validation errors returned
+// by Database.ValidateAll() if the designated constraints aren't met.
+type DatabaseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m DatabaseMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m DatabaseMultiError) AllErrors() []error { return m }
+
+// DatabaseValidationError is the validation error returned by
+// Database.Validate if the designated constraints aren't met.
+type DatabaseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e DatabaseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e DatabaseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e DatabaseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e DatabaseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e DatabaseValidationError) ErrorName() string { return "DatabaseValidationError" }
+
+// Error satisfies the builtin error interface
+func (e DatabaseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sDatabase.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = DatabaseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = DatabaseValidationError{}
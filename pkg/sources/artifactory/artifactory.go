@@ -0,0 +1,344 @@
+package artifactory
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common/glob"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/log"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/docker"
+)
+
+const SourceType = sourcespb.SourceType_SOURCE_TYPE_JFROG_ARTIFACTORY
+
+// dockerUnitKind identifies units that are Docker repositories. Their images
+// are scanned by the docker source instead of file by file.
+const dockerUnitKind sources.SourceUnitKind = "docker_repository"
+
+// Source scans the files stored in the local repositories and remote
+// repository caches of a JFrog Artifactory instance. Docker repositories are
+// scanned image by image with the docker source.
+//
+// The size and modification time filters are not part of the connection
+// proto and are set with the With* methods after Init.
+type Source struct {
+	name     string
+	sourceID sources.SourceID
+	jobID    sources.JobID
+	verify   bool
+
+	client *client
+	// registryHost is the host that serves the Docker registry API.
+	registryHost string
+	// docker scans the images of Docker repositories.
+	docker        *docker.Source
+	includeRepo   func(key string) bool
+	ignorePath    func(path string) bool
+	maxSize       int64
+	modifiedSince time.Time
+
+	jobPool *errgroup.Group
+	sources.Progress
+	sources.CommonSourceUnitUnmarshaller
+}
+
+// Ensure the Source satisfies the interfaces at compile time.
+var _ sources.Source = (*Source)(nil)
+var _ sources.SourceUnitUnmarshaller = (*Source)(nil)
+var _ sources.SourceUnitEnumChunker = (*Source)(nil)
+
+// Type returns the type of source.
+// It is used for matching source types in configuration and job input.
+func (s *Source) Type() sourcespb.SourceType {
+	return SourceType
+}
+
+func (s *Source) SourceID() sources.SourceID {
+	return s.sourceID
+}
+
+func (s *Source) JobID() sources.JobID {
+	return s.jobID
+}
+
+// Init returns an initialized Artifactory source.
+func (s *Source) Init(ctx context.Context, name string, jobId sources.JobID, sourceId sources.SourceID, verify bool, connection *anypb.Any, concurrency int) error {
+	s.name = name
+	s.sourceID = sourceId
+	s.jobID = jobId
+	s.verify = verify
+	s.jobPool = &errgroup.Group{}
+	s.jobPool.SetLimit(concurrency)
+
+	var conn sourcespb.Artifactory
+	if err := anypb.UnmarshalTo(connection, &conn, proto.UnmarshalOptions{}); err != nil {
+		return fmt.Errorf("error unmarshalling connection: %w", err)
+	}
+	if conn.GetEndpoint() == "" {
+		return fmt.Errorf("an Artifactory endpoint is required")
+	}
+	endpoint := strings.TrimSuffix(conn.GetEndpoint(), "/")
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid Artifactory endpoint %q: %w", endpoint, err)
+	}
+	s.registryHost = u.Host
+
+	var (
+		authorize        func(*http.Request)
+		dockerCredential *sourcespb.Docker
+	)
+	switch cred := conn.GetCredential().(type) {
+	case *sourcespb.Artifactory_BasicAuth:
+		user, password := cred.BasicAuth.GetUsername(), cred.BasicAuth.GetPassword()
+		log.RedactGlobally(password)
+		authorize = func(req *http.Request) { req.SetBasicAuth(user, password) }
+		dockerCredential = &sourcespb.Docker{Credential: &sourcespb.Docker_BasicAuth{BasicAuth: cred.BasicAuth}}
+	case *sourcespb.Artifactory_AccessToken:
+		token := cred.AccessToken
+		log.RedactGlobally(token)
+		authorize = func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) }
+		dockerCredential = &sourcespb.Docker{Credential: &sourcespb.Docker_BearerToken{BearerToken: token}}
+	case *sourcespb.Artifactory_Unauthenticated:
+		dockerCredential = &sourcespb.Docker{Credential: &sourcespb.Docker_Unauthenticated{Unauthenticated: &credentialspb.Unauthenticated{}}}
+	default:
+		return fmt.Errorf("invalid configuration given for source %q (%s)", name, s.Type().String())
+	}
+	s.client = &client{httpClient: common.RetryableHTTPClientTimeout(300), baseURL: endpoint, authorize: authorize}
+
+	dockerConn, err := anypb.New(dockerCredential)
+	if err != nil {
+		return fmt.Errorf("could not create docker connection: %w", err)
+	}
+	s.docker = &docker.Source{}
+	if err := s.docker.Init(ctx, name, jobId, sourceId, verify, dockerConn, concurrency); err != nil {
+		return fmt.Errorf("could not initialize docker source: %w", err)
+	}
+
+	onCompileErr := func(err error, pattern string) {
+		ctx.Logger().Error(err, "could not compile glob", "glob", pattern)
+	}
+	ignoreRepo := glob.BuildIgnorer(conn.GetRepositories(), nil, onCompileErr, glob.WithSeparators('/'))
+	s.includeRepo = func(key string) bool { return !ignoreRepo(key) }
+	s.ignorePath = glob.BuildIgnorer(conn.GetIncludePaths(), conn.GetIgnorePaths(), onCompileErr, glob.WithSeparators('/'))
+	return nil
+}
+
+// WithMaxSize skips files larger than size bytes. Zero means no limit.
+func (s *Source) WithMaxSize(size int64) {
+	s.maxSize = size
+}
+
+// WithModifiedSince limits the scan to files modified at or after t.
+func (s *Source) WithModifiedSince(t time.Time) {
+	s.modifiedSince = t
+}
+
+// Enumerate reports the selected repositories. Local repositories are
+// identified by key and remote repositories by the key of their cache.
+// Virtual repositories are skipped since they only aggregate the others.
+func (s *Source) Enumerate(ctx context.Context, reporter sources.UnitReporter) error {
+	repos, err := s.client.listRepositories(ctx)
+	if err != nil {
+		return reporter.UnitErr(ctx, fmt.Errorf("could not list repositories: %w", err))
+	}
+
+	artifactoryReposEnumerated.WithLabelValues(s.name).Set(0)
+	for _, r := range repos {
+		if strings.EqualFold(r.Type, "VIRTUAL") || !s.includeRepo(r.Key) {
+			continue
+		}
+		unit := sources.CommonSourceUnit{ID: r.Key}
+		switch {
+		case strings.EqualFold(r.PackageType, "docker"):
+			unit.Kind = dockerUnitKind
+		case strings.EqualFold(r.Type, "REMOTE"):
+			unit.ID = r.Key + "-cache"
+		}
+		if err := reporter.UnitOk(ctx, unit); err != nil {
+			return err
+		}
+		artifactoryReposEnumerated.WithLabelValues(s.name).Inc()
+	}
+	return nil
+}
+
+// ChunkUnit scans every selected file of the repository, or every image of a
+// Docker repository.
+func (s *Source) ChunkUnit(ctx context.Context, unit sources.SourceUnit, reporter sources.ChunkReporter) error {
+	repo, kind := unit.SourceUnitID()
+	ctx = context.WithValue(ctx, "repo", repo)
+
+	if kind == dockerUnitKind {
+		if err := s.chunkDockerRepo(ctx, repo, reporter); err != nil {
+			return err
+		}
+		artifactoryReposScanned.WithLabelValues(s.name).Inc()
+		return nil
+	}
+
+	var reportErr error
+	err := s.client.listItems(ctx, repo, s.modifiedSince, func(it item) error {
+		if s.skip(it) {
+			return nil
+		}
+		if reportErr = s.chunkItem(ctx, it, reporter); reportErr != nil {
+			return reportErr
+		}
+		artifactoryArtifactsScanned.WithLabelValues(s.name).Inc()
+		return nil
+	})
+	if reportErr != nil {
+		return reportErr
+	}
+	if err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not list files: %w", err))
+	}
+	artifactoryReposScanned.WithLabelValues(s.name).Inc()
+	return nil
+}
+
+// skip reports whether a file is filtered out by path, size or
+// modification time.
+func (s *Source) skip(it item) bool {
+	if s.ignorePath(it.FullPath()) {
+		return true
+	}
+	if s.maxSize > 0 && it.Size > s.maxSize {
+		return true
+	}
+	if !s.modifiedSince.IsZero() {
+		if modified, err := time.Parse(time.RFC3339, it.Modified); err == nil && modified.Before(s.modifiedSince) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Source) chunkItem(ctx context.Context, it item, reporter sources.ChunkReporter) error {
+	body, err := s.client.download(ctx, it)
+	if err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not download %s: %w", it.FullPath(), err))
+	}
+	defer body.Close()
+
+	chunkSkel := &sources.Chunk{
+		SourceName: s.name,
+		SourceID:   s.SourceID(),
+		JobID:      s.JobID(),
+		SourceType: s.Type(),
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Artifactory{
+				Artifactory: &source_metadatapb.Artifactory{
+					Repo:      sanitizer.UTF8(it.Repo),
+					Path:      sanitizer.UTF8(it.FullPath()),
+					Link:      sanitizer.UTF8(s.client.artifactURL(it)),
+					Timestamp: sanitizer.UTF8(it.Modified),
+					Username:  sanitizer.UTF8(it.ModifiedBy),
+				},
+			},
+		},
+		Verify: s.verify,
+	}
+	if err := handlers.HandleFile(ctx, body, chunkSkel, reporter); err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not scan %s: %w", it.FullPath(), err))
+	}
+	return nil
+}
+
+// dockerImage is a tag of an image of a Docker repository.
+type dockerImage struct {
+	name string
+	tag  string
+}
+
+// dockerImages returns every tag of every image of a Docker repository.
+func (s *Source) dockerImages(ctx context.Context, repo string, reporter sources.ChunkReporter) ([]dockerImage, error) {
+	names, err := s.client.listImages(ctx, repo)
+	if err != nil {
+		return nil, reporter.ChunkErr(ctx, fmt.Errorf("could not list images: %w", err))
+	}
+	var images []dockerImage
+	for _, name := range names {
+		tags, err := s.client.listTags(ctx, repo, name)
+		if err != nil {
+			if err := reporter.ChunkErr(ctx, fmt.Errorf("could not list tags of %s: %w", name, err)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		for _, tag := range tags {
+			images = append(images, dockerImage{name: name, tag: tag})
+		}
+	}
+	return images, nil
+}
+
+// chunkDockerRepo scans the images of a Docker repository one at a time with
+// the docker source, which is shared by every repository so that its limit on
+// the layers read at once holds across them. Images are referenced with the
+// repository path method, host/repo/image:tag.
+func (s *Source) chunkDockerRepo(ctx context.Context, repo string, reporter sources.ChunkReporter) error {
+	images, err := s.dockerImages(ctx, repo, reporter)
+	if err != nil {
+		return err
+	}
+	for _, image := range images {
+		if common.IsDone(ctx) {
+			return ctx.Err()
+		}
+		ref := fmt.Sprintf("%s/%s/%s:%s", s.registryHost, repo, image.name, image.tag)
+		imageReporter := dockerReporter{ChunkReporter: reporter, source: s, repo: repo, image: image}
+		if err := s.docker.ChunkUnit(ctx, sources.CommonSourceUnit{ID: ref}, imageReporter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dockerReporter reports the chunks of an image of a Docker repository as
+// chunks of this source, located by the folder Artifactory keeps the image
+// in, image/tag, followed by the file in the image.
+type dockerReporter struct {
+	sources.ChunkReporter
+	source *Source
+	repo   string
+	image  dockerImage
+}
+
+func (r dockerReporter) ChunkOk(ctx context.Context, chunk sources.Chunk) error {
+	folder := item{Repo: r.repo, Path: r.image.name, Name: r.image.tag}
+	chunk.SourceType = r.source.Type()
+	chunk.SourceMetadata = &source_metadatapb.MetaData{
+		Data: &source_metadatapb.MetaData_Artifactory{
+			Artifactory: &source_metadatapb.Artifactory{
+				Repo: sanitizer.UTF8(r.repo),
+				Path: sanitizer.UTF8(path.Join(folder.FullPath(), chunk.SourceMetadata.GetDocker().GetFile())),
+				Link: sanitizer.UTF8(r.source.client.artifactURL(folder)),
+			},
+		},
+	}
+	return r.ChunkReporter.ChunkOk(ctx, chunk)
+}
+
+// Chunks emits chunks of bytes over a channel.
+func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ ...sources.ChunkingTarget) error {
+	artifactoryReposScanned.WithLabelValues(s.name).Set(0)
+	return sources.ChunkUnits(ctx, s, s.jobPool, &s.Progress, "Repository", chunksChan)
+}
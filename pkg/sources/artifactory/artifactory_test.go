@@ -0,0 +1,191 @@
+package artifactory
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sourcestest"
+)
+
+func jar(t *testing.T, name, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create(name)
+	require.NoError(t, err)
+	_, err = w.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+// newTestServer serves an Artifactory instance whose AQL endpoint is only
+// available with the "admin" access token.
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	files := map[string][]byte{
+		"/artifactory/libs-release/com/acme/app/1.0/app-1.0.jar": jar(t, "application.properties", "db.password=from-jar"),
+		"/artifactory/libs-release/notes.txt":                    []byte("token=from-notes"),
+		"/artifactory/libs-release/big/dump.bin":                 []byte("secret=too-big-to-scan"),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if data, ok := files[r.URL.Path]; ok {
+			_, _ = w.Write(data)
+			return
+		}
+		var resp string
+		switch r.URL.Path {
+		case "/artifactory/api/repositories":
+			resp = `[
+				{"key": "libs-release", "type": "LOCAL", "packageType": "Maven"},
+				{"key": "npmjs", "type": "REMOTE", "packageType": "Npm"},
+				{"key": "libs", "type": "VIRTUAL", "packageType": "Maven"},
+				{"key": "docker-local", "type": "LOCAL", "packageType": "Docker"},
+				{"key": "scratch", "type": "LOCAL", "packageType": "Generic"}
+			]`
+		case "/artifactory/api/search/aql":
+			if r.Header.Get("Authorization") != "Bearer admin" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			query, _ := io.ReadAll(r.Body)
+			if !strings.Contains(string(query), `"repo":"libs-release"`) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			resp = `{"results": [
+				{"repo": "libs-release", "path": ".", "name": "notes.txt", "size": 16, "modified": "2023-01-01T00:00:00.000Z", "modified_by": "bob"},
+				{"repo": "libs-release", "path": "big", "name": "dump.bin", "size": 104857600, "modified": "2024-06-01T00:00:00.000Z", "modified_by": "ci"},
+				{"repo": "libs-release", "path": "com/acme/app/1.0", "name": "app-1.0.jar", "size": 200, "modified": "2024-06-01T00:00:00.000Z", "modified_by": "ci"}
+			], "range": {"start_pos": 0, "end_pos": 3, "total": 3}}`
+		case "/artifactory/api/storage/libs-release/":
+			resp = `{"files": [
+				{"uri": "/notes.txt", "size": 16, "lastModified": "2023-01-01T00:00:00.000Z", "folder": false},
+				{"uri": "/com/acme/app/1.0/app-1.0.jar", "size": 200, "lastModified": "2024-06-01T00:00:00.000Z", "folder": false}
+			]}`
+		case "/artifactory/api/docker/docker-local/v2/_catalog":
+			resp = `{"repositories": ["team/api", "web"]}`
+		case "/artifactory/api/docker/docker-local/v2/team/api/tags/list":
+			resp = `{"name": "team/api", "tags": ["1.0", "latest"]}`
+		case "/artifactory/api/docker/docker-local/v2/web/tags/list":
+			resp = `{"name": "web", "tags": ["2.3"]}`
+		}
+		if resp == "" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(resp))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestSource(t *testing.T, srv *httptest.Server, conn *sourcespb.Artifactory) *Source {
+	t.Helper()
+	conn.Endpoint = srv.URL + "/artifactory/"
+	if conn.Credential == nil {
+		conn.Credential = &sourcespb.Artifactory_AccessToken{AccessToken: "admin"}
+	}
+	anyConn, err := anypb.New(conn)
+	require.NoError(t, err)
+	s := &Source{}
+	require.NoError(t, s.Init(context.Background(), "test", 0, 0, false, anyConn, 1))
+	return s
+}
+
+func TestEnumerate(t *testing.T) {
+	srv := newTestServer(t)
+	s := newTestSource(t, srv, &sourcespb.Artifactory{Repositories: []string{"libs-*", "npm*", "docker-*"}})
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+	assert.Empty(t, reporter.UnitErrs)
+	assert.Equal(t, []sources.SourceUnit{
+		sources.CommonSourceUnit{ID: "libs-release"},
+		sources.CommonSourceUnit{ID: "npmjs-cache"},
+		sources.CommonSourceUnit{Kind: dockerUnitKind, ID: "docker-local"},
+	}, reporter.Units)
+}
+
+func TestChunkUnit(t *testing.T) {
+	srv := newTestServer(t)
+	unit := sources.CommonSourceUnit{ID: "libs-release"}
+
+	t.Run("aql with filters", func(t *testing.T) {
+		s := newTestSource(t, srv, &sourcespb.Artifactory{IgnorePaths: []string{"*.txt"}})
+		s.WithMaxSize(1 << 20)
+
+		reporter := sourcestest.TestReporter{}
+		require.NoError(t, s.ChunkUnit(context.Background(), unit, &reporter))
+		require.Empty(t, reporter.ChunkErrs)
+		require.Len(t, reporter.Chunks, 1)
+
+		assert.Contains(t, string(reporter.Chunks[0].Data), "db.password=from-jar")
+		meta := reporter.Chunks[0].SourceMetadata.GetArtifactory()
+		assert.Equal(t, "libs-release", meta.GetRepo())
+		assert.Equal(t, "com/acme/app/1.0/app-1.0.jar", meta.GetPath())
+		assert.Equal(t, srv.URL+"/artifactory/libs-release/com/acme/app/1.0/app-1.0.jar", meta.GetLink())
+		assert.Equal(t, "ci", meta.GetUsername())
+	})
+
+	t.Run("storage api fallback", func(t *testing.T) {
+		s := newTestSource(t, srv, &sourcespb.Artifactory{
+			Credential: &sourcespb.Artifactory_AccessToken{AccessToken: "reader"},
+		})
+		s.WithModifiedSince(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+		reporter := sourcestest.TestReporter{}
+		require.NoError(t, s.ChunkUnit(context.Background(), unit, &reporter))
+		require.Empty(t, reporter.ChunkErrs)
+		require.Len(t, reporter.Chunks, 1)
+		assert.Equal(t, "com/acme/app/1.0/app-1.0.jar", reporter.Chunks[0].SourceMetadata.GetArtifactory().GetPath())
+	})
+}
+
+func TestDockerImages(t *testing.T) {
+	srv := newTestServer(t)
+	s := newTestSource(t, srv, &sourcespb.Artifactory{})
+
+	reporter := sourcestest.TestReporter{}
+	images, err := s.dockerImages(context.Background(), "docker-local", &reporter)
+	require.NoError(t, err)
+	assert.Equal(t, []dockerImage{
+		{name: "team/api", tag: "1.0"},
+		{name: "team/api", tag: "latest"},
+		{name: "web", tag: "2.3"},
+	}, images)
+}
+
+func TestDockerReporter(t *testing.T) {
+	srv := newTestServer(t)
+	s := newTestSource(t, srv, &sourcespb.Artifactory{})
+
+	reporter := sourcestest.TestReporter{}
+	imageReporter := dockerReporter{ChunkReporter: &reporter, source: s, repo: "docker-local", image: dockerImage{name: "team/api", tag: "1.0"}}
+	require.NoError(t, imageReporter.ChunkOk(context.Background(), sources.Chunk{
+		SourceType: sourcespb.SourceType_SOURCE_TYPE_DOCKER,
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Docker{Docker: &source_metadatapb.Docker{File: "/app/.env", Image: "team/api"}},
+		},
+	}))
+	require.Len(t, reporter.Chunks, 1)
+	assert.Equal(t, sourcespb.SourceType_SOURCE_TYPE_JFROG_ARTIFACTORY, reporter.Chunks[0].SourceType)
+	meta := reporter.Chunks[0].SourceMetadata.GetArtifactory()
+	assert.Equal(t, "docker-local", meta.GetRepo())
+	assert.Equal(t, "team/api/1.0/app/.env", meta.GetPath())
+	assert.Equal(t, srv.URL+"/artifactory/docker-local/team/api/1.0", meta.GetLink())
+}
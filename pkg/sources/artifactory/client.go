@@ -0,0 +1,236 @@
+package artifactory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+const aqlPageSize = 1000
+
+// repository is an entry of the repository list.
+type repository struct {
+	Key string `json:"key"`
+	// Type is LOCAL, REMOTE, VIRTUAL or FEDERATED.
+	Type        string `json:"type"`
+	PackageType string `json:"packageType"`
+	URL         string `json:"url"`
+}
+
+// item is a file stored in a repository.
+type item struct {
+	Repo string `json:"repo"`
+	// Path is the folder of the file, "." for the repository root.
+	Path       string `json:"path"`
+	Name       string `json:"name"`
+	Size       int64  `json:"size"`
+	Modified   string `json:"modified"`
+	ModifiedBy string `json:"modified_by"`
+}
+
+// FullPath returns the path of the file relative to the repository root.
+func (i item) FullPath() string {
+	if i.Path == "" || i.Path == "." {
+		return i.Name
+	}
+	return i.Path + "/" + i.Name
+}
+
+type aqlPage struct {
+	Results []item `json:"results"`
+	Range   struct {
+		StartPos int `json:"start_pos"`
+		EndPos   int `json:"end_pos"`
+		Total    int `json:"total"`
+	} `json:"range"`
+}
+
+type fileList struct {
+	Files []struct {
+		URI          string `json:"uri"`
+		Size         int64  `json:"size"`
+		LastModified string `json:"lastModified"`
+		Folder       bool   `json:"folder"`
+	} `json:"files"`
+}
+
+// statusError is returned for responses with an unexpected status code.
+type statusError struct {
+	StatusCode int
+	Path       string
+	Body       string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d from %q: %s", e.StatusCode, e.Path, e.Body)
+}
+
+// client is a minimal Artifactory REST API client.
+type client struct {
+	httpClient *http.Client
+	// baseURL is the Artifactory URL, such as https://acme.jfrog.io/artifactory.
+	baseURL string
+	// authorize sets the credentials on a request.
+	authorize func(*http.Request)
+}
+
+func (c *client) do(ctx context.Context, method, rawURL, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Artifactory API request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.authorize != nil {
+		c.authorize(req)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request to Artifactory API: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, &statusError{StatusCode: resp.StatusCode, Path: req.URL.Path, Body: strings.TrimSpace(string(body))}
+	}
+	return resp, nil
+}
+
+func (c *client) decode(ctx context.Context, method, path, contentType string, body io.Reader, target any) error {
+	resp, err := c.do(ctx, method, c.baseURL+path, contentType, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode Artifactory API response: %w", err)
+	}
+	return nil
+}
+
+func (c *client) get(ctx context.Context, path string, target any) error {
+	return c.decode(ctx, http.MethodGet, path, "", nil, target)
+}
+
+// listRepositories lists every repository visible to the user.
+func (c *client) listRepositories(ctx context.Context) ([]repository, error) {
+	var repos []repository
+	if err := c.get(ctx, "/api/repositories", &repos); err != nil {
+		return nil, err
+	}
+	return repos, nil
+}
+
+// listItems visits every file of the repository. It uses AQL, which can
+// filter on the modification time, and falls back to the storage API when
+// AQL is not available to the user, as is the case for anonymous access.
+func (c *client) listItems(ctx context.Context, repo string, modifiedSince time.Time, visit func(item) error) error {
+	err := c.searchAQL(ctx, repo, modifiedSince, visit)
+	var se *statusError
+	if errors.As(err, &se) && (se.StatusCode == http.StatusUnauthorized || se.StatusCode == http.StatusForbidden || se.StatusCode == http.StatusNotFound) {
+		return c.listStorage(ctx, repo, visit)
+	}
+	return err
+}
+
+func (c *client) searchAQL(ctx context.Context, repo string, modifiedSince time.Time, visit func(item) error) error {
+	criteria := map[string]any{"repo": repo, "type": "file"}
+	if !modifiedSince.IsZero() {
+		criteria["modified"] = map[string]string{"$gte": modifiedSince.UTC().Format(time.RFC3339)}
+	}
+	find, err := json.Marshal(criteria)
+	if err != nil {
+		return err
+	}
+
+	for offset := 0; ; {
+		query := fmt.Sprintf(`items.find(%s).include("repo","path","name","size","modified","modified_by").sort({"$asc":["path","name"]}).offset(%d).limit(%d)`,
+			find, offset, aqlPageSize)
+		var page aqlPage
+		if err := c.decode(ctx, http.MethodPost, "/api/search/aql", "text/plain", strings.NewReader(query), &page); err != nil {
+			return err
+		}
+		for _, it := range page.Results {
+			if err := visit(it); err != nil {
+				return err
+			}
+		}
+		offset += len(page.Results)
+		if len(page.Results) < aqlPageSize {
+			return nil
+		}
+	}
+}
+
+func (c *client) listStorage(ctx context.Context, repo string, visit func(item) error) error {
+	var list fileList
+	if err := c.get(ctx, "/api/storage/"+url.PathEscape(repo)+"/?list&deep=1&listFolders=0", &list); err != nil {
+		return err
+	}
+	for _, f := range list.Files {
+		if f.Folder {
+			continue
+		}
+		dir, name := "", strings.TrimPrefix(f.URI, "/")
+		if i := strings.LastIndex(name, "/"); i >= 0 {
+			dir, name = name[:i], name[i+1:]
+		}
+		it := item{Repo: repo, Path: dir, Name: name, Size: f.Size, Modified: f.LastModified}
+		if err := visit(it); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// artifactURL returns the download URL of a file.
+func (c *client) artifactURL(it item) string {
+	segments := strings.Split(it.FullPath(), "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return c.baseURL + "/" + url.PathEscape(it.Repo) + "/" + strings.Join(segments, "/")
+}
+
+// download returns the content of a file. The caller must close the
+// returned reader.
+func (c *client) download(ctx context.Context, it item) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, http.MethodGet, c.artifactURL(it), "", nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// listImages returns the images of a Docker repository through the registry
+// API that Artifactory serves for every Docker repository.
+func (c *client) listImages(ctx context.Context, repo string) ([]string, error) {
+	var catalog struct {
+		Repositories []string `json:"repositories"`
+	}
+	if err := c.get(ctx, "/api/docker/"+url.PathEscape(repo)+"/v2/_catalog", &catalog); err != nil {
+		return nil, err
+	}
+	return catalog.Repositories, nil
+}
+
+// listTags returns the tags of an image of a Docker repository.
+func (c *client) listTags(ctx context.Context, repo, image string) ([]string, error) {
+	var tags struct {
+		Tags []string `json:"tags"`
+	}
+	if err := c.get(ctx, "/api/docker/"+url.PathEscape(repo)+"/v2/"+image+"/tags/list", &tags); err != nil {
+		return nil, err
+	}
+	return tags.Tags, nil
+}
@@ -0,0 +1,34 @@
+package artifactory
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+)
+
+var (
+	artifactoryReposEnumerated = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "artifactory_repos_enumerated",
+		Help:      "Total number of Artifactory repositories enumerated.",
+	},
+		[]string{"source_name"})
+
+	artifactoryReposScanned = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "artifactory_repos_scanned",
+		Help:      "Total number of Artifactory repositories scanned.",
+	},
+		[]string{"source_name"})
+
+	artifactoryArtifactsScanned = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "artifactory_artifacts_scanned",
+		Help:      "Total number of Artifactory artifacts scanned.",
+	},
+		[]string{"source_name"})
+)
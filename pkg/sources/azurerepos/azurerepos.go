@@ -0,0 +1,563 @@
+package azurerepos
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	gogit "github.com/go-git/go-git/v5"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common/glob"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/giturl"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/log"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/git"
+)
+
+const SourceType = sourcespb.SourceType_SOURCE_TYPE_AZURE_REPOS
+
+// azureBaseURL is the endpoint of Azure DevOps Services.
+const azureBaseURL = "https://dev.azure.com"
+
+// Source scans Azure DevOps Services or Azure DevOps Server git repositories.
+// Pull request threads and project wikis have no field in
+// sourcespb.AzureRepos and are enabled with the With* methods after Init.
+type Source struct {
+	name     string
+	sourceID sources.SourceID
+	jobID    sources.JobID
+	verify   bool
+
+	authMethod string
+	token      string
+	endpoint   string
+	client     *client
+
+	repos           []string
+	organizations   []string
+	projects        []string
+	includeForks    bool
+	ignoreRepos     []string
+	includeRepos    []string
+	ignoreProjects  []string
+	includeProjects []string
+
+	includePRThreads bool
+	includeWikis     bool
+
+	// visibility records the project visibility of enumerated repositories.
+	visibilityMu sync.Mutex
+	visibility   map[string]source_metadatapb.Visibility
+
+	useCustomContentWriter bool
+	git                    *git.Git
+	scanOptions            *git.ScanOptions
+
+	jobPool *errgroup.Group
+	sources.Progress
+	sources.CommonSourceUnitUnmarshaller
+}
+
+// Ensure the Source satisfies the interfaces at compile time.
+var _ sources.Source = (*Source)(nil)
+var _ sources.SourceUnitUnmarshaller = (*Source)(nil)
+var _ sources.Validator = (*Source)(nil)
+var _ sources.SourceUnitEnumChunker = (*Source)(nil)
+
+// WithCustomContentWriter sets the useCustomContentWriter flag on the source.
+func (s *Source) WithCustomContentWriter() { s.useCustomContentWriter = true }
+
+// Type returns the type of source.
+// It is used for matching source types in configuration and job input.
+func (s *Source) Type() sourcespb.SourceType {
+	return SourceType
+}
+
+func (s *Source) SourceID() sources.SourceID {
+	return s.sourceID
+}
+
+func (s *Source) JobID() sources.JobID {
+	return s.jobID
+}
+
+// Init returns an initialized Azure Repos source.
+func (s *Source) Init(ctx context.Context, name string, jobId sources.JobID, sourceId sources.SourceID, verify bool, connection *anypb.Any, concurrency int) error {
+	s.name = name
+	s.sourceID = sourceId
+	s.jobID = jobId
+	s.verify = verify
+	s.jobPool = &errgroup.Group{}
+	s.jobPool.SetLimit(concurrency)
+	s.visibility = make(map[string]source_metadatapb.Visibility)
+
+	if err := git.CmdCheck(); err != nil {
+		return err
+	}
+
+	var conn sourcespb.AzureRepos
+	if err := anypb.UnmarshalTo(connection, &conn, proto.UnmarshalOptions{}); err != nil {
+		return fmt.Errorf("error unmarshalling connection: %w", err)
+	}
+
+	s.repos = conn.GetRepositories()
+	s.organizations = conn.GetOrganizations()
+	s.projects = conn.GetProjects()
+	s.includeForks = conn.GetIncludeForks()
+	s.ignoreRepos = conn.GetIgnoreRepos()
+	s.includeRepos = conn.GetIncludeRepos()
+	s.ignoreProjects = conn.GetIgnoreProjects()
+	s.includeProjects = conn.GetIncludeProjects()
+
+	switch cred := conn.GetCredential().(type) {
+	case *sourcespb.AzureRepos_Token:
+		s.authMethod = "TOKEN"
+		s.token = cred.Token
+	case *sourcespb.AzureRepos_Oauth:
+		s.authMethod = "OAUTH"
+		s.token = cred.Oauth.GetAccessToken()
+	default:
+		return fmt.Errorf("invalid configuration given for source %q (%s)", name, s.Type().String())
+	}
+	log.RedactGlobally(s.token)
+
+	endpoint, err := normalizeAzureEndpoint(conn.GetEndpoint())
+	if err != nil {
+		return err
+	}
+	s.endpoint = endpoint
+	s.client = &client{
+		httpClient: common.RetryableHTTPClientTimeout(60),
+		endpoint:   s.endpoint,
+		profileURL: profileBaseURL,
+		token:      s.token,
+		oauth:      s.authMethod == "OAUTH",
+	}
+
+	cfg := &git.Config{
+		SourceName:   s.name,
+		JobID:        s.jobID,
+		SourceID:     s.sourceID,
+		SourceType:   s.Type(),
+		Verify:       s.verify,
+		SkipBinaries: conn.GetSkipBinaries(),
+		SkipArchives: conn.GetSkipArchives(),
+		Concurrency:  concurrency,
+		SourceMetadataFunc: func(file, email, commit, timestamp, repository string, line int64) *source_metadatapb.MetaData {
+			loc, _ := s.parseRepoURL(repository)
+			return &source_metadatapb.MetaData{
+				Data: &source_metadatapb.MetaData_AzureRepos{
+					AzureRepos: &source_metadatapb.AzureRepos{
+						Commit:       sanitizer.UTF8(commit),
+						File:         sanitizer.UTF8(file),
+						Email:        sanitizer.UTF8(email),
+						Repository:   sanitizer.UTF8(repository),
+						Organization: sanitizer.UTF8(loc.org),
+						Project:      sanitizer.UTF8(loc.project),
+						Link:         generateLink(repository, commit, file, line),
+						Timestamp:    sanitizer.UTF8(timestamp),
+						Line:         line,
+						Visibility:   s.visibilityOf(repository),
+					},
+				},
+			}
+		},
+		UseCustomContentWriter: s.useCustomContentWriter,
+	}
+	s.git = git.NewGit(cfg)
+
+	return nil
+}
+
+// WithScanOptions sets the git scan options used for every repository.
+func (s *Source) WithScanOptions(scanOptions *git.ScanOptions) {
+	s.scanOptions = scanOptions
+}
+
+// WithPullRequestThreads enables scanning pull request descriptions and comment threads.
+func (s *Source) WithPullRequestThreads() { s.includePRThreads = true }
+
+// WithWikis enables scanning the git repositories backing project wikis.
+func (s *Source) WithWikis() { s.includeWikis = true }
+
+// normalizeAzureEndpoint returns the endpoint without a trailing slash,
+// defaulting to Azure DevOps Services.
+func normalizeAzureEndpoint(endpoint string) (string, error) {
+	if endpoint == "" {
+		return azureBaseURL, nil
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	// We probably didn't receive a URL with a scheme, which messed up the parsing.
+	if u.Host == "" {
+		if u, err = url.Parse("https://" + endpoint); err != nil {
+			return "", err
+		}
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.RawQuery, u.Fragment = "", ""
+	return u.String(), nil
+}
+
+// repoLocation identifies a repository within Azure DevOps.
+type repoLocation struct {
+	org, project, repo string
+}
+
+// parseRepoURL extracts the organization (or collection), project and
+// repository name from a clone URL of the form
+// <endpoint>/<org>/<project>/_git/<repo>.
+func (s *Source) parseRepoURL(repoURL string) (repoLocation, error) {
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return repoLocation{}, err
+	}
+	path := u.Path
+	if base, err := url.Parse(s.endpoint); err == nil && base.Host == u.Host {
+		path = strings.TrimPrefix(path, base.Path)
+	}
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	idx := -1
+	for i, p := range parts {
+		if p == "_git" {
+			idx = i
+			break
+		}
+	}
+	if idx < 1 || idx+1 >= len(parts) {
+		return repoLocation{}, fmt.Errorf("%q is not an Azure Repos clone URL", repoURL)
+	}
+
+	loc := repoLocation{repo: parts[idx+1]}
+	if idx == 1 {
+		// <org>/_git/<repo> is used when the repository and project share a name.
+		loc.org, loc.project = parts[0], loc.repo
+	} else {
+		loc.org, loc.project = parts[idx-2], parts[idx-1]
+	}
+	for _, v := range []*string{&loc.org, &loc.project, &loc.repo} {
+		if unescaped, err := url.PathUnescape(*v); err == nil {
+			*v = unescaped
+		}
+	}
+	return loc, nil
+}
+
+// generateLink returns a link to the file and line at the given commit.
+func generateLink(repoURL, commit, file string, line int64) string {
+	if strings.Contains(repoURL, "dev.azure.com/") {
+		return giturl.GenerateLink(repoURL, commit, file, line)
+	}
+	// Azure DevOps Server uses the same URL layout on its own host.
+	link := repoURL + "/commit/" + commit + "/" + strings.ReplaceAll(file, "%", "%25")
+	if line > 0 {
+		link += "?line=" + strconv.FormatInt(line, 10)
+	}
+	return link
+}
+
+func (s *Source) visibilityOf(repoURL string) source_metadatapb.Visibility {
+	s.visibilityMu.Lock()
+	defer s.visibilityMu.Unlock()
+	if v, ok := s.visibility[repoURL]; ok {
+		return v
+	}
+	return source_metadatapb.Visibility_unknown
+}
+
+func (s *Source) setVisibility(repoURL, visibility string) {
+	v := source_metadatapb.Visibility_private
+	if strings.EqualFold(visibility, "public") {
+		v = source_metadatapb.Visibility_public
+	}
+	s.visibilityMu.Lock()
+	s.visibility[repoURL] = v
+	s.visibilityMu.Unlock()
+}
+
+// Validate checks that the configured credentials are accepted by Azure DevOps.
+func (s *Source) Validate(ctx context.Context) []error {
+	var errs []error
+	for _, r := range s.repos {
+		if _, err := s.parseRepoURL(r); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	glob.BuildIgnorer(s.globPatterns(), nil, func(err error, pattern string) {
+		errs = append(errs, fmt.Errorf("could not compile include/exclude pattern %q: %w", pattern, err))
+	})
+
+	orgs := s.organizations
+	if len(orgs) == 0 {
+		var err error
+		if orgs, err = s.client.listOrganizations(ctx); err != nil {
+			return append(errs, fmt.Errorf("azure devops authentication failed using method %v: %w", s.authMethod, err))
+		}
+	}
+	for _, org := range orgs {
+		if _, err := s.client.listProjects(ctx, org); err != nil {
+			errs = append(errs, fmt.Errorf("could not list projects in organization %q: %w", org, err))
+		}
+	}
+	return errs
+}
+
+func (s *Source) globPatterns() []string {
+	var patterns []string
+	for _, p := range [][]string{s.includeRepos, s.ignoreRepos, s.includeProjects, s.ignoreProjects} {
+		patterns = append(patterns, p...)
+	}
+	return patterns
+}
+
+// Enumerate reports all Azure Repos repositories to be scanned to the
+// reporter. If none are configured, it lists every repository of the
+// configured (or, on Azure DevOps Services, all accessible) organizations and
+// projects, while respecting the fork setting and the include and ignore
+// globs. Repository globs match "<project>/<repo>" and project globs match
+// the project name.
+func (s *Source) Enumerate(ctx context.Context, reporter sources.UnitReporter) error {
+	azureReposEnumerated.WithLabelValues(s.name).Set(0)
+	if len(s.repos) > 0 {
+		for _, repo := range s.repos {
+			if _, err := s.parseRepoURL(repo); err != nil {
+				if err := reporter.UnitErr(ctx, err); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := reporter.UnitOk(ctx, git.SourceUnit{Kind: git.UnitRepo, ID: repo}); err != nil {
+				return err
+			}
+			azureReposEnumerated.WithLabelValues(s.name).Inc()
+		}
+		return nil
+	}
+
+	onCompileErr := func(err error, pattern string) {
+		ctx.Logger().Error(err, "could not compile include/exclude glob", "glob", pattern)
+		_ = reporter.UnitErr(ctx, fmt.Errorf("could not compile include/exclude glob: %w", err))
+	}
+	ignoreRepo := glob.BuildIgnorer(s.includeRepos, s.ignoreRepos, onCompileErr)
+	ignoreProject := glob.BuildIgnorer(s.includeProjects, s.ignoreProjects, onCompileErr)
+
+	orgs := s.organizations
+	if len(orgs) == 0 {
+		if s.endpoint != azureBaseURL {
+			return fmt.Errorf("organizations (collections) must be configured for Azure DevOps Server")
+		}
+		var err error
+		if orgs, err = s.client.listOrganizations(ctx); err != nil {
+			return reporter.UnitErr(ctx, err)
+		}
+	}
+
+	for _, org := range orgs {
+		ctx := context.WithValue(ctx, "organization", org)
+		projects := s.projects
+		if len(projects) == 0 {
+			listed, err := s.client.listProjects(ctx, org)
+			if err != nil {
+				if err := reporter.UnitErr(ctx, fmt.Errorf("could not list projects in %q: %w", org, err)); err != nil {
+					return err
+				}
+				continue
+			}
+			for _, p := range listed {
+				projects = append(projects, p.Name)
+			}
+		}
+
+		for _, proj := range projects {
+			if ignoreProject(proj) {
+				ctx.Logger().V(3).Info("skipping project", "project", proj, "reason", "ignored in config")
+				continue
+			}
+			if err := s.enumerateProject(ctx, org, proj, ignoreRepo, reporter); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Source) enumerateProject(ctx context.Context, org, proj string, ignoreRepo func(string) bool, reporter sources.UnitReporter) error {
+	ctx = context.WithValue(ctx, "project", proj)
+	repos, err := s.client.listRepos(ctx, org, proj)
+	if err != nil {
+		return reporter.UnitErr(ctx, fmt.Errorf("could not list repositories in %s/%s: %w", org, proj, err))
+	}
+
+	report := func(remoteURL, visibility string) error {
+		remoteURL = stripUserInfo(remoteURL)
+		s.setVisibility(remoteURL, visibility)
+		if err := reporter.UnitOk(ctx, git.SourceUnit{Kind: git.UnitRepo, ID: remoteURL}); err != nil {
+			return err
+		}
+		azureReposEnumerated.WithLabelValues(s.name).Inc()
+		return nil
+	}
+
+	for _, r := range repos {
+		fullName := proj + "/" + r.Name
+		switch {
+		case r.IsDisabled:
+			ctx.Logger().V(3).Info("skipping repo", "repo", fullName, "reason", "disabled")
+		case r.IsFork && !s.includeForks:
+			ctx.Logger().V(3).Info("skipping repo", "repo", fullName, "reason", "fork")
+		case ignoreRepo(fullName):
+			ctx.Logger().V(3).Info("skipping repo", "repo", fullName, "reason", "ignored in config")
+		default:
+			if err := report(r.RemoteURL, r.Project.Visibility); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !s.includeWikis {
+		return nil
+	}
+	wikis, err := s.client.listWikis(ctx, org, proj)
+	if err != nil {
+		return reporter.UnitErr(ctx, fmt.Errorf("could not list wikis in %s/%s: %w", org, proj, err))
+	}
+	for _, w := range wikis {
+		// Code wikis are published from a repository that is already enumerated.
+		if w.Type != "projectWiki" || w.RemoteURL == "" {
+			continue
+		}
+		if err := report(w.RemoteURL, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stripUserInfo removes the organization name Azure DevOps embeds as the
+// username of clone URLs.
+func stripUserInfo(remoteURL string) string {
+	u, err := url.Parse(remoteURL)
+	if err != nil {
+		return remoteURL
+	}
+	u.User = nil
+	return u.String()
+}
+
+// ChunkUnit clones and scans the given repository unit, followed by its pull
+// request threads if enabled.
+func (s *Source) ChunkUnit(ctx context.Context, unit sources.SourceUnit, reporter sources.ChunkReporter) error {
+	repoURL, _ := unit.SourceUnitID()
+	ctx = context.WithValue(ctx, "repo", repoURL)
+
+	path, repo, err := s.cloneRepo(ctx, repoURL)
+	if err != nil {
+		return reporter.ChunkErr(ctx, err)
+	}
+	defer os.RemoveAll(path)
+
+	if err := s.git.ScanRepo(ctx, repo, path, s.scanOptions, reporter); err != nil {
+		return reporter.ChunkErr(ctx, err)
+	}
+	azureReposScanned.WithLabelValues(s.name).Inc()
+
+	loc, err := s.parseRepoURL(repoURL)
+	if err != nil || !s.includePRThreads || strings.HasSuffix(loc.repo, ".wiki") {
+		return nil
+	}
+	return s.chunkPullRequests(ctx, repoURL, loc, reporter)
+}
+
+func (s *Source) cloneRepo(ctx context.Context, repoURL string) (string, *gogit.Repository, error) {
+	if s.authMethod == "OAUTH" {
+		return git.CloneRepoUsingBearerToken(ctx, s.token, repoURL)
+	}
+	// Any non-empty username is accepted alongside a personal access token.
+	return git.CloneRepoUsingToken(ctx, s.token, repoURL, "pat")
+}
+
+// chunkPullRequests reports the description and comments of every pull
+// request in the repository.
+func (s *Source) chunkPullRequests(ctx context.Context, repoURL string, loc repoLocation, reporter sources.ChunkReporter) error {
+	prs, err := s.client.listPullRequests(ctx, loc.org, loc.project, loc.repo)
+	if err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not list pull requests: %w", err))
+	}
+
+	for _, pr := range prs {
+		link := fmt.Sprintf("%s/pullrequest/%d", repoURL, pr.PullRequestID)
+		data := pr.Title
+		if pr.Description != "" {
+			data += "\n" + pr.Description
+		}
+		if err := reporter.ChunkOk(ctx, s.pullRequestChunk(repoURL, loc, data, pr.CreatedBy.String(), pr.CreationDate, link)); err != nil {
+			return err
+		}
+
+		threads, err := s.client.listThreads(ctx, loc.org, loc.project, loc.repo, pr.PullRequestID)
+		if err != nil {
+			if err := reporter.ChunkErr(ctx, fmt.Errorf("could not list threads for pull request %d: %w", pr.PullRequestID, err)); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, t := range threads {
+			for _, c := range t.Comments {
+				// System comments record votes and pushes, not user content.
+				if c.CommentType == "system" || c.Content == "" {
+					continue
+				}
+				commentLink := fmt.Sprintf("%s?discussionId=%d", link, t.ID)
+				chunk := s.pullRequestChunk(repoURL, loc, c.Content, c.Author.String(), c.PublishedDate, commentLink)
+				if err := reporter.ChunkOk(ctx, chunk); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Source) pullRequestChunk(repoURL string, loc repoLocation, data, author, created, link string) sources.Chunk {
+	return sources.Chunk{
+		SourceName: s.name,
+		SourceID:   s.SourceID(),
+		JobID:      s.JobID(),
+		SourceType: s.Type(),
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_AzureRepos{
+				AzureRepos: &source_metadatapb.AzureRepos{
+					Repository:   sanitizer.UTF8(repoURL),
+					Organization: sanitizer.UTF8(loc.org),
+					Project:      sanitizer.UTF8(loc.project),
+					Username:     sanitizer.UTF8(author),
+					Link:         sanitizer.UTF8(link),
+					Timestamp:    sanitizer.UTF8(created),
+					Visibility:   s.visibilityOf(repoURL),
+				},
+			},
+		},
+		Data:   []byte(data),
+		Verify: s.verify,
+	}
+}
+
+// Chunks emits chunks of bytes over a channel.
+func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ ...sources.ChunkingTarget) error {
+	azureReposScanned.WithLabelValues(s.name).Set(0)
+	return sources.ChunkUnits(ctx, s, s.jobPool, &s.Progress, "Repo", chunksChan)
+}
@@ -0,0 +1,205 @@
+package azurerepos
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sourcestest"
+)
+
+// newTestServer serves canned JSON responses keyed by request path. Responses
+// for a path followed by "?continuationToken=next" are served as its second
+// page.
+func newTestServer(t *testing.T, responses map[string]any) *sourcestest.Server {
+	t.Helper()
+	return sourcestest.NewServer(t, responses,
+		sourcestest.WithAuth(func(r *http.Request) bool {
+			_, pass, _ := r.BasicAuth()
+			return pass == "pat-token" && r.URL.Query().Get("api-version") == apiVersion
+		}),
+		sourcestest.WithPages("continuationToken", "next", func(_ string, more bool) http.Header {
+			if !more {
+				return nil
+			}
+			return http.Header{"X-Ms-Continuationtoken": {"next"}}
+		}),
+	)
+}
+
+func initSource(t *testing.T, conn *sourcespb.AzureRepos) *Source {
+	t.Helper()
+	conn.Credential = &sourcespb.AzureRepos_Token{Token: "pat-token"}
+	anyConn, err := anypb.New(conn)
+	require.NoError(t, err)
+
+	s := &Source{}
+	require.NoError(t, s.Init(context.Background(), "test", 0, 0, false, anyConn, 1))
+	return s
+}
+
+func TestEnumerate(t *testing.T) {
+	var srv *sourcestest.Server
+	repo := func(name string, fork bool) map[string]any {
+		return map[string]any{
+			"name":      name,
+			"isFork":    fork,
+			"remoteUrl": srv.URL + "/acme/Platform/_git/" + name,
+			"project":   map[string]any{"name": "Platform", "visibility": "public"},
+		}
+	}
+	// The responses reference the server URL, so fill them in once it is running.
+	responses := map[string]any{}
+	srv = newTestServer(t, responses)
+	responses["/acme/_apis/projects"] = map[string]any{"value": []any{
+		map[string]any{"name": "Platform"},
+		map[string]any{"name": "Sandbox"},
+	}}
+	responses["/acme/Platform/_apis/git/repositories"] = map[string]any{"value": []any{
+		repo("api", false),
+		repo("api-fork", true),
+		repo("legacy", false),
+	}}
+	responses["/acme/Platform/_apis/wiki/wikis"] = map[string]any{"value": []any{
+		map[string]any{"type": "projectWiki", "remoteUrl": srv.URL + "/acme/Platform/_git/Platform.wiki"},
+		map[string]any{"type": "codeWiki", "remoteUrl": srv.URL + "/acme/Platform/_git/api"},
+	}}
+
+	s := initSource(t, &sourcespb.AzureRepos{
+		Endpoint:       srv.URL,
+		Organizations:  []string{"acme"},
+		IgnoreRepos:    []string{"*/legacy"},
+		IgnoreProjects: []string{"Sand*"},
+	})
+	s.WithWikis()
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+	assert.Empty(t, reporter.UnitErrs)
+
+	var ids []string
+	for _, u := range reporter.Units {
+		id, _ := u.SourceUnitID()
+		ids = append(ids, id)
+	}
+	assert.Equal(t, []string{
+		srv.URL + "/acme/Platform/_git/api",
+		srv.URL + "/acme/Platform/_git/Platform.wiki",
+	}, ids)
+	assert.Equal(t, source_metadatapb.Visibility_public, s.visibilityOf(srv.URL+"/acme/Platform/_git/api"))
+}
+
+func TestEnumerate_Errors(t *testing.T) {
+	const projects = "/acme/_apis/projects"
+	repos := func(project, name string) map[string]any {
+		return map[string]any{"value": []any{map[string]any{
+			"name":      name,
+			"remoteUrl": "https://dev.azure.com/acme/" + project + "/_git/" + name,
+		}}}
+	}
+	sourcestest.APIErrorTest{
+		Setup: func(t *testing.T) (*sourcestest.Server, func(t *testing.T) ([]string, []error)) {
+			srv := newTestServer(t, map[string]any{
+				projects:                                map[string]any{"value": []any{map[string]any{"name": "Platform"}}},
+				projects + "?continuationToken=next":    map[string]any{"value": []any{map[string]any{"name": "Tools"}}},
+				"/acme/Platform/_apis/git/repositories": repos("Platform", "api"),
+				"/acme/Tools/_apis/git/repositories":    repos("Tools", "cli"),
+			})
+			s := initSource(t, &sourcespb.AzureRepos{Endpoint: srv.URL, Organizations: []string{"acme"}})
+			return srv, func(t *testing.T) ([]string, []error) {
+				reporter := sourcestest.TestReporter{}
+				require.NoError(t, s.Enumerate(context.Background(), &reporter))
+				var ids []string
+				for _, u := range reporter.Units {
+					id, _ := u.SourceUnitID()
+					ids = append(ids, id)
+				}
+				return ids, reporter.UnitErrs
+			}
+		},
+		First:    projects,
+		LastPage: projects + "?continuationToken=next",
+		Item:     "/acme/Platform/_apis/git/repositories",
+		All: []string{
+			"https://dev.azure.com/acme/Platform/_git/api",
+			"https://dev.azure.com/acme/Tools/_git/cli",
+		},
+		WithoutItem: []string{"https://dev.azure.com/acme/Tools/_git/cli"},
+	}.Run(t)
+}
+
+func TestParseRepoURL(t *testing.T) {
+	s := &Source{endpoint: "https://tfs.example.com/tfs"}
+	tests := []struct {
+		url     string
+		want    repoLocation
+		wantErr bool
+	}{
+		{url: "https://dev.azure.com/acme/Platform/_git/api", want: repoLocation{org: "acme", project: "Platform", repo: "api"}},
+		{url: "https://dev.azure.com/acme/_git/Platform", want: repoLocation{org: "acme", project: "Platform", repo: "Platform"}},
+		{url: "https://tfs.example.com/tfs/Default/My%20Project/_git/svc", want: repoLocation{org: "Default", project: "My Project", repo: "svc"}},
+		{url: "https://dev.azure.com/acme/Platform", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := s.parseRepoURL(tt.url)
+		if tt.wantErr {
+			assert.Error(t, err, tt.url)
+			continue
+		}
+		require.NoError(t, err, tt.url)
+		assert.Equal(t, tt.want, got, tt.url)
+	}
+}
+
+func TestChunkPullRequests(t *testing.T) {
+	srv := newTestServer(t, map[string]any{
+		"/acme/Platform/_apis/git/repositories/api/pullrequests": map[string]any{"value": []any{map[string]any{
+			"pullRequestId": 12,
+			"title":         "Rotate keys",
+			"description":   "old key: abc",
+			"createdBy":     map[string]any{"displayName": "Alice", "uniqueName": "alice@example.com"},
+			"creationDate":  "2024-05-01T10:00:00Z",
+		}}},
+		"/acme/Platform/_apis/git/repositories/api/pullRequests/12/threads": map[string]any{"value": []any{
+			map[string]any{"id": 4, "comments": []any{
+				map[string]any{"id": 1, "content": "password=hunter2", "commentType": "text", "author": map[string]any{"displayName": "Bob"}},
+				map[string]any{"id": 2, "content": "Alice voted 10", "commentType": "system"},
+			}},
+		}},
+	})
+
+	s := initSource(t, &sourcespb.AzureRepos{Endpoint: srv.URL})
+	repoURL := srv.URL + "/acme/Platform/_git/api"
+	loc, err := s.parseRepoURL(repoURL)
+	require.NoError(t, err)
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.chunkPullRequests(context.Background(), repoURL, loc, &reporter))
+	assert.Empty(t, reporter.ChunkErrs)
+	require.Len(t, reporter.Chunks, 2)
+
+	assert.Equal(t, "Rotate keys\nold key: abc", string(reporter.Chunks[0].Data))
+	meta := reporter.Chunks[0].SourceMetadata.GetAzureRepos()
+	assert.Equal(t, "alice@example.com", meta.GetUsername())
+	assert.Equal(t, "Platform", meta.GetProject())
+	assert.Equal(t, "acme", meta.GetOrganization())
+	assert.Equal(t, repoURL+"/pullrequest/12", meta.GetLink())
+
+	assert.Equal(t, "password=hunter2", string(reporter.Chunks[1].Data))
+	assert.Equal(t, repoURL+"/pullrequest/12?discussionId=4", reporter.Chunks[1].SourceMetadata.GetAzureRepos().GetLink())
+}
+
+func TestGenerateLink(t *testing.T) {
+	assert.Equal(t,
+		"https://dev.azure.com/acme/Platform/_git/api/commit/abc/config.yaml?line=3",
+		generateLink("https://dev.azure.com/acme/Platform/_git/api", "abc", "config.yaml", 3))
+	assert.Equal(t,
+		"https://tfs.example.com/tfs/Default/Platform/_git/api/commit/abc/config.yaml?line=3",
+		generateLink("https://tfs.example.com/tfs/Default/Platform/_git/api", "abc", "config.yaml", 3))
+}
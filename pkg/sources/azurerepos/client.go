@@ -0,0 +1,227 @@
+package azurerepos
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+const (
+	apiVersion = "7.1"
+	// profileBaseURL hosts the Azure DevOps Services profile and accounts
+	// APIs, which are used to discover organizations.
+	profileBaseURL = "https://app.vssps.visualstudio.com"
+	pageSize       = 100
+)
+
+// project is an Azure DevOps project.
+type project struct {
+	Name       string `json:"name"`
+	Visibility string `json:"visibility"`
+}
+
+// repository is an Azure DevOps git repository.
+type repository struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	RemoteURL  string  `json:"remoteUrl"`
+	WebURL     string  `json:"webUrl"`
+	IsFork     bool    `json:"isFork"`
+	IsDisabled bool    `json:"isDisabled"`
+	Project    project `json:"project"`
+}
+
+// wiki is an Azure DevOps wiki. Project wikis are backed by their own git
+// repository; code wikis are published from an existing repository.
+type wiki struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	RemoteURL string `json:"remoteUrl"`
+}
+
+type identity struct {
+	DisplayName string `json:"displayName"`
+	UniqueName  string `json:"uniqueName"`
+}
+
+// String prefers the unique name, which is usually an email address.
+func (i identity) String() string {
+	if i.UniqueName != "" {
+		return i.UniqueName
+	}
+	return i.DisplayName
+}
+
+// pullRequest is an Azure DevOps pull request.
+type pullRequest struct {
+	PullRequestID int      `json:"pullRequestId"`
+	Title         string   `json:"title"`
+	Description   string   `json:"description"`
+	CreatedBy     identity `json:"createdBy"`
+	CreationDate  string   `json:"creationDate"`
+}
+
+// thread is a pull request comment thread.
+type thread struct {
+	ID       int `json:"id"`
+	Comments []struct {
+		ID            int      `json:"id"`
+		Content       string   `json:"content"`
+		Author        identity `json:"author"`
+		PublishedDate string   `json:"publishedDate"`
+		CommentType   string   `json:"commentType"`
+	} `json:"comments"`
+}
+
+type listResponse[T any] struct {
+	Value []T `json:"value"`
+}
+
+// client is a minimal Azure DevOps REST API client.
+type client struct {
+	httpClient *http.Client
+	endpoint   string
+	profileURL string
+	token      string
+	oauth      bool
+}
+
+func (c *client) get(ctx context.Context, reqURL string, target any) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Azure DevOps API request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.oauth {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	} else {
+		// Personal access tokens are sent as the password of basic auth
+		// with an empty username.
+		req.SetBasicAuth("", c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request to Azure DevOps API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Azure DevOps answers unauthenticated API calls with a sign-in page.
+	if resp.StatusCode == http.StatusNonAuthoritativeInfo || resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("invalid or expired credentials (status %d)", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("unexpected status code %d from %q: %s", resp.StatusCode, req.URL.Path, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return nil, fmt.Errorf("failed to decode Azure DevOps API response: %w", err)
+	}
+	return resp.Header, nil
+}
+
+func (c *client) apiURL(org, project, path string, query url.Values) string {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("api-version", apiVersion)
+	parts := []string{c.endpoint, url.PathEscape(org)}
+	if project != "" {
+		parts = append(parts, url.PathEscape(project))
+	}
+	return strings.Join(parts, "/") + "/_apis/" + path + "?" + query.Encode()
+}
+
+// listOrganizations lists the organizations the authenticated user is a
+// member of. It is only supported by Azure DevOps Services.
+func (c *client) listOrganizations(ctx context.Context) ([]string, error) {
+	var profile struct {
+		ID string `json:"id"`
+	}
+	if _, err := c.get(ctx, c.profileURL+"/_apis/profile/profiles/me?api-version="+apiVersion, &profile); err != nil {
+		return nil, fmt.Errorf("could not get user profile: %w", err)
+	}
+
+	var accounts listResponse[struct {
+		AccountName string `json:"accountName"`
+	}]
+	reqURL := fmt.Sprintf("%s/_apis/accounts?memberId=%s&api-version=%s", c.profileURL, url.QueryEscape(profile.ID), apiVersion)
+	if _, err := c.get(ctx, reqURL, &accounts); err != nil {
+		return nil, fmt.Errorf("could not list organizations: %w", err)
+	}
+	orgs := make([]string, 0, len(accounts.Value))
+	for _, a := range accounts.Value {
+		orgs = append(orgs, a.AccountName)
+	}
+	return orgs, nil
+}
+
+// listProjects lists all projects in the organization, following continuation tokens.
+func (c *client) listProjects(ctx context.Context, org string) ([]project, error) {
+	var projects []project
+	query := url.Values{"$top": {fmt.Sprint(pageSize)}}
+	for {
+		var page listResponse[project]
+		header, err := c.get(ctx, c.apiURL(org, "", "projects", query), &page)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, page.Value...)
+		token := header.Get("X-Ms-Continuationtoken")
+		if token == "" {
+			return projects, nil
+		}
+		query.Set("continuationToken", token)
+	}
+}
+
+func (c *client) listRepos(ctx context.Context, org, project string) ([]repository, error) {
+	var repos listResponse[repository]
+	if _, err := c.get(ctx, c.apiURL(org, project, "git/repositories", nil), &repos); err != nil {
+		return nil, err
+	}
+	return repos.Value, nil
+}
+
+func (c *client) listWikis(ctx context.Context, org, project string) ([]wiki, error) {
+	var wikis listResponse[wiki]
+	if _, err := c.get(ctx, c.apiURL(org, project, "wiki/wikis", nil), &wikis); err != nil {
+		return nil, err
+	}
+	return wikis.Value, nil
+}
+
+// listPullRequests lists pull requests of every status, using $skip pagination.
+func (c *client) listPullRequests(ctx context.Context, org, project, repo string) ([]pullRequest, error) {
+	var prs []pullRequest
+	for skip := 0; ; skip += pageSize {
+		query := url.Values{
+			"searchCriteria.status": {"all"},
+			"$top":                  {fmt.Sprint(pageSize)},
+			"$skip":                 {fmt.Sprint(skip)},
+		}
+		var page listResponse[pullRequest]
+		path := fmt.Sprintf("git/repositories/%s/pullrequests", url.PathEscape(repo))
+		if _, err := c.get(ctx, c.apiURL(org, project, path, query), &page); err != nil {
+			return nil, err
+		}
+		prs = append(prs, page.Value...)
+		if len(page.Value) < pageSize {
+			return prs, nil
+		}
+	}
+}
+
+func (c *client) listThreads(ctx context.Context, org, project, repo string, prID int) ([]thread, error) {
+	var threads listResponse[thread]
+	path := fmt.Sprintf("git/repositories/%s/pullRequests/%d/threads", url.PathEscape(repo), prID)
+	if _, err := c.get(ctx, c.apiURL(org, project, path, nil), &threads); err != nil {
+		return nil, err
+	}
+	return threads.Value, nil
+}
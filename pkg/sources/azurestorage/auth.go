@@ -0,0 +1,319 @@
+package azurestorage
+
+import (
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+const (
+	// Azurite, the storage emulator, uses this well-known account and key.
+	devStoreAccount = "devstoreaccount1"
+	devStoreKey     = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+	devStoreURL     = "http://127.0.0.1:10000/" + devStoreAccount
+
+	defaultAuthorityHost = "https://login.microsoftonline.com"
+	storageScope         = "https://storage.azure.com/.default"
+)
+
+// connectionString holds the parts of a storage account connection string
+// that are needed to reach the blob service.
+type connectionString struct {
+	account    string
+	accountKey string
+	sas        string
+	serviceURL string
+}
+
+// parseConnectionString parses a connection string such as
+// "DefaultEndpointsProtocol=https;AccountName=acme;AccountKey=...;EndpointSuffix=core.windows.net".
+func parseConnectionString(s string) (connectionString, error) {
+	fields := make(map[string]string)
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		fields[strings.ToLower(key)] = value
+	}
+
+	if strings.EqualFold(fields["usedevelopmentstorage"], "true") {
+		return connectionString{account: devStoreAccount, accountKey: devStoreKey, serviceURL: devStoreURL}, nil
+	}
+
+	cs := connectionString{
+		account:    fields["accountname"],
+		accountKey: fields["accountkey"],
+		sas:        strings.TrimPrefix(fields["sharedaccesssignature"], "?"),
+		serviceURL: strings.TrimSuffix(fields["blobendpoint"], "/"),
+	}
+	if cs.serviceURL == "" {
+		if cs.account == "" {
+			return connectionString{}, errors.New("connection string has neither AccountName nor BlobEndpoint")
+		}
+		protocol, suffix := fields["defaultendpointsprotocol"], fields["endpointsuffix"]
+		if protocol == "" {
+			protocol = "https"
+		}
+		if suffix == "" {
+			suffix = "core.windows.net"
+		}
+		cs.serviceURL = fmt.Sprintf("%s://%s.blob.%s", protocol, cs.account, suffix)
+	}
+	if cs.accountKey != "" && cs.account == "" {
+		return connectionString{}, errors.New("connection string has an AccountKey but no AccountName")
+	}
+	return cs, nil
+}
+
+// sasAuthorizer adds a shared access signature to the query of requests.
+func sasAuthorizer(sas string) func(context.Context, *http.Request) error {
+	return func(_ context.Context, req *http.Request) error {
+		if req.URL.RawQuery == "" {
+			req.URL.RawQuery = sas
+		} else {
+			req.URL.RawQuery += "&" + sas
+		}
+		return nil
+	}
+}
+
+// sharedKeyAuthorizer signs requests with the account key.
+func sharedKeyAuthorizer(account, accountKey string) (func(context.Context, *http.Request) error, error) {
+	key, err := base64.StdEncoding.DecodeString(accountKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid account key: %w", err)
+	}
+	return func(_ context.Context, req *http.Request) error {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(stringToSign(account, req)))
+		signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+		req.Header.Set("Authorization", "SharedKey "+account+":"+signature)
+		return nil
+	}, nil
+}
+
+// stringToSign returns the string that is signed for Shared Key
+// authorization of the blob service.
+func stringToSign(account string, req *http.Request) string {
+	contentLength := req.Header.Get("Content-Length")
+	if contentLength == "0" {
+		contentLength = ""
+	}
+	lines := []string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		req.Header.Get("Date"),
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+	}
+	return strings.Join(lines, "\n") + "\n" + canonicalizedHeaders(req.Header) + canonicalizedResource(account, req.URL)
+}
+
+func canonicalizedHeaders(headers http.Header) string {
+	var names []string
+	for name := range headers {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-ms-") {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool { return strings.ToLower(names[i]) < strings.ToLower(names[j]) })
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(strings.ToLower(name) + ":" + strings.Join(headers[name], ",") + "\n")
+	}
+	return b.String()
+}
+
+func canonicalizedResource(account string, u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	resource := "/" + account + path
+
+	params := make(map[string][]string)
+	var keys []string
+	for key, values := range u.Query() {
+		lower := strings.ToLower(key)
+		if _, ok := params[lower]; !ok {
+			keys = append(keys, lower)
+		}
+		params[lower] = append(params[lower], values...)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		values := params[key]
+		sort.Strings(values)
+		resource += "\n" + key + ":" + strings.Join(values, ",")
+	}
+	return resource
+}
+
+// certificateCredential gets Microsoft Entra ID tokens for a service
+// principal that authenticates with a certificate.
+type certificateCredential struct {
+	httpClient    *http.Client
+	authorityHost string
+	tenantID      string
+	clientID      string
+	cert          *x509.Certificate
+	key           *rsa.PrivateKey
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// loadCertificate reads a PEM file holding a certificate and its RSA private
+// key, the only key type Entra ID accepts for certificate credentials.
+func loadCertificate(path string) (*x509.Certificate, *rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not read client certificate: %w", err)
+	}
+
+	var (
+		cert *x509.Certificate
+		key  *rsa.PrivateKey
+	)
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		switch block.Type {
+		case "CERTIFICATE":
+			if cert == nil {
+				if cert, err = x509.ParseCertificate(block.Bytes); err != nil {
+					return nil, nil, fmt.Errorf("could not parse client certificate: %w", err)
+				}
+			}
+		case "RSA PRIVATE KEY":
+			if key, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+				return nil, nil, fmt.Errorf("could not parse private key: %w", err)
+			}
+		case "PRIVATE KEY":
+			parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, nil, fmt.Errorf("could not parse private key: %w", err)
+			}
+			rsaKey, ok := parsed.(*rsa.PrivateKey)
+			if !ok {
+				return nil, nil, fmt.Errorf("unsupported private key type %T", parsed)
+			}
+			key = rsaKey
+		}
+	}
+	if cert == nil || key == nil {
+		return nil, nil, errors.New("client certificate file must hold a PEM certificate and private key")
+	}
+	return cert, key, nil
+}
+
+func (c *certificateCredential) authorize(ctx context.Context, req *http.Request) error {
+	token, err := c.getToken(ctx)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// getToken returns a cached token, or requests a new one with a client
+// assertion signed by the certificate when it is about to expire.
+func (c *certificateCredential) getToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Until(c.expiry) > 5*time.Minute {
+		return c.token, nil
+	}
+	if c.tenantID == "" || c.clientID == "" {
+		return "", errors.New("a tenant ID and client ID are required for client certificate authentication")
+	}
+
+	tokenURL := c.authorityHost + "/" + url.PathEscape(c.tenantID) + "/oauth2/v2.0/token"
+	assertion, err := c.assertion(tokenURL)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type":            {"client_credentials"},
+		"client_id":             {c.clientID},
+		"scope":                 {storageScope},
+		"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+		"client_assertion":      {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("unexpected status code %d from %q: %s", resp.StatusCode, req.URL.Path, strings.TrimSpace(string(body)))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode token response: %w", err)
+	}
+	c.token = token.AccessToken
+	c.expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	return c.token, nil
+}
+
+// assertion returns a JWT signed by the certificate key. The x5t header is
+// the SHA-1 thumbprint of the certificate, as required by Entra ID.
+func (c *certificateCredential) assertion(tokenURL string) (string, error) {
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.RegisteredClaims{
+		Audience:  jwt.ClaimStrings{tokenURL},
+		Issuer:    c.clientID,
+		Subject:   c.clientID,
+		ID:        uuid.NewString(),
+		NotBefore: jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(10 * time.Minute)),
+	})
+	thumbprint := sha1.Sum(c.cert.Raw)
+	token.Header["x5t"] = base64.RawURLEncoding.EncodeToString(thumbprint[:])
+	signed, err := token.SignedString(c.key)
+	if err != nil {
+		return "", fmt.Errorf("could not sign client assertion: %w", err)
+	}
+	return signed, nil
+}
@@ -0,0 +1,340 @@
+package azurestorage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common/glob"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/log"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+const SourceType = sourcespb.SourceType_SOURCE_TYPE_AZURE_STORAGE
+
+// Source scans the blobs of an Azure Storage account, and optionally their
+// snapshots and previous versions.
+//
+// Containers are scanned one at a time in name order, and each page of
+// blobs concurrently. The marker of the next page is saved as resume info
+// once a page is done, so an interrupted scan resumes at that page.
+//
+// The connection proto only holds the credential and the containers. The
+// endpoint, filters and service principal are set with the With* methods
+// after Init.
+type Source struct {
+	name        string
+	sourceID    sources.SourceID
+	jobID       sources.JobID
+	verify      bool
+	concurrency int
+
+	client *client
+	// certificate is set for client certificate authentication.
+	certificate     *certificateCredential
+	containers      []string
+	ignoreContainer func(name string) bool
+	prefix          string
+	ignoreBlob      func(name string) bool
+	maxObjectSize   int64
+	snapshots       bool
+	versions        bool
+
+	sources.Progress
+}
+
+// Ensure the Source satisfies the interface at compile time.
+var _ sources.Source = (*Source)(nil)
+
+// resumeInfo is the position of an interrupted scan: the container and the
+// marker of the first page that was not completely scanned.
+type resumeInfo struct {
+	Container string `json:"container"`
+	Marker    string `json:"marker,omitempty"`
+}
+
+// Type returns the type of source.
+// It is used for matching source types in configuration and job input.
+func (s *Source) Type() sourcespb.SourceType {
+	return SourceType
+}
+
+func (s *Source) SourceID() sources.SourceID {
+	return s.sourceID
+}
+
+func (s *Source) JobID() sources.JobID {
+	return s.jobID
+}
+
+// Init returns an initialized Azure Storage source.
+func (s *Source) Init(_ context.Context, name string, jobId sources.JobID, sourceId sources.SourceID, verify bool, connection *anypb.Any, concurrency int) error {
+	s.name = name
+	s.sourceID = sourceId
+	s.jobID = jobId
+	s.verify = verify
+	s.concurrency = concurrency
+	if s.concurrency < 1 {
+		s.concurrency = 1
+	}
+
+	var conn sourcespb.AzureStorage
+	if err := anypb.UnmarshalTo(connection, &conn, proto.UnmarshalOptions{}); err != nil {
+		return fmt.Errorf("error unmarshalling connection: %w", err)
+	}
+	s.containers = conn.GetStorageContainers()
+	s.client = &client{httpClient: common.RetryableHTTPClientTimeout(300)}
+
+	switch cred := conn.GetCredential().(type) {
+	case *sourcespb.AzureStorage_ConnectionString:
+		cs, err := parseConnectionString(cred.ConnectionString)
+		if err != nil {
+			return fmt.Errorf("invalid connection string: %w", err)
+		}
+		s.client.serviceURL = cs.serviceURL
+		switch {
+		case cs.accountKey != "":
+			log.RedactGlobally(cs.accountKey)
+			if s.client.authorize, err = sharedKeyAuthorizer(cs.account, cs.accountKey); err != nil {
+				return err
+			}
+		case cs.sas != "":
+			log.RedactGlobally(cs.sas)
+			s.client.authorize = sasAuthorizer(cs.sas)
+		}
+	case *sourcespb.AzureStorage_BasicAuth:
+		account, key := cred.BasicAuth.GetUsername(), cred.BasicAuth.GetPassword()
+		log.RedactGlobally(key)
+		authorize, err := sharedKeyAuthorizer(account, key)
+		if err != nil {
+			return err
+		}
+		s.client.serviceURL = fmt.Sprintf("https://%s.blob.core.windows.net", account)
+		s.client.authorize = authorize
+	case *sourcespb.AzureStorage_ClientCertificate:
+		cert, key, err := loadCertificate(cred.ClientCertificate)
+		if err != nil {
+			return err
+		}
+		s.certificate = &certificateCredential{
+			httpClient:    common.RetryableHTTPClientTimeout(60),
+			authorityHost: defaultAuthorityHost,
+			cert:          cert,
+			key:           key,
+		}
+		s.client.authorize = s.certificate.authorize
+	case *sourcespb.AzureStorage_Unauthenticated:
+	default:
+		return fmt.Errorf("invalid configuration given for source %q (%s)", name, s.Type().String())
+	}
+
+	s.ignoreContainer = func(string) bool { return false }
+	s.ignoreBlob = func(string) bool { return false }
+	return nil
+}
+
+// WithEndpoint sets the blob endpoint of the storage account, such as
+// https://acme.blob.core.windows.net or http://127.0.0.1:10000/devstoreaccount1
+// for Azurite. It is required for client certificate and anonymous access.
+func (s *Source) WithEndpoint(endpoint string) {
+	if endpoint != "" {
+		s.client.serviceURL = strings.TrimSuffix(endpoint, "/")
+	}
+}
+
+// WithServicePrincipal sets the tenant and the application (client) ID used
+// with client certificate authentication.
+func (s *Source) WithServicePrincipal(tenantID, clientID string) {
+	if s.certificate != nil {
+		s.certificate.tenantID = tenantID
+		s.certificate.clientID = clientID
+	}
+}
+
+// WithPrefix limits the scan to blobs whose name starts with prefix.
+func (s *Source) WithPrefix(prefix string) {
+	s.prefix = prefix
+}
+
+// WithIgnoreContainers skips containers matching any of the globs.
+func (s *Source) WithIgnoreContainers(globs ...string) error {
+	var globErr error
+	s.ignoreContainer = glob.BuildIgnorer(nil, globs, func(err error, pattern string) {
+		globErr = errors.Join(globErr, fmt.Errorf("invalid glob %q: %w", pattern, err))
+	}, glob.WithSeparators('/'))
+	return globErr
+}
+
+// WithBlobGlobs limits the scan to blobs matching an include glob, if any,
+// and no exclude glob. In globs, "*" does not match "/" and "**" does.
+func (s *Source) WithBlobGlobs(include, exclude []string) error {
+	var globErr error
+	s.ignoreBlob = glob.BuildIgnorer(include, exclude, func(err error, pattern string) {
+		globErr = errors.Join(globErr, fmt.Errorf("invalid glob %q: %w", pattern, err))
+	}, glob.WithSeparators('/'))
+	return globErr
+}
+
+// WithMaxObjectSize skips blobs larger than size bytes. Zero means no limit.
+func (s *Source) WithMaxObjectSize(size int64) {
+	s.maxObjectSize = size
+}
+
+// WithSnapshots also scans the snapshots of blobs.
+func (s *Source) WithSnapshots(snapshots bool) {
+	s.snapshots = snapshots
+}
+
+// WithVersions also scans the previous versions of blobs, for accounts with
+// blob versioning enabled.
+func (s *Source) WithVersions(versions bool) {
+	s.versions = versions
+}
+
+// Chunks emits chunks of bytes over a channel.
+func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ ...sources.ChunkingTarget) error {
+	if s.client.serviceURL == "" {
+		return errors.New("an Azure Storage endpoint is required")
+	}
+
+	containers := s.containers
+	if len(containers) == 0 {
+		var err error
+		if containers, err = s.client.listContainers(ctx); err != nil {
+			return fmt.Errorf("could not list containers: %w", err)
+		}
+	}
+	var selected []string
+	for _, c := range containers {
+		if !s.ignoreContainer(c) {
+			selected = append(selected, c)
+		}
+	}
+	sort.Strings(selected)
+
+	var resume resumeInfo
+	if s.Progress.EncodedResumeInfo != "" {
+		if err := json.Unmarshal([]byte(s.Progress.EncodedResumeInfo), &resume); err != nil {
+			ctx.Logger().Error(err, "could not decode resume info, scanning from the start")
+			resume = resumeInfo{}
+		}
+	}
+
+	azureContainersScanned.WithLabelValues(s.name).Set(0)
+	reporter := sources.ChanReporter{Ch: chunksChan}
+	for i, c := range selected {
+		if c < resume.Container {
+			continue
+		}
+		marker := ""
+		if c == resume.Container {
+			marker = resume.Marker
+		}
+		checkpoint := func(marker string) {
+			info, _ := json.Marshal(resumeInfo{Container: c, Marker: marker})
+			s.SetProgressComplete(i, len(selected), fmt.Sprintf("Container: %s", c), string(info))
+		}
+		checkpoint(marker)
+
+		if err := s.scanContainer(ctx, c, marker, checkpoint, reporter); err != nil {
+			return err
+		}
+		azureContainersScanned.WithLabelValues(s.name).Inc()
+	}
+	s.SetProgressComplete(len(selected), len(selected), "Completed Azure Storage scan", "")
+
+	return nil
+}
+
+// scanContainer scans the blobs of a container page by page, starting at
+// marker, and calls checkpoint with the marker of each following page.
+// Only errors returned by the reporter are returned.
+func (s *Source) scanContainer(ctx context.Context, container, marker string, checkpoint func(marker string), reporter sources.ChunkReporter) error {
+	ctx = context.WithValue(ctx, "container", container)
+	for {
+		page, err := s.client.listBlobs(ctx, container, s.prefix, marker, s.snapshots, s.versions)
+		if err != nil {
+			return reporter.ChunkErr(ctx, fmt.Errorf("could not list blobs of %s: %w", container, err))
+		}
+
+		workers := &errgroup.Group{}
+		workers.SetLimit(s.concurrency)
+		for _, b := range page.Blobs {
+			if s.skip(b) {
+				continue
+			}
+			workers.Go(func() error {
+				if common.IsDone(ctx) {
+					return ctx.Err()
+				}
+				return s.chunkBlob(ctx, container, b, reporter)
+			})
+		}
+		if err := workers.Wait(); err != nil {
+			return err
+		}
+
+		if page.NextMarker == "" {
+			return nil
+		}
+		marker = page.NextMarker
+		checkpoint(marker)
+	}
+}
+
+// skip reports whether a blob is filtered out by name or size.
+func (s *Source) skip(b blob) bool {
+	if s.ignoreBlob(b.Name) {
+		return true
+	}
+	return s.maxObjectSize > 0 && b.Properties.ContentLength > s.maxObjectSize
+}
+
+func (s *Source) chunkBlob(ctx context.Context, container string, b blob, reporter sources.ChunkReporter) error {
+	body, err := s.client.download(ctx, container, b)
+	if err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not download %s: %w", b.Name, err))
+	}
+	defer body.Close()
+
+	uploaded := b.Properties.LastModified
+	if t, err := time.Parse(http.TimeFormat, uploaded); err == nil {
+		uploaded = t.Format(time.RFC3339)
+	}
+	chunkSkel := &sources.Chunk{
+		SourceName: s.name,
+		SourceID:   s.SourceID(),
+		JobID:      s.JobID(),
+		SourceType: s.Type(),
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Azure{
+				Azure: &source_metadatapb.Azure{
+					Container: sanitizer.UTF8(container),
+					File:      sanitizer.UTF8(b.Name),
+					Uploaded:  sanitizer.UTF8(uploaded),
+					Link:      sanitizer.UTF8(s.client.blobURL(container, b)),
+				},
+			},
+		},
+		Verify: s.verify,
+	}
+	if err := handlers.HandleFile(ctx, body, chunkSkel, reporter); err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not scan %s: %w", b.Name, err))
+	}
+	azureBlobsScanned.WithLabelValues(s.name).Inc()
+	return nil
+}
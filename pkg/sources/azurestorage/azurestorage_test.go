@@ -0,0 +1,254 @@
+package azurestorage
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+func TestParseConnectionString(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want connectionString
+	}{
+		{
+			name: "account key",
+			in:   "DefaultEndpointsProtocol=https;AccountName=acme;AccountKey=a2V5;EndpointSuffix=core.windows.net",
+			want: connectionString{account: "acme", accountKey: "a2V5", serviceURL: "https://acme.blob.core.windows.net"},
+		},
+		{
+			name: "sas and blob endpoint",
+			in:   "BlobEndpoint=https://acme.blob.core.windows.net/;SharedAccessSignature=sv=2021-08-06&sig=abc%3D",
+			want: connectionString{sas: "sv=2021-08-06&sig=abc%3D", serviceURL: "https://acme.blob.core.windows.net"},
+		},
+		{
+			name: "azurite",
+			in:   "UseDevelopmentStorage=true",
+			want: connectionString{account: devStoreAccount, accountKey: devStoreKey, serviceURL: devStoreURL},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseConnectionString(tt.in)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := parseConnectionString("AccountKey=a2V5")
+	assert.Error(t, err)
+}
+
+// newTestServer serves an Azurite-like account with the "backups" container
+// split over two pages, and the "logs" container. Every request must carry
+// authorization.
+func newTestServer(t *testing.T, authorized func(*http.Request) bool) *httptest.Server {
+	t.Helper()
+	blobs := map[string]string{
+		"/devstoreaccount1/backups/db/prod.env":                     "DB_PASSWORD=from-page-one",
+		"/devstoreaccount1/backups/db/prod.env?snapshot=2024-01-01": "DB_PASSWORD=from-snapshot",
+		"/devstoreaccount1/backups/keys/id_rsa.txt":                 "token=from-page-two",
+		"/devstoreaccount1/logs/app.log":                            "api_key=from-logs",
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		q := r.URL.Query()
+		key := r.URL.Path
+		if snapshot := q.Get("snapshot"); snapshot != "" {
+			key += "?snapshot=" + snapshot
+		}
+		if data, ok := blobs[key]; ok && q.Get("comp") == "" {
+			_, _ = w.Write([]byte(data))
+			return
+		}
+
+		var resp string
+		switch r.URL.Path {
+		case "/devstoreaccount1/":
+			resp = `<EnumerationResults><Containers>
+				<Container><Name>logs</Name></Container>
+				<Container><Name>backups</Name></Container>
+				<Container><Name>tmp</Name></Container>
+			</Containers><NextMarker/></EnumerationResults>`
+		case "/devstoreaccount1/backups":
+			if q.Get("marker") == "" {
+				snapshot := ""
+				if strings.Contains(q.Get("include"), "snapshots") {
+					snapshot = `<Blob><Name>db/prod.env</Name><Snapshot>2024-01-01</Snapshot><Properties><Content-Length>26</Content-Length></Properties></Blob>`
+				}
+				resp = `<EnumerationResults><Blobs>
+					<Blob><Name>db/prod.env</Name><Properties><Last-Modified>Mon, 01 Jan 2024 10:00:00 GMT</Last-Modified><Content-Length>26</Content-Length></Properties></Blob>` + snapshot + `
+					<Blob><Name>db/huge.bak</Name><Properties><Content-Length>104857600</Content-Length></Properties></Blob>
+				</Blobs><NextMarker>page2</NextMarker></EnumerationResults>`
+			} else {
+				resp = `<EnumerationResults><Blobs>
+					<Blob><Name>keys/id_rsa.txt</Name><Properties><Content-Length>19</Content-Length></Properties></Blob>
+				</Blobs><NextMarker/></EnumerationResults>`
+			}
+		case "/devstoreaccount1/logs":
+			resp = `<EnumerationResults><Blobs>
+				<Blob><Name>app.log</Name><Properties><Content-Length>17</Content-Length></Properties></Blob>
+			</Blobs><NextMarker/></EnumerationResults>`
+		}
+		if resp == "" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(resp))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestSource(t *testing.T, conn *sourcespb.AzureStorage) *Source {
+	t.Helper()
+	anyConn, err := anypb.New(conn)
+	require.NoError(t, err)
+	s := &Source{}
+	require.NoError(t, s.Init(context.Background(), "test", 0, 0, false, anyConn, 2))
+	return s
+}
+
+func collect(t *testing.T, s *Source) map[string]string {
+	t.Helper()
+	chunksCh := make(chan *sources.Chunk, 16)
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(chunksCh)
+		errCh <- s.Chunks(context.Background(), chunksCh)
+	}()
+	links := make(map[string]string)
+	for chunk := range chunksCh {
+		links[chunk.SourceMetadata.GetAzure().GetLink()] = string(chunk.Data)
+	}
+	require.NoError(t, <-errCh)
+	return links
+}
+
+func TestChunks(t *testing.T) {
+	srv := newTestServer(t, func(r *http.Request) bool {
+		return strings.HasPrefix(r.Header.Get("Authorization"), "SharedKey devstoreaccount1:")
+	})
+	s := newTestSource(t, &sourcespb.AzureStorage{
+		Credential: &sourcespb.AzureStorage_ConnectionString{
+			ConnectionString: "AccountName=devstoreaccount1;AccountKey=" + devStoreKey + ";BlobEndpoint=" + srv.URL + "/devstoreaccount1;",
+		},
+	})
+	require.NoError(t, s.WithIgnoreContainers("tmp"))
+	s.WithMaxObjectSize(1 << 20)
+	s.WithSnapshots(true)
+
+	base := srv.URL + "/devstoreaccount1/"
+	assert.Equal(t, map[string]string{
+		base + "backups/db/prod.env":                     "DB_PASSWORD=from-page-one",
+		base + "backups/db/prod.env?snapshot=2024-01-01": "DB_PASSWORD=from-snapshot",
+		base + "backups/keys/id_rsa.txt":                 "token=from-page-two",
+		base + "logs/app.log":                            "api_key=from-logs",
+	}, collect(t, s))
+	assert.Equal(t, "", s.GetProgress().EncodedResumeInfo)
+	assert.Equal(t, int64(100), s.GetProgress().PercentComplete)
+}
+
+func TestChunks_Resume(t *testing.T) {
+	srv := newTestServer(t, func(r *http.Request) bool {
+		return r.URL.Query().Get("sig") == "abc"
+	})
+	s := newTestSource(t, &sourcespb.AzureStorage{
+		Credential: &sourcespb.AzureStorage_ConnectionString{
+			ConnectionString: "BlobEndpoint=" + srv.URL + "/devstoreaccount1;SharedAccessSignature=sv=2021-08-06&sig=abc",
+		},
+		StorageContainers: []string{"logs", "backups"},
+	})
+	require.NoError(t, s.WithBlobGlobs(nil, []string{"**.log"}))
+	s.Progress.EncodedResumeInfo = `{"container":"backups","marker":"page2"}`
+
+	links := collect(t, s)
+	keys := make([]string, 0, len(links))
+	for link := range links {
+		keys = append(keys, strings.TrimPrefix(link, srv.URL))
+	}
+	sort.Strings(keys)
+	assert.Equal(t, []string{"/devstoreaccount1/backups/keys/id_rsa.txt"}, keys)
+}
+
+func TestClientCertificate(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "trufflehog-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	certPath := filepath.Join(t.TempDir(), "client.pem")
+	pemData := append(
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})...,
+	)
+	require.NoError(t, os.WriteFile(certPath, pemData, 0o600))
+
+	authority := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assertion, err := jwt.Parse(r.PostForm.Get("client_assertion"), func(*jwt.Token) (any, error) {
+			return &key.PublicKey, nil
+		})
+		if err != nil || r.URL.Path != "/tenant/oauth2/v2.0/token" || r.PostForm.Get("client_id") != "app" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		sub, _ := assertion.Claims.GetSubject()
+		assert.Equal(t, "app", sub)
+		_, _ = w.Write([]byte(`{"access_token": "entra-token", "expires_in": 3600}`))
+	}))
+	t.Cleanup(authority.Close)
+
+	srv := newTestServer(t, func(r *http.Request) bool {
+		return r.Header.Get("Authorization") == "Bearer entra-token"
+	})
+	s := newTestSource(t, &sourcespb.AzureStorage{
+		Credential:        &sourcespb.AzureStorage_ClientCertificate{ClientCertificate: certPath},
+		StorageContainers: []string{"logs"},
+	})
+	s.certificate.authorityHost = authority.URL
+	s.WithServicePrincipal("tenant", "app")
+	s.WithEndpoint(srv.URL + "/devstoreaccount1/")
+
+	assert.Equal(t, map[string]string{
+		srv.URL + "/devstoreaccount1/logs/app.log": "api_key=from-logs",
+	}, collect(t, s))
+}
+
+func TestInit_Unauthenticated(t *testing.T) {
+	s := newTestSource(t, &sourcespb.AzureStorage{
+		Credential: &sourcespb.AzureStorage_Unauthenticated{Unauthenticated: &credentialspb.Unauthenticated{}},
+	})
+	err := s.Chunks(context.Background(), make(chan *sources.Chunk))
+	assert.ErrorContains(t, err, "endpoint is required")
+}
@@ -0,0 +1,179 @@
+package azurestorage
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+// apiVersion is the Blob service REST API version. Listing blob versions
+// requires at least 2019-12-12.
+const apiVersion = "2021-08-06"
+
+type container struct {
+	Name string `xml:"Name"`
+}
+
+type containerList struct {
+	Containers []container `xml:"Containers>Container"`
+	NextMarker string      `xml:"NextMarker"`
+}
+
+// blob is an entry of a blob listing. Snapshot and VersionID are only set
+// when snapshots and versions are listed.
+type blob struct {
+	Name             string `xml:"Name"`
+	Snapshot         string `xml:"Snapshot"`
+	VersionID        string `xml:"VersionId"`
+	IsCurrentVersion bool   `xml:"IsCurrentVersion"`
+	Properties       struct {
+		LastModified  string `xml:"Last-Modified"`
+		ContentLength int64  `xml:"Content-Length"`
+		ContentType   string `xml:"Content-Type"`
+	} `xml:"Properties"`
+}
+
+// query returns the query that addresses this snapshot or version of the blob.
+func (b blob) query() string {
+	switch {
+	case b.Snapshot != "":
+		return "snapshot=" + url.QueryEscape(b.Snapshot)
+	case b.VersionID != "" && !b.IsCurrentVersion:
+		return "versionid=" + url.QueryEscape(b.VersionID)
+	default:
+		return ""
+	}
+}
+
+type blobPage struct {
+	Blobs      []blob `xml:"Blobs>Blob"`
+	NextMarker string `xml:"NextMarker"`
+}
+
+// client is a minimal Blob service REST API client.
+type client struct {
+	httpClient *http.Client
+	// serviceURL is the blob endpoint of the account, without a trailing
+	// slash, such as https://acme.blob.core.windows.net or, for Azurite,
+	// http://127.0.0.1:10000/devstoreaccount1.
+	serviceURL string
+	// authorize sets the credentials on a request.
+	authorize func(context.Context, *http.Request) error
+}
+
+func (c *client) do(ctx context.Context, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Azure Storage request: %w", err)
+	}
+	req.Header.Set("x-ms-version", apiVersion)
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	if c.authorize != nil {
+		if err := c.authorize(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request to Azure Storage: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("unexpected status code %d from %q: %s", resp.StatusCode, req.URL.Path, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+func (c *client) get(ctx context.Context, rawURL string, target any) error {
+	resp, err := c.do(ctx, rawURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := xml.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode Azure Storage response: %w", err)
+	}
+	return nil
+}
+
+// listContainers lists every container of the account.
+func (c *client) listContainers(ctx context.Context) ([]string, error) {
+	var names []string
+	query := url.Values{"comp": {"list"}}
+	for {
+		var page containerList
+		if err := c.get(ctx, c.serviceURL+"/?"+query.Encode(), &page); err != nil {
+			return nil, err
+		}
+		for _, ct := range page.Containers {
+			names = append(names, ct.Name)
+		}
+		if page.NextMarker == "" {
+			return names, nil
+		}
+		query.Set("marker", page.NextMarker)
+	}
+}
+
+// listBlobs returns a page of the blobs of a container, starting at marker.
+// The returned page holds the marker of the next page, empty on the last one.
+func (c *client) listBlobs(ctx context.Context, containerName, prefix, marker string, snapshots, versions bool) (blobPage, error) {
+	query := url.Values{"restype": {"container"}, "comp": {"list"}}
+	if prefix != "" {
+		query.Set("prefix", prefix)
+	}
+	if marker != "" {
+		query.Set("marker", marker)
+	}
+	var include []string
+	if snapshots {
+		include = append(include, "snapshots")
+	}
+	if versions {
+		include = append(include, "versions")
+	}
+	if len(include) > 0 {
+		query.Set("include", strings.Join(include, ","))
+	}
+
+	var page blobPage
+	err := c.get(ctx, c.containerURL(containerName)+"?"+query.Encode(), &page)
+	return page, err
+}
+
+func (c *client) containerURL(containerName string) string {
+	return c.serviceURL + "/" + url.PathEscape(containerName)
+}
+
+// blobURL returns the URL of the blob, addressing its snapshot or version
+// when it is not the current one.
+func (c *client) blobURL(containerName string, b blob) string {
+	segments := strings.Split(b.Name, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	u := c.containerURL(containerName) + "/" + strings.Join(segments, "/")
+	if q := b.query(); q != "" {
+		u += "?" + q
+	}
+	return u
+}
+
+// download returns the content of a blob. The caller must close the
+// returned reader.
+func (c *client) download(ctx context.Context, containerName string, b blob) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, c.blobURL(containerName, b))
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
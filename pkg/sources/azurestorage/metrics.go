@@ -0,0 +1,26 @@
+package azurestorage
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+)
+
+var (
+	azureContainersScanned = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "azure_storage_containers_scanned",
+		Help:      "Total number of Azure Storage containers scanned.",
+	},
+		[]string{"source_name"})
+
+	azureBlobsScanned = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "azure_storage_blobs_scanned",
+		Help:      "Total number of Azure Storage blobs scanned.",
+	},
+		[]string{"source_name"})
+)
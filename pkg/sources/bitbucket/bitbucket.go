@@ -0,0 +1,493 @@
+package bitbucket
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common/glob"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/giturl"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/log"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/git"
+)
+
+const SourceType = sourcespb.SourceType_SOURCE_TYPE_BITBUCKET
+
+const (
+	// cloudWebURL is the web URL of Bitbucket Cloud.
+	cloudWebURL = "https://bitbucket.org"
+	// tokenCloneUser is the username Bitbucket expects when cloning with an
+	// access token.
+	tokenCloneUser = "x-token-auth"
+)
+
+// Source scans Bitbucket Cloud workspaces or Bitbucket Data Center projects.
+// Options that have no field in sourcespb.Bitbucket (workspaces, projects,
+// include globs and pull request comments) are set with the With* methods
+// after Init.
+type Source struct {
+	name     string
+	sourceID sources.SourceID
+	jobID    sources.JobID
+	verify   bool
+
+	authMethod string
+	user       string
+	password   string
+	token      string
+
+	// cloud is true when scanning Bitbucket Cloud rather than Data Center.
+	cloud bool
+	// webURL is the base URL of the Bitbucket web UI.
+	webURL string
+	client apiClient
+
+	repos        []string
+	ignoreRepos  []string
+	includeRepos []string
+	workspaces   []string
+	projects     []string
+
+	includePRComments bool
+
+	useCustomContentWriter bool
+	git                    *git.Git
+	scanOptions            *git.ScanOptions
+
+	jobPool *errgroup.Group
+	sources.Progress
+	sources.CommonSourceUnitUnmarshaller
+}
+
+// Ensure the Source satisfies the interfaces at compile time.
+var _ sources.Source = (*Source)(nil)
+var _ sources.SourceUnitUnmarshaller = (*Source)(nil)
+var _ sources.Validator = (*Source)(nil)
+var _ sources.SourceUnitEnumChunker = (*Source)(nil)
+
+// WithCustomContentWriter sets the useCustomContentWriter flag on the source.
+func (s *Source) WithCustomContentWriter() { s.useCustomContentWriter = true }
+
+// Type returns the type of source.
+// It is used for matching source types in configuration and job input.
+func (s *Source) Type() sourcespb.SourceType {
+	return SourceType
+}
+
+func (s *Source) SourceID() sources.SourceID {
+	return s.sourceID
+}
+
+func (s *Source) JobID() sources.JobID {
+	return s.jobID
+}
+
+// Init returns an initialized Bitbucket source.
+func (s *Source) Init(ctx context.Context, name string, jobId sources.JobID, sourceId sources.SourceID, verify bool, connection *anypb.Any, concurrency int) error {
+	s.name = name
+	s.sourceID = sourceId
+	s.jobID = jobId
+	s.verify = verify
+	s.jobPool = &errgroup.Group{}
+	s.jobPool.SetLimit(concurrency)
+
+	if err := git.CmdCheck(); err != nil {
+		return err
+	}
+
+	var conn sourcespb.Bitbucket
+	if err := anypb.UnmarshalTo(connection, &conn, proto.UnmarshalOptions{}); err != nil {
+		return fmt.Errorf("error unmarshalling connection: %w", err)
+	}
+
+	s.repos = conn.GetRepositories()
+	s.ignoreRepos = conn.GetIgnoreRepos()
+
+	switch cred := conn.GetCredential().(type) {
+	case *sourcespb.Bitbucket_Token:
+		s.authMethod = "TOKEN"
+		s.token = cred.Token
+		log.RedactGlobally(s.token)
+	case *sourcespb.Bitbucket_Oauth:
+		s.authMethod = "OAUTH"
+		s.token = cred.Oauth.GetAccessToken()
+		log.RedactGlobally(s.token)
+	case *sourcespb.Bitbucket_BasicAuth:
+		s.authMethod = "BASIC_AUTH"
+		s.user = cred.BasicAuth.GetUsername()
+		s.password = cred.BasicAuth.GetPassword()
+		log.RedactGlobally(s.password)
+	default:
+		return fmt.Errorf("invalid configuration given for source %q (%s)", name, s.Type().String())
+	}
+
+	webURL, cloud, err := normalizeBitbucketEndpoint(conn.GetEndpoint())
+	if err != nil {
+		return err
+	}
+	s.webURL, s.cloud = webURL, cloud
+
+	base := httpClient{
+		client:   common.RetryableHTTPClientTimeout(60),
+		token:    s.token,
+		username: s.user,
+		password: s.password,
+	}
+	if s.cloud {
+		base.baseURL = cloudAPIBaseURL
+		s.client = &cloudClient{httpClient: base}
+	} else {
+		base.baseURL = s.webURL + "/rest/api/1.0"
+		s.client = &dataCenterClient{httpClient: base, webURL: s.webURL}
+	}
+
+	cfg := &git.Config{
+		SourceName:   s.name,
+		JobID:        s.jobID,
+		SourceID:     s.sourceID,
+		SourceType:   s.Type(),
+		Verify:       s.verify,
+		SkipBinaries: conn.GetSkipBinaries(),
+		SkipArchives: conn.GetSkipArchives(),
+		Concurrency:  concurrency,
+		SourceMetadataFunc: func(file, email, commit, timestamp, repository string, line int64) *source_metadatapb.MetaData {
+			repo, _ := s.repoFromURL(repository)
+			return &source_metadatapb.MetaData{
+				Data: &source_metadatapb.MetaData_Bitbucket{
+					Bitbucket: &source_metadatapb.Bitbucket{
+						Commit:     sanitizer.UTF8(commit),
+						File:       sanitizer.UTF8(file),
+						Email:      sanitizer.UTF8(email),
+						Repository: sanitizer.UTF8(repository),
+						Workspace:  sanitizer.UTF8(repo.workspace),
+						Link:       s.commitLink(repository, repo, commit, file, line),
+						Timestamp:  sanitizer.UTF8(timestamp),
+						Line:       line,
+					},
+				},
+			}
+		},
+		UseCustomContentWriter: s.useCustomContentWriter,
+	}
+	s.git = git.NewGit(cfg)
+
+	return nil
+}
+
+// WithScanOptions sets the git scan options used for every repository.
+func (s *Source) WithScanOptions(scanOptions *git.ScanOptions) {
+	s.scanOptions = scanOptions
+}
+
+// WithWorkspaces limits enumeration to the given Bitbucket Cloud workspaces
+// or, for Data Center, project keys.
+func (s *Source) WithWorkspaces(workspaces ...string) { s.workspaces = workspaces }
+
+// WithProjects limits enumeration to repositories in the given project keys.
+func (s *Source) WithProjects(projects ...string) { s.projects = projects }
+
+// WithIncludeRepos limits enumeration to repositories whose full name
+// ("workspace/repo" or "PROJECT/repo") matches one of the globs.
+func (s *Source) WithIncludeRepos(globs ...string) { s.includeRepos = globs }
+
+// WithPullRequestComments enables scanning pull request descriptions and comments.
+func (s *Source) WithPullRequestComments() { s.includePRComments = true }
+
+// normalizeBitbucketEndpoint returns the web URL of the Bitbucket instance and
+// whether it is Bitbucket Cloud. An empty endpoint means Bitbucket Cloud.
+func normalizeBitbucketEndpoint(endpoint string) (string, bool, error) {
+	if endpoint == "" {
+		return cloudWebURL, true, nil
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", false, err
+	}
+	// We probably didn't receive a URL with a scheme, which messed up the parsing.
+	if u.Host == "" {
+		if u, err = url.Parse("https://" + endpoint); err != nil {
+			return "", false, err
+		}
+	}
+
+	switch u.Host {
+	case "bitbucket.org", "www.bitbucket.org", "api.bitbucket.org":
+		return cloudWebURL, true, nil
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.RawQuery, u.Fragment = "", ""
+	return u.String(), false, nil
+}
+
+// repoFromURL recovers the workspace (or project key) and slug of a
+// repository from its clone URL.
+func (s *Source) repoFromURL(cloneURL string) (repository, error) {
+	u, err := url.Parse(cloneURL)
+	if err != nil {
+		return repository{}, err
+	}
+	parts := strings.Split(strings.Trim(strings.TrimSuffix(u.Path, ".git"), "/"), "/")
+	if len(parts) < 2 {
+		return repository{}, fmt.Errorf("could not determine workspace and repository from %q", cloneURL)
+	}
+	repo := repository{
+		workspace: parts[len(parts)-2],
+		slug:      parts[len(parts)-1],
+		cloneURL:  cloneURL,
+	}
+	if s.cloud {
+		repo.link = fmt.Sprintf("%s/%s/%s", cloudWebURL, repo.workspace, repo.slug)
+	} else {
+		// Data Center clone URLs use a lower case project key.
+		repo.workspace = strings.ToUpper(repo.workspace)
+		repo.project = repo.workspace
+		repo.link = fmt.Sprintf("%s/projects/%s/repos/%s", s.webURL, repo.workspace, repo.slug)
+	}
+	return repo, nil
+}
+
+// commitLink returns a link to the file at the given commit.
+func (s *Source) commitLink(repoURL string, repo repository, commit, file string, line int64) string {
+	if s.cloud || repo.link == "" {
+		return giturl.GenerateLink(repoURL, commit, file, line)
+	}
+	link := repo.link + "/commits/" + commit
+	if file != "" {
+		link = fmt.Sprintf("%s/browse/%s?at=%s", repo.link, file, commit)
+		if line > 0 {
+			link += "#" + strconv.FormatInt(line, 10)
+		}
+	}
+	return link
+}
+
+// Validate checks that the configured credentials are accepted by Bitbucket.
+func (s *Source) Validate(ctx context.Context) []error {
+	if err := s.client.validate(ctx); err != nil {
+		return []error{fmt.Errorf("bitbucket authentication failed using method %v: %w", s.authMethod, err)}
+	}
+
+	_, errs := s.normalizeRepos(s.repos)
+	glob.BuildIgnorer(s.includeRepos, s.ignoreRepos, func(err error, pattern string) {
+		errs = append(errs, fmt.Errorf("could not compile include/exclude repo pattern %q: %w", pattern, err))
+	})
+	return errs
+}
+
+func (s *Source) normalizeRepos(repos []string) ([]string, []error) {
+	validRepos := make([]string, 0, len(repos))
+	var errs []error
+	for _, r := range repos {
+		if s.cloud {
+			normalized, err := giturl.NormalizeBitbucketRepo(r)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("unable to normalize bitbucket repo url %q: %w", r, err))
+				continue
+			}
+			validRepos = append(validRepos, normalized)
+			continue
+		}
+		if _, err := s.repoFromURL(r); err != nil {
+			errs = append(errs, fmt.Errorf("unable to normalize bitbucket repo url %q: %w", r, err))
+			continue
+		}
+		validRepos = append(validRepos, r)
+	}
+	return validRepos, errs
+}
+
+// Enumerate reports all Bitbucket repositories to be scanned to the reporter.
+// If none are configured, it lists every repository in the configured (or all
+// accessible) workspaces, while respecting the project filter and the
+// include and ignore globs.
+func (s *Source) Enumerate(ctx context.Context, reporter sources.UnitReporter) error {
+	repos, errs := s.normalizeRepos(s.repos)
+	for _, repoErr := range errs {
+		ctx.Logger().Info("error normalizing repo", "error", repoErr)
+		if err := reporter.UnitErr(ctx, repoErr); err != nil {
+			return err
+		}
+	}
+	if len(errs) > 0 && len(repos) == 0 {
+		return fmt.Errorf("all configured repos had validation issues")
+	}
+
+	bitbucketReposEnumerated.WithLabelValues(s.name).Set(0)
+	if len(repos) > 0 {
+		for _, repo := range repos {
+			if err := reporter.UnitOk(ctx, git.SourceUnit{Kind: git.UnitRepo, ID: repo}); err != nil {
+				return err
+			}
+			bitbucketReposEnumerated.WithLabelValues(s.name).Inc()
+		}
+		return nil
+	}
+
+	ignoreRepo := glob.BuildIgnorer(s.includeRepos, s.ignoreRepos, func(err error, pattern string) {
+		ctx.Logger().Error(err, "could not compile include/exclude repo glob", "glob", pattern)
+		_ = reporter.UnitErr(ctx, fmt.Errorf("could not compile include/exclude repo glob: %w", err))
+	})
+
+	workspaces := s.workspaces
+	if len(workspaces) == 0 {
+		var err error
+		if workspaces, err = s.client.listWorkspaces(ctx); err != nil {
+			return reporter.UnitErr(ctx, fmt.Errorf("could not list workspaces: %w", err))
+		}
+		ctx.Logger().V(2).Info("enumerated workspaces", "count", len(workspaces))
+	}
+
+	for _, workspace := range workspaces {
+		ctx := context.WithValue(ctx, "workspace", workspace)
+		var reportErr error
+		err := s.client.listRepos(ctx, workspace, func(repo repository) error {
+			if len(s.projects) > 0 && !slices.ContainsFunc(s.projects, func(p string) bool { return strings.EqualFold(p, repo.project) }) {
+				ctx.Logger().V(3).Info("skipping repo", "repo", repo.fullName(), "reason", "project not configured")
+				return nil
+			}
+			if ignoreRepo(repo.fullName()) {
+				ctx.Logger().V(3).Info("skipping repo", "repo", repo.fullName(), "reason", "ignored in config")
+				return nil
+			}
+			if repo.cloneURL == "" {
+				reportErr = reporter.UnitErr(ctx, fmt.Errorf("no HTTP clone URL for repository %q", repo.fullName()))
+				return reportErr
+			}
+			if reportErr = reporter.UnitOk(ctx, git.SourceUnit{Kind: git.UnitRepo, ID: repo.cloneURL}); reportErr != nil {
+				return reportErr
+			}
+			bitbucketReposEnumerated.WithLabelValues(s.name).Inc()
+			return nil
+		})
+		if reportErr != nil {
+			return reportErr
+		}
+		if err != nil {
+			if err := reporter.UnitErr(ctx, fmt.Errorf("could not list repositories in %q: %w", workspace, err)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ChunkUnit clones and scans the given Bitbucket repository unit, followed by
+// its pull requests if enabled.
+func (s *Source) ChunkUnit(ctx context.Context, unit sources.SourceUnit, reporter sources.ChunkReporter) error {
+	repoURL, _ := unit.SourceUnitID()
+	ctx = context.WithValue(ctx, "repo", repoURL)
+
+	path, gitRepo, err := s.cloneRepo(ctx, repoURL)
+	if err != nil {
+		return reporter.ChunkErr(ctx, err)
+	}
+	defer os.RemoveAll(path)
+
+	if err := s.git.ScanRepo(ctx, gitRepo, path, s.scanOptions, reporter); err != nil {
+		return reporter.ChunkErr(ctx, err)
+	}
+	bitbucketReposScanned.WithLabelValues(s.name).Inc()
+
+	if !s.includePRComments {
+		return nil
+	}
+	repo, err := s.repoFromURL(repoURL)
+	if err != nil {
+		return reporter.ChunkErr(ctx, err)
+	}
+	return s.chunkPullRequests(ctx, repo, reporter)
+}
+
+func (s *Source) cloneRepo(ctx context.Context, repoURL string) (string, *gogit.Repository, error) {
+	switch s.authMethod {
+	case "BASIC_AUTH":
+		return git.CloneRepoUsingToken(ctx, s.password, repoURL, s.user)
+	default:
+		return git.CloneRepoUsingToken(ctx, s.token, repoURL, tokenCloneUser)
+	}
+}
+
+// chunkPullRequests reports the description and comments of every pull
+// request in the repository.
+func (s *Source) chunkPullRequests(ctx context.Context, repo repository, reporter sources.ChunkReporter) error {
+	prs, err := s.client.listPullRequests(ctx, repo)
+	if err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not list pull requests: %w", err))
+	}
+
+	for _, pr := range prs {
+		link := pr.link
+		if link == "" {
+			link = fmt.Sprintf("%s/pull-requests/%d", repo.link, pr.id)
+		}
+		description := pr.title
+		if pr.description != "" {
+			description += "\n" + pr.description
+		}
+		if err := reporter.ChunkOk(ctx, s.pullRequestChunk(repo, pr, description, pr.author, pr.created, link)); err != nil {
+			return err
+		}
+
+		comments, err := s.client.listComments(ctx, repo, pr)
+		if err != nil {
+			if err := reporter.ChunkErr(ctx, fmt.Errorf("could not list comments for pull request %d: %w", pr.id, err)); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, c := range comments {
+			commentLink := c.link
+			if commentLink == "" {
+				commentLink = fmt.Sprintf("%s#comment-%d", link, c.id)
+			}
+			if err := reporter.ChunkOk(ctx, s.pullRequestChunk(repo, pr, c.text, c.author, c.created, commentLink)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Source) pullRequestChunk(repo repository, pr pullRequest, data, author, created, link string) sources.Chunk {
+	return sources.Chunk{
+		SourceName: s.name,
+		SourceID:   s.SourceID(),
+		JobID:      s.JobID(),
+		SourceType: s.Type(),
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Bitbucket{
+				Bitbucket: &source_metadatapb.Bitbucket{
+					Repository: sanitizer.UTF8(repo.cloneURL),
+					Workspace:  sanitizer.UTF8(repo.workspace),
+					Title:      sanitizer.UTF8(pr.title),
+					Email:      sanitizer.UTF8(author),
+					Link:       sanitizer.UTF8(link),
+					Timestamp:  sanitizer.UTF8(created),
+				},
+			},
+		},
+		Data:   []byte(data),
+		Verify: s.verify,
+	}
+}
+
+// Chunks emits chunks of bytes over a channel.
+func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ ...sources.ChunkingTarget) error {
+	bitbucketReposScanned.WithLabelValues(s.name).Set(0)
+	return sources.ChunkUnits(ctx, s, s.jobPool, &s.Progress, "Repo", chunksChan)
+}
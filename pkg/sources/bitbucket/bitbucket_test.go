@@ -0,0 +1,180 @@
+package bitbucket
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/git"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sourcestest"
+)
+
+// newTestServer serves canned JSON responses keyed by request path.
+func newTestServer(t *testing.T, responses map[string]any) *sourcestest.Server {
+	t.Helper()
+	return sourcestest.NewServer(t, responses, sourcestest.WithAuth(sourcestest.BearerAuth("token")))
+}
+
+func initSource(t *testing.T, endpoint string) *Source {
+	t.Helper()
+	conn, err := anypb.New(&sourcespb.Bitbucket{
+		Endpoint:   endpoint,
+		Credential: &sourcespb.Bitbucket_Token{Token: "token"},
+	})
+	require.NoError(t, err)
+
+	s := &Source{}
+	require.NoError(t, s.Init(context.Background(), "test", 0, 0, false, conn, 1))
+	return s
+}
+
+func unitIDs(units []sources.SourceUnit) []string {
+	ids := make([]string, 0, len(units))
+	for _, u := range units {
+		id, kind := u.SourceUnitID()
+		if kind == git.UnitRepo {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func TestEnumerate_Cloud(t *testing.T) {
+	repo := func(slug, project string) map[string]any {
+		return map[string]any{
+			"slug":    slug,
+			"project": map[string]any{"key": project},
+			"links": map[string]any{"clone": []map[string]any{
+				{"name": "https", "href": "https://user@bitbucket.org/acme/" + slug + ".git"},
+				{"name": "ssh", "href": "git@bitbucket.org:acme/" + slug + ".git"},
+			}},
+		}
+	}
+	srv := newTestServer(t, map[string]any{
+		"/user/permissions/workspaces": map[string]any{
+			"values": []any{map[string]any{"workspace": map[string]any{"slug": "acme"}}},
+		},
+		"/repositories/acme": map[string]any{
+			"values": []any{repo("api", "CORE"), repo("web", "CORE"), repo("api-legacy", "CORE"), repo("docs", "DOCS")},
+		},
+	})
+
+	s := initSource(t, "")
+	require.True(t, s.cloud)
+	s.client.(*cloudClient).baseURL = srv.URL
+	s.WithProjects("core")
+	s.WithIncludeRepos("acme/*")
+	s.ignoreRepos = []string{"acme/*-legacy"}
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+	assert.Empty(t, reporter.UnitErrs)
+	assert.Equal(t, []string{
+		"https://bitbucket.org/acme/api.git",
+		"https://bitbucket.org/acme/web.git",
+	}, unitIDs(reporter.Units))
+}
+
+func TestEnumerate_DataCenter(t *testing.T) {
+	srv := newTestServer(t, map[string]any{
+		"/rest/api/1.0/projects/PROJ/repos": map[string]any{
+			"isLastPage": true,
+			"values": []any{map[string]any{
+				"slug":    "service",
+				"project": map[string]any{"key": "PROJ"},
+				"links": map[string]any{"clone": []map[string]any{
+					{"name": "http", "href": "https://admin@bitbucket.example.com/scm/proj/service.git"},
+				}},
+			}},
+		},
+	})
+
+	s := initSource(t, srv.URL)
+	require.False(t, s.cloud)
+	s.WithWorkspaces("PROJ", "MISSING")
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+	assert.Equal(t, []string{"https://bitbucket.example.com/scm/proj/service.git"}, unitIDs(reporter.Units))
+	assert.Len(t, reporter.UnitErrs, 1, "listing a missing project should be reported")
+}
+
+func TestChunkPullRequests_DataCenter(t *testing.T) {
+	prBase := "/rest/api/1.0/projects/PROJ/repos/service/pull-requests"
+	srv := newTestServer(t, map[string]any{
+		prBase: map[string]any{
+			"isLastPage": true,
+			"values": []any{map[string]any{
+				"id":          7,
+				"title":       "Add config",
+				"description": "password=hunter2",
+				"author":      map[string]any{"user": map[string]any{"name": "alice", "emailAddress": "alice@example.com"}},
+				"createdDate": 1700000000000,
+				"links":       map[string]any{"self": []map[string]any{{"href": "https://bitbucket.example.com/projects/PROJ/repos/service/pull-requests/7"}}},
+			}},
+		},
+		prBase + "/7/activities": map[string]any{
+			"isLastPage": true,
+			"values": []any{
+				map[string]any{"action": "APPROVED"},
+				map[string]any{"action": "COMMENTED", "comment": map[string]any{
+					"id": 3, "text": "token: abc123", "author": map[string]any{"name": "bob"},
+					"comments": []any{map[string]any{
+						"id": 4, "text": "secret: xyz789", "author": map[string]any{"name": "carol"},
+					}},
+				}},
+			},
+		},
+	})
+
+	s := initSource(t, srv.URL)
+	repo, err := s.repoFromURL(srv.URL + "/scm/proj/service.git")
+	require.NoError(t, err)
+	assert.Equal(t, "PROJ", repo.workspace)
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.chunkPullRequests(context.Background(), repo, &reporter))
+	assert.Empty(t, reporter.ChunkErrs)
+	require.Len(t, reporter.Chunks, 3)
+
+	desc := reporter.Chunks[0].SourceMetadata.GetBitbucket()
+	assert.Equal(t, "Add config\npassword=hunter2", string(reporter.Chunks[0].Data))
+	assert.Equal(t, "Add config", desc.GetTitle())
+	assert.Equal(t, "alice@example.com", desc.GetEmail())
+	assert.Equal(t, "PROJ", desc.GetWorkspace())
+	assert.Equal(t, "2023-11-14T22:13:20Z", desc.GetTimestamp())
+
+	comment := reporter.Chunks[1].SourceMetadata.GetBitbucket()
+	assert.Equal(t, "token: abc123", string(reporter.Chunks[1].Data))
+	assert.Equal(t, "bob", comment.GetEmail())
+	assert.Equal(t, "https://bitbucket.example.com/projects/PROJ/repos/service/pull-requests/7?commentId=3", comment.GetLink())
+
+	reply := reporter.Chunks[2].SourceMetadata.GetBitbucket()
+	assert.Equal(t, "secret: xyz789", string(reporter.Chunks[2].Data))
+	assert.Equal(t, "carol", reply.GetEmail())
+	assert.Equal(t, "https://bitbucket.example.com/projects/PROJ/repos/service/pull-requests/7?commentId=4", reply.GetLink())
+}
+
+func TestNormalizeBitbucketEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint  string
+		wantURL   string
+		wantCloud bool
+	}{
+		{endpoint: "", wantURL: cloudWebURL, wantCloud: true},
+		{endpoint: "https://api.bitbucket.org/2.0", wantURL: cloudWebURL, wantCloud: true},
+		{endpoint: "bitbucket.example.com/", wantURL: "https://bitbucket.example.com"},
+		{endpoint: "http://10.0.0.1:7990/bitbucket/", wantURL: "http://10.0.0.1:7990/bitbucket"},
+	}
+	for _, tt := range tests {
+		gotURL, gotCloud, err := normalizeBitbucketEndpoint(tt.endpoint)
+		require.NoError(t, err)
+		assert.Equal(t, tt.wantURL, gotURL, tt.endpoint)
+		assert.Equal(t, tt.wantCloud, gotCloud, tt.endpoint)
+	}
+}
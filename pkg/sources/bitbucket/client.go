@@ -0,0 +1,438 @@
+package bitbucket
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+const (
+	cloudAPIBaseURL = "https://api.bitbucket.org/2.0"
+	cloudPageLen    = 100
+	dataCenterLimit = 100
+)
+
+// repository is a Bitbucket repository, normalized across Cloud and Data
+// Center. For Data Center, the workspace is the project key.
+type repository struct {
+	workspace string
+	project   string
+	slug      string
+	cloneURL  string
+	link      string
+}
+
+// fullName is the name include and ignore globs are matched against.
+func (r repository) fullName() string { return r.workspace + "/" + r.slug }
+
+// pullRequest is a pull request and its description.
+type pullRequest struct {
+	id          int
+	title       string
+	description string
+	author      string
+	created     string
+	link        string
+}
+
+// comment is a single pull request comment.
+type comment struct {
+	id      int
+	text    string
+	author  string
+	created string
+	link    string
+}
+
+// apiClient is the subset of the Bitbucket API used by the source. It is
+// implemented separately for Bitbucket Cloud and Bitbucket Data Center.
+type apiClient interface {
+	validate(ctx context.Context) error
+	listWorkspaces(ctx context.Context) ([]string, error)
+	listRepos(ctx context.Context, workspace string, visit func(repository) error) error
+	listPullRequests(ctx context.Context, repo repository) ([]pullRequest, error)
+	listComments(ctx context.Context, repo repository, pr pullRequest) ([]comment, error)
+}
+
+// httpClient performs authenticated JSON requests against a Bitbucket API.
+type httpClient struct {
+	client   *http.Client
+	baseURL  string
+	token    string
+	username string
+	password string
+}
+
+func (c *httpClient) get(ctx context.Context, reqURL string, target any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create Bitbucket API request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	} else if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request to Bitbucket API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status code %d from %q: %s", resp.StatusCode, req.URL.Path, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode Bitbucket API response: %w", err)
+	}
+	return nil
+}
+
+// cloudClient talks to the Bitbucket Cloud REST API (v2.0).
+type cloudClient struct{ httpClient }
+
+var _ apiClient = (*cloudClient)(nil)
+
+type cloudPage[T any] struct {
+	Values []T    `json:"values"`
+	Next   string `json:"next"`
+}
+
+// cloudPaginate follows the "next" links of a paginated Cloud response.
+func cloudPaginate[T any](ctx context.Context, c *cloudClient, reqURL string, visit func(T) error) error {
+	for reqURL != "" {
+		var page cloudPage[T]
+		if err := c.get(ctx, reqURL, &page); err != nil {
+			return err
+		}
+		for _, v := range page.Values {
+			if err := visit(v); err != nil {
+				return err
+			}
+		}
+		reqURL = page.Next
+	}
+	return nil
+}
+
+type cloudLink struct {
+	Href string `json:"href"`
+	Name string `json:"name"`
+}
+
+type cloudUser struct {
+	DisplayName string `json:"display_name"`
+	Nickname    string `json:"nickname"`
+}
+
+func (c *cloudClient) validate(ctx context.Context) error {
+	var user cloudUser
+	return c.get(ctx, c.baseURL+"/user", &user)
+}
+
+func (c *cloudClient) listWorkspaces(ctx context.Context) ([]string, error) {
+	type permission struct {
+		Workspace struct {
+			Slug string `json:"slug"`
+		} `json:"workspace"`
+	}
+	var workspaces []string
+	reqURL := fmt.Sprintf("%s/user/permissions/workspaces?pagelen=%d", c.baseURL, cloudPageLen)
+	err := cloudPaginate(ctx, c, reqURL, func(p permission) error {
+		workspaces = append(workspaces, p.Workspace.Slug)
+		return nil
+	})
+	return workspaces, err
+}
+
+func (c *cloudClient) listRepos(ctx context.Context, workspace string, visit func(repository) error) error {
+	type cloudRepo struct {
+		Slug    string `json:"slug"`
+		Project struct {
+			Key string `json:"key"`
+		} `json:"project"`
+		Links struct {
+			Clone []cloudLink `json:"clone"`
+			HTML  cloudLink   `json:"html"`
+		} `json:"links"`
+	}
+	reqURL := fmt.Sprintf("%s/repositories/%s?pagelen=%d", c.baseURL, url.PathEscape(workspace), cloudPageLen)
+	return cloudPaginate(ctx, c, reqURL, func(r cloudRepo) error {
+		repo := repository{
+			workspace: workspace,
+			project:   r.Project.Key,
+			slug:      r.Slug,
+			link:      r.Links.HTML.Href,
+		}
+		for _, l := range r.Links.Clone {
+			if l.Name == "https" {
+				repo.cloneURL = stripUserInfo(l.Href)
+			}
+		}
+		return visit(repo)
+	})
+}
+
+func (c *cloudClient) listPullRequests(ctx context.Context, repo repository) ([]pullRequest, error) {
+	type cloudPR struct {
+		ID          int       `json:"id"`
+		Title       string    `json:"title"`
+		Description string    `json:"description"`
+		Author      cloudUser `json:"author"`
+		CreatedOn   string    `json:"created_on"`
+		Links       struct {
+			HTML cloudLink `json:"html"`
+		} `json:"links"`
+	}
+	var prs []pullRequest
+	reqURL := fmt.Sprintf("%s/repositories/%s/%s/pullrequests?state=OPEN&state=MERGED&state=DECLINED&state=SUPERSEDED&pagelen=50",
+		c.baseURL, url.PathEscape(repo.workspace), url.PathEscape(repo.slug))
+	err := cloudPaginate(ctx, c, reqURL, func(pr cloudPR) error {
+		prs = append(prs, pullRequest{
+			id:          pr.ID,
+			title:       pr.Title,
+			description: pr.Description,
+			author:      pr.Author.DisplayName,
+			created:     pr.CreatedOn,
+			link:        pr.Links.HTML.Href,
+		})
+		return nil
+	})
+	return prs, err
+}
+
+func (c *cloudClient) listComments(ctx context.Context, repo repository, pr pullRequest) ([]comment, error) {
+	type cloudComment struct {
+		ID      int `json:"id"`
+		Content struct {
+			Raw string `json:"raw"`
+		} `json:"content"`
+		User      cloudUser `json:"user"`
+		CreatedOn string    `json:"created_on"`
+		Deleted   bool      `json:"deleted"`
+		Links     struct {
+			HTML cloudLink `json:"html"`
+		} `json:"links"`
+	}
+	var comments []comment
+	reqURL := fmt.Sprintf("%s/repositories/%s/%s/pullrequests/%d/comments?pagelen=%d",
+		c.baseURL, url.PathEscape(repo.workspace), url.PathEscape(repo.slug), pr.id, cloudPageLen)
+	err := cloudPaginate(ctx, c, reqURL, func(cm cloudComment) error {
+		if cm.Deleted || cm.Content.Raw == "" {
+			return nil
+		}
+		comments = append(comments, comment{
+			id:      cm.ID,
+			text:    cm.Content.Raw,
+			author:  cm.User.DisplayName,
+			created: cm.CreatedOn,
+			link:    cm.Links.HTML.Href,
+		})
+		return nil
+	})
+	return comments, err
+}
+
+// dataCenterClient talks to the Bitbucket Data Center (Server) REST API (1.0).
+type dataCenterClient struct {
+	httpClient
+	// webURL is the base URL of the Bitbucket web UI, used to build links.
+	webURL string
+}
+
+var _ apiClient = (*dataCenterClient)(nil)
+
+type dataCenterPage[T any] struct {
+	Values        []T  `json:"values"`
+	IsLastPage    bool `json:"isLastPage"`
+	NextPageStart int  `json:"nextPageStart"`
+}
+
+// dataCenterPaginate follows the start/limit pagination of a Data Center response.
+func dataCenterPaginate[T any](ctx context.Context, c *dataCenterClient, reqURL string, visit func(T) error) error {
+	sep := "?"
+	if strings.Contains(reqURL, "?") {
+		sep = "&"
+	}
+	start := 0
+	for {
+		var page dataCenterPage[T]
+		if err := c.get(ctx, fmt.Sprintf("%s%sstart=%d&limit=%d", reqURL, sep, start, dataCenterLimit), &page); err != nil {
+			return err
+		}
+		for _, v := range page.Values {
+			if err := visit(v); err != nil {
+				return err
+			}
+		}
+		if page.IsLastPage || len(page.Values) == 0 {
+			return nil
+		}
+		start = page.NextPageStart
+	}
+}
+
+type dataCenterUser struct {
+	Name         string `json:"name"`
+	EmailAddress string `json:"emailAddress"`
+}
+
+func (u dataCenterUser) String() string {
+	if u.EmailAddress != "" {
+		return u.EmailAddress
+	}
+	return u.Name
+}
+
+func (c *dataCenterClient) validate(ctx context.Context) error {
+	// There is no "current user" endpoint, so list a single project instead.
+	var page dataCenterPage[json.RawMessage]
+	return c.get(ctx, c.baseURL+"/projects?limit=1", &page)
+}
+
+// listWorkspaces lists the keys of all projects the credentials can access.
+func (c *dataCenterClient) listWorkspaces(ctx context.Context) ([]string, error) {
+	type project struct {
+		Key string `json:"key"`
+	}
+	var keys []string
+	err := dataCenterPaginate(ctx, c, c.baseURL+"/projects", func(p project) error {
+		keys = append(keys, p.Key)
+		return nil
+	})
+	return keys, err
+}
+
+func (c *dataCenterClient) listRepos(ctx context.Context, projectKey string, visit func(repository) error) error {
+	type dataCenterRepo struct {
+		Slug    string `json:"slug"`
+		Project struct {
+			Key string `json:"key"`
+		} `json:"project"`
+		Links struct {
+			Clone []cloudLink `json:"clone"`
+			Self  []cloudLink `json:"self"`
+		} `json:"links"`
+	}
+	reqURL := fmt.Sprintf("%s/projects/%s/repos", c.baseURL, url.PathEscape(projectKey))
+	return dataCenterPaginate(ctx, c, reqURL, func(r dataCenterRepo) error {
+		repo := repository{workspace: r.Project.Key, project: r.Project.Key, slug: r.Slug}
+		for _, l := range r.Links.Clone {
+			if l.Name == "http" || l.Name == "https" {
+				repo.cloneURL = stripUserInfo(l.Href)
+			}
+		}
+		if len(r.Links.Self) > 0 {
+			repo.link = r.Links.Self[0].Href
+		}
+		return visit(repo)
+	})
+}
+
+func (c *dataCenterClient) listPullRequests(ctx context.Context, repo repository) ([]pullRequest, error) {
+	type dataCenterPR struct {
+		ID          int    `json:"id"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Author      struct {
+			User dataCenterUser `json:"user"`
+		} `json:"author"`
+		CreatedDate int64 `json:"createdDate"`
+		Links       struct {
+			Self []cloudLink `json:"self"`
+		} `json:"links"`
+	}
+	var prs []pullRequest
+	reqURL := fmt.Sprintf("%s/projects/%s/repos/%s/pull-requests?state=ALL",
+		c.baseURL, url.PathEscape(repo.workspace), url.PathEscape(repo.slug))
+	err := dataCenterPaginate(ctx, c, reqURL, func(pr dataCenterPR) error {
+		p := pullRequest{
+			id:          pr.ID,
+			title:       pr.Title,
+			description: pr.Description,
+			author:      pr.Author.User.String(),
+			created:     millisToTimestamp(pr.CreatedDate),
+		}
+		if len(pr.Links.Self) > 0 {
+			p.link = pr.Links.Self[0].Href
+		}
+		prs = append(prs, p)
+		return nil
+	})
+	return prs, err
+}
+
+// dataCenterComment is a pull request comment along with its replies.
+type dataCenterComment struct {
+	ID          int                 `json:"id"`
+	Text        string              `json:"text"`
+	Author      dataCenterUser      `json:"author"`
+	CreatedDate int64               `json:"createdDate"`
+	Comments    []dataCenterComment `json:"comments"`
+}
+
+func (c *dataCenterClient) listComments(ctx context.Context, repo repository, pr pullRequest) ([]comment, error) {
+	type activity struct {
+		Action  string             `json:"action"`
+		Comment *dataCenterComment `json:"comment"`
+	}
+	var comments []comment
+	// Replies are only included in the activity of the comment they reply
+	// to, nested in its comments.
+	var addThread func(dc dataCenterComment)
+	addThread = func(dc dataCenterComment) {
+		if dc.Text != "" {
+			cm := comment{
+				id:      dc.ID,
+				text:    dc.Text,
+				author:  dc.Author.String(),
+				created: millisToTimestamp(dc.CreatedDate),
+			}
+			if pr.link != "" {
+				cm.link = pr.link + "?commentId=" + strconv.Itoa(dc.ID)
+			}
+			comments = append(comments, cm)
+		}
+		for _, reply := range dc.Comments {
+			addThread(reply)
+		}
+	}
+	reqURL := fmt.Sprintf("%s/projects/%s/repos/%s/pull-requests/%d/activities",
+		c.baseURL, url.PathEscape(repo.workspace), url.PathEscape(repo.slug), pr.id)
+	err := dataCenterPaginate(ctx, c, reqURL, func(a activity) error {
+		if a.Action != "COMMENTED" || a.Comment == nil {
+			return nil
+		}
+		addThread(*a.Comment)
+		return nil
+	})
+	return comments, err
+}
+
+func millisToTimestamp(ms int64) string {
+	if ms == 0 {
+		return ""
+	}
+	return time.UnixMilli(ms).UTC().Format(time.RFC3339)
+}
+
+// stripUserInfo removes any username embedded in a clone URL, which Bitbucket
+// includes for the authenticated user.
+func stripUserInfo(cloneURL string) string {
+	u, err := url.Parse(cloneURL)
+	if err != nil {
+		return cloneURL
+	}
+	u.User = nil
+	return u.String()
+}
@@ -0,0 +1,310 @@
+package buildkite
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common/glob"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/log"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+const SourceType = sourcespb.SourceType_SOURCE_TYPE_BUILDKITE
+
+// Source scans the pipelines of Buildkite organizations: their YAML and
+// environment, and the environment, meta-data, job logs and artifacts of
+// their builds. Units are pipelines, identified by org/pipeline.
+type Source struct {
+	name     string
+	sourceID sources.SourceID
+	jobID    sources.JobID
+	verify   bool
+
+	client        *client
+	organizations []string
+	ignore        func(pipeline string) bool
+	createdSince  time.Time
+	skipArtifacts bool
+
+	jobPool *errgroup.Group
+	sources.Progress
+	sources.CommonSourceUnitUnmarshaller
+}
+
+// Ensure the Source satisfies the interfaces at compile time.
+var _ sources.Source = (*Source)(nil)
+var _ sources.SourceUnitUnmarshaller = (*Source)(nil)
+var _ sources.SourceUnitEnumChunker = (*Source)(nil)
+
+// Type returns the type of source.
+// It is used for matching source types in configuration and job input.
+func (s *Source) Type() sourcespb.SourceType {
+	return SourceType
+}
+
+func (s *Source) SourceID() sources.SourceID {
+	return s.sourceID
+}
+
+func (s *Source) JobID() sources.JobID {
+	return s.jobID
+}
+
+// Init returns an initialized Buildkite source.
+func (s *Source) Init(ctx context.Context, name string, jobId sources.JobID, sourceId sources.SourceID, verify bool, connection *anypb.Any, concurrency int) error {
+	s.name = name
+	s.sourceID = sourceId
+	s.jobID = jobId
+	s.verify = verify
+	s.jobPool = &errgroup.Group{}
+	s.jobPool.SetLimit(concurrency)
+
+	var conn sourcespb.Buildkite
+	if err := anypb.UnmarshalTo(connection, &conn, proto.UnmarshalOptions{}); err != nil {
+		return fmt.Errorf("error unmarshalling connection: %w", err)
+	}
+
+	switch conn.GetCredential().(type) {
+	case *sourcespb.Buildkite_Token:
+		if conn.GetToken() == "" {
+			return fmt.Errorf("token is empty")
+		}
+		log.RedactGlobally(conn.GetToken())
+	default:
+		return fmt.Errorf("invalid configuration given for source %q (%s)", name, s.Type().String())
+	}
+	s.client = &client{httpClient: common.RetryableHTTPClientTimeout(60), baseURL: apiURL, token: conn.GetToken()}
+
+	s.organizations = conn.GetOrganizations()
+	s.ignore = glob.BuildIgnorer(conn.GetPipelines(), conn.GetIgnorePipelines(), func(err error, pattern string) {
+		ctx.Logger().Error(err, "could not compile pipeline glob", "glob", pattern)
+	}, glob.IgnoreCase())
+	if since := conn.GetCreatedSince(); since != nil {
+		s.createdSince = since.AsTime()
+	}
+	s.skipArtifacts = conn.GetSkipArtifacts()
+	return nil
+}
+
+// Enumerate reports every pipeline of the configured organizations, or of
+// every organization the token can access, that is not ignored.
+func (s *Source) Enumerate(ctx context.Context, reporter sources.UnitReporter) error {
+	orgs := s.organizations
+	if len(orgs) == 0 {
+		all, err := s.client.listOrganizations(ctx)
+		if err != nil {
+			return reporter.UnitErr(ctx, fmt.Errorf("could not list organizations: %w", err))
+		}
+		for _, o := range all {
+			orgs = append(orgs, o.Slug)
+		}
+	}
+
+	buildkitePipelinesEnumerated.WithLabelValues(s.name).Set(0)
+	for _, org := range orgs {
+		pipelines, err := s.client.listPipelines(ctx, org)
+		if err != nil {
+			if err := reporter.UnitErr(ctx, fmt.Errorf("could not list pipelines of %s: %w", org, err)); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, p := range pipelines {
+			id := org + "/" + p.Slug
+			if s.ignore(id) {
+				continue
+			}
+			if err := reporter.UnitOk(ctx, sources.CommonSourceUnit{ID: id}); err != nil {
+				return err
+			}
+			buildkitePipelinesEnumerated.WithLabelValues(s.name).Inc()
+		}
+	}
+	return nil
+}
+
+// ChunkUnit scans a pipeline and its builds.
+func (s *Source) ChunkUnit(ctx context.Context, unit sources.SourceUnit, reporter sources.ChunkReporter) error {
+	id, _ := unit.SourceUnitID()
+	org, slug, ok := strings.Cut(id, "/")
+	if !ok {
+		return fmt.Errorf("invalid pipeline %q: expected org/pipeline", id)
+	}
+	ctx = context.WithValues(ctx, "org", org, "pipeline", slug)
+
+	p, err := s.client.getPipeline(ctx, org, slug)
+	if err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not get pipeline %s: %w", id, err))
+	}
+	if err := s.chunkPipeline(ctx, org, p, reporter); err != nil {
+		return err
+	}
+
+	var reportErr error
+	err = s.client.listBuilds(ctx, org, slug, s.createdSince, func(b build) error {
+		if reportErr = s.chunkBuild(ctx, org, slug, b, reporter); reportErr != nil {
+			return reportErr
+		}
+		buildkiteBuildsScanned.WithLabelValues(s.name).Inc()
+		return nil
+	})
+	if reportErr != nil {
+		return reportErr
+	}
+	if err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not list builds of %s: %w", id, err))
+	}
+	buildkitePipelinesScanned.WithLabelValues(s.name).Inc()
+	return nil
+}
+
+// chunkPipeline reports the steps and environment of a pipeline.
+func (s *Source) chunkPipeline(ctx context.Context, org string, p pipeline, reporter sources.ChunkReporter) error {
+	steps := p.Configuration
+	if steps == "" && len(p.Steps) > 0 && string(p.Steps) != "null" {
+		steps = string(p.Steps)
+	}
+	chunks := []struct{ location, data string }{
+		{"pipeline.yml", steps},
+		{"pipeline env", formatEnv(p.Env)},
+	}
+	for _, c := range chunks {
+		if strings.TrimSpace(c.data) == "" {
+			continue
+		}
+		chunk := s.chunkSkel(org, p.Slug, build{}, "", c.location, p.WebURL)
+		chunk.Data = []byte(c.data)
+		if err := reporter.ChunkOk(ctx, chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chunkBuild reports the environment, meta-data, job logs and artifacts of a
+// build. Only errors returned by the reporter are returned.
+func (s *Source) chunkBuild(ctx context.Context, org, slug string, b build, reporter sources.ChunkReporter) error {
+	report := func(location, data string) error {
+		if strings.TrimSpace(data) == "" {
+			return nil
+		}
+		chunk := s.chunkSkel(org, slug, b, "", location, b.WebURL)
+		chunk.Data = []byte(data)
+		return reporter.ChunkOk(ctx, chunk)
+	}
+
+	if err := report("build env", formatEnv(b.Env)); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(b.MetaData))
+	for key := range b.MetaData {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := report("meta-data: "+key, b.MetaData[key]); err != nil {
+			return err
+		}
+	}
+
+	jobLinks := make(map[string]string, len(b.Jobs))
+	for _, j := range b.Jobs {
+		jobLinks[j.ID] = j.WebURL
+		// Only command jobs have logs.
+		if j.RawLogURL == "" {
+			continue
+		}
+		chunkSkel := s.chunkSkel(org, slug, b, j.ID, "log: "+j.Name, j.WebURL)
+		if err := s.chunkDownload(ctx, j.RawLogURL, chunkSkel, reporter); err != nil {
+			return err
+		}
+	}
+
+	if s.skipArtifacts {
+		return nil
+	}
+	artifacts, err := s.client.listArtifacts(ctx, org, slug, b.Number)
+	if err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not list artifacts of build %d: %w", b.Number, err))
+	}
+	for _, a := range artifacts {
+		if a.State != "finished" {
+			continue
+		}
+		link := jobLinks[a.JobID]
+		if link == "" {
+			link = b.WebURL
+		}
+		chunkSkel := s.chunkSkel(org, slug, b, a.JobID, "artifact: "+a.Path, link)
+		if err := s.chunkDownload(ctx, a.DownloadURL, chunkSkel, reporter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Source) chunkDownload(ctx context.Context, rawURL string, chunkSkel sources.Chunk, reporter sources.ChunkReporter) error {
+	location := chunkSkel.SourceMetadata.GetBuildkite().GetLocation()
+	body, err := s.client.download(ctx, rawURL)
+	if err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not download %s: %w", location, err))
+	}
+	defer body.Close()
+
+	if err := handlers.HandleFile(ctx, body, &chunkSkel, reporter); err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not scan %s: %w", location, err))
+	}
+	return nil
+}
+
+// formatEnv formats environment variables as KEY=value lines, sorted by key.
+func formatEnv(env map[string]any) string {
+	lines := make([]string, 0, len(env))
+	for key, value := range env {
+		lines = append(lines, fmt.Sprintf("%s=%v", key, value))
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+func (s *Source) chunkSkel(org, pipelineSlug string, b build, jobID, location, link string) sources.Chunk {
+	return sources.Chunk{
+		SourceName: s.name,
+		SourceID:   s.SourceID(),
+		JobID:      s.JobID(),
+		SourceType: s.Type(),
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Buildkite{
+				Buildkite: &source_metadatapb.Buildkite{
+					Org:         sanitizer.UTF8(org),
+					Pipeline:    sanitizer.UTF8(pipelineSlug),
+					Link:        sanitizer.UTF8(link),
+					Email:       sanitizer.UTF8(b.Creator.Email),
+					BuildNumber: b.Number,
+					Timestamp:   sanitizer.UTF8(b.CreatedAt),
+					Job:         sanitizer.UTF8(jobID),
+					Location:    sanitizer.UTF8(location),
+				},
+			},
+		},
+		Verify: s.verify,
+	}
+}
+
+// Chunks emits chunks of bytes over a channel.
+func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ ...sources.ChunkingTarget) error {
+	buildkitePipelinesScanned.WithLabelValues(s.name).Set(0)
+	return sources.ChunkUnits(ctx, s, s.jobPool, &s.Progress, "Pipeline", chunksChan)
+}
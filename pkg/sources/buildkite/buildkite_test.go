@@ -0,0 +1,191 @@
+package buildkite
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sourcestest"
+)
+
+// newTestServer serves canned responses keyed by request path. The literal
+// "SERVER" in a response is replaced by the server URL. Responses for a path
+// followed by "?page=2" are served when the page query parameter is 2.
+// Artifact a1 redirects to its storage, which is served without the token.
+func newTestServer(t *testing.T, responses map[string]any) *sourcestest.Server {
+	t.Helper()
+	const storage = "/storage/a1"
+	var srv *sourcestest.Server
+	srv = sourcestest.NewServer(t, responses,
+		sourcestest.WithAuth(sourcestest.BearerAuth("bk-token")),
+		sourcestest.WithPages("page", "2", func(next string, more bool) http.Header {
+			if !more {
+				return nil
+			}
+			return http.Header{"Link": {`<` + next + `>; rel="next", <` + next + `>; rel="last"`}}
+		}),
+		sourcestest.WithRoute("/organizations/acme/pipelines/deploy/builds/7/artifacts/a1/download", func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, srv.URL+storage, http.StatusFound)
+		}),
+		sourcestest.WithRoute(storage, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, srv.Responses[storage])
+		}),
+	)
+	return srv
+}
+
+// rawQueries returns the raw queries of the requests served so far.
+func rawQueries(srv *sourcestest.Server) []string {
+	var queries []string
+	for _, u := range srv.Requests() {
+		queries = append(queries, u.RawQuery)
+	}
+	return queries
+}
+
+func initSource(t *testing.T, endpoint string, conn *sourcespb.Buildkite) *Source {
+	t.Helper()
+	conn.Credential = &sourcespb.Buildkite_Token{Token: "bk-token"}
+	anyConn, err := anypb.New(conn)
+	require.NoError(t, err)
+
+	s := &Source{}
+	require.NoError(t, s.Init(context.Background(), "test", 0, 0, false, anyConn, 1))
+	s.client.baseURL = endpoint
+	return s
+}
+
+func TestEnumerate(t *testing.T) {
+	srv := newTestServer(t, map[string]any{
+		"/organizations":                       `[{"slug": "acme"}, {"slug": "beta"}]`,
+		"/organizations/acme/pipelines":        `[{"slug": "deploy"}, {"slug": "sandbox"}]`,
+		"/organizations/acme/pipelines?page=2": `[{"slug": "docs"}]`,
+		"/organizations/beta/pipelines":        `[{"slug": "deploy"}]`,
+	})
+	s := initSource(t, srv.URL, &sourcespb.Buildkite{IgnorePipelines: []string{"*/Sandbox"}})
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+	assert.Empty(t, reporter.UnitErrs)
+	assert.Equal(t, []sources.SourceUnit{
+		sources.CommonSourceUnit{ID: "acme/deploy"},
+		sources.CommonSourceUnit{ID: "acme/docs"},
+		sources.CommonSourceUnit{ID: "beta/deploy"},
+	}, reporter.Units)
+
+	s = initSource(t, srv.URL, &sourcespb.Buildkite{Organizations: []string{"beta"}, Pipelines: []string{"beta/*"}})
+	reporter = sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+	assert.Equal(t, []sources.SourceUnit{sources.CommonSourceUnit{ID: "beta/deploy"}}, reporter.Units)
+}
+
+func TestChunkUnit(t *testing.T) {
+	srv := newTestServer(t, map[string]any{
+		"/organizations/acme/pipelines/deploy": `{
+			"slug": "deploy",
+			"web_url": "https://buildkite.com/acme/deploy",
+			"configuration": "steps:\n  - command: deploy --token from-pipeline-yaml",
+			"env": {"NPM_TOKEN": "from-pipeline-env"}
+		}`,
+		"/organizations/acme/pipelines/deploy/builds": `[{
+			"number": 7,
+			"web_url": "https://buildkite.com/acme/deploy/builds/7",
+			"created_at": "2024-05-02T10:00:00.000Z",
+			"creator": {"email": "alice@example.com"},
+			"env": {"AWS_SECRET_ACCESS_KEY": "from-build-env"},
+			"meta_data": {"release-token": "from-meta-data"},
+			"jobs": [
+				{"id": "j1", "type": "script", "name": "deploy", "web_url": "https://buildkite.com/acme/deploy/builds/7#j1",
+					"raw_log_url": "SERVER/organizations/acme/pipelines/deploy/builds/7/jobs/j1/log"},
+				{"id": "w1", "type": "waiter"}
+			]
+		}]`,
+		"/organizations/acme/pipelines/deploy/builds/7/jobs/j1/log": "echo from-job-log",
+		"/organizations/acme/pipelines/deploy/builds/7/artifacts": `[
+			{"id": "a1", "job_id": "j1", "path": "out/.env", "state": "finished",
+				"download_url": "SERVER/organizations/acme/pipelines/deploy/builds/7/artifacts/a1/download"},
+			{"id": "a2", "job_id": "j1", "path": "out/pending", "state": "new"}
+		]`,
+		"/storage/a1": "DB_PASSWORD=from-artifact",
+	})
+	s := initSource(t, srv.URL, &sourcespb.Buildkite{
+		CreatedSince: timestamppb.New(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)),
+	})
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.ChunkUnit(context.Background(), sources.CommonSourceUnit{ID: "acme/deploy"}, &reporter))
+	require.Empty(t, reporter.ChunkErrs)
+	assert.Contains(t, rawQueries(srv), "created_from=2024-05-01T00%3A00%3A00Z&include_retried_jobs=true&per_page=100")
+
+	byLocation := make(map[string]sources.Chunk)
+	for _, c := range reporter.Chunks {
+		byLocation[c.SourceMetadata.GetBuildkite().GetLocation()] = c
+	}
+	require.Len(t, byLocation, 6)
+	assert.Contains(t, string(byLocation["pipeline.yml"].Data), "from-pipeline-yaml")
+	assert.Equal(t, "NPM_TOKEN=from-pipeline-env", string(byLocation["pipeline env"].Data))
+	assert.Equal(t, "AWS_SECRET_ACCESS_KEY=from-build-env", string(byLocation["build env"].Data))
+	assert.Equal(t, "from-meta-data", string(byLocation["meta-data: release-token"].Data))
+	assert.Equal(t, "echo from-job-log", string(byLocation["log: deploy"].Data))
+	assert.Equal(t, "DB_PASSWORD=from-artifact", string(byLocation["artifact: out/.env"].Data))
+
+	meta := byLocation["artifact: out/.env"].SourceMetadata.GetBuildkite()
+	assert.Equal(t, "acme", meta.GetOrg())
+	assert.Equal(t, "deploy", meta.GetPipeline())
+	assert.Equal(t, int64(7), meta.GetBuildNumber())
+	assert.Equal(t, "j1", meta.GetJob())
+	assert.Equal(t, "alice@example.com", meta.GetEmail())
+	assert.Equal(t, "https://buildkite.com/acme/deploy/builds/7#j1", meta.GetLink())
+
+	// Artifacts are not listed when skipped.
+	s.skipArtifacts = true
+	reporter = sourcestest.TestReporter{}
+	require.NoError(t, s.ChunkUnit(context.Background(), sources.CommonSourceUnit{ID: "acme/deploy"}, &reporter))
+	assert.Len(t, reporter.Chunks, 5)
+}
+
+func TestChunkUnit_Errors(t *testing.T) {
+	const pipeline = "/organizations/acme/pipelines/deploy"
+	const builds = pipeline + "/builds"
+	const log = builds + "/7/jobs/j1/log"
+	sourcestest.APIErrorTest{
+		Setup: func(t *testing.T) (*sourcestest.Server, func(t *testing.T) ([]string, []error)) {
+			srv := newTestServer(t, map[string]any{
+				pipeline: `{"slug": "deploy", "configuration": "from-pipeline-yaml"}`,
+				builds: `[{"number": 7, "env": {"A": "from-build-7"},
+					"jobs": [{"id": "j1", "type": "script", "name": "deploy", "raw_log_url": "SERVER` + log + `"}]}]`,
+				builds + "?page=2": `[{"number": 6, "env": {"B": "from-build-6"}}]`,
+				log:                "from-job-log",
+			})
+			s := initSource(t, srv.URL, &sourcespb.Buildkite{})
+			s.skipArtifacts = true
+			return srv, func(t *testing.T) ([]string, []error) {
+				reporter := sourcestest.TestReporter{}
+				require.NoError(t, s.ChunkUnit(context.Background(), sources.CommonSourceUnit{ID: "acme/deploy"}, &reporter))
+				return reporter.ChunkData(), reporter.ChunkErrs
+			}
+		},
+		First:           pipeline,
+		LastPage:        builds + "?page=2",
+		Item:            log,
+		All:             []string{"from-pipeline-yaml", "A=from-build-7", "from-job-log", "B=from-build-6"},
+		WithoutItem:     []string{"from-pipeline-yaml", "A=from-build-7", "B=from-build-6"},
+		WithoutLastPage: []string{"from-pipeline-yaml", "A=from-build-7", "from-job-log"},
+	}.Run(t)
+}
+
+func TestNextPageURL(t *testing.T) {
+	header := `<https://api.buildkite.com/v2/organizations?page=3>; rel="next", <https://api.buildkite.com/v2/organizations?page=5>; rel="last"`
+	assert.Equal(t, "https://api.buildkite.com/v2/organizations?page=3", nextPageURL(header))
+	assert.Empty(t, nextPageURL(`<https://api.buildkite.com/v2/organizations?page=1>; rel="first"`))
+	assert.Empty(t, nextPageURL(""))
+}
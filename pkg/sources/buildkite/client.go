@@ -0,0 +1,201 @@
+package buildkite
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+const (
+	apiURL   = "https://api.buildkite.com/v2"
+	pageSize = 100
+)
+
+type organization struct {
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+type pipeline struct {
+	Slug   string `json:"slug"`
+	Name   string `json:"name"`
+	WebURL string `json:"web_url"`
+	// Configuration is the YAML of pipelines defined in the UI. It is empty
+	// for pipelines whose steps are uploaded from the repository.
+	Configuration string          `json:"configuration"`
+	Steps         json.RawMessage `json:"steps"`
+	Env           map[string]any  `json:"env"`
+}
+
+type creator struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+type job struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	WebURL    string `json:"web_url"`
+	RawLogURL string `json:"raw_log_url"`
+}
+
+type build struct {
+	ID        string            `json:"id"`
+	Number    int64             `json:"number"`
+	WebURL    string            `json:"web_url"`
+	CreatedAt string            `json:"created_at"`
+	Creator   creator           `json:"creator"`
+	Env       map[string]any    `json:"env"`
+	MetaData  map[string]string `json:"meta_data"`
+	Jobs      []job             `json:"jobs"`
+}
+
+type artifact struct {
+	ID          string `json:"id"`
+	JobID       string `json:"job_id"`
+	Path        string `json:"path"`
+	State       string `json:"state"`
+	FileSize    int64  `json:"file_size"`
+	DownloadURL string `json:"download_url"`
+}
+
+// client is a minimal Buildkite REST API client.
+type client struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+}
+
+func (c *client) do(ctx context.Context, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Buildkite API request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request to Buildkite API: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("unexpected status code %d from %q: %s", resp.StatusCode, req.URL.Path, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+func (c *client) get(ctx context.Context, path string, target any) error {
+	_, err := c.getPage(ctx, c.baseURL+path, target)
+	return err
+}
+
+// getPage decodes the response into target and returns the URL of the next
+// page, if any.
+func (c *client) getPage(ctx context.Context, rawURL string, target any) (string, error) {
+	resp, err := c.do(ctx, rawURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return "", fmt.Errorf("failed to decode Buildkite API response: %w", err)
+	}
+	return nextPageURL(resp.Header.Get("Link")), nil
+}
+
+var nextLink = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// nextPageURL returns the next page of a paginated response from its Link
+// header.
+func nextPageURL(linkHeader string) string {
+	if m := nextLink.FindStringSubmatch(linkHeader); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// listAll visits every item of a paginated list.
+func listAll[T any](ctx context.Context, c *client, path string, visit func(T) error) error {
+	for next := c.baseURL + path; next != ""; {
+		var page []T
+		var err error
+		if next, err = c.getPage(ctx, next, &page); err != nil {
+			return err
+		}
+		for _, item := range page {
+			if err := visit(item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *client) listOrganizations(ctx context.Context) ([]organization, error) {
+	var orgs []organization
+	err := listAll(ctx, c, fmt.Sprintf("/organizations?per_page=%d", pageSize), func(o organization) error {
+		orgs = append(orgs, o)
+		return nil
+	})
+	return orgs, err
+}
+
+func (c *client) listPipelines(ctx context.Context, org string) ([]pipeline, error) {
+	var pipelines []pipeline
+	path := fmt.Sprintf("/organizations/%s/pipelines?per_page=%d", url.PathEscape(org), pageSize)
+	err := listAll(ctx, c, path, func(p pipeline) error {
+		pipelines = append(pipelines, p)
+		return nil
+	})
+	return pipelines, err
+}
+
+func (c *client) getPipeline(ctx context.Context, org, slug string) (pipeline, error) {
+	var p pipeline
+	err := c.get(ctx, fmt.Sprintf("/organizations/%s/pipelines/%s", url.PathEscape(org), url.PathEscape(slug)), &p)
+	return p, err
+}
+
+// listBuilds visits the builds of a pipeline created after since, newest
+// first, with their retried jobs.
+func (c *client) listBuilds(ctx context.Context, org, slug string, since time.Time, visit func(build) error) error {
+	query := url.Values{
+		"per_page":             {fmt.Sprint(pageSize)},
+		"include_retried_jobs": {"true"},
+	}
+	if !since.IsZero() {
+		query.Set("created_from", since.UTC().Format(time.RFC3339))
+	}
+	path := fmt.Sprintf("/organizations/%s/pipelines/%s/builds?%s", url.PathEscape(org), url.PathEscape(slug), query.Encode())
+	return listAll(ctx, c, path, visit)
+}
+
+func (c *client) listArtifacts(ctx context.Context, org, slug string, buildNumber int64) ([]artifact, error) {
+	var artifacts []artifact
+	path := fmt.Sprintf("/organizations/%s/pipelines/%s/builds/%d/artifacts?per_page=%d", url.PathEscape(org), url.PathEscape(slug), buildNumber, pageSize)
+	err := listAll(ctx, c, path, func(a artifact) error {
+		artifacts = append(artifacts, a)
+		return nil
+	})
+	return artifacts, err
+}
+
+// download returns the body of a job log or an artifact. Artifact downloads
+// redirect to the storage of the artifact, to which the token is not sent.
+func (c *client) download(ctx context.Context, rawURL string) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,34 @@
+package buildkite
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+)
+
+var (
+	buildkitePipelinesEnumerated = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "buildkite_pipelines_enumerated",
+		Help:      "Total number of Buildkite pipelines enumerated.",
+	},
+		[]string{"source_name"})
+
+	buildkitePipelinesScanned = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "buildkite_pipelines_scanned",
+		Help:      "Total number of Buildkite pipelines scanned.",
+	},
+		[]string{"source_name"})
+
+	buildkiteBuildsScanned = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "buildkite_builds_scanned",
+		Help:      "Total number of Buildkite builds scanned.",
+	},
+		[]string{"source_name"})
+)
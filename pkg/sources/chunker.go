@@ -2,6 +2,7 @@ package sources
 
 import (
 	"bufio"
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
@@ -44,8 +45,9 @@ func WithPeekSize(size int) ConfigOption {
 // ChunkResult is the output unit of a ChunkReader,
 // it contains the data and error of a chunk.
 type ChunkResult struct {
-	data []byte
-	err  error
+	data   []byte
+	err    error
+	offset FileOffset
 }
 
 // Bytes for a ChunkResult.
@@ -58,6 +60,11 @@ func (cr ChunkResult) Error() error {
 	return cr.err
 }
 
+// Offset is the location of the start of the chunk within the reader's data.
+func (cr ChunkResult) Offset() FileOffset {
+	return cr.offset
+}
+
 // ChunkReader reads chunks from a reader and returns a channel of chunks and a channel of errors.
 // The channel of chunks is closed when the reader is closed.
 // This should be used whenever a large amount of data is read from a reader.
@@ -116,12 +123,22 @@ func readInChunks(ctx context.Context, reader io.Reader, config *chunkReaderConf
 			}
 		}()
 
+		var offset FileOffset
 		for {
-			chunkRes := ChunkResult{}
+			chunkRes := ChunkResult{offset: offset}
 			chunkBytes := make([]byte, config.totalSize)
 			chunkBytes = chunkBytes[:config.chunkSize]
 			n, err := io.ReadFull(chunkReader, chunkBytes)
 			if n > 0 {
+				// Only the bytes read count towards the next chunk's offset, because
+				// the peeked bytes are read again as the start of the next chunk.
+				offset.Byte += int64(n)
+				offset.Lines += int64(bytes.Count(chunkBytes[:n], []byte("\n")))
+				if idx := bytes.LastIndexByte(chunkBytes[:n], '\n'); idx != -1 {
+					offset.Column = int64(n - idx - 1)
+				} else {
+					offset.Column += int64(n)
+				}
 				peekData, _ := chunkReader.Peek(config.totalSize - n)
 				chunkBytes = append(chunkBytes[:n], peekData...)
 				chunkRes.data = chunkBytes
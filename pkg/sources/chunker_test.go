@@ -123,6 +123,24 @@ func TestNewChunkedReader(t *testing.T) {
 	}
 }
 
+func TestChunkReader_Offsets(t *testing.T) {
+	// Chunks of 4 bytes with a 2 byte peek: "ab\nc", "defg", "\nhi".
+	readerFunc := NewChunkReader(WithChunkSize(4), WithPeekSize(2))
+	chunkResChan := readerFunc(context.Background(), strings.NewReader("ab\ncdefg\nhi"))
+
+	var offsets []FileOffset
+	for data := range chunkResChan {
+		require.NoError(t, data.Error())
+		offsets = append(offsets, data.Offset())
+	}
+
+	assert.Equal(t, []FileOffset{
+		{Byte: 0, Lines: 0, Column: 0},
+		{Byte: 4, Lines: 1, Column: 1},
+		{Byte: 8, Lines: 1, Column: 5},
+	}, offsets)
+}
+
 type panicReader struct{}
 
 var _ io.Reader = (*panicReader)(nil)
@@ -0,0 +1,166 @@
+package confluence
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+const pageSize = 50
+
+type space struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type version struct {
+	Number int    `json:"number"`
+	When   string `json:"when"`
+	By     struct {
+		DisplayName string `json:"displayName"`
+		Email       string `json:"email"`
+	} `json:"by"`
+}
+
+// content is a page or an attachment.
+type content struct {
+	ID      string  `json:"id"`
+	Type    string  `json:"type"`
+	Title   string  `json:"title"`
+	Version version `json:"version"`
+	Body    struct {
+		Storage struct {
+			Value string `json:"value"`
+		} `json:"storage"`
+	} `json:"body"`
+	Links struct {
+		WebUI    string `json:"webui"`
+		Download string `json:"download"`
+	} `json:"_links"`
+}
+
+type resultPage[T any] struct {
+	Results []T `json:"results"`
+	Start   int `json:"start"`
+	Limit   int `json:"limit"`
+	Size    int `json:"size"`
+}
+
+// client is a minimal Confluence REST API client. The v1 API is served by
+// both Confluence Cloud (under /wiki) and Confluence Data Center.
+type client struct {
+	httpClient *http.Client
+	baseURL    string
+	// authorize sets the credentials on a request.
+	authorize func(*http.Request)
+}
+
+func (c *client) do(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Confluence API request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.authorize != nil {
+		c.authorize(req)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request to Confluence API: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("unexpected status code %d from %q: %s", resp.StatusCode, req.URL.Path, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+func (c *client) get(ctx context.Context, path string, target any) error {
+	resp, err := c.do(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode Confluence API response: %w", err)
+	}
+	return nil
+}
+
+// paginate visits every result of a paginated endpoint.
+func paginate[T any](ctx context.Context, c *client, path string, query url.Values, visit func(T) error) error {
+	query.Set("limit", fmt.Sprint(pageSize))
+	for start := 0; ; {
+		query.Set("start", fmt.Sprint(start))
+		var page resultPage[T]
+		if err := c.get(ctx, path+"?"+query.Encode(), &page); err != nil {
+			return err
+		}
+		for _, r := range page.Results {
+			if err := visit(r); err != nil {
+				return err
+			}
+		}
+		start += len(page.Results)
+		if len(page.Results) == 0 || len(page.Results) < page.Limit {
+			return nil
+		}
+	}
+}
+
+// listSpaces visits every space of the given type. An empty type lists all
+// spaces.
+func (c *client) listSpaces(ctx context.Context, spaceType string, visit func(space) error) error {
+	query := url.Values{}
+	if spaceType != "" {
+		query.Set("type", spaceType)
+	}
+	return paginate(ctx, c, "/rest/api/space", query, visit)
+}
+
+// listPages visits the current version of every page of a space.
+func (c *client) listPages(ctx context.Context, spaceKey string, visit func(content) error) error {
+	query := url.Values{
+		"spaceKey": {spaceKey},
+		"type":     {"page"},
+		"expand":   {"body.storage,version"},
+	}
+	return paginate(ctx, c, "/rest/api/content", query, visit)
+}
+
+// getPageVersion returns a historical version of a page.
+func (c *client) getPageVersion(ctx context.Context, pageID string, number int) (content, error) {
+	query := url.Values{
+		"status":  {"historical"},
+		"version": {fmt.Sprint(number)},
+		"expand":  {"body.storage,version"},
+	}
+	var page content
+	err := c.get(ctx, "/rest/api/content/"+url.PathEscape(pageID)+"?"+query.Encode(), &page)
+	return page, err
+}
+
+// listAttachments visits the attachments of a page.
+func (c *client) listAttachments(ctx context.Context, pageID string, visit func(content) error) error {
+	query := url.Values{"expand": {"version"}}
+	return paginate(ctx, c, "/rest/api/content/"+url.PathEscape(pageID)+"/child/attachment", query, visit)
+}
+
+// download returns the content of an attachment. The caller must close the
+// returned reader.
+func (c *client) download(ctx context.Context, a content) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, a.Links.Download)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
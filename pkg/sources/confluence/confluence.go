@@ -0,0 +1,291 @@
+package confluence
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common/glob"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/log"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/roundtripper"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+const SourceType = sourcespb.SourceType_SOURCE_TYPE_CONFLUENCE
+
+// Source scans the pages of a Confluence Cloud or Confluence Data Center
+// instance, including every historical version and, optionally, attachments.
+type Source struct {
+	name     string
+	sourceID sources.SourceID
+	jobID    sources.JobID
+	verify   bool
+
+	// endpoint is the Confluence URL without a trailing slash. For Confluence
+	// Cloud it includes the /wiki path.
+	endpoint           string
+	spacesScope        sourcespb.Confluence_GetAllSpacesScope
+	spaces             []string
+	ignore             func(spaceKey string) bool
+	includeAttachments bool
+	skipHistory        bool
+	client             *client
+
+	jobPool *errgroup.Group
+	sources.Progress
+	sources.CommonSourceUnitUnmarshaller
+}
+
+// Ensure the Source satisfies the interfaces at compile time.
+var _ sources.Source = (*Source)(nil)
+var _ sources.SourceUnitUnmarshaller = (*Source)(nil)
+var _ sources.SourceUnitEnumChunker = (*Source)(nil)
+
+// Type returns the type of source.
+// It is used for matching source types in configuration and job input.
+func (s *Source) Type() sourcespb.SourceType {
+	return SourceType
+}
+
+func (s *Source) SourceID() sources.SourceID {
+	return s.sourceID
+}
+
+func (s *Source) JobID() sources.JobID {
+	return s.jobID
+}
+
+// Init returns an initialized Confluence source.
+func (s *Source) Init(ctx context.Context, name string, jobId sources.JobID, sourceId sources.SourceID, verify bool, connection *anypb.Any, concurrency int) error {
+	s.name = name
+	s.sourceID = sourceId
+	s.jobID = jobId
+	s.verify = verify
+	s.jobPool = &errgroup.Group{}
+	s.jobPool.SetLimit(concurrency)
+
+	var conn sourcespb.Confluence
+	if err := anypb.UnmarshalTo(connection, &conn, proto.UnmarshalOptions{}); err != nil {
+		return fmt.Errorf("error unmarshalling connection: %w", err)
+	}
+	if conn.GetEndpoint() == "" {
+		return fmt.Errorf("a Confluence endpoint is required")
+	}
+	s.endpoint = strings.TrimSuffix(conn.GetEndpoint(), "/")
+	s.spacesScope = conn.GetSpacesScope()
+	s.spaces = conn.GetSpaces()
+	s.includeAttachments = conn.GetIncludeAttachments()
+	s.skipHistory = conn.GetSkipHistory()
+
+	var authorize func(*http.Request)
+	switch cred := conn.GetCredential().(type) {
+	case *sourcespb.Confluence_BasicAuth:
+		user, password := cred.BasicAuth.GetUsername(), cred.BasicAuth.GetPassword()
+		log.RedactGlobally(password)
+		authorize = func(req *http.Request) { req.SetBasicAuth(user, password) }
+	case *sourcespb.Confluence_Token:
+		token := cred.Token
+		log.RedactGlobally(token)
+		authorize = func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) }
+	case *sourcespb.Confluence_Unauthenticated:
+	default:
+		return fmt.Errorf("invalid configuration given for source %q (%s)", name, s.Type().String())
+	}
+
+	var clientOpts []common.ClientOption
+	if conn.GetInsecureSkipVerifyTls() {
+		clientOpts = append(clientOpts, common.WithTransport(roundtripper.NewRoundTripper(nil, roundtripper.WithInsecureTLS())))
+	}
+	httpClient := common.RetryableHTTPClientTimeout(60, clientOpts...)
+	s.client = &client{httpClient: httpClient, baseURL: s.endpoint, authorize: authorize}
+
+	s.ignore = glob.BuildIgnorer(nil, conn.GetIgnoreSpaces(), func(err error, pattern string) {
+		ctx.Logger().Error(err, "could not compile space glob", "glob", pattern)
+	}, glob.IgnoreCase())
+	return nil
+}
+
+// scopeSpaceType maps the spaces scope to the space type filter of the API.
+func scopeSpaceType(scope sourcespb.Confluence_GetAllSpacesScope) string {
+	switch scope {
+	case sourcespb.Confluence_GLOBAL:
+		return "global"
+	case sourcespb.Confluence_PERSONAL:
+		return "personal"
+	default:
+		return ""
+	}
+}
+
+// Enumerate reports the configured spaces, or every space in the spaces
+// scope, that are not ignored. Units are identified by space key.
+func (s *Source) Enumerate(ctx context.Context, reporter sources.UnitReporter) error {
+	confluenceSpacesEnumerated.WithLabelValues(s.name).Set(0)
+	report := func(key string) error {
+		if s.ignore(key) {
+			return nil
+		}
+		if err := reporter.UnitOk(ctx, sources.CommonSourceUnit{ID: key}); err != nil {
+			return err
+		}
+		confluenceSpacesEnumerated.WithLabelValues(s.name).Inc()
+		return nil
+	}
+
+	if len(s.spaces) > 0 {
+		for _, key := range s.spaces {
+			if err := report(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var reportErr error
+	err := s.client.listSpaces(ctx, scopeSpaceType(s.spacesScope), func(sp space) error {
+		reportErr = report(sp.Key)
+		return reportErr
+	})
+	if reportErr != nil {
+		return reportErr
+	}
+	if err != nil {
+		return reporter.UnitErr(ctx, fmt.Errorf("could not list spaces: %w", err))
+	}
+	return nil
+}
+
+// ChunkUnit scans every page of the space.
+func (s *Source) ChunkUnit(ctx context.Context, unit sources.SourceUnit, reporter sources.ChunkReporter) error {
+	spaceKey, _ := unit.SourceUnitID()
+	ctx = context.WithValue(ctx, "space", spaceKey)
+
+	var reportErr error
+	err := s.client.listPages(ctx, spaceKey, func(page content) error {
+		if reportErr = s.chunkPage(ctx, spaceKey, page, reporter); reportErr != nil {
+			return reportErr
+		}
+		confluencePagesScanned.WithLabelValues(s.name).Inc()
+		return nil
+	})
+	if reportErr != nil {
+		return reportErr
+	}
+	if err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not list pages: %w", err))
+	}
+	confluenceSpacesScanned.WithLabelValues(s.name).Inc()
+	return nil
+}
+
+// chunkPage reports the current version of a page, its previous versions
+// unless history is skipped, and its attachments if they are included. Only
+// errors returned by the reporter are returned.
+func (s *Source) chunkPage(ctx context.Context, spaceKey string, page content, reporter sources.ChunkReporter) error {
+	if err := s.reportVersion(ctx, spaceKey, page, s.endpoint+page.Links.WebUI, reporter); err != nil {
+		return err
+	}
+
+	if !s.skipHistory {
+		for number := page.Version.Number - 1; number >= 1; number-- {
+			old, err := s.client.getPageVersion(ctx, page.ID, number)
+			if err != nil {
+				if err := reporter.ChunkErr(ctx, fmt.Errorf("could not get version %d of page %s: %w", number, page.ID, err)); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := s.reportVersion(ctx, spaceKey, old, s.versionLink(page.ID, number), reporter); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !s.includeAttachments {
+		return nil
+	}
+	var reportErr error
+	err := s.client.listAttachments(ctx, page.ID, func(a content) error {
+		reportErr = s.chunkAttachment(ctx, spaceKey, page, a, reporter)
+		return reportErr
+	})
+	if reportErr != nil {
+		return reportErr
+	}
+	if err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not list attachments of page %s: %w", page.ID, err))
+	}
+	return nil
+}
+
+// versionLink returns the link to a specific version of a page.
+func (s *Source) versionLink(pageID string, number int) string {
+	query := url.Values{"pageId": {pageID}, "pageVersion": {strconv.Itoa(number)}}
+	return s.endpoint + "/pages/viewpage.action?" + query.Encode()
+}
+
+func (s *Source) reportVersion(ctx context.Context, spaceKey string, page content, link string, reporter sources.ChunkReporter) error {
+	text := storageToText(page.Body.Storage.Value)
+	if text == "" {
+		return nil
+	}
+	chunk := s.chunkSkel(spaceKey, page, page.Version, "page", "", link)
+	chunk.Data = []byte(page.Title + "\n" + text)
+	return reporter.ChunkOk(ctx, chunk)
+}
+
+func (s *Source) chunkAttachment(ctx context.Context, spaceKey string, page, a content, reporter sources.ChunkReporter) error {
+	body, err := s.client.download(ctx, a)
+	if err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not download attachment %q of page %s: %w", a.Title, page.ID, err))
+	}
+	defer body.Close()
+
+	chunkSkel := s.chunkSkel(spaceKey, page, a.Version, "attachment", a.Title, s.endpoint+a.Links.Download)
+	if err := handlers.HandleFile(ctx, body, &chunkSkel, reporter); err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not scan attachment %q of page %s: %w", a.Title, page.ID, err))
+	}
+	return nil
+}
+
+func (s *Source) chunkSkel(spaceKey string, page content, v version, location, file, link string) sources.Chunk {
+	return sources.Chunk{
+		SourceName: s.name,
+		SourceID:   s.SourceID(),
+		JobID:      s.JobID(),
+		SourceType: s.Type(),
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Confluence{
+				Confluence: &source_metadatapb.Confluence{
+					Page:      sanitizer.UTF8(page.Title),
+					Space:     sanitizer.UTF8(spaceKey),
+					Version:   strconv.Itoa(v.Number),
+					Link:      sanitizer.UTF8(link),
+					Email:     sanitizer.UTF8(v.By.Email),
+					Timestamp: sanitizer.UTF8(v.When),
+					Location:  location,
+					File:      sanitizer.UTF8(file),
+				},
+			},
+		},
+		Verify: s.verify,
+	}
+}
+
+// Chunks emits chunks of bytes over a channel.
+func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ ...sources.ChunkingTarget) error {
+	confluenceSpacesScanned.WithLabelValues(s.name).Set(0)
+	return sources.ChunkUnits(ctx, s, s.jobPool, &s.Progress, "Space", chunksChan)
+}
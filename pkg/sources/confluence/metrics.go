@@ -0,0 +1,34 @@
+package confluence
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+)
+
+var (
+	confluenceSpacesEnumerated = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "confluence_spaces_enumerated",
+		Help:      "Total number of Confluence spaces enumerated.",
+	},
+		[]string{"source_name"})
+
+	confluenceSpacesScanned = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "confluence_spaces_scanned",
+		Help:      "Total number of Confluence spaces scanned.",
+	},
+		[]string{"source_name"})
+
+	confluencePagesScanned = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "confluence_pages_scanned",
+		Help:      "Total number of Confluence pages scanned, including their history.",
+	},
+		[]string{"source_name"})
+)
@@ -0,0 +1,62 @@
+package confluence
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// blockElements end a line of text when they are opened or closed.
+var blockElements = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "tr": true, "td": true, "th": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"pre": true, "blockquote": true, "table": true, "hr": true,
+	"ac:structured-macro": true, "ac:parameter": true, "ac:plain-text-body": true, "ac:rich-text-body": true,
+}
+
+// structuralAttributes only name or lay out elements, so their values are
+// left out of the text.
+var structuralAttributes = map[string]bool{
+	"ac:name": true, "ac:schema-version": true, "ac:macro-id": true, "ac:local-id": true,
+	"local-id": true, "class": true, "style": true,
+}
+
+// storageToText converts a page body in Confluence storage format, which is
+// XHTML with Confluence-specific elements, to plain text. Markup is dropped,
+// entities are decoded and the bodies of code macros, which are held in CDATA
+// sections, are kept verbatim. Attribute values, such as link targets and
+// macro parameters given as attributes, are kept on lines of their own.
+func storageToText(storage string) string {
+	z := html.NewTokenizer(strings.NewReader(storage))
+	z.AllowCDATA(true)
+
+	var b strings.Builder
+	newline := func() {
+		if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+			b.WriteByte('\n')
+		}
+	}
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return strings.TrimSpace(b.String())
+		case html.TextToken:
+			b.Write(z.Text())
+		case html.StartTagToken, html.EndTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if blockElements[string(name)] {
+				newline()
+			}
+			for hasAttr {
+				var key, value []byte
+				key, value, hasAttr = z.TagAttr()
+				if len(value) == 0 || structuralAttributes[string(key)] {
+					continue
+				}
+				newline()
+				b.Write(value)
+				b.WriteByte('\n')
+			}
+		}
+	}
+}
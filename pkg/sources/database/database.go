@@ -0,0 +1,442 @@
+package database
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common/glob"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+const (
+	SourceType = sourcespb.SourceType_SOURCE_TYPE_DATABASE
+
+	defaultBatchSize = 1000
+)
+
+// Source scans the text, JSON and binary columns of the tables of a
+// PostgreSQL or MySQL database, one table at a time.
+//
+// Rows are read in batches ordered by primary key, so memory use is bounded
+// by the batch size whatever the size of the table. Tables without a primary
+// key are read with a single query whose rows are streamed, as neither
+// database has a row ID that is stable and cheap to page by.
+type Source struct {
+	name     string
+	sourceID sources.SourceID
+	jobID    sources.JobID
+	verify   bool
+
+	db       *sql.DB
+	dialect  dialect
+	database string
+	// ignoreTable reports whether a table, as schema.table, is skipped.
+	ignoreTable   func(name string) bool
+	sampleRows    int64
+	batchSize     int64
+	updatedColumn string
+	updatedSince  time.Time
+
+	jobPool *errgroup.Group
+	sources.Progress
+	sources.CommonSourceUnitUnmarshaller
+}
+
+// Ensure the Source satisfies the interfaces at compile time.
+var _ sources.Source = (*Source)(nil)
+var _ sources.SourceUnitUnmarshaller = (*Source)(nil)
+var _ sources.SourceUnitEnumChunker = (*Source)(nil)
+var _ io.Closer = (*Source)(nil)
+
+// Type returns the type of source.
+// It is used for matching source types in configuration and job input.
+func (s *Source) Type() sourcespb.SourceType {
+	return SourceType
+}
+
+func (s *Source) SourceID() sources.SourceID {
+	return s.sourceID
+}
+
+func (s *Source) JobID() sources.JobID {
+	return s.jobID
+}
+
+// Init returns an initialized database source. No connection is made until
+// the scan starts.
+func (s *Source) Init(_ context.Context, name string, jobId sources.JobID, sourceId sources.SourceID, verify bool, connection *anypb.Any, concurrency int) error {
+	s.name = name
+	s.sourceID = sourceId
+	s.jobID = jobId
+	s.verify = verify
+	s.jobPool = &errgroup.Group{}
+	s.jobPool.SetLimit(concurrency)
+
+	var conn sourcespb.Database
+	if err := anypb.UnmarshalTo(connection, &conn, proto.UnmarshalOptions{}); err != nil {
+		return fmt.Errorf("error unmarshalling connection: %w", err)
+	}
+
+	var err error
+	if s.dialect, err = dialectFor(conn.GetDriver()); err != nil {
+		return err
+	}
+	if conn.GetConnectionString() == "" {
+		return errors.New("a database connection string is required")
+	}
+	redactConnectionString(conn.GetDriver(), conn.GetConnectionString())
+	if s.db, err = sql.Open(s.dialect.driverName, conn.GetConnectionString()); err != nil {
+		return fmt.Errorf("could not open database: %w", err)
+	}
+	if concurrency > 0 {
+		s.db.SetMaxOpenConns(concurrency)
+	}
+
+	var globErr error
+	s.ignoreTable = glob.BuildIgnorer(conn.GetIncludeTables(), conn.GetExcludeTables(), func(err error, pattern string) {
+		globErr = errors.Join(globErr, fmt.Errorf("invalid glob %q: %w", pattern, err))
+	}, glob.WithSeparators('.'))
+	if globErr != nil {
+		return globErr
+	}
+	s.sampleRows = conn.GetSampleRows()
+	s.batchSize = conn.GetBatchSize()
+	if s.batchSize <= 0 {
+		s.batchSize = defaultBatchSize
+	}
+	s.updatedColumn = conn.GetUpdatedColumn()
+	if conn.GetUpdatedSince() != nil {
+		s.updatedSince = conn.GetUpdatedSince().AsTime()
+	}
+	return nil
+}
+
+// Close closes the connections to the database.
+func (s *Source) Close() error {
+	return s.db.Close()
+}
+
+// Enumerate reports the tables of the database that are not filtered out.
+// Units are identified by schema.table.
+func (s *Source) Enumerate(ctx context.Context, reporter sources.UnitReporter) error {
+	databaseTablesEnumerated.WithLabelValues(s.name).Set(0)
+	if err := s.db.QueryRowContext(ctx, s.dialect.databaseQuery).Scan(&s.database); err != nil {
+		return reporter.UnitErr(ctx, fmt.Errorf("could not connect to database: %w", err))
+	}
+
+	rows, err := s.db.QueryContext(ctx, s.dialect.tablesQuery)
+	if err != nil {
+		return reporter.UnitErr(ctx, fmt.Errorf("could not list tables: %w", err))
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var schema, table string
+		if err := rows.Scan(&schema, &table); err != nil {
+			return reporter.UnitErr(ctx, fmt.Errorf("could not list tables: %w", err))
+		}
+		tables = append(tables, schema+"."+table)
+	}
+	if err := rows.Err(); err != nil {
+		return reporter.UnitErr(ctx, fmt.Errorf("could not list tables: %w", err))
+	}
+
+	for _, t := range tables {
+		if s.ignoreTable(t) {
+			continue
+		}
+		if err := reporter.UnitOk(ctx, sources.CommonSourceUnit{ID: t}); err != nil {
+			return err
+		}
+		databaseTablesEnumerated.WithLabelValues(s.name).Inc()
+	}
+	return nil
+}
+
+// column is a column of a table.
+type column struct {
+	name     string
+	dataType string
+	// keyPosition is the position of the column in the primary key, starting
+	// at 1, or 0 if it is not part of it.
+	keyPosition int
+}
+
+// table is the layout of a table needed to scan it.
+type table struct {
+	schema string
+	name   string
+	// key is the list of columns of the primary key, in order. It is empty
+	// if the table has none, and its rows cannot be read in batches.
+	key []string
+	// scanned is the list of text, JSON and binary columns.
+	scanned []string
+	// updated is the name of the column holding modification times, if the
+	// table has one.
+	updated string
+}
+
+func (s *Source) describeTable(ctx context.Context, schema, name string) (*table, error) {
+	rows, err := s.db.QueryContext(ctx, s.dialect.columnsQuery, schema, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []column
+	for rows.Next() {
+		var c column
+		if err := rows.Scan(&c.name, &c.dataType, &c.keyPosition); err != nil {
+			return nil, err
+		}
+		columns = append(columns, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table %s.%s not found", schema, name)
+	}
+
+	t := &table{schema: schema, name: name}
+	key := make(map[int]string)
+	for _, c := range columns {
+		if c.keyPosition > 0 {
+			key[c.keyPosition] = c.name
+		}
+		if s.dialect.scannable(c.dataType) {
+			t.scanned = append(t.scanned, c.name)
+		}
+		if s.updatedColumn != "" && strings.EqualFold(c.name, s.updatedColumn) {
+			t.updated = c.name
+		}
+	}
+	for i := 1; i <= len(key); i++ {
+		t.key = append(t.key, key[i])
+	}
+	return t, nil
+}
+
+// ChunkUnit scans the text, JSON and binary columns of a table.
+func (s *Source) ChunkUnit(ctx context.Context, unit sources.SourceUnit, reporter sources.ChunkReporter) error {
+	id, _ := unit.SourceUnitID()
+	ctx = context.WithValue(ctx, "table", id)
+	schema, name, ok := strings.Cut(id, ".")
+	if !ok {
+		return reporter.ChunkErr(ctx, fmt.Errorf("invalid table %q: expected schema.table", id))
+	}
+
+	t, err := s.describeTable(ctx, schema, name)
+	if err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not describe table %s: %w", id, err))
+	}
+	if len(t.scanned) == 0 {
+		ctx.Logger().V(2).Info("skipping table without text or binary columns")
+		return nil
+	}
+	if s.updatedColumn != "" && t.updated == "" {
+		ctx.Logger().V(2).Info("table has no updated column, scanning every row", "column", s.updatedColumn)
+	}
+
+	if err := s.scanTable(ctx, t, reporter); err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not scan table %s: %w", id, err))
+	}
+	databaseTablesScanned.WithLabelValues(s.name).Inc()
+	return nil
+}
+
+// scanTable reads the rows of a table in batches and scans their non-null
+// values. Only errors of the database or of the reporter are returned.
+func (s *Source) scanTable(ctx context.Context, t *table, reporter sources.ChunkReporter) error {
+	var (
+		// last holds the key of the last row read, after which the next
+		// batch starts.
+		last    []any
+		scanned int64
+	)
+	for {
+		limit := s.batchSize
+		if s.sampleRows > 0 {
+			limit = min(limit, s.sampleRows-scanned)
+			if limit <= 0 {
+				return nil
+			}
+		}
+
+		query, args := s.batchQuery(t, last, limit)
+		rows, err := s.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		n, lastKey, err := s.scanRows(ctx, t, rows, reporter)
+		rows.Close()
+		if err != nil {
+			return err
+		}
+
+		scanned += n
+		last = lastKey
+		if len(t.key) == 0 || n < limit {
+			return nil
+		}
+	}
+}
+
+// batchQuery returns the query and arguments of the batch of rows following
+// the row whose key is last. Tables without a key are read in one query, as
+// pages taken by offset without an order may skip or repeat rows; at most
+// sampleRows rows are read when set.
+func (s *Source) batchQuery(t *table, last []any, limit int64) (string, []any) {
+	q := s.dialect.quote
+	columns := make([]string, 0, len(t.key)+len(t.scanned))
+	for _, c := range append(append([]string{}, t.key...), t.scanned...) {
+		columns = append(columns, q(c))
+	}
+	key := columns[:len(t.key)]
+
+	var (
+		conditions []string
+		args       []any
+	)
+	if len(last) > 0 {
+		placeholders := make([]string, len(last))
+		for i := range last {
+			placeholders[i] = s.dialect.placeholder(len(args) + i + 1)
+		}
+		args = append(args, last...)
+		if len(key) == 1 {
+			conditions = append(conditions, key[0]+" > "+placeholders[0])
+		} else {
+			conditions = append(conditions, "("+strings.Join(key, ", ")+") > ("+strings.Join(placeholders, ", ")+")")
+		}
+	}
+	if t.updated != "" && !s.updatedSince.IsZero() {
+		args = append(args, s.dialect.timeArg(s.updatedSince))
+		conditions = append(conditions, q(t.updated)+" > "+s.dialect.placeholder(len(args)))
+	}
+
+	var query strings.Builder
+	fmt.Fprintf(&query, "SELECT %s FROM %s.%s", strings.Join(columns, ", "), q(t.schema), q(t.name))
+	if len(conditions) > 0 {
+		query.WriteString(" WHERE " + strings.Join(conditions, " AND "))
+	}
+	switch {
+	case len(key) > 0:
+		fmt.Fprintf(&query, " ORDER BY %s LIMIT %d", strings.Join(key, ", "), limit)
+	case s.sampleRows > 0:
+		fmt.Fprintf(&query, " LIMIT %d", s.sampleRows)
+	}
+	return query.String(), args
+}
+
+// scanRows scans a batch of rows and returns their number and the key of the
+// last one.
+func (s *Source) scanRows(ctx context.Context, t *table, rows *sql.Rows, reporter sources.ChunkReporter) (int64, []any, error) {
+	key := make([]any, len(t.key))
+	values := make([]sql.RawBytes, len(t.scanned))
+	dest := make([]any, 0, len(key)+len(values))
+	for i := range key {
+		dest = append(dest, &key[i])
+	}
+	for i := range values {
+		dest = append(dest, &values[i])
+	}
+
+	var n int64
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return n, nil, err
+		}
+		n++
+		primaryKey := formatKey(t.key, key)
+		for i, value := range values {
+			if len(value) == 0 {
+				continue
+			}
+			if err := s.chunkValue(ctx, t, primaryKey, t.scanned[i], value, reporter); err != nil {
+				return n, nil, err
+			}
+		}
+		databaseRowsScanned.WithLabelValues(s.name).Inc()
+	}
+	if err := rows.Err(); err != nil {
+		return n, nil, err
+	}
+	// Values scanned into any are copies, so they outlive the rows. Text is
+	// passed back as a string so that drivers do not bind it as binary data.
+	for i, v := range key {
+		if b, ok := v.([]byte); ok {
+			key[i] = string(b)
+		}
+	}
+	return n, key, nil
+}
+
+// formatKey formats the key of a row as column=value pairs, such as
+// "id=42" or "tenant=acme, id=7".
+func formatKey(columns []string, values []any) string {
+	pairs := make([]string, len(columns))
+	for i, c := range columns {
+		var value string
+		switch v := values[i].(type) {
+		case []byte:
+			value = string(v)
+		case time.Time:
+			value = v.Format(time.RFC3339Nano)
+		case nil:
+			value = "NULL"
+		default:
+			value = fmt.Sprint(v)
+		}
+		pairs[i] = c + "=" + value
+	}
+	return strings.Join(pairs, ", ")
+}
+
+func (s *Source) chunkValue(ctx context.Context, t *table, primaryKey, column string, value []byte, reporter sources.ChunkReporter) error {
+	chunkSkel := &sources.Chunk{
+		SourceName: s.name,
+		SourceID:   s.SourceID(),
+		JobID:      s.JobID(),
+		SourceType: s.Type(),
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Database{
+				Database: &source_metadatapb.Database{
+					Database:   sanitizer.UTF8(s.database),
+					Table:      sanitizer.UTF8(t.schema + "." + t.name),
+					PrimaryKey: sanitizer.UTF8(primaryKey),
+					Column:     sanitizer.UTF8(column),
+				},
+			},
+		},
+		Verify: s.verify,
+	}
+	// The value is only valid until the next row is read, and HandleFile
+	// returns once it has been read.
+	if err := handlers.HandleFile(ctx, bytes.NewReader(value), chunkSkel, reporter); err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not scan %s.%s %s (%s): %w", t.schema, t.name, column, primaryKey, err))
+	}
+	return nil
+}
+
+// Chunks emits chunks of bytes over a channel.
+func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ ...sources.ChunkingTarget) error {
+	databaseTablesScanned.WithLabelValues(s.name).Set(0)
+	databaseRowsScanned.WithLabelValues(s.name).Set(0)
+	return sources.ChunkUnits(ctx, s, s.jobPool, &s.Progress, "Table", chunksChan)
+}
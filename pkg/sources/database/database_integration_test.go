@@ -0,0 +1,248 @@
+//go:build integration
+// +build integration
+
+package database
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/mysql"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sourcestest"
+)
+
+// testIntegrationSource creates the tables of the test on a database server
+// and returns a source scanning it with the options of conn.
+func testIntegrationSource(t *testing.T, conn *sourcespb.Database, statements ...string) *Source {
+	t.Helper()
+	d, err := dialectFor(conn.Driver)
+	require.NoError(t, err)
+	db, err := sql.Open(d.driverName, conn.ConnectionString)
+	require.NoError(t, err)
+	for _, stmt := range statements {
+		_, err := db.Exec(stmt)
+		require.NoError(t, err, stmt)
+	}
+	require.NoError(t, db.Close())
+
+	connection, err := anypb.New(conn)
+	require.NoError(t, err)
+	s := &Source{}
+	require.NoError(t, s.Init(context.Background(), "test", 0, 0, false, connection, 1))
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// startPostgres starts a PostgreSQL server with an app database and returns
+// its connection string.
+func startPostgres(t *testing.T) string {
+	t.Helper()
+	ctx := context.Background()
+	container, err := postgres.Run(ctx, "postgres:13-alpine",
+		postgres.WithDatabase("app"),
+		postgres.WithUsername("trufflehog"),
+		postgres.WithPassword("trufflehog"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").WithOccurrence(2).WithStartupTimeout(time.Minute),
+		),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+	connectionString, err := container.ConnectionString(ctx, "sslmode=disable")
+	require.NoError(t, err)
+	return connectionString
+}
+
+func enumerate(t *testing.T, s *Source) []string {
+	t.Helper()
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+	require.Empty(t, reporter.UnitErrs)
+	var ids []string
+	for _, unit := range reporter.Units {
+		id, _ := unit.SourceUnitID()
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// chunkTable scans a table and returns the data of its chunks keyed by
+// primary key and column.
+func chunkTable(t *testing.T, s *Source, table string) map[string]string {
+	t.Helper()
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.ChunkUnit(context.Background(), sources.CommonSourceUnit{ID: table}, &reporter))
+	require.Empty(t, reporter.ChunkErrs)
+	chunks := make(map[string]string, len(reporter.Chunks))
+	for _, c := range reporter.Chunks {
+		meta := c.SourceMetadata.GetDatabase()
+		assert.Equal(t, "app", meta.GetDatabase())
+		assert.Equal(t, table, meta.GetTable())
+		chunks[meta.GetPrimaryKey()+" "+meta.GetColumn()] += string(c.Data)
+	}
+	return chunks
+}
+
+func TestSource_Postgres(t *testing.T) {
+	connectionString := startPostgres(t)
+	s := testIntegrationSource(t, &sourcespb.Database{Driver: "postgres", ConnectionString: connectionString, BatchSize: 2},
+		`CREATE SCHEMA billing`,
+		`CREATE TABLE billing.integrations (id SERIAL PRIMARY KEY, token TEXT, settings JSONB, certificate BYTEA, retries INT)`,
+		`INSERT INTO billing.integrations (token, settings, certificate, retries) VALUES
+			('token-1', '{"webhook": "hook-1"}', NULL, 1), ('token-2', NULL, 'cert-2', 2), ('token-3', NULL, NULL, 3)`,
+		`CREATE TABLE public.audit_log (message VARCHAR(64))`,
+		`INSERT INTO public.audit_log VALUES ('log-1'), ('log-2'), ('log-3')`,
+	)
+	assert.Equal(t, []string{"billing.integrations", "public.audit_log"}, enumerate(t, s))
+	assert.Equal(t, "app", s.database)
+
+	chunks := chunkTable(t, s, "billing.integrations")
+	assert.Equal(t, map[string]string{
+		"id=1 token":       "token-1",
+		"id=1 settings":    `{"webhook": "hook-1"}`,
+		"id=2 token":       "token-2",
+		"id=2 certificate": "cert-2",
+		"id=3 token":       "token-3",
+	}, chunks)
+	assert.Len(t, chunkTable(t, s, "public.audit_log"), 3)
+}
+
+func TestSource_MySQL(t *testing.T) {
+	ctx := context.Background()
+	container, err := mysql.Run(ctx, "mysql:8.0",
+		mysql.WithDatabase("app"),
+		mysql.WithUsername("root"),
+		mysql.WithPassword("trufflehog"),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+	connectionString, err := container.ConnectionString(ctx)
+	require.NoError(t, err)
+
+	s := testIntegrationSource(t, &sourcespb.Database{Driver: "mysql", ConnectionString: connectionString, BatchSize: 2},
+		`CREATE TABLE integrations (id INT AUTO_INCREMENT PRIMARY KEY, token TEXT, settings JSON, certificate BLOB, retries INT)`,
+		`INSERT INTO integrations (token, settings, certificate, retries) VALUES
+			('token-1', '{"webhook": "hook-1"}', NULL, 1), ('token-2', NULL, 'cert-2', 2), ('token-3', NULL, NULL, 3)`,
+		`CREATE TABLE audit_log (message VARCHAR(64))`,
+		`INSERT INTO audit_log VALUES ('log-1'), ('log-2'), ('log-3')`,
+	)
+	assert.Equal(t, []string{"app.audit_log", "app.integrations"}, enumerate(t, s))
+
+	chunks := chunkTable(t, s, "app.integrations")
+	assert.Equal(t, map[string]string{
+		"id=1 token":       "token-1",
+		"id=1 settings":    `{"webhook": "hook-1"}`,
+		"id=2 token":       "token-2",
+		"id=2 certificate": "cert-2",
+		"id=3 token":       "token-3",
+	}, chunks)
+
+	// Tables without a primary key are read in one query, and their rows
+	// have no key to report.
+	chunks = chunkTable(t, s, "app.audit_log")
+	assert.Equal(t, map[string]string{" message": "log-1log-2log-3"}, chunks)
+}
+
+var tablesSchema = []string{
+	`CREATE TABLE integrations (
+		id INTEGER PRIMARY KEY,
+		name VARCHAR(64),
+		token TEXT,
+		settings JSON,
+		certificate BYTEA,
+		retries INTEGER,
+		updated_at TIMESTAMP
+	)`,
+	`INSERT INTO integrations VALUES
+		(1, 'slack', 'token-1', '{"webhook":"hook-1"}', NULL, 3, '2024-01-01 10:00:00'),
+		(2, 'github', 'token-2', NULL, '\x636572742d32', 3, '2024-03-01 10:00:00'),
+		(3, 'stripe', NULL, NULL, NULL, 1, '2024-05-01 10:00:00'),
+		(4, 'twilio', 'token-4', NULL, NULL, 0, '2024-05-02 10:00:00'),
+		(5, 'sendgrid', 'token-5', NULL, NULL, 0, '2024-05-03 10:00:00')`,
+	`CREATE TABLE tenant_keys (tenant TEXT, seq INTEGER, secret TEXT, PRIMARY KEY (tenant, seq))`,
+	`INSERT INTO tenant_keys VALUES ('acme', 2, 'acme-2'), ('acme', 1, 'acme-1'), ('beta', 1, 'beta-1')`,
+	`CREATE TABLE audit_log (message TEXT)`,
+	`INSERT INTO audit_log VALUES ('log-1'), ('log-2'), ('log-3')`,
+	`CREATE TABLE counters (id INTEGER PRIMARY KEY, value INTEGER)`,
+	`INSERT INTO counters VALUES (1, 42)`,
+}
+
+func TestSource_PostgresTables(t *testing.T) {
+	connectionString := startPostgres(t)
+	s := testIntegrationSource(t, &sourcespb.Database{Driver: "postgres", ConnectionString: connectionString, BatchSize: 2}, tablesSchema...)
+	assert.Equal(t, []string{"public.audit_log", "public.counters", "public.integrations", "public.tenant_keys"}, enumerate(t, s))
+
+	// Batches of two rows exercise paging by primary key.
+	assert.Equal(t, map[string]string{
+		"id=1 name":        "slack",
+		"id=1 token":       "token-1",
+		"id=1 settings":    `{"webhook":"hook-1"}`,
+		"id=2 name":        "github",
+		"id=2 token":       "token-2",
+		"id=2 certificate": "cert-2",
+		"id=3 name":        "stripe",
+		"id=4 name":        "twilio",
+		"id=4 token":       "token-4",
+		"id=5 name":        "sendgrid",
+		"id=5 token":       "token-5",
+	}, chunkTable(t, s, "public.integrations"))
+
+	chunks := chunkTable(t, s, "public.tenant_keys")
+	assert.Equal(t, "acme-1", chunks["tenant=acme, seq=1 secret"])
+	assert.Equal(t, "acme-2", chunks["tenant=acme, seq=2 secret"])
+	assert.Equal(t, "beta-1", chunks["tenant=beta, seq=1 secret"])
+
+	// Tables without a primary key are read in one query, whatever the
+	// batch size.
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.ChunkUnit(context.Background(), sources.CommonSourceUnit{ID: "public.audit_log"}, &reporter))
+	require.Empty(t, reporter.ChunkErrs)
+	var messages []string
+	for _, c := range reporter.Chunks {
+		assert.Empty(t, c.SourceMetadata.GetDatabase().GetPrimaryKey())
+		messages = append(messages, string(c.Data))
+	}
+	assert.ElementsMatch(t, []string{"log-1", "log-2", "log-3"}, messages)
+
+	assert.Empty(t, chunkTable(t, s, "public.counters"))
+
+	filtered := testIntegrationSource(t, &sourcespb.Database{
+		Driver:           "postgres",
+		ConnectionString: connectionString,
+		IncludeTables:    []string{"public.*s"},
+		ExcludeTables:    []string{"*.tenant_*"},
+	})
+	assert.Equal(t, []string{"public.counters", "public.integrations"}, enumerate(t, filtered))
+
+	sampled := testIntegrationSource(t, &sourcespb.Database{Driver: "postgres", ConnectionString: connectionString, BatchSize: 2, SampleRows: 3})
+	chunks = chunkTable(t, sampled, "public.integrations")
+	assert.Contains(t, chunks, "id=3 name")
+	assert.NotContains(t, chunks, "id=4 name")
+
+	updated := testIntegrationSource(t, &sourcespb.Database{
+		Driver:           "postgres",
+		ConnectionString: connectionString,
+		UpdatedColumn:    "updated_at",
+		UpdatedSince:     timestamppb.New(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)),
+	})
+	assert.Equal(t, map[string]string{
+		"id=4 name":  "twilio",
+		"id=4 token": "token-4",
+		"id=5 name":  "sendgrid",
+		"id=5 token": "token-5",
+	}, chunkTable(t, updated, "public.integrations"))
+	// Tables without the column are scanned in full.
+	assert.Len(t, chunkTable(t, updated, "public.audit_log"), 1)
+}
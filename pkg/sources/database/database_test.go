@@ -0,0 +1,32 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchQuery(t *testing.T) {
+	since := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	tbl := &table{schema: "public", name: "keys", key: []string{"tenant", "id"}, scanned: []string{"secret"}, updated: "updated_at"}
+
+	s := &Source{dialect: postgresDialect, updatedColumn: "updated_at", updatedSince: since}
+	query, args := s.batchQuery(tbl, []any{"acme", int64(7)}, 100)
+	assert.Equal(t, `SELECT "tenant", "id", "secret" FROM "public"."keys" WHERE ("tenant", "id") > ($1, $2) AND "updated_at" > $3 ORDER BY "tenant", "id" LIMIT 100`, query)
+	assert.Equal(t, []any{"acme", int64(7), since}, args)
+
+	// Tables without a primary key are read in one query.
+	s = &Source{dialect: postgresDialect}
+	query, _ = s.batchQuery(&table{schema: "public", name: "logs", scanned: []string{"message"}}, nil, 100)
+	assert.Equal(t, `SELECT "message" FROM "public"."logs"`, query)
+
+	s = &Source{dialect: mysqlDialect}
+	query, args = s.batchQuery(&table{schema: "app", name: "logs", scanned: []string{"message"}}, nil, 100)
+	assert.Equal(t, "SELECT `message` FROM `app`.`logs`", query)
+	assert.Empty(t, args)
+
+	s = &Source{dialect: mysqlDialect, sampleRows: 250}
+	query, _ = s.batchQuery(&table{schema: "app", name: "logs", scanned: []string{"message"}}, nil, 100)
+	assert.Equal(t, "SELECT `message` FROM `app`.`logs` LIMIT 250", query)
+}
@@ -0,0 +1,130 @@
+package database
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/log"
+)
+
+// dialect holds what differs between the supported databases: the name of
+// their database/sql driver, their catalog queries and their SQL syntax.
+type dialect struct {
+	driverName string
+	// databaseQuery returns the name of the database connected to.
+	databaseQuery string
+	// tablesQuery returns the schema and name of every table, in order.
+	tablesQuery string
+	// columnsQuery returns the name, type and position in the primary key,
+	// zero if not part of it, of every column of a table given its schema
+	// and name.
+	columnsQuery string
+	quote        func(identifier string) string
+	placeholder  func(n int) string
+	// scannable reports whether a column of the given type can hold text or
+	// binary data.
+	scannable func(dataType string) bool
+	// timeArg converts a time to the argument compared with a column holding
+	// modification times.
+	timeArg func(t time.Time) any
+}
+
+// primaryKeyColumnsQuery is the information_schema query shared by PostgreSQL
+// and MySQL, with ? placeholders.
+const primaryKeyColumnsQuery = `
+SELECT c.column_name, c.data_type, COALESCE(k.ordinal_position, 0)
+FROM information_schema.columns c
+LEFT JOIN information_schema.table_constraints t
+	ON t.table_schema = c.table_schema AND t.table_name = c.table_name AND t.constraint_type = 'PRIMARY KEY'
+LEFT JOIN information_schema.key_column_usage k
+	ON k.constraint_schema = t.constraint_schema AND k.constraint_name = t.constraint_name
+	AND k.table_schema = c.table_schema AND k.table_name = c.table_name AND k.column_name = c.column_name
+WHERE c.table_schema = ? AND c.table_name = ?
+ORDER BY c.ordinal_position`
+
+var (
+	postgresDialect = dialect{
+		driverName:    "postgres",
+		databaseQuery: `SELECT current_database()`,
+		tablesQuery: `
+SELECT table_schema, table_name FROM information_schema.tables
+WHERE table_type = 'BASE TABLE' AND table_schema NOT IN ('pg_catalog', 'information_schema')
+ORDER BY table_schema, table_name`,
+		columnsQuery: strings.NewReplacer("c.table_schema = ?", "c.table_schema = $1", "c.table_name = ?", "c.table_name = $2").Replace(primaryKeyColumnsQuery),
+		quote:        doubleQuote,
+		placeholder:  func(n int) string { return fmt.Sprintf("$%d", n) },
+		scannable:    oneOf("text", "character varying", "character", "json", "jsonb", "bytea", "xml"),
+		timeArg:      func(t time.Time) any { return t },
+	}
+
+	mysqlDialect = dialect{
+		driverName:    "mysql",
+		databaseQuery: `SELECT COALESCE(DATABASE(), '')`,
+		tablesQuery: `
+SELECT table_schema, table_name FROM information_schema.tables
+WHERE table_type = 'BASE TABLE' AND table_schema NOT IN ('mysql', 'information_schema', 'performance_schema', 'sys')
+ORDER BY table_schema, table_name`,
+		columnsQuery: primaryKeyColumnsQuery,
+		quote: func(identifier string) string {
+			return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+		},
+		placeholder: func(int) string { return "?" },
+		scannable: oneOf("char", "varchar", "tinytext", "text", "mediumtext", "longtext", "json",
+			"binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob"),
+		timeArg: func(t time.Time) any { return t },
+	}
+)
+
+func dialectFor(driver string) (dialect, error) {
+	switch driver {
+	case "postgres":
+		return postgresDialect, nil
+	case "mysql":
+		return mysqlDialect, nil
+	default:
+		return dialect{}, fmt.Errorf("unsupported database driver %q: expected postgres or mysql", driver)
+	}
+}
+
+func doubleQuote(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+func oneOf(types ...string) func(string) bool {
+	return func(dataType string) bool {
+		for _, t := range types {
+			if strings.EqualFold(dataType, t) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// redactConnectionString redacts the password of a connection string from
+// logs.
+func redactConnectionString(driver, connectionString string) {
+	switch driver {
+	case "postgres":
+		if u, err := url.Parse(connectionString); err == nil && u.User != nil {
+			password, _ := u.User.Password()
+			log.RedactGlobally(password)
+			return
+		}
+		// Key/value connection strings, such as "host=db password=secret".
+		for _, field := range strings.Fields(connectionString) {
+			if value, ok := strings.CutPrefix(field, "password="); ok {
+				log.RedactGlobally(strings.Trim(value, "'"))
+			}
+		}
+	case "mysql":
+		if cfg, err := mysql.ParseDSN(connectionString); err == nil {
+			log.RedactGlobally(cfg.Passwd)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+)
+
+var (
+	databaseTablesEnumerated = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "database_tables_enumerated",
+		Help:      "Total number of database tables enumerated.",
+	},
+		[]string{"source_name"})
+
+	databaseTablesScanned = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "database_tables_scanned",
+		Help:      "Total number of database tables scanned.",
+	},
+		[]string{"source_name"})
+
+	databaseRowsScanned = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "database_rows_scanned",
+		Help:      "Total number of database rows scanned.",
+	},
+		[]string{"source_name"})
+)
@@ -0,0 +1,310 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types/system"
+	"github.com/docker/docker/client"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+const (
+	// containerdSnapshotter is the driver type the Docker engine reports when
+	// it keeps images in containerd rather than in a storage driver.
+	containerdSnapshotter = "io.containerd.snapshotter.v1"
+	// daemonNamespace is the containerd namespace of the Docker engine.
+	daemonNamespace = "moby"
+)
+
+// daemonImage reads an image of the Docker engine, as found by the usual
+// DOCKER_HOST environment. When the engine runs on this host and its storage
+// is readable, the engine is just asked where the image is stored, and its
+// layers are read from there: the containerd content store when the engine
+// uses it, otherwise the layer directories of the overlay2 storage driver.
+// Otherwise, as for a remote engine, Docker Desktop or a rootless setup, the
+// image is streamed from the engine's export.
+func (s *Source) daemonImage(ctx context.Context, image string) (v1.Image, error) {
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("error creating docker client: %w", err)
+	}
+	// Closing the client only drops its idle connections, so an export can
+	// still be requested once the image is returned.
+	defer cli.Close()
+
+	img, err := s.daemonStorageImage(ctx, cli, image)
+	if err == nil {
+		return img, nil
+	}
+	ctx.Logger().V(2).Info("exporting image from docker: storage not readable", "image", image, "error", err)
+	return savedImage(ctx, cli, image)
+}
+
+// daemonStorageImage reads an image from the storage of the Docker engine.
+func (s *Source) daemonStorageImage(ctx context.Context, cli *client.Client, image string) (v1.Image, error) {
+	info, err := cli.Info(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error querying docker: %w", err)
+	}
+	inspect, _, err := cli.ImageInspectWithRaw(ctx, image)
+	if err != nil {
+		return nil, fmt.Errorf("error inspecting image in docker: %w", err)
+	}
+
+	if usesContainerd(info) {
+		names := containerdNames(image)
+		for _, tag := range inspect.RepoTags {
+			names = append(names, containerdNames(tag)...)
+		}
+		return s.containerdStoreImage(daemonNamespace, names)
+	}
+
+	if inspect.GraphDriver.Name != "overlay2" {
+		return nil, fmt.Errorf("unsupported docker storage driver %q", inspect.GraphDriver.Name)
+	}
+	id, err := v1.NewHash(inspect.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image ID %q: %w", inspect.ID, err)
+	}
+	rawConfig, err := os.ReadFile(filepath.Join(info.DockerRootDir, "image", info.Driver, "imagedb", "content", id.Algorithm, id.Hex))
+	if err != nil {
+		return nil, fmt.Errorf("error reading image config: %w", err)
+	}
+	dirs := overlayDiffDirs(inspect.GraphDriver.Data)
+	for _, dir := range dirs {
+		f, err := os.Open(dir)
+		if err != nil {
+			return nil, fmt.Errorf("error reading layer directory: %w", err)
+		}
+		f.Close()
+	}
+	return newOverlayImage(rawConfig, dirs)
+}
+
+// savedImage reads an image from the export of the Docker engine, which is
+// requested again each time a part of the image is read rather than kept.
+func savedImage(ctx context.Context, cli *client.Client, image string) (v1.Image, error) {
+	img, err := tarball.Image(func() (io.ReadCloser, error) {
+		return cli.ImageSave(ctx, []string{image})
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error exporting image from docker: %w", err)
+	}
+	return img, nil
+}
+
+// usesContainerd reports whether the engine keeps its images in containerd.
+func usesContainerd(info system.Info) bool {
+	for _, status := range info.DriverStatus {
+		if status[0] == "driver-type" && status[1] == containerdSnapshotter {
+			return true
+		}
+	}
+	return false
+}
+
+// overlayDiffDirs returns the layer directories of an image of the overlay2
+// storage driver from the bottom layer up. The driver lists the top layer as
+// UpperDir and the others, top down, as LowerDir.
+func overlayDiffDirs(data map[string]string) []string {
+	var dirs []string
+	if lower := data["LowerDir"]; lower != "" {
+		dirs = strings.Split(lower, ":")
+	}
+	dirs = append([]string{data["UpperDir"]}, dirs...)
+	for i, j := 0, len(dirs)-1; i < j; i, j = i+1, j-1 {
+		dirs[i], dirs[j] = dirs[j], dirs[i]
+	}
+	return dirs
+}
+
+// overlayImage is an image of the overlay2 storage driver, which keeps layers
+// unpacked in directories.
+type overlayImage struct {
+	v1.Image
+	layers []v1.Layer
+}
+
+// newOverlayImage returns the image of the config with one layer per diff
+// directory, from the bottom layer up.
+func newOverlayImage(rawConfig []byte, dirs []string) (v1.Image, error) {
+	config, err := v1.ParseConfigFile(bytes.NewReader(rawConfig))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing image config: %w", err)
+	}
+	diffIDs := config.RootFS.DiffIDs
+	if len(diffIDs) != len(dirs) {
+		return nil, fmt.Errorf("image has %d layers, found %d layer directories", len(diffIDs), len(dirs))
+	}
+
+	core := &overlayCore{rawConfig: rawConfig, layers: make(map[v1.Hash]*diffLayer)}
+	layers := make([]v1.Layer, len(dirs))
+	for i, dir := range dirs {
+		layer := &diffLayer{dir: dir, diffID: diffIDs[i]}
+		core.layers[diffIDs[i]] = layer
+		layers[i] = layer
+	}
+	img, err := partial.UncompressedToImage(core)
+	if err != nil {
+		return nil, err
+	}
+	return &overlayImage{Image: img, layers: layers}, nil
+}
+
+// Layers returns the diff layers themselves, which unlike the layers of
+// partial.UncompressedToImage need not be compressed to get their digest.
+func (i *overlayImage) Layers() ([]v1.Layer, error) {
+	return i.layers, nil
+}
+
+type overlayCore struct {
+	rawConfig []byte
+	layers    map[v1.Hash]*diffLayer
+}
+
+func (c *overlayCore) RawConfigFile() ([]byte, error) {
+	return c.rawConfig, nil
+}
+
+func (c *overlayCore) MediaType() (types.MediaType, error) {
+	return types.DockerManifestSchema2, nil
+}
+
+func (c *overlayCore) LayerByDiffID(h v1.Hash) (partial.UncompressedLayer, error) {
+	layer, ok := c.layers[h]
+	if !ok {
+		return nil, fmt.Errorf("layer %s not found", h)
+	}
+	return layer, nil
+}
+
+// diffLayer is a layer read from its diff directory and packed as it is read,
+// without compression. Its digest is its diff ID, as the compressed layer it
+// came from is gone.
+type diffLayer struct {
+	dir    string
+	diffID v1.Hash
+}
+
+func (l *diffLayer) Digest() (v1.Hash, error) {
+	return l.diffID, nil
+}
+
+func (l *diffLayer) DiffID() (v1.Hash, error) {
+	return l.diffID, nil
+}
+
+func (l *diffLayer) Uncompressed() (io.ReadCloser, error) {
+	pr, pw := io.Pipe()
+	go func() { pw.CloseWithError(writeDiffTar(pw, l.dir)) }()
+	return pr, nil
+}
+
+// Compressed returns the layer in gzip format, as layers are served by
+// registries, but with stored blocks that cost nothing to write.
+func (l *diffLayer) Compressed() (io.ReadCloser, error) {
+	pr, pw := io.Pipe()
+	go func() {
+		zw, err := gzip.NewWriterLevel(pw, gzip.NoCompression)
+		if err == nil {
+			err = writeDiffTar(zw, l.dir)
+			if closeErr := zw.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr, nil
+}
+
+// Size is unknown until the layer is packed.
+func (l *diffLayer) Size() (int64, error) {
+	return -1, nil
+}
+
+func (l *diffLayer) MediaType() (types.MediaType, error) {
+	return types.DockerLayer, nil
+}
+
+// writeDiffTar packs a diff directory of the overlay2 storage driver into a
+// layer tarball. Overlay whiteouts, which are character devices, and opaque
+// directories, which are marked with an extended attribute, become whiteout
+// entries; other special files are left out.
+func writeDiffTar(w io.Writer, dir string) error {
+	tw := tar.NewWriter(w)
+	err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || file == dir {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		if isOverlayWhiteout(info) {
+			whiteout := path.Join(path.Dir(name), whiteoutPrefix+path.Base(name))
+			return tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: whiteout, Mode: 0o644})
+		}
+
+		var link string
+		switch {
+		case info.Mode()&fs.ModeSymlink != 0:
+			if link, err = os.Readlink(file); err != nil {
+				return err
+			}
+		case !info.Mode().IsRegular() && !info.IsDir():
+			return nil
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		switch {
+		case info.IsDir():
+			if isOverlayOpaque(file) {
+				return tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name + "/" + whiteoutOpaque, Mode: 0o644})
+			}
+		case info.Mode().IsRegular():
+			f, err := os.Open(file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			if _, err := io.CopyN(tw, f, header.Size); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
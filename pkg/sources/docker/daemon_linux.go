@@ -0,0 +1,29 @@
+package docker
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// isOverlayWhiteout reports whether info is an overlay whiteout, a character
+// device with device number 0/0.
+func isOverlayWhiteout(info fs.FileInfo) bool {
+	if info.Mode()&fs.ModeCharDevice == 0 {
+		return false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	return ok && stat.Rdev == 0
+}
+
+// isOverlayOpaque reports whether dir is an opaque overlay directory, marked
+// with a trusted.overlay.opaque attribute, or user.overlay.opaque when
+// rootless.
+func isOverlayOpaque(dir string) bool {
+	buf := make([]byte, 1)
+	for _, attr := range []string{"trusted.overlay.opaque", "user.overlay.opaque"} {
+		if n, err := syscall.Getxattr(dir, attr, buf); err == nil && n == 1 && buf[0] == 'y' {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package docker
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/system"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/stretchr/testify/require"
+)
+
+// writeOverlayStorage writes the test image to the storage of the overlay2
+// driver under root, with a diff directory per layer, and returns the image ID
+// and the driver data of the image.
+func writeOverlayStorage(t *testing.T, root string) (string, map[string]string) {
+	t.Helper()
+	rawConfig, err := buildTestImage(t).RawConfigFile()
+	require.NoError(t, err)
+	id, _, err := v1.SHA256(bytes.NewReader(rawConfig))
+	require.NoError(t, err)
+	configDir := filepath.Join(root, "image", "overlay2", "imagedb", "content", "sha256")
+	require.NoError(t, os.MkdirAll(configDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, id.Hex), rawConfig, 0o600))
+
+	writeFiles := func(dir string, files ...layerFile) {
+		for _, f := range files {
+			path := filepath.Join(dir, filepath.FromSlash(f.name))
+			require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+			require.NoError(t, os.WriteFile(path, []byte(f.content), 0o644))
+		}
+	}
+	lower := filepath.Join(root, "overlay2", "lower", "diff")
+	writeFiles(lower,
+		layerFile{"app/.env", "AWS_SECRET=deleted"},
+		layerFile{"app/config.yml", "debug: true"},
+		layerFile{"cache/token", "cached"},
+	)
+	upper := filepath.Join(root, "overlay2", "upper", "diff")
+	writeFiles(upper, layerFile{"cache/fresh", "fresh"})
+
+	// The upper layer deletes app/.env and replaces the cache directory, which
+	// overlay marks with a whiteout device and an opaque attribute.
+	require.NoError(t, os.MkdirAll(filepath.Join(upper, "app"), 0o755))
+	if err := syscall.Mknod(filepath.Join(upper, "app", ".env"), syscall.S_IFCHR, 0); err != nil {
+		t.Skipf("cannot create overlay whiteouts: %v", err)
+	}
+	if err := syscall.Setxattr(filepath.Join(upper, "cache"), "trusted.overlay.opaque", []byte("y"), 0); err != nil {
+		t.Skipf("cannot create overlay whiteouts: %v", err)
+	}
+
+	return id.String(), map[string]string{"LowerDir": lower, "UpperDir": upper}
+}
+
+func TestChunkUnit_DaemonOverlay(t *testing.T) {
+	root := t.TempDir()
+	id, data := writeOverlayStorage(t, root)
+	fakeDaemon(t, "app:ci",
+		system.Info{Driver: "overlay2", DockerRootDir: root},
+		types.ImageInspect{ID: id, GraphDriver: types.GraphDriverData{Name: "overlay2", Data: data}},
+	)
+	testChunkUnitLocalImage(t, newTestSource(t), "docker-daemon://app:ci")
+}
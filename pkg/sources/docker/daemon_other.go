@@ -0,0 +1,11 @@
+//go:build !linux
+
+package docker
+
+import "io/fs"
+
+// Overlay storage only exists on Linux.
+
+func isOverlayWhiteout(fs.FileInfo) bool { return false }
+
+func isOverlayOpaque(string) bool { return false }
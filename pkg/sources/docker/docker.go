@@ -2,11 +2,15 @@ package docker
 
 import (
 	"archive/tar"
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"path"
+	"sort"
 	"strings"
 	"time"
 
@@ -17,6 +21,7 @@ import (
 	"github.com/google/go-containerregistry/pkg/v1/tarball"
 	gzip "github.com/klauspost/pgzip"
 	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/anypb"
 
@@ -36,6 +41,21 @@ type Source struct {
 	verify      bool
 	concurrency int
 	conn        sourcespb.Docker
+	jobPool     *errgroup.Group
+	// layerLimit bounds the layers read at once, across images, as each one
+	// takes a large decompression buffer.
+	layerLimit *semaphore.Weighted
+
+	// platform, when set, selects the image of multi-platform indexes.
+	platform            *v1.Platform
+	containerdRoot      string
+	containerdNamespace string
+
+	// registry, when set, is enumerated for images in addition to conn.
+	registry *registryScan
+	// layerCache, when set, deduplicates layers across images.
+	layerCache *layerCache
+
 	sources.Progress
 	sources.CommonSourceUnitUnmarshaller
 }
@@ -43,6 +63,7 @@ type Source struct {
 // Ensure the Source satisfies the interfaces at compile time.
 var _ sources.Source = (*Source)(nil)
 var _ sources.SourceUnitUnmarshaller = (*Source)(nil)
+var _ sources.SourceUnitEnumChunker = (*Source)(nil)
 
 // Type returns the type of source.
 // It is used for matching source types in configuration and job input.
@@ -65,6 +86,11 @@ func (s *Source) Init(_ context.Context, name string, jobId sources.JobID, sourc
 	s.jobId = jobId
 	s.verify = verify
 	s.concurrency = concurrency
+	s.jobPool = &errgroup.Group{}
+	s.jobPool.SetLimit(concurrency)
+	s.layerLimit = semaphore.NewWeighted(int64(concurrency))
+	s.containerdRoot = defaultContainerdRoot
+	s.containerdNamespace = defaultContainerdNamespace
 
 	// Reset metrics for this source at initialization time.
 	dockerImagesScanned.WithLabelValues(s.name).Set(0)
@@ -77,6 +103,34 @@ func (s *Source) Init(_ context.Context, name string, jobId sources.JobID, sourc
 	return nil
 }
 
+// WithPlatform sets the platform, such as linux/arm64, of the image to scan
+// out of multi-platform indexes. It defaults to linux/amd64. Once set, single
+// platform images built for another platform are rejected.
+func (s *Source) WithPlatform(platform string) error {
+	p, err := v1.ParsePlatform(platform)
+	if err != nil {
+		return fmt.Errorf("invalid platform %q: %w", platform, err)
+	}
+	s.platform = p
+	return nil
+}
+
+// WithContainerdRoot sets the root directory of containerd, used for
+// containerd:// images. It defaults to /var/lib/containerd.
+func (s *Source) WithContainerdRoot(root string) {
+	if root != "" {
+		s.containerdRoot = root
+	}
+}
+
+// WithContainerdNamespace sets the containerd namespace of containerd://
+// images, such as k8s.io for images pulled by Kubernetes.
+func (s *Source) WithContainerdNamespace(namespace string) {
+	if namespace != "" {
+		s.containerdNamespace = namespace
+	}
+}
+
 type imageInfo struct {
 	image v1.Image
 	base  string
@@ -97,70 +151,98 @@ type layerInfo struct {
 	tag    string
 }
 
-// Chunks emits data over a channel that is decoded and scanned for secrets.
-func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ ...sources.ChunkingTarget) error {
-	ctx = context.WithValues(ctx, "source_type", s.Type(), "source_name", s.name)
-
-	workers := new(errgroup.Group)
-	workers.SetLimit(s.concurrency)
-
+// Enumerate reports the configured images, then the tags of the registry when
+// one is set. Units are identified by the image reference as given, including
+// any file:// prefix.
+func (s *Source) Enumerate(ctx context.Context, reporter sources.UnitReporter) error {
 	for _, image := range s.conn.GetImages() {
-		if common.IsDone(ctx) {
-			return nil
+		if err := reporter.UnitOk(ctx, sources.CommonSourceUnit{ID: image}); err != nil {
+			return err
 		}
+	}
+	if s.registry != nil {
+		return s.enumerateRegistry(ctx, reporter)
+	}
+	return nil
+}
 
-		imgInfo, err := s.processImage(ctx, image)
-		if err != nil {
-			ctx.Logger().Error(err, "error processing image", "image", image)
-			return nil
-		}
+// ChunkUnit scans a single image: its config, its history and the files of
+// every layer. A failing image is reported as a chunk error so that the
+// remaining units are still scanned.
+func (s *Source) ChunkUnit(ctx context.Context, unit sources.SourceUnit, reporter sources.ChunkReporter) error {
+	image, _ := unit.SourceUnitID()
+	if err := s.scanImage(ctx, image, reporter); err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("error scanning image %q: %w", image, err))
+	}
+	return nil
+}
 
-		ctx = context.WithValues(ctx, "image", imgInfo.base, "tag", imgInfo.tag)
+// Chunks emits data over a channel that is decoded and scanned for secrets.
+func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ ...sources.ChunkingTarget) error {
+	ctx = context.WithValues(ctx, "source_type", s.Type(), "source_name", s.name)
+	return sources.ChunkUnits(ctx, s, s.jobPool, &s.Progress, "Image", chunksChan)
+}
 
-		ctx.Logger().V(2).Info("scanning image history")
+// scanImage scans the config, history and layers of an image.
+func (s *Source) scanImage(ctx context.Context, image string, reporter sources.ChunkReporter) error {
+	imgInfo, err := s.processImage(ctx, image)
+	if err != nil {
+		return err
+	}
 
-		layers, err := imgInfo.image.Layers()
-		if err != nil {
-			ctx.Logger().Error(err, "error getting image layers")
-			return nil
-		}
+	ctx = context.WithValues(ctx, "image", imgInfo.base, "tag", imgInfo.tag)
 
-		historyEntries, err := getHistoryEntries(ctx, imgInfo, layers)
-		if err != nil {
-			ctx.Logger().Error(err, "error getting image history entries")
-			return nil
-		}
+	config, err := imgInfo.image.ConfigFile()
+	if err != nil {
+		return fmt.Errorf("error getting image config: %w", err)
+	}
+	if err := s.processConfig(ctx, config.Config, imgInfo, reporter); err != nil {
+		return err
+	}
 
-		for _, historyEntry := range historyEntries {
-			if err := s.processHistoryEntry(ctx, historyEntry, chunksChan); err != nil {
-				ctx.Logger().Error(err, "error processing history entry")
-				return nil
-			}
-			dockerHistoryEntriesScanned.WithLabelValues(s.name).Inc()
-		}
+	ctx.Logger().V(2).Info("scanning image history")
 
-		ctx.Logger().V(2).Info("scanning image layers")
+	layers, err := imgInfo.image.Layers()
+	if err != nil {
+		return fmt.Errorf("error getting image layers: %w", err)
+	}
 
-		for _, layer := range layers {
-			workers.Go(func() error {
-				if err := s.processLayer(ctx, layer, imgInfo, chunksChan); err != nil {
-					ctx.Logger().Error(err, "error processing layer")
-					return nil
-				}
-				dockerLayersScanned.WithLabelValues(s.name).Inc()
+	historyEntries, err := getHistoryEntries(ctx, imgInfo, layers)
+	if err != nil {
+		return fmt.Errorf("error getting image history entries: %w", err)
+	}
 
-				return nil
-			})
+	for _, historyEntry := range historyEntries {
+		if err := s.processHistoryEntry(ctx, historyEntry, reporter); err != nil {
+			return err
 		}
+		dockerHistoryEntriesScanned.WithLabelValues(s.name).Inc()
+	}
 
-		if err := workers.Wait(); err != nil {
-			ctx.Logger().Error(err, "error processing layers")
+	ctx.Logger().V(2).Info("scanning image layers")
+
+	// Whether a file is present in history only depends on the whiteouts of
+	// the layers above it, so those are read first, from the tar headers, and
+	// the layers are then scanned with their chunks flagged as they are read.
+	deleted := s.deletedAbove(ctx, layers, reporter)
+	var workers errgroup.Group
+	for i, layer := range layers {
+		workers.Go(func() error {
+			if err := s.scanLayer(ctx, layer, imgInfo, deleted[i], reporter); err != nil {
+				return reporter.ChunkErr(ctx, fmt.Errorf("error processing layer %d: %w", i, err))
+			}
+			dockerLayersScanned.WithLabelValues(s.name).Inc()
 			return nil
-		}
-
-		dockerImagesScanned.WithLabelValues(s.name).Inc()
+		})
+	}
+	if err := workers.Wait(); err != nil {
+		return err
+	}
+	if common.IsDone(ctx) {
+		return ctx.Err()
 	}
 
+	dockerImagesScanned.WithLabelValues(s.name).Inc()
 	return nil
 }
 
@@ -178,14 +260,35 @@ func (s *Source) processImage(ctx context.Context, image string) (imageInfo, err
 	}
 
 	const filePrefix = "file://"
-	if strings.HasPrefix(image, filePrefix) {
+	switch {
+	case strings.HasPrefix(image, filePrefix):
 		image = strings.TrimPrefix(image, filePrefix)
 		imgInfo.base = image
 		imgInfo.image, err = tarball.ImageFromPath(image, nil)
 		if err != nil {
 			return imgInfo, err
 		}
-	} else {
+	case strings.HasPrefix(image, daemonPrefix):
+		image = strings.TrimPrefix(image, daemonPrefix)
+		imgInfo.base, imgInfo.tag, _ = baseAndTagFromImage(image)
+		imgInfo.image, err = s.daemonImage(ctx, image)
+		if err != nil {
+			return imgInfo, err
+		}
+	case strings.HasPrefix(image, ociLayoutPrefix):
+		imgInfo.base, imgInfo.tag = splitLayoutReference(strings.TrimPrefix(image, ociLayoutPrefix))
+		imgInfo.image, err = s.layoutImage(imgInfo.base, imgInfo.tag)
+		if err != nil {
+			return imgInfo, err
+		}
+	case strings.HasPrefix(image, containerdPrefix):
+		image = strings.TrimPrefix(image, containerdPrefix)
+		imgInfo.base, imgInfo.tag, _ = baseAndTagFromImage(image)
+		imgInfo.image, err = s.containerdImage(image)
+		if err != nil {
+			return imgInfo, err
+		}
+	default:
 		imgInfo.base, imgInfo.tag, hasDigest = baseAndTagFromImage(image)
 
 		if hasDigest {
@@ -197,17 +300,113 @@ func (s *Source) processImage(ctx context.Context, image string) (imageInfo, err
 			return imgInfo, err
 		}
 
+		if s.platform != nil {
+			remoteOpts = append(remoteOpts, remote.WithPlatform(*s.platform))
+		}
 		imgInfo.image, err = remote.Image(imageName, remoteOpts...)
 		if err != nil {
 			return imgInfo, err
 		}
 	}
 
+	if err := s.checkPlatform(imgInfo.image); err != nil {
+		return imgInfo, err
+	}
+
 	ctx.Logger().WithValues("image", imgInfo.base, "tag", imgInfo.tag).V(2).Info("scanning image")
 
 	return imgInfo, nil
 }
 
+// checkPlatform rejects an image built for another platform than the
+// configured one. Images that are not picked out of an index, such as those of
+// a tarball or the Docker engine, are only checked here.
+func (s *Source) checkPlatform(img v1.Image) error {
+	if s.platform == nil {
+		return nil
+	}
+	config, err := img.ConfigFile()
+	if err != nil {
+		return err
+	}
+	if p := config.Platform(); p != nil && !p.Satisfies(*s.platform) {
+		return fmt.Errorf("image is for platform %s, not %s", p.String(), s.platform.String())
+	}
+	return nil
+}
+
+// configPath is the made up file name of the image config chunk.
+const configPath = "image-metadata:config"
+
+// processConfig scans the runtime configuration of the image. Build arguments
+// and credentials often end up in the environment, the labels or the command,
+// none of which are stored in a layer.
+func (s *Source) processConfig(ctx context.Context, config v1.Config, imgInfo imageInfo, reporter sources.ChunkReporter) error {
+	data := configData(config)
+	if len(data) == 0 {
+		return nil
+	}
+
+	ctx.Logger().V(2).Info("scanning image config")
+
+	return reporter.ChunkOk(ctx, sources.Chunk{
+		SourceType: s.Type(),
+		SourceName: s.name,
+		SourceID:   s.SourceID(),
+		JobID:      s.JobID(),
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Docker{
+				Docker: &source_metadatapb.Docker{
+					File:  configPath,
+					Image: imgInfo.base,
+					Tag:   imgInfo.tag,
+				},
+			},
+		},
+		Verify: s.verify,
+		Data:   data,
+	})
+}
+
+// configData renders the fields of an image config that can hold secrets as
+// Dockerfile instructions, one per line.
+func configData(config v1.Config) []byte {
+	var buf bytes.Buffer
+	for _, env := range config.Env {
+		fmt.Fprintf(&buf, "ENV %s\n", env)
+	}
+
+	labels := make([]string, 0, len(config.Labels))
+	for k := range config.Labels {
+		labels = append(labels, k)
+	}
+	sort.Strings(labels)
+	for _, k := range labels {
+		fmt.Fprintf(&buf, "LABEL %s=%s\n", k, config.Labels[k])
+	}
+
+	if len(config.Entrypoint) > 0 {
+		fmt.Fprintf(&buf, "ENTRYPOINT %s\n", execForm(config.Entrypoint))
+	}
+	if len(config.Cmd) > 0 {
+		fmt.Fprintf(&buf, "CMD %s\n", execForm(config.Cmd))
+	}
+	for _, trigger := range config.OnBuild {
+		fmt.Fprintf(&buf, "ONBUILD %s\n", trigger)
+	}
+	return buf.Bytes()
+}
+
+// execForm formats a command the way the exec form of a Dockerfile instruction
+// is written, as a JSON array.
+func execForm(args []string) string {
+	b, err := json.Marshal(args)
+	if err != nil {
+		return strings.Join(args, " ")
+	}
+	return string(b)
+}
+
 // getHistoryEntries collates an image's configuration history together with the
 // corresponding layer digests for any non-empty layers.
 func getHistoryEntries(ctx context.Context, imgInfo imageInfo, layers []v1.Layer) ([]historyEntryInfo, error) {
@@ -255,15 +454,16 @@ func getHistoryEntries(ctx context.Context, imgInfo imageInfo, layers []v1.Layer
 }
 
 // processHistoryEntry processes a history entry from the image configuration metadata.
-func (s *Source) processHistoryEntry(ctx context.Context, historyInfo historyEntryInfo, chunksChan chan *sources.Chunk) error {
+func (s *Source) processHistoryEntry(ctx context.Context, historyInfo historyEntryInfo, reporter sources.ChunkReporter) error {
 	// Make up an identifier for this entry that is moderately sensible. There is
 	// no file name to use here, so the path tries to be a little descriptive.
 	entryPath := fmt.Sprintf("image-metadata:history:%d:created-by", historyInfo.index)
 
-	chunk := &sources.Chunk{
+	chunk := sources.Chunk{
 		SourceType: s.Type(),
 		SourceName: s.name,
 		SourceID:   s.SourceID(),
+		JobID:      s.JobID(),
 		SourceMetadata: &source_metadatapb.MetaData{
 			Data: &source_metadatapb.MetaData_Docker{
 				Docker: &source_metadatapb.Docker{
@@ -280,11 +480,209 @@ func (s *Source) processHistoryEntry(ctx context.Context, historyInfo historyEnt
 
 	ctx.Logger().V(2).Info("scanning image history entry", "index", historyInfo.index, "layer", historyInfo.layerDigest)
 
-	return common.CancellableWrite(ctx, chunksChan, chunk)
+	return reporter.ChunkOk(ctx, chunk)
+}
+
+// Whiteout file names, as defined by the OCI image layer specification.
+const (
+	whiteoutPrefix = ".wh."
+	whiteoutOpaque = ".wh..wh..opq"
+)
+
+// whiteouts holds the paths deleted by the layers read so far. A ".wh.name"
+// entry deletes name from the layers below it, and an opaque whiteout hides
+// everything the layers below put in its directory.
+type whiteouts struct {
+	deleted map[string]struct{}
+	opaque  map[string]struct{}
+}
+
+func newWhiteouts() *whiteouts {
+	return &whiteouts{deleted: make(map[string]struct{}), opaque: make(map[string]struct{})}
+}
+
+// add records a whiteout entry. It reports false if the entry is a regular
+// file.
+func (w *whiteouts) add(entry string) bool {
+	dir, base := path.Split(cleanPath(entry))
+	dir = path.Clean(dir)
+	switch {
+	case base == whiteoutOpaque:
+		w.opaque[dir] = struct{}{}
+	case strings.HasPrefix(base, whiteoutPrefix):
+		w.deleted[path.Join(dir, strings.TrimPrefix(base, whiteoutPrefix))] = struct{}{}
+	default:
+		return false
+	}
+	return true
+}
+
+func (w *whiteouts) merge(other *whiteouts) {
+	for p := range other.deleted {
+		w.deleted[p] = struct{}{}
+	}
+	for p := range other.opaque {
+		w.opaque[p] = struct{}{}
+	}
+}
+
+// hides reports whether a file of a lower layer was deleted, either itself or
+// through one of its parent directories.
+func (w *whiteouts) hides(file string) bool {
+	for p := cleanPath(file); p != "."; p = path.Dir(p) {
+		if _, ok := w.deleted[p]; ok {
+			return true
+		}
+		if _, ok := w.opaque[path.Dir(p)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanPath returns a layer entry name relative to the root of the image, such
+// as "etc/passwd" for "./etc/passwd".
+func cleanPath(entry string) string {
+	p := strings.TrimPrefix(path.Clean("/"+entry), "/")
+	if p == "" {
+		return "."
+	}
+	return p
+}
+
+// deletedAbove returns, for each layer, the files deleted by the layers above
+// it. The whiteouts of every layer but the bottom one are read concurrently;
+// those of a layer that cannot be read are reported and left out.
+func (s *Source) deletedAbove(ctx context.Context, layers []v1.Layer, reporter sources.ChunkReporter) []*whiteouts {
+	layerWhiteouts := make([]*whiteouts, len(layers))
+	var workers errgroup.Group
+	for i := 1; i < len(layers); i++ {
+		workers.Go(func() error {
+			w, err := s.layerWhiteouts(ctx, layers[i])
+			if err != nil {
+				_ = reporter.ChunkErr(ctx, fmt.Errorf("error reading whiteouts of layer %d: %w", i, err))
+				w = newWhiteouts()
+			}
+			layerWhiteouts[i] = w
+			return nil
+		})
+	}
+	_ = workers.Wait()
+
+	deleted := make([]*whiteouts, len(layers))
+	above := newWhiteouts()
+	for i := len(layers) - 1; i >= 0; i-- {
+		deleted[i] = newWhiteouts()
+		deleted[i].merge(above)
+		if i > 0 {
+			above.merge(layerWhiteouts[i])
+		}
+	}
+	return deleted
+}
+
+// layerWhiteouts returns the whiteouts of a layer, from the layer cache if the
+// layer was scanned for another image, or else from its tar headers.
+func (s *Source) layerWhiteouts(ctx context.Context, layer v1.Layer) (*whiteouts, error) {
+	if s.layerCache != nil {
+		digest, err := layer.Digest()
+		if err != nil {
+			return nil, err
+		}
+		if w, ok := s.layerCache.whiteouts(digest); ok {
+			return w, nil
+		}
+	}
+
+	if err := s.layerLimit.Acquire(ctx, 1); err != nil {
+		return nil, err
+	}
+	defer s.layerLimit.Release(1)
+
+	rc, err := openLayer(layer)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	w := newWhiteouts()
+	tarReader := tar.NewReader(rc)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return w, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		w.add(header.Name)
+	}
+}
+
+// flagReporter flags the chunks of the files deleted by upper layers as
+// present in history only.
+type flagReporter struct {
+	sources.ChunkReporter
+	deleted *whiteouts
+}
+
+func (r flagReporter) ChunkOk(ctx context.Context, chunk sources.Chunk) error {
+	if docker := chunk.SourceMetadata.GetDocker(); docker != nil {
+		docker.HistoryOnly = r.deleted.hides(docker.GetFile())
+	}
+	return r.ChunkReporter.ChunkOk(ctx, chunk)
+}
+
+// scanLayer scans a layer of the image, given the files deleted by the layers
+// above it. When layers are deduplicated, a layer already scanned for another
+// image is skipped.
+func (s *Source) scanLayer(ctx context.Context, layer v1.Layer, imgInfo imageInfo, deleted *whiteouts, reporter sources.ChunkReporter) error {
+	var (
+		digest v1.Hash
+		entry  *cachedLayer
+	)
+	if s.layerCache != nil {
+		var err error
+		if digest, err = layer.Digest(); err != nil {
+			return err
+		}
+		for {
+			var owner bool
+			if entry, owner = s.layerCache.claim(digest); owner {
+				break
+			}
+			select {
+			case <-entry.done:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			if !entry.failed {
+				ctx.Logger().V(3).Info("skipping layer: already scanned", "layer", digest.String())
+				return nil
+			}
+			ctx.Logger().V(3).Info("scanning layer again: scan failed for another image", "layer", digest.String())
+		}
+	}
+
+	layerWhiteouts, err := s.limitedProcessLayer(ctx, layer, imgInfo, flagReporter{ChunkReporter: reporter, deleted: deleted})
+	if entry != nil {
+		s.layerCache.finish(digest, entry, layerWhiteouts, err)
+	}
+	return err
+}
+
+// limitedProcessLayer is processLayer within the limit on layers read at once.
+func (s *Source) limitedProcessLayer(ctx context.Context, layer v1.Layer, imgInfo imageInfo, reporter sources.ChunkReporter) (*whiteouts, error) {
+	if err := s.layerLimit.Acquire(ctx, 1); err != nil {
+		return nil, err
+	}
+	defer s.layerLimit.Release(1)
+	return s.processLayer(ctx, layer, imgInfo, reporter)
 }
 
-// processLayer processes an individual layer of an image.
-func (s *Source) processLayer(ctx context.Context, layer v1.Layer, imgInfo imageInfo, chunksChan chan *sources.Chunk) error {
+// processLayer processes an individual layer of an image and returns its
+// whiteouts.
+func (s *Source) processLayer(ctx context.Context, layer v1.Layer, imgInfo imageInfo, reporter sources.ChunkReporter) (*whiteouts, error) {
 	layerInfo := layerInfo{
 		base: imgInfo.base,
 		tag:  imgInfo.tag,
@@ -293,45 +691,75 @@ func (s *Source) processLayer(ctx context.Context, layer v1.Layer, imgInfo image
 	var err error
 	layerInfo.digest, err = layer.Digest()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	ctx.Logger().WithValues("layer", layerInfo.digest.String()).V(2).Info("scanning layer")
 
-	rc, err := layer.Compressed()
+	rc, err := openLayer(layer)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer rc.Close()
 
-	const (
-		defaultBlockSize = 1 << 24 // 16MB
-		defaultBlocks    = 8
-	)
-
-	gzipReader, err := gzip.NewReaderN(rc, defaultBlockSize, defaultBlocks)
-	if err != nil {
-		return err
-	}
-	defer gzipReader.Close()
-
-	tarReader := tar.NewReader(gzipReader)
+	layerWhiteouts := newWhiteouts()
+	tarReader := tar.NewReader(rc)
 	for {
 		header, err := tarReader.Next()
 		if errors.Is(err, io.EOF) {
 			break
 		}
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		info := chunkProcessingInfo{size: header.Size, name: header.Name, reader: tarReader, layer: layerInfo}
-		if err := s.processChunk(ctx, info, chunksChan); err != nil {
-			return err
+		if layerWhiteouts.add(header.Name) {
+			continue
+		}
+
+		info := chunkProcessingInfo{
+			size:   header.Size,
+			name:   header.Name,
+			reader: tarReader,
+			layer:  layerInfo,
+		}
+		if err := s.processChunk(ctx, info, reporter); err != nil {
+			return nil, err
 		}
 	}
 
-	return nil
+	return layerWhiteouts, nil
+}
+
+// openLayer returns the tar stream of a layer.
+func openLayer(layer v1.Layer) (io.ReadCloser, error) {
+	rc, err := layer.Compressed()
+	if err != nil {
+		return nil, err
+	}
+
+	const (
+		defaultBlockSize = 1 << 24 // 16MB
+		defaultBlocks    = 8
+	)
+
+	gzipReader, err := gzip.NewReaderN(rc, defaultBlockSize, defaultBlocks)
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+	return &layerReader{Reader: gzipReader, compressed: rc}, nil
+}
+
+// layerReader is the decompressed stream of a layer.
+type layerReader struct {
+	*gzip.Reader
+	compressed io.ReadCloser
+}
+
+func (r *layerReader) Close() error {
+	_ = r.Reader.Close()
+	return r.compressed.Close()
 }
 
 type chunkProcessingInfo struct {
@@ -342,7 +770,7 @@ type chunkProcessingInfo struct {
 }
 
 // processChunk processes an individual chunk of a layer.
-func (s *Source) processChunk(ctx context.Context, info chunkProcessingInfo, chunksChan chan *sources.Chunk) error {
+func (s *Source) processChunk(ctx context.Context, info chunkProcessingInfo, reporter sources.ChunkReporter) error {
 	const filesizeLimitBytes int64 = 50 * 1024 * 1024 // 50MB
 	if info.size > filesizeLimitBytes {
 		ctx.Logger().V(2).Info("skipping file: size exceeds max allowed", "file", info.name, "size", info.size, "limit", filesizeLimitBytes)
@@ -358,10 +786,11 @@ func (s *Source) processChunk(ctx context.Context, info chunkProcessingInfo, chu
 			continue
 		}
 
-		chunk := &sources.Chunk{
+		chunk := sources.Chunk{
 			SourceType: s.Type(),
 			SourceName: s.name,
 			SourceID:   s.SourceID(),
+			JobID:      s.JobID(),
 			SourceMetadata: &source_metadatapb.MetaData{
 				Data: &source_metadatapb.MetaData_Docker{
 					Docker: &source_metadatapb.Docker{
@@ -375,8 +804,9 @@ func (s *Source) processChunk(ctx context.Context, info chunkProcessingInfo, chu
 			Verify: s.verify,
 		}
 		chunk.Data = data.Bytes()
+		chunk.SourceMetadata = sources.WithFileOffset(chunk.SourceMetadata, data.Offset())
 
-		if err := common.CancellableWrite(ctx, chunksChan, chunk); err != nil {
+		if err := reporter.ChunkOk(ctx, chunk); err != nil {
 			return err
 		}
 	}
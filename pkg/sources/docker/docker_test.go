@@ -1,11 +1,34 @@
 package docker
 
 import (
+	"archive/tar"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/system"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
 	"google.golang.org/protobuf/types/known/anypb"
 
 	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
@@ -13,6 +36,7 @@ import (
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sourcestest"
 )
 
 func TestDockerImageScan(t *testing.T) {
@@ -42,6 +66,9 @@ func TestDockerImageScan(t *testing.T) {
 		defer wg.Done()
 		for chunk := range chunksChan {
 			assert.NotEmpty(t, chunk)
+			if isConfigChunk(t, chunk) {
+				continue
+			}
 			chunkCounter++
 
 			if isHistoryChunk(t, chunk) {
@@ -93,6 +120,9 @@ func TestDockerImageScanWithDigest(t *testing.T) {
 		defer wg.Done()
 		for chunk := range chunksChan {
 			assert.NotEmpty(t, chunk)
+			if isConfigChunk(t, chunk) {
+				continue
+			}
 			chunkCounter++
 
 			if isHistoryChunk(t, chunk) {
@@ -134,6 +164,331 @@ func TestDockerImageScanWithDigest(t *testing.T) {
 	assert.Equal(t, 1, historyCounter)
 }
 
+type layerFile struct {
+	name, content string
+}
+
+func testLayer(t *testing.T, files ...layerFile) v1.Layer {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, f := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: f.name, Mode: 0o644, Size: int64(len(f.content))}))
+		_, err := tw.Write([]byte(f.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(buf.Bytes())), nil
+	})
+	require.NoError(t, err)
+	return layer
+}
+
+func buildTestImage(t *testing.T) v1.Image {
+	t.Helper()
+	img, err := mutate.Append(empty.Image,
+		mutate.Addendum{
+			Layer: testLayer(t,
+				layerFile{"app/.env", "AWS_SECRET=deleted"},
+				layerFile{"app/config.yml", "debug: true"},
+				layerFile{"cache/token", "cached"},
+			),
+			History: v1.History{CreatedBy: "COPY . /"},
+		},
+		mutate.Addendum{
+			Layer: testLayer(t,
+				layerFile{"app/.wh..env", ""},
+				layerFile{"cache/.wh..wh..opq", ""},
+				layerFile{"cache/fresh", "fresh"},
+			),
+			History: v1.History{CreatedBy: "RUN rm -rf app/.env cache/*"},
+		},
+	)
+	require.NoError(t, err)
+	img, err = mutate.Config(img, v1.Config{
+		Env:        []string{"API_TOKEN=hunter2"},
+		Labels:     map[string]string{"maintainer": "ops@example.com"},
+		Entrypoint: []string{"/app/run"},
+		Cmd:        []string{"--token", "hunter3"},
+		OnBuild:    []string{"RUN echo $SECRET"},
+	})
+	require.NoError(t, err)
+	return img
+}
+
+// writeTestImage writes an image tarball, as produced by docker save, and
+// returns its file:// reference.
+func writeTestImage(t *testing.T) string {
+	t.Helper()
+	img := buildTestImage(t)
+	path := filepath.Join(t.TempDir(), "image.tar")
+	ref, err := name.NewTag("example/app:latest")
+	require.NoError(t, err)
+	require.NoError(t, tarball.WriteToFile(path, ref, img))
+	return "file://" + path
+}
+
+func newTestSource(t *testing.T, images ...string) *Source {
+	t.Helper()
+	return newTestSourceConcurrency(t, 1, images...)
+}
+
+func newTestSourceConcurrency(t *testing.T, concurrency int, images ...string) *Source {
+	t.Helper()
+	conn, err := anypb.New(&sourcespb.Docker{
+		Credential: &sourcespb.Docker_Unauthenticated{Unauthenticated: &credentialspb.Unauthenticated{}},
+		Images:     images,
+	})
+	require.NoError(t, err)
+	s := &Source{}
+	require.NoError(t, s.Init(context.Background(), "test source", 0, 0, false, conn, concurrency))
+	return s
+}
+
+func TestChunkUnit_LocalImage(t *testing.T) {
+	image := writeTestImage(t)
+	// Layers are scanned concurrently unless the concurrency is 1.
+	for _, concurrency := range []int{1, 4} {
+		t.Run(fmt.Sprintf("concurrency %d", concurrency), func(t *testing.T) {
+			testChunkUnitLocalImage(t, newTestSourceConcurrency(t, concurrency, image), image)
+		})
+	}
+}
+
+func testChunkUnitLocalImage(t *testing.T, s *Source, image string) {
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.ChunkUnit(context.Background(), sources.CommonSourceUnit{ID: image}, &reporter))
+	require.Empty(t, reporter.ChunkErrs)
+
+	files := make(map[string]*source_metadatapb.Docker)
+	data := make(map[string]string)
+	for _, chunk := range reporter.Chunks {
+		meta := chunk.SourceMetadata.GetDocker()
+		files[meta.GetFile()] = meta
+		data[meta.GetFile()] = string(chunk.Data)
+	}
+
+	assert.Equal(t, "ENV API_TOKEN=hunter2\n"+
+		"LABEL maintainer=ops@example.com\n"+
+		"ENTRYPOINT [\"/app/run\"]\n"+
+		"CMD [\"--token\",\"hunter3\"]\n"+
+		"ONBUILD RUN echo $SECRET\n", data[configPath])
+	assert.Equal(t, "COPY . /", data["image-metadata:history:0:created-by"])
+	assert.Equal(t, "RUN rm -rf app/.env cache/*", data["image-metadata:history:1:created-by"])
+
+	assert.True(t, files["/app/.env"].GetHistoryOnly())
+	assert.True(t, files["/cache/token"].GetHistoryOnly())
+	assert.False(t, files["/app/config.yml"].GetHistoryOnly())
+	assert.False(t, files["/cache/fresh"].GetHistoryOnly())
+	assert.Equal(t, "AWS_SECRET=deleted", data["/app/.env"])
+
+	// Whiteout entries are markers, not files.
+	assert.NotContains(t, files, "/app/.wh..env")
+	assert.NotContains(t, files, "/cache/.wh..wh..opq")
+	assert.Len(t, reporter.Chunks, 7)
+}
+
+func TestChunks_FailingImage(t *testing.T) {
+	image := writeTestImage(t)
+	s := newTestSource(t, "file://"+filepath.Join(t.TempDir(), "missing.tar"), image)
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+	require.Len(t, reporter.Units, 2)
+
+	// The first image cannot be read, which must not stop the second one.
+	chunksChan := make(chan *sources.Chunk, 16)
+	require.NoError(t, s.Chunks(context.Background(), chunksChan))
+	close(chunksChan)
+
+	var count int
+	for chunk := range chunksChan {
+		assert.Equal(t, strings.TrimPrefix(image, "file://"), chunk.SourceMetadata.GetDocker().GetImage())
+		count++
+	}
+	assert.Equal(t, 7, count)
+}
+
+// buildTestIndex returns a multi-platform index of the test image for
+// linux/amd64 and a one file image for linux/arm64.
+func buildTestIndex(t *testing.T) v1.ImageIndex {
+	t.Helper()
+	arm, err := mutate.AppendLayers(empty.Image, testLayer(t, layerFile{"arch", "arm64"}))
+	require.NoError(t, err)
+	return mutate.AppendManifests(empty.Index,
+		mutate.IndexAddendum{
+			Add:        buildTestImage(t),
+			Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "amd64"}},
+		},
+		mutate.IndexAddendum{
+			Add:        arm,
+			Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "arm64"}},
+		},
+	)
+}
+
+func chunkFiles(t *testing.T, s *Source, image string) []string {
+	t.Helper()
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.ChunkUnit(context.Background(), sources.CommonSourceUnit{ID: image}, &reporter))
+	require.Empty(t, reporter.ChunkErrs)
+	var files []string
+	for _, chunk := range reporter.Chunks {
+		files = append(files, chunk.SourceMetadata.GetDocker().GetFile())
+	}
+	return files
+}
+
+func TestChunkUnit_OCILayout(t *testing.T) {
+	dir := t.TempDir()
+	p, err := layout.Write(dir, empty.Index)
+	require.NoError(t, err)
+	require.NoError(t, p.AppendIndex(buildTestIndex(t), layout.WithAnnotations(map[string]string{refNameAnnotation: "v1"})))
+
+	image := "oci-layout://" + dir + ":v1"
+	s := newTestSource(t, image)
+	assert.Contains(t, chunkFiles(t, s, image), "/app/config.yml")
+
+	require.NoError(t, s.WithPlatform("linux/arm64"))
+	assert.Equal(t, []string{"image-metadata:history:0:created-by", "/arch"}, chunkFiles(t, s, image))
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.ChunkUnit(context.Background(), sources.CommonSourceUnit{ID: "oci-layout://" + dir + ":v2"}, &reporter))
+	assert.Len(t, reporter.ChunkErrs, 1)
+}
+
+// writeContainerdStore writes the test index to a containerd content store
+// under root, as the image name of the namespace.
+func writeContainerdStore(t *testing.T, root, namespace, image string) {
+	t.Helper()
+	index := buildTestIndex(t)
+	p, err := layout.Write(filepath.Join(root, "io.containerd.content.v1.content"), empty.Index)
+	require.NoError(t, err)
+	require.NoError(t, p.AppendIndex(index))
+
+	digest, err := index.Digest()
+	require.NoError(t, err)
+	mediaType, err := index.MediaType()
+	require.NoError(t, err)
+	size, err := index.Size()
+	require.NoError(t, err)
+
+	dbDir := filepath.Join(root, "io.containerd.metadata.v1.bolt")
+	require.NoError(t, os.MkdirAll(dbDir, 0o755))
+	db, err := bolt.Open(filepath.Join(dbDir, "meta.db"), 0o600, nil)
+	require.NoError(t, err)
+	require.NoError(t, db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte("v1"))
+		require.NoError(t, err)
+		for _, name := range []string{namespace, "images", image, "target"} {
+			b, err = b.CreateBucketIfNotExists([]byte(name))
+			require.NoError(t, err)
+		}
+		require.NoError(t, b.Put([]byte("digest"), []byte(digest.String())))
+		require.NoError(t, b.Put([]byte("mediatype"), []byte(mediaType)))
+		return b.Put([]byte("size"), binary.AppendVarint(nil, size))
+	}))
+	require.NoError(t, db.Close())
+}
+
+func TestChunkUnit_Containerd(t *testing.T) {
+	root := t.TempDir()
+	writeContainerdStore(t, root, "k8s.io", "docker.io/library/app:latest")
+
+	s := newTestSource(t)
+	s.WithContainerdRoot(root)
+	s.WithContainerdNamespace("k8s.io")
+	require.NoError(t, s.WithPlatform("linux/arm64"))
+	assert.Equal(t, []string{"image-metadata:history:0:created-by", "/arch"}, chunkFiles(t, s, "containerd://app"))
+}
+
+// fakeDaemon serves the Docker engine API calls made for an image, info and
+// image inspection, and points DOCKER_HOST at them.
+func fakeDaemon(t *testing.T, image string, info system.Info, inspect types.ImageInspect) *sourcestest.Server {
+	t.Helper()
+	srv := sourcestest.NewServer(t, map[string]any{
+		"/_ping":                     "OK",
+		"/info":                      info,
+		"/images/" + image + "/json": inspect,
+	}, sourcestest.WithKey(func(r *http.Request) string {
+		// Requests other than the ping carry the API version, /v1.24/info.
+		if version, rest, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/"); ok && strings.HasPrefix(version, "v1.") {
+			return "/" + rest
+		}
+		return r.URL.Path
+	}))
+	t.Setenv("DOCKER_HOST", "tcp://"+srv.Listener.Addr().String())
+	return srv
+}
+
+func TestChunkUnit_DaemonContainerd(t *testing.T) {
+	root := t.TempDir()
+	writeContainerdStore(t, root, daemonNamespace, "docker.io/library/app:ci")
+	fakeDaemon(t, "app:ci",
+		system.Info{Driver: "overlayfs", DriverStatus: [][2]string{{"driver-type", containerdSnapshotter}}},
+		types.ImageInspect{ID: "sha256:0123", RepoTags: []string{"app:ci"}},
+	)
+
+	s := newTestSource(t)
+	s.WithContainerdRoot(root)
+	require.NoError(t, s.WithPlatform("linux/arm64"))
+	assert.Equal(t, []string{"image-metadata:history:0:created-by", "/arch"}, chunkFiles(t, s, "docker-daemon://app:ci"))
+}
+
+func TestChunkUnit_DaemonExport(t *testing.T) {
+	export, err := os.ReadFile(strings.TrimPrefix(writeTestImage(t), "file://"))
+	require.NoError(t, err)
+	srv := fakeDaemon(t, "app:ci",
+		system.Info{Driver: "overlay2", DockerRootDir: filepath.Join(t.TempDir(), "missing")},
+		types.ImageInspect{ID: "sha256:0123", RepoTags: []string{"app:ci"}, GraphDriver: types.GraphDriverData{Name: "overlay2"}},
+	)
+	srv.Responses["/images/get"] = string(export)
+
+	s := newTestSource(t)
+	assert.Contains(t, chunkFiles(t, s, "docker-daemon://app:ci"), "/app/config.yml")
+	for _, u := range srv.Requests() {
+		if strings.HasSuffix(u.Path, "/images/get") {
+			assert.Equal(t, []string{"app:ci"}, u.Query()["names"])
+		}
+	}
+}
+
+func TestChunkUnit_SinglePlatformImage(t *testing.T) {
+	img := buildTestImage(t)
+	config, err := img.ConfigFile()
+	require.NoError(t, err)
+	config.OS, config.Architecture = "linux", "arm64"
+	img, err = mutate.ConfigFile(img, config)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "image.tar")
+	require.NoError(t, tarball.WriteToFile(path, name.MustParseReference("example/app:arm64"), img))
+	image := "file://" + path
+
+	s := newTestSource(t)
+	require.NoError(t, s.WithPlatform("linux/amd64"))
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.ChunkUnit(context.Background(), sources.CommonSourceUnit{ID: image}, &reporter))
+	require.Len(t, reporter.ChunkErrs, 1)
+	assert.ErrorContains(t, reporter.ChunkErrs[0], "image is for platform linux/arm64, not linux/amd64")
+
+	require.NoError(t, s.WithPlatform("linux/arm64"))
+	assert.Contains(t, chunkFiles(t, s, image), "/app/config.yml")
+}
+
+func TestSplitLayoutReference(t *testing.T) {
+	for ref, want := range map[string][2]string{
+		"/tmp/build":                 {"/tmp/build", ""},
+		"/tmp/build:v1":              {"/tmp/build", "v1"},
+		"build@sha256:abc":           {"build", "sha256:abc"},
+		"/tmp/with:colon/build":      {"/tmp/with:colon/build", ""},
+		"/tmp/with:colon/build:v1.2": {"/tmp/with:colon/build", "v1.2"},
+	} {
+		dir, tag := splitLayoutReference(ref)
+		assert.Equal(t, want, [2]string{dir, tag}, ref)
+	}
+}
+
 func TestBaseAndTagFromImage(t *testing.T) {
 	tests := []struct {
 		image      string
@@ -166,3 +521,111 @@ func isHistoryChunk(t *testing.T, chunk *sources.Chunk) bool {
 	return metadata != nil &&
 		strings.HasPrefix(metadata.File, "image-metadata:history:")
 }
+
+func isConfigChunk(t *testing.T, chunk *sources.Chunk) bool {
+	t.Helper()
+
+	return chunk.SourceMetadata.GetDocker().GetFile() == configPath
+}
+
+func TestRegistryScan(t *testing.T) {
+	srv := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(srv.Close)
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	base := testLayer(t, layerFile{"etc/shared.conf", "password=base"})
+	push := func(repo string, created time.Time, app string) {
+		img, err := mutate.AppendLayers(empty.Image, base, testLayer(t, layerFile{"app", app}))
+		require.NoError(t, err)
+		img, err = mutate.CreatedAt(img, v1.Time{Time: created})
+		require.NoError(t, err)
+		ref, err := name.NewTag(host + "/" + repo)
+		require.NoError(t, err)
+		require.NoError(t, remote.Write(ref, img))
+	}
+	now := time.Now()
+	push("team/app:v1", now.Add(-3*time.Hour), "v1")
+	push("team/app:v2", now.Add(-2*time.Hour), "v2")
+	push("team/app:dev", now.Add(-1*time.Hour), "dev")
+	push("team/app:v3", now, "v3")
+	push("team/nested/lib:v1", now, "lib")
+	push("other/tool:v1", now, "tool")
+
+	s := newTestSource(t)
+	require.NoError(t, s.WithRegistry(host))
+	require.NoError(t, s.WithRepositoryGlobs([]string{"team/*"}, nil))
+	require.NoError(t, s.WithTagGlobs(nil, []string{"dev"}))
+	s.WithMaxTags(2)
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+	require.Empty(t, reporter.UnitErrs)
+	assert.Equal(t, []sources.SourceUnit{
+		sources.CommonSourceUnit{ID: host + "/team/app:v3"},
+		sources.CommonSourceUnit{ID: host + "/team/app:v2"},
+	}, reporter.Units)
+
+	// The base layer is shared by both images and scanned once.
+	chunksChan := make(chan *sources.Chunk, 64)
+	require.NoError(t, s.Chunks(context.Background(), chunksChan))
+	close(chunksChan)
+	counts := make(map[string]int)
+	for chunk := range chunksChan {
+		counts[chunk.SourceMetadata.GetDocker().GetFile()]++
+	}
+	assert.Equal(t, 1, counts["/etc/shared.conf"])
+	assert.Equal(t, 2, counts["/app"])
+}
+
+// failingLayer fails to open the first time, once released.
+type failingLayer struct {
+	v1.Layer
+	opened, release chan struct{}
+	once            sync.Once
+}
+
+func (l *failingLayer) Compressed() (io.ReadCloser, error) {
+	failed := false
+	l.once.Do(func() {
+		close(l.opened)
+		<-l.release
+		failed = true
+	})
+	if failed {
+		return nil, fmt.Errorf("connection reset")
+	}
+	return l.Layer.Compressed()
+}
+
+func TestScanLayer_CachedLayerFails(t *testing.T) {
+	s := newTestSource(t)
+	s.layerCache = newLayerCache()
+	layer := &failingLayer{
+		Layer:   testLayer(t, layerFile{"etc/shared.conf", "password=base"}),
+		opened:  make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	scan := func(image string, reporter *sourcestest.TestReporter) error {
+		return s.scanLayer(context.Background(), layer, imageInfo{base: image}, newWhiteouts(), reporter)
+	}
+
+	// The first image fails to read the layer while the second one waits for
+	// it, and must then scan it itself rather than skip it.
+	var first, second sourcestest.TestReporter
+	firstErr := make(chan error, 1)
+	go func() { firstErr <- scan("first", &first) }()
+	<-layer.opened
+	secondErr := make(chan error, 1)
+	go func() { secondErr <- scan("second", &second) }()
+	close(layer.release)
+
+	assert.Error(t, <-firstErr)
+	require.NoError(t, <-secondErr)
+	require.Len(t, second.Chunks, 1)
+	assert.Equal(t, "/etc/shared.conf", second.Chunks[0].SourceMetadata.GetDocker().GetFile())
+
+	// Once scanned, the layer is skipped.
+	var third sourcestest.TestReporter
+	require.NoError(t, scan("third", &third))
+	assert.Empty(t, third.Chunks)
+}
@@ -0,0 +1,363 @@
+package docker
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Prefixes of image references that are read from a local image store instead
+// of a registry.
+const (
+	daemonPrefix     = "docker-daemon://"
+	ociLayoutPrefix  = "oci-layout://"
+	containerdPrefix = "containerd://"
+)
+
+const (
+	defaultContainerdRoot      = "/var/lib/containerd"
+	defaultContainerdNamespace = "default"
+
+	// refNameAnnotation holds the tag of a manifest in the index of an OCI
+	// image layout.
+	refNameAnnotation = "org.opencontainers.image.ref.name"
+)
+
+// defaultPlatform is used to pick an image out of a multi-platform index when
+// no platform is configured, as it is for registries.
+var defaultPlatform = v1.Platform{OS: "linux", Architecture: "amd64"}
+
+// splitLayoutReference splits an oci-layout reference, without its prefix,
+// into the layout directory and an optional tag or digest: dir, dir:tag or
+// dir@sha256:....
+func splitLayoutReference(ref string) (dir, tag string) {
+	if i := strings.LastIndex(ref, "@"); i >= 0 {
+		return ref[:i], ref[i+1:]
+	}
+	if i := strings.LastIndex(ref, ":"); i >= 0 && !strings.Contains(ref[i+1:], "/") {
+		return ref[:i], ref[i+1:]
+	}
+	return ref, ""
+}
+
+// layoutImage reads an image from an OCI image layout directory. The tag
+// selects a manifest of the layout index by its ref name annotation or by
+// digest, and may be omitted when the layout holds a single image.
+func (s *Source) layoutImage(dir, tag string) (v1.Image, error) {
+	f, err := os.Open(filepath.Join(dir, "index.json"))
+	if err != nil {
+		return nil, fmt.Errorf("error reading OCI layout: %w", err)
+	}
+	defer f.Close()
+	index, err := v1.ParseIndexManifest(f)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing OCI layout index: %w", err)
+	}
+
+	descs := index.Manifests
+	if tag != "" {
+		descs = nil
+		for _, desc := range index.Manifests {
+			if desc.Annotations[refNameAnnotation] == tag || desc.Digest.String() == tag {
+				descs = append(descs, desc)
+			}
+		}
+		if len(descs) == 0 {
+			return nil, fmt.Errorf("no image %q in OCI layout %s", tag, dir)
+		}
+	}
+
+	desc, err := s.selectManifest(descs)
+	if err != nil {
+		return nil, err
+	}
+	return s.resolveImage(blobStore(dir), desc)
+}
+
+// containerdImage reads an image from the containerd content store.
+func (s *Source) containerdImage(image string) (v1.Image, error) {
+	return s.containerdStoreImage(s.containerdNamespace, containerdNames(image))
+}
+
+// containerdStoreImage reads the first of names found in the namespace of the
+// containerd content store. Image names are resolved with the containerd
+// metadata database, which containerd keeps locked while it runs, so a copy of
+// it is read.
+func (s *Source) containerdStoreImage(namespace string, names []string) (v1.Image, error) {
+	dbPath := filepath.Join(s.containerdRoot, "io.containerd.metadata.v1.bolt", "meta.db")
+	desc, err := containerdTarget(dbPath, namespace, names)
+	if err != nil {
+		return nil, err
+	}
+	store := blobStore(filepath.Join(s.containerdRoot, "io.containerd.content.v1.content"))
+	return s.resolveImage(store, desc)
+}
+
+// containerdNames returns the names an image may be stored under in
+// containerd, which keeps fully qualified names such as
+// docker.io/library/alpine:latest.
+func containerdNames(image string) []string {
+	names := []string{image}
+	base, tag, hasDigest := baseAndTagFromImage(image)
+	sep := ":"
+	if hasDigest {
+		sep = "@"
+	}
+	if first, _, _ := strings.Cut(base, "/"); !strings.ContainsAny(first, ".:") && first != "localhost" {
+		if !strings.Contains(base, "/") {
+			base = "library/" + base
+		}
+		base = "docker.io/" + base
+	}
+	if qualified := base + sep + tag; qualified != image {
+		names = append(names, qualified)
+	}
+	return names
+}
+
+// containerdTarget looks up the target descriptor of the first of names found
+// in the images of the namespace.
+func containerdTarget(dbPath, namespace string, names []string) (v1.Descriptor, error) {
+	src, err := os.Open(dbPath)
+	if err != nil {
+		return v1.Descriptor{}, fmt.Errorf("error opening containerd metadata: %w", err)
+	}
+	defer src.Close()
+
+	tmp, err := os.CreateTemp("", "trufflehog-containerd-*.db")
+	if err != nil {
+		return v1.Descriptor{}, err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, src)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return v1.Descriptor{}, fmt.Errorf("error copying containerd metadata: %w", err)
+	}
+
+	db, err := bolt.Open(tmp.Name(), 0o600, &bolt.Options{ReadOnly: true})
+	if err != nil {
+		return v1.Descriptor{}, fmt.Errorf("error opening containerd metadata: %w", err)
+	}
+	defer db.Close()
+
+	var desc v1.Descriptor
+	err = db.View(func(tx *bolt.Tx) error {
+		images := bucket(tx, "v1", namespace, "images")
+		if images == nil {
+			return fmt.Errorf("no images in containerd namespace %q", namespace)
+		}
+		for _, name := range names {
+			target := images.Bucket([]byte(name))
+			if target != nil {
+				target = target.Bucket([]byte("target"))
+			}
+			if target == nil {
+				continue
+			}
+			digest, err := v1.NewHash(string(target.Get([]byte("digest"))))
+			if err != nil {
+				return fmt.Errorf("invalid digest for image %q: %w", name, err)
+			}
+			size, _ := binary.Varint(target.Get([]byte("size")))
+			desc = v1.Descriptor{
+				MediaType: types.MediaType(target.Get([]byte("mediatype"))),
+				Digest:    digest,
+				Size:      size,
+			}
+			return nil
+		}
+		return fmt.Errorf("image %q not found in containerd namespace %q", names[0], namespace)
+	})
+	return desc, err
+}
+
+// bucket returns the nested bucket at path, or nil if it does not exist.
+func bucket(tx *bolt.Tx, path ...string) *bolt.Bucket {
+	b := tx.Bucket([]byte(path[0]))
+	for _, name := range path[1:] {
+		if b == nil {
+			return nil
+		}
+		b = b.Bucket([]byte(name))
+	}
+	return b
+}
+
+// selectManifest picks the manifest to scan out of an index: the only one if
+// there is a single candidate, otherwise the one matching the configured
+// platform. Manifests without a real platform, such as build attestations,
+// are not candidates.
+func (s *Source) selectManifest(descs []v1.Descriptor) (v1.Descriptor, error) {
+	var candidates []v1.Descriptor
+	for _, desc := range descs {
+		if desc.Platform != nil && desc.Platform.OS == "unknown" {
+			continue
+		}
+		candidates = append(candidates, desc)
+	}
+	if len(candidates) == 0 {
+		return v1.Descriptor{}, errors.New("no image manifest found")
+	}
+	// A manifest without a platform cannot be matched; a nested index may
+	// still carry platforms.
+	if len(candidates) == 1 && (s.platform == nil || candidates[0].Platform == nil) {
+		return candidates[0], nil
+	}
+
+	want := defaultPlatform
+	if s.platform != nil {
+		want = *s.platform
+	}
+	var available []string
+	for _, desc := range candidates {
+		if desc.Platform == nil {
+			continue
+		}
+		if desc.Platform.Satisfies(want) {
+			return desc, nil
+		}
+		available = append(available, desc.Platform.String())
+	}
+	return v1.Descriptor{}, fmt.Errorf("no image for platform %s, available: %s", want.String(), strings.Join(available, ", "))
+}
+
+// resolveImage returns the image described by desc, picking a manifest by
+// platform when desc is an index.
+func (s *Source) resolveImage(store blobStore, desc v1.Descriptor) (v1.Image, error) {
+	switch {
+	case desc.MediaType.IsIndex():
+		rc, err := store.blob(desc.Digest)
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		index, err := v1.ParseIndexManifest(rc)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing image index %s: %w", desc.Digest, err)
+		}
+		manifest, err := s.selectManifest(index.Manifests)
+		if err != nil {
+			return nil, err
+		}
+		return s.resolveImage(store, manifest)
+	case desc.MediaType.IsImage():
+		return partial.CompressedToImage(&storeImage{store: store, desc: desc})
+	default:
+		return nil, fmt.Errorf("unsupported media type %q for %s", desc.MediaType, desc.Digest)
+	}
+}
+
+// blobStore is a directory of content addressed blobs stored as
+// blobs/<algorithm>/<hex>, the layout shared by OCI image layouts and the
+// containerd content store.
+type blobStore string
+
+func (b blobStore) blob(h v1.Hash) (io.ReadCloser, error) {
+	f, err := os.Open(filepath.Join(string(b), "blobs", h.Algorithm, h.Hex))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("blob %s not found in %s", h, b)
+	}
+	return f, err
+}
+
+func (b blobStore) bytes(h v1.Hash) ([]byte, error) {
+	rc, err := b.blob(h)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// storeImage is an image read from a blobStore.
+type storeImage struct {
+	store    blobStore
+	desc     v1.Descriptor
+	manifest *v1.Manifest
+}
+
+var _ partial.CompressedImageCore = (*storeImage)(nil)
+
+func (i *storeImage) MediaType() (types.MediaType, error) {
+	return i.desc.MediaType, nil
+}
+
+func (i *storeImage) RawManifest() ([]byte, error) {
+	return i.store.bytes(i.desc.Digest)
+}
+
+func (i *storeImage) loadManifest() (*v1.Manifest, error) {
+	if i.manifest != nil {
+		return i.manifest, nil
+	}
+	raw, err := i.RawManifest()
+	if err != nil {
+		return nil, err
+	}
+	var m v1.Manifest
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, fmt.Errorf("error parsing manifest %s: %w", i.desc.Digest, err)
+	}
+	i.manifest = &m
+	return i.manifest, nil
+}
+
+func (i *storeImage) RawConfigFile() ([]byte, error) {
+	m, err := i.loadManifest()
+	if err != nil {
+		return nil, err
+	}
+	return i.store.bytes(m.Config.Digest)
+}
+
+func (i *storeImage) LayerByDigest(h v1.Hash) (partial.CompressedLayer, error) {
+	m, err := i.loadManifest()
+	if err != nil {
+		return nil, err
+	}
+	if m.Config.Digest == h {
+		return &storeLayer{store: i.store, desc: m.Config}, nil
+	}
+	for _, desc := range m.Layers {
+		if desc.Digest == h {
+			return &storeLayer{store: i.store, desc: desc}, nil
+		}
+	}
+	return nil, fmt.Errorf("layer %s not found in manifest %s", h, i.desc.Digest)
+}
+
+// storeLayer is a compressed layer, or the config, of a storeImage.
+type storeLayer struct {
+	store blobStore
+	desc  v1.Descriptor
+}
+
+func (l *storeLayer) Digest() (v1.Hash, error) {
+	return l.desc.Digest, nil
+}
+
+func (l *storeLayer) Compressed() (io.ReadCloser, error) {
+	return l.store.blob(l.desc.Digest)
+}
+
+func (l *storeLayer) Size() (int64, error) {
+	return l.desc.Size, nil
+}
+
+func (l *storeLayer) MediaType() (types.MediaType, error) {
+	return l.desc.MediaType, nil
+}
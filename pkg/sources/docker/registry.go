@@ -0,0 +1,254 @@
+package docker
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gobwas/glob"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// registryScan selects the repositories and tags of a registry to scan.
+type registryScan struct {
+	registry     name.Registry
+	includeRepos []glob.Glob
+	excludeRepos []glob.Glob
+	includeTags  []glob.Glob
+	excludeTags  []glob.Glob
+	// maxTags, when positive, limits the scan to the most recently created
+	// tags of each repository.
+	maxTags int
+}
+
+// WithRegistry enumerates every repository of the registry, such as
+// registry.example.com or localhost:5000, through the catalog API. Layers
+// shared by several images are then scanned once, and their results are
+// reported with the first image they were found in.
+func (s *Source) WithRegistry(registry string) error {
+	reg, err := name.NewRegistry(registry)
+	if err != nil {
+		return fmt.Errorf("invalid registry %q: %w", registry, err)
+	}
+	if s.registry == nil {
+		s.registry = &registryScan{}
+	}
+	s.registry.registry = reg
+	s.layerCache = newLayerCache()
+	return nil
+}
+
+// WithRepositoryGlobs limits a registry scan to the repositories matching an
+// include glob, if any, and none of the exclude globs. In globs, "*" does not
+// match "/" and "**" does.
+func (s *Source) WithRepositoryGlobs(include, exclude []string) error {
+	if s.registry == nil {
+		s.registry = &registryScan{}
+	}
+	var err error
+	if s.registry.includeRepos, err = compileGlobs(include); err != nil {
+		return err
+	}
+	s.registry.excludeRepos, err = compileGlobs(exclude)
+	return err
+}
+
+// WithTagGlobs limits a registry scan to the tags matching an include glob,
+// if any, and none of the exclude globs.
+func (s *Source) WithTagGlobs(include, exclude []string) error {
+	if s.registry == nil {
+		s.registry = &registryScan{}
+	}
+	var err error
+	if s.registry.includeTags, err = compileGlobs(include); err != nil {
+		return err
+	}
+	s.registry.excludeTags, err = compileGlobs(exclude)
+	return err
+}
+
+// WithMaxTags limits a registry scan to the n most recently created tags of
+// each repository. Zero means every tag.
+func (s *Source) WithMaxTags(n int) {
+	if s.registry == nil {
+		s.registry = &registryScan{}
+	}
+	s.registry.maxTags = n
+}
+
+func compileGlobs(patterns []string) ([]glob.Glob, error) {
+	globs := make([]glob.Glob, 0, len(patterns))
+	for _, p := range patterns {
+		g, err := glob.Compile(p, '/')
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", p, err)
+		}
+		globs = append(globs, g)
+	}
+	return globs, nil
+}
+
+// selected reports whether name matches one of include, or include is empty,
+// and none of exclude.
+func selected(include, exclude []glob.Glob, name string) bool {
+	matchesAny := func(globs []glob.Glob) bool {
+		for _, g := range globs {
+			if g.Match(name) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(include) > 0 && !matchesAny(include) {
+		return false
+	}
+	return !matchesAny(exclude)
+}
+
+// enumerateRegistry reports every selected tag of every selected repository
+// of the registry.
+func (s *Source) enumerateRegistry(ctx context.Context, reporter sources.UnitReporter) error {
+	if s.registry.registry.Name() == "" {
+		return nil
+	}
+	opts, err := s.remoteOpts()
+	if err != nil {
+		return err
+	}
+	opts = append(opts, remote.WithContext(ctx))
+
+	repos, err := remote.Catalog(ctx, s.registry.registry, opts...)
+	if err != nil {
+		return reporter.UnitErr(ctx, fmt.Errorf("could not list repositories of %s: %w", s.registry.registry, err))
+	}
+
+	for _, repo := range repos {
+		if !selected(s.registry.includeRepos, s.registry.excludeRepos, repo) {
+			continue
+		}
+		ref := s.registry.registry.Repo(repo)
+		tags, err := remote.List(ref, opts...)
+		if err != nil {
+			if err := reporter.UnitErr(ctx, fmt.Errorf("could not list tags of %s: %w", ref, err)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var kept []string
+		for _, tag := range tags {
+			if selected(s.registry.includeTags, s.registry.excludeTags, tag) {
+				kept = append(kept, tag)
+			}
+		}
+		if s.registry.maxTags > 0 && len(kept) > s.registry.maxTags {
+			kept = latestTags(ctx, ref, kept, s.registry.maxTags, opts)
+		}
+
+		for _, tag := range kept {
+			if err := reporter.UnitOk(ctx, sources.CommonSourceUnit{ID: ref.Tag(tag).String()}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// latestTags returns the n tags whose images were created last. The tags list
+// API has no dates, so the config of every tag is read. Tags whose config
+// cannot be read sort last.
+func latestTags(ctx context.Context, repo name.Repository, tags []string, n int, opts []remote.Option) []string {
+	created := make(map[string]time.Time, len(tags))
+	for _, tag := range tags {
+		img, err := remote.Image(repo.Tag(tag), opts...)
+		if err != nil {
+			ctx.Logger().V(2).Error(err, "could not get image creation time", "image", repo.Tag(tag).String())
+			continue
+		}
+		config, err := img.ConfigFile()
+		if err != nil {
+			ctx.Logger().V(2).Error(err, "could not get image creation time", "image", repo.Tag(tag).String())
+			continue
+		}
+		created[tag] = config.Created.Time
+	}
+
+	sorted := append([]string(nil), tags...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ti, tj := created[sorted[i]], created[sorted[j]]
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return sorted[i] > sorted[j]
+	})
+	return sorted[:n]
+}
+
+// layerCache records the layers already scanned so that layers shared by
+// several images are scanned once.
+type layerCache struct {
+	mu     sync.Mutex
+	layers map[v1.Hash]*cachedLayer
+}
+
+// cachedLayer is a layer scanned, or being scanned, for an image. Its
+// whiteouts, or failed, are set before done is closed.
+type cachedLayer struct {
+	done      chan struct{}
+	whiteouts *whiteouts
+	failed    bool
+}
+
+func newLayerCache() *layerCache {
+	return &layerCache{layers: make(map[v1.Hash]*cachedLayer)}
+}
+
+// claim returns the cache entry of a layer and whether the caller is the first
+// to see it, in which case it must scan the layer and then close done.
+func (c *layerCache) claim(digest v1.Hash) (*cachedLayer, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.layers[digest]; ok {
+		return entry, false
+	}
+	entry := &cachedLayer{done: make(chan struct{}), whiteouts: newWhiteouts()}
+	c.layers[digest] = entry
+	return entry, true
+}
+
+// whiteouts returns the whiteouts of a layer scanned for another image.
+func (c *layerCache) whiteouts(digest v1.Hash) (*whiteouts, bool) {
+	c.mu.Lock()
+	entry, ok := c.layers[digest]
+	c.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	select {
+	case <-entry.done:
+		return entry.whiteouts, !entry.failed
+	default:
+		return nil, false
+	}
+}
+
+// finish records the outcome of the scan of a claimed layer. A layer that
+// failed to scan is forgotten, so that the images waiting for it scan it
+// again instead of skipping it.
+func (c *layerCache) finish(digest v1.Hash, entry *cachedLayer, whiteouts *whiteouts, err error) {
+	if err != nil {
+		c.mu.Lock()
+		delete(c.layers, digest)
+		c.mu.Unlock()
+		entry.failed = true
+	} else {
+		entry.whiteouts = whiteouts
+	}
+	close(entry.done)
+}
@@ -49,6 +49,7 @@ func TestSource_Scan(t *testing.T) {
 						File: "filesystem.go",
 					},
 				},
+				FileOffset: &source_metadatapb.FileOffset{},
 			},
 			wantErr: false,
 		},
@@ -0,0 +1,194 @@
+package gerrit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+// xssiPrefix is prepended by Gerrit to every JSON response to prevent
+// cross-site script inclusion.
+var xssiPrefix = []byte(")]}'")
+
+const changesPageSize = 100
+
+type account struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Username string `json:"username"`
+}
+
+// String prefers the email address of the account.
+func (a account) String() string {
+	switch {
+	case a.Email != "":
+		return a.Email
+	case a.Username != "":
+		return a.Username
+	default:
+		return a.Name
+	}
+}
+
+type revision struct {
+	Number int    `json:"_number"`
+	Ref    string `json:"ref"`
+}
+
+type changeMessage struct {
+	ID             string  `json:"id"`
+	Author         account `json:"author"`
+	Date           string  `json:"date"`
+	Message        string  `json:"message"`
+	RevisionNumber int     `json:"_revision_number"`
+}
+
+// change is a Gerrit change with all of its patch sets and messages.
+type change struct {
+	ID          string              `json:"id"`
+	Number      int                 `json:"_number"`
+	Project     string              `json:"project"`
+	Branch      string              `json:"branch"`
+	Subject     string              `json:"subject"`
+	Status      string              `json:"status"`
+	Owner       account             `json:"owner"`
+	Created     string              `json:"created"`
+	Revisions   map[string]revision `json:"revisions"`
+	Messages    []changeMessage     `json:"messages"`
+	MoreChanges bool                `json:"_more_changes"`
+}
+
+// revisionByNumber returns the commit of the given patch set number.
+func (c change) revisionByNumber(number int) string {
+	for sha, rev := range c.Revisions {
+		if rev.Number == number {
+			return sha
+		}
+	}
+	return ""
+}
+
+// comment is an inline review comment.
+type comment struct {
+	ID       string  `json:"id"`
+	Path     string  `json:"-"`
+	Line     int64   `json:"line"`
+	Message  string  `json:"message"`
+	Author   account `json:"author"`
+	Updated  string  `json:"updated"`
+	PatchSet int     `json:"patch_set"`
+	CommitID string  `json:"commit_id"`
+}
+
+// client is a minimal Gerrit REST API client.
+type client struct {
+	httpClient *http.Client
+	// baseURL is the endpoint, with the "/a" prefix when authenticated.
+	baseURL  string
+	username string
+	password string
+}
+
+func (c *client) get(ctx context.Context, path string, target any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create Gerrit API request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request to Gerrit API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status code %d from %q: %s", resp.StatusCode, req.URL.Path, strings.TrimSpace(string(body)))
+	}
+
+	body := bufio.NewReader(resp.Body)
+	if prefix, err := body.Peek(len(xssiPrefix)); err == nil && bytes.Equal(prefix, xssiPrefix) {
+		// Discard the rest of the prefix line.
+		if _, err := body.ReadString('\n'); err != nil {
+			return fmt.Errorf("failed to read Gerrit API response: %w", err)
+		}
+	}
+	if err := json.NewDecoder(body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode Gerrit API response: %w", err)
+	}
+	return nil
+}
+
+// listProjects lists the names of all code projects, sorted.
+func (c *client) listProjects(ctx context.Context) ([]string, error) {
+	var projects map[string]json.RawMessage
+	if err := c.get(ctx, "/projects/?type=CODE", &projects); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(projects))
+	for name := range projects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// listChanges visits every change of the project, regardless of status.
+func (c *client) listChanges(ctx context.Context, project string, visit func(change) error) error {
+	query := url.Values{
+		"q": {"project:" + project},
+		"o": {"ALL_REVISIONS", "MESSAGES", "DETAILED_ACCOUNTS"},
+		"n": {fmt.Sprint(changesPageSize)},
+	}
+	for start := 0; ; {
+		query.Set("S", fmt.Sprint(start))
+		var changes []change
+		if err := c.get(ctx, "/changes/?"+query.Encode(), &changes); err != nil {
+			return err
+		}
+		for _, ch := range changes {
+			if err := visit(ch); err != nil {
+				return err
+			}
+		}
+		if len(changes) == 0 || !changes[len(changes)-1].MoreChanges {
+			return nil
+		}
+		start += len(changes)
+	}
+}
+
+// listComments lists the published inline comments of every patch set of a
+// change, sorted by file.
+func (c *client) listComments(ctx context.Context, changeNumber int) ([]comment, error) {
+	var byFile map[string][]comment
+	if err := c.get(ctx, fmt.Sprintf("/changes/%d/comments", changeNumber), &byFile); err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(byFile))
+	for file := range byFile {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	var comments []comment
+	for _, file := range files {
+		for _, cm := range byFile[file] {
+			cm.Path = file
+			comments = append(comments, cm)
+		}
+	}
+	return comments, nil
+}
@@ -0,0 +1,327 @@
+package gerrit
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/log"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/git"
+)
+
+const SourceType = sourcespb.SourceType_SOURCE_TYPE_GERRIT
+
+// changeRefs is the refspec of every patch set of every change. Patch sets
+// that were amended away or belong to abandoned changes are only reachable
+// from these refs.
+const changeRefs = "+refs/changes/*:refs/changes/*"
+
+// Source scans the projects of a Gerrit server: their full branch history,
+// every patch set of every change, and change messages and inline comments.
+type Source struct {
+	name     string
+	sourceID sources.SourceID
+	jobID    sources.JobID
+	verify   bool
+
+	// endpoint is the Gerrit URL without a trailing slash.
+	endpoint string
+	user     string
+	password string
+	projects []string
+	client   *client
+
+	useCustomContentWriter bool
+	git                    *git.Git
+	scanOptions            *git.ScanOptions
+
+	jobPool *errgroup.Group
+	sources.Progress
+	sources.CommonSourceUnitUnmarshaller
+}
+
+// Ensure the Source satisfies the interfaces at compile time.
+var _ sources.Source = (*Source)(nil)
+var _ sources.SourceUnitUnmarshaller = (*Source)(nil)
+var _ sources.SourceUnitEnumChunker = (*Source)(nil)
+
+// WithCustomContentWriter sets the useCustomContentWriter flag on the source.
+func (s *Source) WithCustomContentWriter() { s.useCustomContentWriter = true }
+
+// Type returns the type of source.
+// It is used for matching source types in configuration and job input.
+func (s *Source) Type() sourcespb.SourceType {
+	return SourceType
+}
+
+func (s *Source) SourceID() sources.SourceID {
+	return s.sourceID
+}
+
+func (s *Source) JobID() sources.JobID {
+	return s.jobID
+}
+
+// Init returns an initialized Gerrit source.
+func (s *Source) Init(ctx context.Context, name string, jobId sources.JobID, sourceId sources.SourceID, verify bool, connection *anypb.Any, concurrency int) error {
+	s.name = name
+	s.sourceID = sourceId
+	s.jobID = jobId
+	s.verify = verify
+	s.jobPool = &errgroup.Group{}
+	s.jobPool.SetLimit(concurrency)
+
+	if err := git.CmdCheck(); err != nil {
+		return err
+	}
+
+	var conn sourcespb.Gerrit
+	if err := anypb.UnmarshalTo(connection, &conn, proto.UnmarshalOptions{}); err != nil {
+		return fmt.Errorf("error unmarshalling connection: %w", err)
+	}
+	if conn.GetEndpoint() == "" {
+		return fmt.Errorf("a Gerrit endpoint is required")
+	}
+	s.endpoint = strings.TrimSuffix(conn.GetEndpoint(), "/")
+	s.projects = conn.GetProjects()
+
+	switch cred := conn.GetCredential().(type) {
+	case *sourcespb.Gerrit_BasicAuth:
+		s.user = cred.BasicAuth.GetUsername()
+		s.password = cred.BasicAuth.GetPassword()
+		log.RedactGlobally(s.password)
+	case *sourcespb.Gerrit_Unauthenticated:
+	default:
+		return fmt.Errorf("invalid configuration given for source %q (%s)", name, s.Type().String())
+	}
+
+	s.client = &client{
+		httpClient: common.RetryableHTTPClientTimeout(60),
+		baseURL:    s.restBaseURL(),
+		username:   s.user,
+		password:   s.password,
+	}
+
+	cfg := &git.Config{
+		SourceName:   s.name,
+		JobID:        s.jobID,
+		SourceID:     s.sourceID,
+		SourceType:   s.Type(),
+		Verify:       s.verify,
+		SkipBinaries: conn.GetSkipBinaries(),
+		SkipArchives: conn.GetSkipArchives(),
+		Concurrency:  concurrency,
+		SourceMetadataFunc: func(file, email, commit, timestamp, repository string, line int64) *source_metadatapb.MetaData {
+			return &source_metadatapb.MetaData{
+				Data: &source_metadatapb.MetaData_Gerrit{
+					Gerrit: &source_metadatapb.Gerrit{
+						Commit:    sanitizer.UTF8(commit),
+						File:      sanitizer.UTF8(file),
+						Email:     sanitizer.UTF8(email),
+						Project:   sanitizer.UTF8(s.projectFromURL(repository)),
+						Timestamp: sanitizer.UTF8(timestamp),
+						Line:      line,
+					},
+				},
+			}
+		},
+		UseCustomContentWriter: s.useCustomContentWriter,
+	}
+	s.git = git.NewGit(cfg)
+
+	return nil
+}
+
+// WithScanOptions sets the git scan options used for every project.
+func (s *Source) WithScanOptions(scanOptions *git.ScanOptions) {
+	s.scanOptions = scanOptions
+}
+
+// restBaseURL returns the base URL for REST and git requests. Gerrit serves
+// authenticated requests under the "/a" prefix.
+func (s *Source) restBaseURL() string {
+	if s.user != "" {
+		return s.endpoint + "/a"
+	}
+	return s.endpoint
+}
+
+// cloneURL returns the HTTP clone URL of a project.
+func (s *Source) cloneURL(project string) string {
+	return s.restBaseURL() + "/" + project
+}
+
+// projectFromURL recovers the project name from its clone URL.
+func (s *Source) projectFromURL(repoURL string) string {
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return repoURL
+	}
+	path := u.Path
+	if base, err := url.Parse(s.endpoint); err == nil {
+		path = strings.TrimPrefix(path, base.Path)
+	}
+	path = strings.TrimPrefix(path, "/")
+	path = strings.TrimPrefix(path, "a/")
+	return strings.TrimSuffix(path, ".git")
+}
+
+// Enumerate reports the configured projects, or every code project on the
+// server, to the reporter. Units are identified by project name.
+func (s *Source) Enumerate(ctx context.Context, reporter sources.UnitReporter) error {
+	projects := s.projects
+	if len(projects) == 0 {
+		var err error
+		if projects, err = s.client.listProjects(ctx); err != nil {
+			return reporter.UnitErr(ctx, fmt.Errorf("could not list projects: %w", err))
+		}
+	}
+
+	gerritProjectsEnumerated.WithLabelValues(s.name).Set(0)
+	for _, project := range projects {
+		// The meta projects hold configuration and user data, not code.
+		if project == "All-Projects" || project == "All-Users" {
+			continue
+		}
+		if err := reporter.UnitOk(ctx, git.SourceUnit{Kind: git.UnitRepo, ID: project}); err != nil {
+			return err
+		}
+		gerritProjectsEnumerated.WithLabelValues(s.name).Inc()
+	}
+	return nil
+}
+
+// ChunkUnit clones the project including every change ref, scans its history,
+// and then scans change messages and inline comments.
+func (s *Source) ChunkUnit(ctx context.Context, unit sources.SourceUnit, reporter sources.ChunkReporter) error {
+	project, _ := unit.SourceUnitID()
+	ctx = context.WithValue(ctx, "project", project)
+
+	path, repo, err := s.cloneProject(ctx, project)
+	if err != nil {
+		return reporter.ChunkErr(ctx, err)
+	}
+	defer os.RemoveAll(path)
+
+	if err := s.git.ScanRepo(ctx, repo, path, s.scanOptions, reporter); err != nil {
+		return reporter.ChunkErr(ctx, err)
+	}
+	gerritProjectsScanned.WithLabelValues(s.name).Inc()
+
+	return s.chunkChanges(ctx, project, reporter)
+}
+
+func (s *Source) cloneProject(ctx context.Context, project string) (string, *gogit.Repository, error) {
+	var (
+		path string
+		repo *gogit.Repository
+		err  error
+	)
+	if s.user != "" {
+		path, repo, err = git.CloneRepoUsingToken(ctx, s.password, s.cloneURL(project), s.user)
+	} else {
+		path, repo, err = git.CloneRepoUsingUnauthenticated(ctx, s.cloneURL(project))
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	if err := fetchChangeRefs(ctx, path); err != nil {
+		os.RemoveAll(path)
+		return "", nil, err
+	}
+	return path, repo, nil
+}
+
+// fetchChangeRefs fetches every patch set ref into the clone so that the git
+// scan, which walks all refs, covers patch sets that never reached a branch.
+func fetchChangeRefs(ctx context.Context, path string) error {
+	cmd := exec.CommandContext(ctx, "git", "-C", path, "fetch", "--quiet", "origin", changeRefs)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("could not fetch change refs: %w, %s", err, out)
+	}
+	return nil
+}
+
+// chunkChanges reports the messages and inline comments of every change of
+// the project.
+func (s *Source) chunkChanges(ctx context.Context, project string, reporter sources.ChunkReporter) error {
+	var reportErr error
+	err := s.client.listChanges(ctx, project, func(ch change) error {
+		for _, msg := range ch.Messages {
+			if msg.Message == "" {
+				continue
+			}
+			chunk := s.reviewChunk(project, ch.revisionByNumber(msg.RevisionNumber), "", 0, msg.Author.String(), msg.Date, msg.Message)
+			if reportErr = reporter.ChunkOk(ctx, chunk); reportErr != nil {
+				return reportErr
+			}
+		}
+
+		comments, err := s.client.listComments(ctx, ch.Number)
+		if err != nil {
+			reportErr = reporter.ChunkErr(ctx, fmt.Errorf("could not list comments of change %d: %w", ch.Number, err))
+			return reportErr
+		}
+		for _, cm := range comments {
+			commit := cm.CommitID
+			if commit == "" {
+				commit = ch.revisionByNumber(cm.PatchSet)
+			}
+			chunk := s.reviewChunk(project, commit, cm.Path, cm.Line, cm.Author.String(), cm.Updated, cm.Message)
+			if reportErr = reporter.ChunkOk(ctx, chunk); reportErr != nil {
+				return reportErr
+			}
+		}
+		gerritChangesScanned.WithLabelValues(s.name).Inc()
+		return nil
+	})
+	if reportErr != nil {
+		return reportErr
+	}
+	if err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not list changes: %w", err))
+	}
+	return nil
+}
+
+func (s *Source) reviewChunk(project, commit, file string, line int64, email, timestamp, data string) sources.Chunk {
+	return sources.Chunk{
+		SourceName: s.name,
+		SourceID:   s.SourceID(),
+		JobID:      s.JobID(),
+		SourceType: s.Type(),
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Gerrit{
+				Gerrit: &source_metadatapb.Gerrit{
+					Commit:    sanitizer.UTF8(commit),
+					File:      sanitizer.UTF8(file),
+					Email:     sanitizer.UTF8(email),
+					Project:   sanitizer.UTF8(project),
+					Timestamp: sanitizer.UTF8(timestamp),
+					Line:      line,
+				},
+			},
+		},
+		Data:   []byte(data),
+		Verify: s.verify,
+	}
+}
+
+// Chunks emits chunks of bytes over a channel.
+func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ ...sources.ChunkingTarget) error {
+	gerritProjectsScanned.WithLabelValues(s.name).Set(0)
+	return sources.ChunkUnits(ctx, s, s.jobPool, &s.Progress, "Project", chunksChan)
+}
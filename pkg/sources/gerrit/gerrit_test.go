@@ -0,0 +1,176 @@
+package gerrit
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/git"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sourcestest"
+)
+
+// newTestServer serves canned, XSSI-prefixed JSON responses keyed by request
+// path. Responses for a path followed by "?S=1" are served when listing from
+// the second result.
+func newTestServer(t *testing.T, responses map[string]any) *sourcestest.Server {
+	t.Helper()
+	return sourcestest.NewServer(t, responses,
+		sourcestest.WithAuth(sourcestest.BasicAuth("user", "http-password")),
+		sourcestest.WithPrefix(")]}'\n"),
+		sourcestest.WithPages("S", "1", nil),
+	)
+}
+
+func initSource(t *testing.T, endpoint string, cred *sourcespb.Gerrit) *Source {
+	t.Helper()
+	cred.Endpoint = endpoint
+	conn, err := anypb.New(cred)
+	require.NoError(t, err)
+
+	s := &Source{}
+	require.NoError(t, s.Init(context.Background(), "test", 0, 0, false, conn, 1))
+	return s
+}
+
+func basicAuth() *sourcespb.Gerrit {
+	return &sourcespb.Gerrit{Credential: &sourcespb.Gerrit_BasicAuth{
+		BasicAuth: &credentialspb.BasicAuth{Username: "user", Password: "http-password"},
+	}}
+}
+
+func TestEnumerate(t *testing.T) {
+	srv := newTestServer(t, map[string]any{
+		"/a/projects/": `{"All-Projects": {}, "All-Users": {}, "platform/api": {}, "docs": {}}`,
+	})
+	s := initSource(t, srv.URL+"/", basicAuth())
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+	assert.Empty(t, reporter.UnitErrs)
+	assert.Equal(t, []string{"docs", "platform/api"}, func() []string {
+		var ids []string
+		for _, u := range reporter.Units {
+			id, _ := u.SourceUnitID()
+			ids = append(ids, id)
+		}
+		return ids
+	}())
+	assert.Equal(t, "platform/api", s.projectFromURL(srv.URL+"/a/platform/api"))
+}
+
+func TestChunkChanges(t *testing.T) {
+	srv := newTestServer(t, map[string]any{
+		"/a/changes/": `[{
+			"id": "platform%2Fapi~main~I1",
+			"_number": 1,
+			"status": "ABANDONED",
+			"revisions": {"aaa": {"_number": 1}, "bbb": {"_number": 2}},
+			"messages": [{"author": {"email": "alice@example.com"}, "date": "2024-01-01 10:00:00.000000000", "message": "Uploaded patch set 1.\n\nAPI_KEY=abc", "_revision_number": 1}]
+		}]`,
+		"/a/changes/1/comments": `{
+			"config.yaml": [{"id": "c1", "line": 4, "message": "remove password=hunter2", "author": {"username": "bob"}, "patch_set": 2, "updated": "2024-01-02 10:00:00.000000000"}]
+		}`,
+	})
+	s := initSource(t, srv.URL, basicAuth())
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.chunkChanges(context.Background(), "platform/api", &reporter))
+	require.Empty(t, reporter.ChunkErrs)
+	require.Len(t, reporter.Chunks, 2)
+
+	msg := reporter.Chunks[0].SourceMetadata.GetGerrit()
+	assert.Contains(t, string(reporter.Chunks[0].Data), "API_KEY=abc")
+	assert.Equal(t, "aaa", msg.GetCommit())
+	assert.Equal(t, "alice@example.com", msg.GetEmail())
+	assert.Equal(t, "platform/api", msg.GetProject())
+
+	comment := reporter.Chunks[1].SourceMetadata.GetGerrit()
+	assert.Equal(t, "remove password=hunter2", string(reporter.Chunks[1].Data))
+	assert.Equal(t, "bbb", comment.GetCommit())
+	assert.Equal(t, "config.yaml", comment.GetFile())
+	assert.Equal(t, int64(4), comment.GetLine())
+	assert.Equal(t, "bob", comment.GetEmail())
+}
+
+func TestChunkChanges_Errors(t *testing.T) {
+	const changes = "/a/changes/"
+	sourcestest.APIErrorTest{
+		Setup: func(t *testing.T) (*sourcestest.Server, func(t *testing.T) ([]string, []error)) {
+			srv := newTestServer(t, map[string]any{
+				changes: `[{"id": "I1", "_number": 1, "revisions": {"aaa": {"_number": 1}},
+					"messages": [{"message": "first API_KEY=abc", "_revision_number": 1}], "_more_changes": true}]`,
+				changes + "?S=1": `[{"id": "I2", "_number": 2, "revisions": {"bbb": {"_number": 1}},
+					"messages": [{"message": "second API_KEY=def", "_revision_number": 1}]}]`,
+				"/a/changes/1/comments": `{}`,
+				"/a/changes/2/comments": `{}`,
+			})
+			s := initSource(t, srv.URL, basicAuth())
+			return srv, func(t *testing.T) ([]string, []error) {
+				reporter := sourcestest.TestReporter{}
+				require.NoError(t, s.chunkChanges(context.Background(), "platform/api", &reporter))
+				return reporter.ChunkData(), reporter.ChunkErrs
+			}
+		},
+		First:           changes,
+		LastPage:        changes + "?S=1",
+		Item:            "/a/changes/1/comments",
+		All:             []string{"first API_KEY=abc", "second API_KEY=def"},
+		WithoutItem:     []string{"first API_KEY=abc", "second API_KEY=def"},
+		WithoutLastPage: []string{"first API_KEY=abc"},
+	}.Run(t)
+}
+
+// TestChunkUnit_ChangeRefs checks that a patch set that only exists under
+// refs/changes is scanned.
+func TestChunkUnit_ChangeRefs(t *testing.T) {
+	dir := t.TempDir()
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Env = append(cmd.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+
+	remote := filepath.Join(dir, "server", "project")
+	work := filepath.Join(dir, "work")
+	run("init", "--quiet", "--bare", remote)
+	run("init", "--quiet", work)
+	run("-C", work, "commit", "--quiet", "--allow-empty", "-m", "initial")
+	run("-C", work, "push", "--quiet", remote, "HEAD:refs/heads/main")
+	run("-C", work, "checkout", "--quiet", "-b", "review")
+	require.NoError(t, exec.Command("sh", "-c", "echo 'patchset secret' > "+filepath.Join(work, "secret.txt")).Run())
+	run("-C", work, "add", "secret.txt")
+	run("-C", work, "commit", "--quiet", "-m", "add secret")
+	run("-C", work, "push", "--quiet", remote, "HEAD:refs/changes/01/1/1")
+
+	s := initSource(t, "file://"+filepath.Join(dir, "server"), &sourcespb.Gerrit{
+		Credential: &sourcespb.Gerrit_Unauthenticated{Unauthenticated: &credentialspb.Unauthenticated{}},
+	})
+	s.WithScanOptions(git.NewScanOptions())
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.ChunkUnit(context.Background(), git.SourceUnit{Kind: git.UnitRepo, ID: "project"}, &reporter))
+
+	var found bool
+	for _, c := range reporter.Chunks {
+		if strings.Contains(string(c.Data), "patchset secret") {
+			found = true
+			assert.Equal(t, "secret.txt", c.SourceMetadata.GetGerrit().GetFile())
+		}
+	}
+	assert.True(t, found, "the patch set commit should be scanned")
+	// Listing changes over file:// fails; that is reported rather than returned.
+	assert.Len(t, reporter.ChunkErrs, 1)
+}
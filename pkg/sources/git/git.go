@@ -19,6 +19,7 @@ import (
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-logr/logr"
 	"github.com/google/go-github/v67/github"
 	"golang.org/x/oauth2"
 	"golang.org/x/sync/semaphore"
@@ -30,6 +31,7 @@ import (
 	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/feature"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/gitparse"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/gitstate"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
@@ -144,6 +146,12 @@ func (s *Source) withScanOptions(scanOptions *ScanOptions) {
 	s.scanOptions = scanOptions
 }
 
+// WithState makes the scans of the source incremental, resuming from the
+// commits recorded in the state store. It must be called after Init.
+func (s *Source) WithState(state *gitstate.Store) {
+	s.scanOptions.State = state
+}
+
 // Init returns an initialized GitHub source.
 func (s *Source) Init(aCtx context.Context, name string, jobId sources.JobID, sourceId sources.SourceID, verify bool, connection *anypb.Any, concurrency int) error {
 	s.name = name
@@ -160,10 +168,15 @@ func (s *Source) Init(aCtx context.Context, name string, jobId sources.JobID, so
 	}
 
 	if uri := conn.GetUri(); uri != "" {
-		repoPath, _, err := prepareRepoSinceCommit(aCtx, uri, conn.GetBase())
+		repoPath, remote, err := prepareRepoSinceCommit(aCtx, uri, conn.GetBase())
 		if err != nil || repoPath == "" {
 			return fmt.Errorf("error preparing repo: %w", err)
 		}
+		if remote && conn.GetScanUnreachable() {
+			if err := fetchHiddenRefs(aCtx, repoPath); err != nil {
+				aCtx.Logger().Error(err, "unable to scan hidden refs", "repo", uri)
+			}
+		}
 		conn.Directories = append(conn.Directories, repoPath)
 	}
 
@@ -189,6 +202,9 @@ func (s *Source) Init(aCtx context.Context, name string, jobId sources.JobID, so
 	if isBare := conn.GetBare(); isBare {
 		opts = append(opts, ScanOptionBare(isBare))
 	}
+	if conn.GetScanUnreachable() {
+		opts = append(opts, ScanOptionScanUnreachable(true))
+	}
 	s.withScanOptions(NewScanOptions(opts...))
 
 	s.conn = &conn
@@ -296,6 +312,11 @@ func (s *Source) scanRepo(ctx context.Context, repoURI string, reporter sources.
 		if err != nil {
 			return err
 		}
+		if s.scanOptions.ScanUnreachable {
+			if err := fetchHiddenRefs(ctx, path); err != nil {
+				ctx.Logger().Error(err, "unable to scan hidden refs", "repo", repoURI)
+			}
+		}
 		return s.git.ScanRepo(ctx, repo, path, s.scanOptions, reporter)
 	}()
 	if err != nil {
@@ -381,6 +402,9 @@ type cloneParams struct {
 	gitURL    string
 	args      []string
 	clonePath string
+	// env is added to the environment of the git command, so that secrets
+	// passed through it stay out of the process list.
+	env []string
 }
 
 // CloneRepo orchestrates the cloning of a given Git repository, returning its local path
@@ -389,12 +413,17 @@ type cloneParams struct {
 // The core cloning logic is delegated to a nested function, which returns errors to the
 // outer function for centralized error handling and cleanup.
 func CloneRepo(ctx context.Context, userInfo *url.Userinfo, gitURL string, args ...string) (string, *git.Repository, error) {
+	return cloneRepo(ctx, cloneParams{userInfo: userInfo, gitURL: gitURL, args: args})
+}
+
+func cloneRepo(ctx context.Context, params cloneParams) (string, *git.Repository, error) {
 	clonePath, err := cleantemp.MkdirTemp()
 	if err != nil {
 		return "", nil, err
 	}
+	params.clonePath = clonePath
 
-	repo, err := executeClone(ctx, cloneParams{userInfo, gitURL, args, clonePath})
+	repo, err := executeClone(ctx, params)
 	if err != nil {
 		// DO NOT FORGET TO CLEAN UP THE CLONE PATH HERE!!
 		// If we don't, we'll end up with a bunch of orphaned directories in the temp dir.
@@ -429,6 +458,9 @@ func executeClone(ctx context.Context, params cloneParams) (*git.Repository, err
 	}
 	gitArgs = append(gitArgs, params.args...)
 	cloneCmd := exec.Command("git", gitArgs...)
+	if len(params.env) > 0 {
+		cloneCmd.Env = append(os.Environ(), params.env...)
+	}
 
 	safeURL, secretForRedaction, err := stripPassword(params.gitURL)
 	if err != nil {
@@ -504,6 +536,22 @@ func CloneRepoUsingToken(ctx context.Context, token, gitUrl, user string, args .
 	return CloneRepo(ctx, userInfo, gitUrl, args...)
 }
 
+// CloneRepoUsingBearerToken clones a repo sending the token as a bearer
+// Authorization header. The header is set through git's GIT_CONFIG_*
+// environment variables rather than "-c", which would show the token in the
+// process list.
+func CloneRepoUsingBearerToken(ctx context.Context, token, gitURL string, args ...string) (string, *git.Repository, error) {
+	return cloneRepo(ctx, cloneParams{
+		gitURL: gitURL,
+		args:   args,
+		env: []string{
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Bearer " + token,
+		},
+	})
+}
+
 // CloneRepoUsingUnauthenticated clones a repo with no authentication required.
 func CloneRepoUsingUnauthenticated(ctx context.Context, url string, args ...string) (string, *git.Repository, error) {
 	return CloneRepo(ctx, nil, url, args...)
@@ -568,7 +616,17 @@ func (s *Git) ScanCommits(ctx context.Context, repo *git.Repository, path string
 		logValues = append(logValues, "max_depth", scanOptions.MaxDepth)
 	}
 
-	diffChan, err := s.parser.RepoPath(repoCtx, path, scanOptions.HeadHash, scanOptions.BaseHash == "", scanOptions.ExcludeGlobs, scanOptions.Bare)
+	var (
+		diffChan chan *gitparse.Diff
+		err      error
+	)
+	if scanOptions.ScanUnreachable && scanOptions.HeadHash == "" {
+		// Commits reached only from other refs are scanned, and marked, by
+		// ScanUnreachable.
+		diffChan, err = s.parser.Log(repoCtx, path, reachableRevisions(repo), scanOptions.BaseHash == "", scanOptions.ExcludeGlobs, scanOptions.Bare)
+	} else {
+		diffChan, err = s.parser.RepoPath(repoCtx, path, scanOptions.HeadHash, scanOptions.BaseHash == "", scanOptions.ExcludeGlobs, scanOptions.Bare)
+	}
 	if err != nil {
 		return err
 	}
@@ -577,11 +635,25 @@ func (s *Git) ScanCommits(ctx context.Context, repo *git.Repository, path string
 	}
 
 	logger.Info("scanning repo", logValues...)
+	return s.scanDiffs(ctx, logger, diffChan, path, remoteURL, scanOptions, nil, reporter)
+}
 
+// scanDiffs chunks the commits and diffs read from a git log. If seen is not
+// nil, the commits it holds are skipped and the ones scanned are added to it.
+func (s *Git) scanDiffs(
+	ctx context.Context,
+	logger logr.Logger,
+	diffChan chan *gitparse.Diff,
+	path, remoteURL string,
+	scanOptions *ScanOptions,
+	seen map[string]struct{},
+	reporter sources.ChunkReporter,
+) error {
 	var (
 		gitDir         = getGitDir(path, scanOptions)
 		depth          int64
 		lastCommitHash string
+		skipCommit     bool
 	)
 
 	for diff := range diffChan {
@@ -603,6 +675,13 @@ func (s *Git) ScanCommits(ctx context.Context, repo *git.Repository, path string
 		if fullHash != lastCommitHash {
 			depth++
 			lastCommitHash = fullHash
+			if seen != nil {
+				_, skipCommit = seen[fullHash]
+				seen[fullHash] = struct{}{}
+			}
+			if skipCommit {
+				continue
+			}
 			atomic.AddUint64(&s.metrics.commitsScanned, 1)
 			logger.V(5).Info("scanning commit", "commit", fullHash)
 
@@ -630,6 +709,9 @@ func (s *Git) ScanCommits(ctx context.Context, repo *git.Repository, path string
 				return err
 			}
 		}
+		if skipCommit {
+			continue
+		}
 
 		fileName := diff.PathB
 		if fileName == "" {
@@ -937,7 +1019,13 @@ func (s *Git) ScanRepo(ctx context.Context, repo *git.Repository, repoPath strin
 	}
 	start := time.Now().Unix()
 
-	if err := s.ScanCommits(ctx, repo, repoPath, scanOptions, reporter); err != nil {
+	var tips map[string]string
+	if scanOptions.State != nil && scanOptions.BaseHash == "" && scanOptions.HeadHash == "" {
+		var err error
+		if tips, err = s.scanIncremental(ctx, repo, repoPath, scanOptions, reporter); err != nil {
+			return err
+		}
+	} else if err := s.ScanCommits(ctx, repo, repoPath, scanOptions, reporter); err != nil {
 		return err
 	}
 	if !scanOptions.Bare {
@@ -945,6 +1033,14 @@ func (s *Git) ScanRepo(ctx context.Context, repo *git.Repository, repoPath strin
 			ctx.Logger().V(1).Info("error scanning unstaged changes", "error", err)
 		}
 	}
+	if scanOptions.ScanUnreachable {
+		if err := s.ScanUnreachable(ctx, repo, repoPath, scanOptions, reporter); err != nil {
+			return err
+		}
+	}
+	if tips != nil {
+		scanOptions.State.Set(stateKey(repo, repoPath), tips)
+	}
 
 	logger := ctx.Logger()
 	// We're logging time, but the repoPath is usually a dynamically generated folder in /tmp.
@@ -1245,7 +1341,18 @@ func HandleBinary(
 		fileCtx.Logger().V(5).Info("file contains ignored extension")
 		return nil
 	}
+	return handleBlob(fileCtx, gitDir, reporter, chunkSkel, commitHash.String()+":"+path, skipArchives)
+}
 
+// handleBlob scans the content of a blob, named as accepted by git cat-file.
+func handleBlob(
+	ctx context.Context,
+	gitDir string,
+	reporter sources.ChunkReporter,
+	chunkSkel *sources.Chunk,
+	object string,
+	skipArchives bool,
+) (err error) {
 	const (
 		cmdTimeout = 60 * time.Second
 		waitDelay  = 5 * time.Second
@@ -1265,10 +1372,10 @@ func HandleBinary(
 
 	// Create a timeout context for the 'git cat-file' command to ensure it does not run indefinitely.
 	// This prevents potential resource exhaustion by terminating the command if it exceeds the specified duration.
-	catFileCtx, cancel := context.WithTimeoutCause(fileCtx, cmdTimeout, errors.New("git cat-file timeout"))
+	catFileCtx, cancel := context.WithTimeoutCause(ctx, cmdTimeout, errors.New("git cat-file timeout"))
 	defer cancel()
 
-	cmd := exec.CommandContext(catFileCtx, "git", "-C", gitDir, "cat-file", "blob", commitHash.String()+":"+path)
+	cmd := exec.CommandContext(catFileCtx, "git", "-C", gitDir, "cat-file", "blob", object)
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 	cmd.WaitDelay = waitDelay // give the command a chance to finish before the timeout :)
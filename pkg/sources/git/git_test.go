@@ -3,15 +3,22 @@ package git
 import (
 	"bytes"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/kylelemons/godebug/pretty"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/types/known/anypb"
 
 	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/gitstate"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
@@ -467,6 +474,20 @@ func BenchmarkPrepareRepo(b *testing.B) {
 	}
 }
 
+func TestCloneRepoUsingBearerToken(t *testing.T) {
+	var auth []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get("Authorization"))
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	_, _, err := CloneRepoUsingBearerToken(context.Background(), "secret-token", srv.URL+"/repo.git")
+	require.Error(t, err)
+	require.NotEmpty(t, auth)
+	assert.Equal(t, "Bearer secret-token", auth[0])
+}
+
 func TestGitURLParse(t *testing.T) {
 	for _, tt := range []struct {
 		url      string
@@ -597,3 +618,192 @@ func TestChunkUnit(t *testing.T) {
 	assert.Equal(t, 22, len(reporter.Chunks))
 	assert.Equal(t, 1, len(reporter.ChunkErrs))
 }
+
+func TestChunkUnit_ScanUnreachable(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	run := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	commit := func(file, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte(content), 0o644))
+		run("add", file)
+		run("commit", "--quiet", "-m", file)
+	}
+
+	run("init", "--quiet")
+	commit("README.md", "from-branch")
+	commit("reset.txt", "from-reflog")
+	run("reset", "--quiet", "--hard", "HEAD~1")
+	commit("pull.txt", "from-hidden-ref")
+	run("update-ref", "refs/pull/1/head", "HEAD")
+	run("reset", "--quiet", "--hard", "HEAD~1")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("from-stash"), 0o644))
+	run("stash", "--quiet")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "blob.txt"), []byte("from-dangling-blob"), 0o644))
+	run("hash-object", "-w", "blob.txt")
+	require.NoError(t, os.Remove(filepath.Join(dir, "blob.txt")))
+
+	conn, err := anypb.New(&sourcespb.Git{
+		Credential:      &sourcespb.Git_Unauthenticated{},
+		ScanUnreachable: true,
+	})
+	require.NoError(t, err)
+	s := Source{}
+	require.NoError(t, s.Init(ctx, "test unreachable", 0, 0, false, conn, 1))
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.ChunkUnit(ctx, SourceUnit{ID: dir, Kind: UnitDir}, &reporter))
+	assert.Empty(t, reporter.ChunkErrs)
+
+	reached := make(map[string]string)
+	for _, chunk := range reporter.Chunks {
+		if data := strings.TrimSpace(string(chunk.Data)); strings.HasPrefix(data, "from-") {
+			reached[data] = chunk.SourceMetadata.GetGit().GetReached()
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"from-branch":        "",
+		"from-hidden-ref":    "hidden ref",
+		"from-stash":         "stash",
+		"from-reflog":        "reflog",
+		"from-dangling-blob": "dangling",
+	}, reached)
+}
+
+// testRepo is a local git repository for tests.
+type testRepo struct {
+	t   *testing.T
+	dir string
+}
+
+func newTestRepo(t *testing.T) *testRepo {
+	t.Helper()
+	r := &testRepo{t: t, dir: t.TempDir()}
+	r.git("init", "--quiet")
+	r.git("symbolic-ref", "HEAD", "refs/heads/main")
+	return r
+}
+
+// git runs a git command in the repository and returns its output.
+func (r *testRepo) git(args ...string) string {
+	r.t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", r.dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	out, err := cmd.CombinedOutput()
+	require.NoError(r.t, err, string(out))
+	return strings.TrimSpace(string(out))
+}
+
+func (r *testRepo) write(file, content string) {
+	r.t.Helper()
+	require.NoError(r.t, os.WriteFile(filepath.Join(r.dir, file), []byte(content), 0o644))
+}
+
+func (r *testRepo) commit(file, content string) {
+	r.t.Helper()
+	r.write(file, content)
+	r.git("add", file)
+	r.git("commit", "--quiet", "-m", file)
+}
+
+// commitAt commits as commit, dated date.
+func (r *testRepo) commitAt(file, content, date string) {
+	r.t.Helper()
+	r.t.Setenv("GIT_AUTHOR_DATE", date)
+	r.t.Setenv("GIT_COMMITTER_DATE", date)
+	r.commit(file, content)
+	require.NoError(r.t, os.Unsetenv("GIT_AUTHOR_DATE"))
+	require.NoError(r.t, os.Unsetenv("GIT_COMMITTER_DATE"))
+}
+
+func TestChunkUnit_State(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	state, err := gitstate.Load(filepath.Join(t.TempDir(), "state.json"))
+	require.NoError(t, err)
+	conn, err := anypb.New(&sourcespb.Git{Credential: &sourcespb.Git_Unauthenticated{}})
+	require.NoError(t, err)
+	s := Source{}
+	require.NoError(t, s.Init(ctx, "test state", 0, 0, false, conn, 1))
+	s.WithState(state)
+
+	repo := newTestRepo(t)
+	scan := func() []string {
+		t.Helper()
+		reporter := sourcestest.TestReporter{}
+		require.NoError(t, s.ChunkUnit(ctx, SourceUnit{ID: repo.dir, Kind: UnitDir}, &reporter))
+		require.Empty(t, reporter.ChunkErrs)
+		var scanned []string
+		for _, chunk := range reporter.Chunks {
+			if data := strings.TrimSpace(string(chunk.Data)); strings.HasPrefix(data, "commit-") {
+				scanned = append(scanned, data)
+			}
+		}
+		return scanned
+	}
+
+	repo.commit("a.txt", "commit-a")
+	repo.commit("b.txt", "commit-b")
+	assert.ElementsMatch(t, []string{"commit-a", "commit-b"}, scan())
+	assert.Equal(t, repo.git("rev-parse", "HEAD"), state.Refs(repo.dir)["refs/heads/main"])
+
+	// Only new commits are scanned.
+	assert.Empty(t, scan())
+	repo.commit("c.txt", "commit-c")
+	assert.Equal(t, []string{"commit-c"}, scan())
+
+	// Rewritten history is scanned from where it diverged.
+	repo.git("reset", "--quiet", "--hard", "HEAD~2")
+	repo.commit("d.txt", "commit-d")
+	assert.Equal(t, []string{"commit-d"}, scan())
+
+	// New refs are scanned from where they diverged from the scanned
+	// history, and commits on several refs are scanned once.
+	repo.git("checkout", "--quiet", "-b", "feature")
+	repo.commit("e.txt", "commit-e")
+	repo.git("branch", "other")
+	assert.Equal(t, []string{"commit-e"}, scan())
+}
+
+func TestChunkUnit_StateMergedOlderBranch(t *testing.T) {
+	ctx := context.Background()
+
+	state, err := gitstate.Load(filepath.Join(t.TempDir(), "state.json"))
+	require.NoError(t, err)
+	conn, err := anypb.New(&sourcespb.Git{Credential: &sourcespb.Git_Unauthenticated{}})
+	require.NoError(t, err)
+	s := Source{}
+	require.NoError(t, s.Init(ctx, "test state", 0, 0, false, conn, 1))
+	s.WithState(state)
+
+	repo := newTestRepo(t)
+	repo.commitAt("a.txt", "commit-a", "2024-01-01T00:00:00Z")
+	repo.commitAt("b.txt", "commit-b", "2024-01-03T00:00:00Z")
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.ChunkUnit(ctx, SourceUnit{ID: repo.dir, Kind: UnitDir}, &reporter))
+
+	// A branch pushed late, with a commit older than the last scanned one, is
+	// merged and deleted. git log lists that commit after the last scanned one.
+	repo.git("checkout", "--quiet", "-b", "feature", "HEAD~1")
+	repo.commitAt("f.txt", "commit-f", "2024-01-02T00:00:00Z")
+	repo.git("checkout", "--quiet", "main")
+	repo.git("merge", "--quiet", "--no-ff", "--no-edit", "feature")
+	repo.git("branch", "--quiet", "-D", "feature")
+
+	reporter = sourcestest.TestReporter{}
+	require.NoError(t, s.ChunkUnit(ctx, SourceUnit{ID: repo.dir, Kind: UnitDir}, &reporter))
+	require.Empty(t, reporter.ChunkErrs)
+	var scanned []string
+	for _, chunk := range reporter.Chunks {
+		if data := strings.TrimSpace(string(chunk.Data)); strings.HasPrefix(data, "commit-") {
+			scanned = append(scanned, data)
+		}
+	}
+	assert.Equal(t, []string{"commit-f"}, scanned)
+}
@@ -0,0 +1,106 @@
+package git
+
+import (
+	"maps"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// scanIncremental scans the commits added since the commits the state store
+// recorded for the repository, and returns the commit each ref now points to.
+//
+// Every commit reachable from a recorded commit was scanned, so the log of the
+// refs that moved excludes them all. This also covers rewritten history, which
+// is scanned from where it diverged, new refs, and branches merged with
+// commits older than the recorded ones. A repository without recorded refs is
+// scanned in full.
+func (s *Git) scanIncremental(ctx context.Context, repo *git.Repository, path string, scanOptions *ScanOptions, reporter sources.ChunkReporter) (map[string]string, error) {
+	gitDir := getGitDir(path, scanOptions)
+	tips, err := refTips(ctx, gitDir)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := scanOptions.State.Refs(stateKey(repo, path))
+	if len(previous) == 0 {
+		return tips, s.ScanCommits(ctx, repo, path, scanOptions, reporter)
+	}
+
+	var commits []string
+	for _, name := range slices.Sorted(maps.Keys(tips)) {
+		if previous[name] != tips[name] {
+			commits = append(commits, tips[name])
+		}
+	}
+	if len(commits) == 0 {
+		return tips, nil
+	}
+	// Recorded commits that are gone, after a force-push and a gc, cannot be
+	// excluded, and their history is scanned again.
+	for _, recorded := range slices.Sorted(maps.Values(previous)) {
+		if _, err := repo.CommitObject(plumbing.NewHash(recorded)); err == nil {
+			commits = append(commits, "^"+recorded)
+		}
+	}
+
+	logger := ctx.Logger().WithValues("path", path)
+	logger.V(1).Info("scanning commits since last scan", "refs", len(tips), "recorded", len(previous))
+	diffChan, err := s.parser.LogStdin(ctx, path, dedupe(commits), true, scanOptions.ExcludeGlobs, scanOptions.Bare)
+	if err != nil {
+		return nil, err
+	}
+	return tips, s.scanDiffs(ctx, logger, diffChan, path, GetSafeRemoteURL(repo, "origin"), scanOptions, nil, reporter)
+}
+
+// refTips returns the commit that HEAD and each branch, remote-tracking branch
+// and tag points to, by ref name.
+func refTips(ctx context.Context, gitDir string) (map[string]string, error) {
+	lines, err := gitOutputLines(ctx, gitDir, "for-each-ref",
+		"--format=%(refname) %(objecttype) %(objectname) %(*objecttype) %(*objectname)",
+		"refs/heads", "refs/remotes", "refs/tags")
+	if err != nil {
+		return nil, err
+	}
+
+	tips := make(map[string]string, len(lines)+1)
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		switch {
+		case strings.HasSuffix(fields[0], "/HEAD"):
+			// Symbolic refs such as refs/remotes/origin/HEAD.
+		case len(fields) == 3 && fields[1] == "commit":
+			tips[fields[0]] = fields[2]
+		case len(fields) == 5 && fields[3] == "commit":
+			tips[fields[0]] = fields[4]
+		}
+	}
+	if head, err := gitOutputLines(ctx, gitDir, "rev-parse", "--verify", "--quiet", "HEAD^{commit}"); err == nil && len(head) == 1 {
+		tips["HEAD"] = head[0]
+	}
+	return tips, nil
+}
+
+// stateKey identifies a repository in the state store: by its remote URL
+// without credentials, or by its absolute path if it has no remote.
+func stateKey(repo *git.Repository, path string) string {
+	remoteURL := GetSafeRemoteURL(repo, "origin")
+	if remoteURL == "" {
+		if abs, err := filepath.Abs(path); err == nil {
+			return abs
+		}
+		return path
+	}
+	if u, err := url.Parse(remoteURL); err == nil && u.User != nil {
+		u.User = nil
+		return u.String()
+	}
+	return remoteURL
+}
@@ -3,6 +3,7 @@ package git
 import (
 	"github.com/go-git/go-git/v5"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/gitstate"
 )
 
 type ScanOptions struct {
@@ -13,6 +14,12 @@ type ScanOptions struct {
 	Bare         bool
 	ExcludeGlobs []string
 	LogOptions   *git.LogOptions
+	// ScanUnreachable also scans hidden refs, notes, stashes, reflogs and
+	// dangling objects. See Git.ScanUnreachable.
+	ScanUnreachable bool
+	// State, if set, makes scans without a BaseHash or HeadHash incremental.
+	// See Git.scanIncremental.
+	State *gitstate.Store
 }
 
 type ScanOption func(*ScanOptions)
@@ -59,6 +66,18 @@ func ScanOptionBare(bare bool) ScanOption {
 	}
 }
 
+func ScanOptionScanUnreachable(scanUnreachable bool) ScanOption {
+	return func(scanOptions *ScanOptions) {
+		scanOptions.ScanUnreachable = scanUnreachable
+	}
+}
+
+func ScanOptionState(state *gitstate.Store) ScanOption {
+	return func(scanOptions *ScanOptions) {
+		scanOptions.State = state
+	}
+}
+
 func NewScanOptions(options ...ScanOption) *ScanOptions {
 	scanOptions := &ScanOptions{
 		Filter:   common.FilterEmpty(),
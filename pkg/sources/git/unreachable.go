@@ -0,0 +1,219 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// How the commits and blobs scanned by ScanUnreachable were reached. These are
+// the values of the Reached field of the git source metadata.
+const (
+	reachedHiddenRef = "hidden ref"
+	reachedNotes     = "notes"
+	reachedStash     = "stash"
+	reachedReflog    = "reflog"
+	reachedDangling  = "dangling"
+)
+
+// reachableRevisions returns the git log revisions of the history scanned by
+// default: branches, tags, remote-tracking branches and a detached HEAD.
+func reachableRevisions(repo *git.Repository) []string {
+	revisions := []string{"--branches", "--tags", "--remotes"}
+	if head, err := repo.Head(); err == nil && !head.Name().IsBranch() {
+		revisions = append(revisions, "HEAD")
+	}
+	return revisions
+}
+
+// ScanUnreachable scans the commits that branches, tags and remote-tracking
+// branches do not reach, and the dangling blobs of the repository. It walks,
+// in order, the commits reached from:
+//
+//   - hidden refs, such as refs/pull/* or refs/changes/*
+//   - notes refs
+//   - every stash entry
+//   - every reflog entry, such as commits reset or force-pushed away
+//   - dangling commits reported by git fsck
+//
+// Each commit is scanned once, and its chunks are marked with the first way it
+// was reached. BaseHash and MaxDepth do not apply.
+func (s *Git) ScanUnreachable(ctx context.Context, repo *git.Repository, path string, scanOptions *ScanOptions, reporter sources.ChunkReporter) error {
+	gitDir := getGitDir(path, scanOptions)
+	remoteURL := GetSafeRemoteURL(repo, "origin")
+	logger := ctx.Logger().WithValues("path", path)
+
+	var hiddenRefs, notes, stashes, reflogs, danglingCommits, danglingBlobs []string
+
+	refs, err := gitOutputLines(ctx, gitDir, "for-each-ref", "--format=%(objecttype) %(objectname) %(refname)")
+	if err != nil {
+		logger.Error(err, "error listing refs")
+	}
+	for _, line := range refs {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[0] != "commit" {
+			continue
+		}
+		switch name := fields[2]; {
+		case strings.HasPrefix(name, "refs/heads/"),
+			strings.HasPrefix(name, "refs/tags/"),
+			strings.HasPrefix(name, "refs/remotes/"),
+			name == "refs/stash":
+		case strings.HasPrefix(name, "refs/notes/"):
+			notes = append(notes, fields[1])
+		default:
+			hiddenRefs = append(hiddenRefs, fields[1])
+		}
+	}
+
+	entries, err := gitOutputLines(ctx, gitDir, "reflog", "show", "--all", "--format=%H %gD")
+	if err != nil {
+		logger.Error(err, "error listing reflog entries")
+	}
+	for _, line := range entries {
+		hash, selector, _ := strings.Cut(line, " ")
+		if strings.HasPrefix(selector, "refs/stash@{") {
+			stashes = append(stashes, hash)
+		} else {
+			reflogs = append(reflogs, hash)
+		}
+	}
+
+	// Only dangling objects are needed, so blobs are not read. git fsck exits
+	// with a non-zero status when it finds missing objects, but still reports
+	// the dangling ones.
+	objects, err := gitOutputLines(ctx, gitDir, "fsck", "--connectivity-only", "--no-progress")
+	if err != nil {
+		logger.V(2).Info("git fsck reported errors", "error", err)
+	}
+	for _, line := range objects {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[0] != "dangling" {
+			continue
+		}
+		switch fields[1] {
+		case "commit":
+			danglingCommits = append(danglingCommits, fields[2])
+		case "blob":
+			danglingBlobs = append(danglingBlobs, fields[2])
+		}
+	}
+
+	passes := []struct {
+		reached string
+		tips    []string
+		args    []string
+	}{
+		{reachedHiddenRef, hiddenRefs, nil},
+		{reachedNotes, notes, nil},
+		// Stash entries are merge commits of HEAD and the index, so their
+		// changes are shown against each parent.
+		{reachedStash, stashes, []string{"-m"}},
+		{reachedReflog, reflogs, nil},
+		{reachedDangling, danglingCommits, nil},
+	}
+
+	opts := *scanOptions
+	opts.BaseHash = ""
+	opts.MaxDepth = 0
+	exclude := append([]string{"--not"}, reachableRevisions(repo)...)
+	seen := make(map[string]struct{})
+	for _, pass := range passes {
+		if len(pass.tips) == 0 {
+			continue
+		}
+		passLogger := logger.WithValues("reached", pass.reached)
+		// A mirror may have more hidden refs than fit on a command line.
+		args := append(append([]string{}, exclude...), pass.args...)
+		diffChan, err := s.parser.LogStdin(ctx, path, dedupe(pass.tips), true, opts.ExcludeGlobs, opts.Bare, args...)
+		if err != nil {
+			passLogger.Error(err, "error running git log")
+			continue
+		}
+		passLogger.V(1).Info("scanning unreachable commits", "tips", len(pass.tips))
+		passReporter := reachedReporter{ChunkReporter: reporter, reached: pass.reached}
+		if err := s.scanDiffs(ctx, passLogger, diffChan, path, remoteURL, &opts, seen, passReporter); err != nil {
+			return err
+		}
+	}
+
+	blobReporter := reachedReporter{ChunkReporter: reporter, reached: reachedDangling}
+	for _, hash := range danglingBlobs {
+		chunkSkel := &sources.Chunk{
+			SourceName:     s.sourceName,
+			SourceID:       s.sourceID,
+			JobID:          s.jobID,
+			SourceType:     s.sourceType,
+			SourceMetadata: s.sourceMetadataFunc(hash, "", "", "", remoteURL, 0),
+			Verify:         s.verify,
+		}
+		if err := handleBlob(ctx, gitDir, blobReporter, chunkSkel, hash, s.skipArchives); err != nil {
+			logger.Error(err, "error handling dangling blob", "blob", hash)
+		}
+	}
+	return nil
+}
+
+// fetchHiddenRefs fetches every ref of the origin remote into the clone at
+// path, such as refs/pull/* and refs/notes/*, which a clone does not fetch.
+func fetchHiddenRefs(ctx context.Context, path string) error {
+	cmd := exec.CommandContext(ctx, "git", "-C", path, "fetch", "--quiet", "--update-head-ok", "origin", "+refs/*:refs/*")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("error fetching hidden refs: %w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// gitOutputLines runs a git command in gitDir and returns the non-empty lines
+// of its output, even when the command fails.
+func gitOutputLines(ctx context.Context, gitDir string, args ...string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", gitDir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+
+	var lines []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err != nil {
+		return lines, fmt.Errorf("error running git %s: %w: %s", args[0], err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return lines, nil
+}
+
+// dedupe returns the distinct values of a slice, in order.
+func dedupe(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	distinct := values[:0:0]
+	for _, v := range values {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		distinct = append(distinct, v)
+	}
+	return distinct
+}
+
+// reachedReporter marks the git metadata of the chunks it reports with how
+// their commit or blob was reached.
+type reachedReporter struct {
+	sources.ChunkReporter
+	reached string
+}
+
+func (r reachedReporter) ChunkOk(ctx context.Context, chunk sources.Chunk) error {
+	if meta := chunk.SourceMetadata.GetGit(); meta != nil {
+		meta.Reached = r.reached
+	}
+	return r.ChunkReporter.ChunkOk(ctx, chunk)
+}
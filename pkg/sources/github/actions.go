@@ -0,0 +1,398 @@
+package github
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v67/github"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// errActionsFileGone is returned when a log archive or an artifact has
+// expired or was deleted.
+var errActionsFileGone = errors.New("file is gone")
+
+// scanActions scans the logs and artifacts of the workflow runs of a
+// repository. Only runs created within the comments timeframe are scanned, if
+// one is set.
+func (s *Source) scanActions(ctx context.Context, repoInfo repoInfo, reporter sources.ChunkReporter) error {
+	ctx.Logger().V(2).Info("Scanning GitHub Actions workflow runs")
+
+	runOpts := &github.ListWorkflowRunsOptions{
+		ListOptions: github.ListOptions{
+			PerPage: defaultPagination,
+			Page:    initialPage,
+		},
+	}
+	if s.commentsTimeframeDays > 0 {
+		cutoffTime := time.Now().AddDate(0, 0, -int(s.commentsTimeframeDays))
+		runOpts.Created = ">=" + cutoffTime.Format("2006-01-02")
+	}
+
+	for {
+		runs, _, err := s.connector.APIClient().Actions.ListRepositoryWorkflowRuns(ctx, repoInfo.owner, repoInfo.name, runOpts)
+		if s.handleRateLimitWithChunkReporter(ctx, reporter, err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, run := range runs.WorkflowRuns {
+			if err := s.scanWorkflowRun(ctx, repoInfo, run, reporter); err != nil {
+				err = fmt.Errorf("error scanning workflow run %d: %w", run.GetID(), err)
+				if err := reporter.ChunkErr(ctx, err); err != nil {
+					return err
+				}
+			}
+		}
+
+		runOpts.ListOptions.Page++
+		if len(runs.WorkflowRuns) < defaultPagination {
+			break
+		}
+	}
+	return nil
+}
+
+func (s *Source) scanWorkflowRun(ctx context.Context, repoInfo repoInfo, run *github.WorkflowRun, reporter sources.ChunkReporter) error {
+	ctx = context.WithValues(ctx, "workflow_run", run.GetID())
+	if s.includeActionsLogs {
+		if err := s.scanWorkflowRunLogs(ctx, repoInfo, run, reporter); err != nil {
+			return fmt.Errorf("error scanning logs: %w", err)
+		}
+	}
+	if s.includeArtifacts {
+		if err := s.scanWorkflowRunArtifacts(ctx, repoInfo, run, reporter); err != nil {
+			return fmt.Errorf("error scanning artifacts: %w", err)
+		}
+	}
+	githubWorkflowRunsScanned.WithLabelValues(s.name).Inc()
+	return nil
+}
+
+// scanWorkflowRunLogs scans the log archive of a workflow run. The archive
+// holds a log per step, in a directory per job, and a log per job, which holds
+// the logs of all of its steps. Each file is scanned separately so that its
+// findings link to its job and step.
+func (s *Source) scanWorkflowRunLogs(ctx context.Context, repoInfo repoInfo, run *github.WorkflowRun, reporter sources.ChunkReporter) error {
+	jobs, err := s.listWorkflowJobs(ctx, repoInfo, run.GetID(), reporter)
+	if err != nil {
+		return err
+	}
+
+	logs, err := s.openActionsFile(ctx, fmt.Sprintf("repos/%s/%s/actions/runs/%d/logs", repoInfo.owner, repoInfo.name, run.GetID()), reporter)
+	if errors.Is(err, errActionsFileGone) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	archive, err := copyToTempFile(logs)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		archive.Close()
+		os.Remove(archive.Name())
+	}()
+
+	info, err := archive.Stat()
+	if err != nil {
+		return err
+	}
+	zipReader, err := zip.NewReader(archive, info.Size())
+	if err != nil {
+		return fmt.Errorf("error reading log archive: %w", err)
+	}
+
+	// The per-job logs repeat the per-step logs, when there are any.
+	jobsWithStepLogs := make(map[string]bool)
+	for _, f := range zipReader.File {
+		if dir, _ := path.Split(f.Name); dir != "" {
+			jobsWithStepLogs[strings.TrimSuffix(dir, "/")] = true
+		}
+	}
+
+	for _, f := range zipReader.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		var (
+			job       *github.WorkflowJob
+			step      *github.TaskStep
+			dir, file = path.Split(f.Name)
+		)
+		if dir != "" {
+			job = jobs[strings.TrimSuffix(dir, "/")]
+			step = findStep(job, file)
+		} else {
+			// Per-job logs are named after the job, prefixed by its index.
+			_, jobName, _ := strings.Cut(strings.TrimSuffix(file, ".txt"), "_")
+			if jobsWithStepLogs[jobName] {
+				continue
+			}
+			job = jobs[jobName]
+		}
+
+		chunkSkel := s.workflowRunChunk(repoInfo, run, f.Name, job, step, nil)
+		fileCtx := context.WithValues(ctx, "path", f.Name)
+		if err := s.handleZipFile(fileCtx, f, chunkSkel, reporter); err != nil {
+			fileCtx.Logger().Error(err, "error handling workflow run log")
+		}
+	}
+	return nil
+}
+
+func (s *Source) handleZipFile(ctx context.Context, f *zip.File, chunkSkel *sources.Chunk, reporter sources.ChunkReporter) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return handlers.HandleFile(ctx, rc, chunkSkel, reporter)
+}
+
+// listWorkflowJobs returns the jobs of the latest attempt of a workflow run,
+// by name.
+func (s *Source) listWorkflowJobs(ctx context.Context, repoInfo repoInfo, runID int64, reporter sources.ChunkReporter) (map[string]*github.WorkflowJob, error) {
+	jobs := make(map[string]*github.WorkflowJob)
+	jobOpts := &github.ListWorkflowJobsOptions{
+		ListOptions: github.ListOptions{
+			PerPage: defaultPagination,
+			Page:    initialPage,
+		},
+	}
+	for {
+		page, _, err := s.connector.APIClient().Actions.ListWorkflowJobs(ctx, repoInfo.owner, repoInfo.name, runID, jobOpts)
+		if s.handleRateLimitWithChunkReporter(ctx, reporter, err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, job := range page.Jobs {
+			jobs[logFileName(job.GetName())] = job
+		}
+
+		jobOpts.ListOptions.Page++
+		if len(page.Jobs) < defaultPagination {
+			break
+		}
+	}
+	return jobs, nil
+}
+
+// findStep returns the step of a job a step log belongs to. Step logs are
+// named after the step, prefixed by its number.
+func findStep(job *github.WorkflowJob, file string) *github.TaskStep {
+	number, _, _ := strings.Cut(file, "_")
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || job == nil {
+		return nil
+	}
+	for _, step := range job.Steps {
+		if step.GetNumber() == n {
+			return step
+		}
+	}
+	return nil
+}
+
+// logFileName returns the name of the log files of a job, in which
+// characters that are not allowed in file names are removed.
+func logFileName(jobName string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) {
+			return -1
+		}
+		return r
+	}, jobName)
+}
+
+// scanWorkflowRunArtifacts scans the artifacts uploaded by a workflow run
+// that have not expired.
+func (s *Source) scanWorkflowRunArtifacts(ctx context.Context, repoInfo repoInfo, run *github.WorkflowRun, reporter sources.ChunkReporter) error {
+	listOpts := &github.ListOptions{
+		PerPage: defaultPagination,
+		Page:    initialPage,
+	}
+	for {
+		artifacts, _, err := s.connector.APIClient().Actions.ListWorkflowRunArtifacts(ctx, repoInfo.owner, repoInfo.name, run.GetID(), listOpts)
+		if s.handleRateLimitWithChunkReporter(ctx, reporter, err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, artifact := range artifacts.Artifacts {
+			if artifact.GetExpired() {
+				continue
+			}
+			fileCtx := context.WithValues(ctx, "artifact", artifact.GetName())
+			if err := s.scanArtifact(fileCtx, repoInfo, run, artifact, reporter); err != nil {
+				err = fmt.Errorf("error scanning artifact %q: %w", artifact.GetName(), err)
+				if err := reporter.ChunkErr(ctx, err); err != nil {
+					return err
+				}
+			}
+		}
+
+		listOpts.Page++
+		if len(artifacts.Artifacts) < defaultPagination {
+			break
+		}
+	}
+	return nil
+}
+
+func (s *Source) scanArtifact(ctx context.Context, repoInfo repoInfo, run *github.WorkflowRun, artifact *github.Artifact, reporter sources.ChunkReporter) error {
+	body, err := s.openActionsFile(ctx, fmt.Sprintf("repos/%s/%s/actions/artifacts/%d/zip", repoInfo.owner, repoInfo.name, artifact.GetID()), reporter)
+	if errors.Is(err, errActionsFileGone) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	chunkSkel := s.workflowRunChunk(repoInfo, run, artifact.GetName(), nil, nil, artifact)
+	return handlers.HandleFile(ctx, body, chunkSkel, reporter)
+}
+
+// workflowRunChunk returns the chunk skeleton of a file of a workflow run: a
+// log of a job or step, or an artifact.
+func (s *Source) workflowRunChunk(
+	repoInfo repoInfo,
+	run *github.WorkflowRun,
+	file string,
+	job *github.WorkflowJob,
+	step *github.TaskStep,
+	artifact *github.Artifact,
+) *sources.Chunk {
+	meta := &source_metadatapb.Github{
+		Link:       sanitizer.UTF8(run.GetHTMLURL()),
+		Username:   sanitizer.UTF8(run.GetTriggeringActor().GetLogin()),
+		Repository: sanitizer.UTF8(repoInfo.fullName),
+		Commit:     sanitizer.UTF8(run.GetHeadSHA()),
+		File:       sanitizer.UTF8(file),
+		Timestamp:  sanitizer.UTF8(run.GetCreatedAt().String()),
+		Visibility: repoInfo.visibility,
+		Workflow:   sanitizer.UTF8(run.GetName()),
+		RunId:      run.GetID(),
+	}
+	if job != nil {
+		meta.Job = sanitizer.UTF8(job.GetName())
+		meta.Link = sanitizer.UTF8(job.GetHTMLURL())
+	}
+	if step != nil {
+		meta.Step = sanitizer.UTF8(step.GetName())
+		meta.Link = sanitizer.UTF8(fmt.Sprintf("%s#step:%d:1", job.GetHTMLURL(), step.GetNumber()))
+	}
+	if artifact != nil {
+		meta.Artifact = sanitizer.UTF8(artifact.GetName())
+		meta.Link = sanitizer.UTF8(fmt.Sprintf("%s/artifacts/%d", run.GetHTMLURL(), artifact.GetID()))
+	}
+
+	return &sources.Chunk{
+		SourceName: s.name,
+		SourceID:   s.SourceID(),
+		JobID:      s.JobID(),
+		SourceType: s.Type(),
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Github{Github: meta},
+		},
+		Verify: s.verify,
+	}
+}
+
+// openActionsFile opens a log archive or an artifact from its GitHub API
+// path. The API redirects to a pre-signed URL, which is fetched without
+// credentials, unless the transport of the API client already followed it.
+func (s *Source) openActionsFile(ctx context.Context, apiPath string, reporter sources.ChunkReporter) (io.ReadCloser, error) {
+	apiClient := s.connector.APIClient()
+	httpClient := apiClient.Client()
+	httpClient.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	for {
+		req, err := apiClient.NewRequest(http.MethodGet, apiPath, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := httpClient.Do(req.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+
+		switch resp.StatusCode {
+		case http.StatusOK:
+			return resp.Body, nil
+		case http.StatusFound, http.StatusMovedPermanently, http.StatusTemporaryRedirect:
+			resp.Body.Close()
+			return s.download(ctx, resp.Header.Get("Location"))
+		case http.StatusGone:
+			resp.Body.Close()
+			return nil, errActionsFileGone
+		}
+
+		err = github.CheckResponse(resp)
+		resp.Body.Close()
+		if s.handleRateLimitWithChunkReporter(ctx, reporter, err) {
+			continue
+		}
+		return nil, err
+	}
+}
+
+// download fetches a log archive or an artifact from the pre-signed URL the
+// GitHub API redirected to.
+func (s *Source) download(ctx context.Context, rawURL string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.actionsClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected HTTP response status when downloading: %v", resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// copyToTempFile copies and closes a download, for archives that must be
+// read at random.
+func copyToTempFile(body io.ReadCloser) (*os.File, error) {
+	defer body.Close()
+
+	f, err := os.CreateTemp("", "trufflehog-github-actions-*.zip")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(f, body); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("error downloading: %w", err)
+	}
+	return f, nil
+}
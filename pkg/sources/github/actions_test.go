@@ -0,0 +1,121 @@
+package github
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sourcestest"
+)
+
+func createZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestScanActions(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com").
+		Get("/repos/acme/api/actions/runs").
+		MatchParam("created", "^>=\\d{4}-\\d{2}-\\d{2}$").
+		Reply(200).
+		JSON(`{"total_count": 1, "workflow_runs": [{
+			"id": 7, "name": "CI", "head_sha": "abc123",
+			"html_url": "https://github.com/acme/api/actions/runs/7",
+			"triggering_actor": {"login": "alice"}
+		}]}`)
+	gock.New("https://api.github.com").
+		Get("/repos/acme/api/actions/runs/7/jobs").
+		Reply(200).
+		JSON(`{"total_count": 1, "jobs": [{
+			"id": 70, "name": "build / test",
+			"html_url": "https://github.com/acme/api/actions/runs/7/job/70",
+			"steps": [{"name": "Set up job", "number": 1}, {"name": "Run tests", "number": 2}]
+		}]}`)
+	gock.New("https://api.github.com").
+		Get("/repos/acme/api/actions/runs/7/logs").
+		Reply(302).
+		SetHeader("Location", "https://results.example.com/logs.zip")
+	gock.New("https://results.example.com").
+		Get("/logs.zip").
+		Reply(200).
+		Body(bytes.NewReader(createZip(t, map[string]string{
+			"0_build  test.txt":            "Set up job\necho from-step-log",
+			"build  test/1_Set up job.txt": "Set up job",
+			"build  test/2_Run tests.txt":  "echo from-step-log",
+			"1_unknown.txt":                "from-job-log",
+		})))
+	gock.New("https://api.github.com").
+		Get("/repos/acme/api/actions/runs/7/artifacts").
+		Reply(200).
+		JSON(`{"total_count": 2, "artifacts": [
+			{"id": 9, "name": "dist", "expired": false},
+			{"id": 10, "name": "old", "expired": true}
+		]}`)
+	gock.New("https://api.github.com").
+		Get("/repos/acme/api/actions/artifacts/9/zip").
+		Reply(302).
+		SetHeader("Location", "https://results.example.com/dist.zip")
+	gock.New("https://results.example.com").
+		Get("/dist.zip").
+		Reply(200).
+		Body(bytes.NewReader(createZip(t, map[string]string{".env": "DB_PASSWORD=from-artifact"})))
+
+	s := initTestSource(&sourcespb.GitHub{
+		Credential:            &sourcespb.GitHub_Token{Token: "super secret token"},
+		IncludeActionsLogs:    true,
+		IncludeArtifacts:      true,
+		CommentsTimeframeDays: 30,
+	})
+	gock.InterceptClient(s.actionsClient)
+
+	reporter := sourcestest.TestReporter{}
+	repoInfo := repoInfo{owner: "acme", name: "api", fullName: "acme/api"}
+	require.NoError(t, s.scanActions(context.Background(), repoInfo, &reporter))
+	assert.Empty(t, reporter.ChunkErrs)
+	assert.True(t, gock.IsDone())
+
+	links := make(map[string]string)
+	for _, chunk := range reporter.Chunks {
+		meta := chunk.SourceMetadata.GetGithub()
+		assert.Equal(t, "CI", meta.GetWorkflow())
+		assert.Equal(t, int64(7), meta.GetRunId())
+		assert.Equal(t, "abc123", meta.GetCommit())
+		assert.Equal(t, "alice", meta.GetUsername())
+		links[string(chunk.Data)] = meta.GetLink()
+	}
+	assert.Equal(t, map[string]string{
+		"Set up job":                "https://github.com/acme/api/actions/runs/7/job/70#step:1:1",
+		"echo from-step-log":        "https://github.com/acme/api/actions/runs/7/job/70#step:2:1",
+		"from-job-log":              "https://github.com/acme/api/actions/runs/7",
+		"DB_PASSWORD=from-artifact": "https://github.com/acme/api/actions/runs/7/artifacts/9",
+	}, links)
+
+	for _, chunk := range reporter.Chunks {
+		meta := chunk.SourceMetadata.GetGithub()
+		switch string(chunk.Data) {
+		case "echo from-step-log":
+			assert.Equal(t, "build / test", meta.GetJob())
+			assert.Equal(t, "Run tests", meta.GetStep())
+			assert.Equal(t, "build  test/2_Run tests.txt", meta.GetFile())
+		case "DB_PASSWORD=from-artifact":
+			assert.Equal(t, "dist", meta.GetArtifact())
+		}
+	}
+}
@@ -72,6 +72,11 @@ type Source struct {
 	includeGistComments   bool
 	commentsTimeframeDays uint32
 
+	includeActionsLogs bool
+	includeArtifacts   bool
+	// actionsClient downloads workflow run logs and artifacts.
+	actionsClient *http.Client
+
 	sources.Progress
 	sources.CommonSourceUnitUnmarshaller
 }
@@ -258,6 +263,9 @@ func (s *Source) Init(aCtx context.Context, name string, jobID sources.JobID, so
 	s.includePRComments = s.conn.IncludePullRequestComments
 	s.includeGistComments = s.conn.IncludeGistComments
 	s.commentsTimeframeDays = s.conn.CommentsTimeframeDays
+	s.includeActionsLogs = s.conn.IncludeActionsLogs
+	s.includeArtifacts = s.conn.IncludeArtifacts
+	s.actionsClient = common.RetryableHTTPClient()
 
 	// Head or base should only be used with incoming webhooks
 	if (len(s.conn.Head) > 0 || len(s.conn.Base) > 0) && len(s.repos) != 1 {
@@ -713,6 +721,18 @@ func (s *Source) scanRepo(ctx context.Context, repoURL string, reporter sources.
 		}
 	}
 
+	// Scan GitHub Actions logs and artifacts, if enabled. Gists have none.
+	if s.includeActionsLogs || s.includeArtifacts {
+		if _, urlParts, err := getRepoURLParts(repoURL); err == nil && !isGistUrl(urlParts) {
+			if err := s.scanActions(ctx, repoInfo, reporter); err != nil {
+				err := fmt.Errorf("error scanning actions: %w", err)
+				if err := reporter.ChunkErr(ctx, err); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
 	ctx.Logger().V(2).Info("finished scanning repo", "duration_seconds", duration)
 	githubReposScanned.WithLabelValues(s.name).Inc()
 	return nil
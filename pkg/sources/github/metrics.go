@@ -47,4 +47,12 @@ var (
 		Help:      "Total number of GitHub organizations enumerated.",
 	},
 		[]string{"source_name"})
+
+	githubWorkflowRunsScanned = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "github_workflow_runs_scanned",
+		Help:      "Total number of GitHub Actions workflow runs scanned.",
+	},
+		[]string{"source_name"})
 )
@@ -9,6 +9,7 @@ import (
 	"sync"
 
 	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common/glob"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/giturl"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/log"
@@ -19,7 +20,6 @@ import (
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/git"
 
 	gogit "github.com/go-git/go-git/v5"
-	"github.com/gobwas/glob"
 	"github.com/xanzy/go-gitlab"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/protobuf/proto"
@@ -85,56 +85,6 @@ func (s *Source) JobID() sources.JobID {
 	return s.jobID
 }
 
-// globRepoFilter is a wrapper around cache.Cache that filters out repos
-// based on include and exclude globs.
-type globRepoFilter struct {
-	include, exclude []glob.Glob
-}
-
-func newGlobRepoFilter(include, exclude []string, onCompileErr func(err error, pattern string)) *globRepoFilter {
-	includeGlobs := make([]glob.Glob, 0, len(include))
-	excludeGlobs := make([]glob.Glob, 0, len(exclude))
-	for _, ig := range include {
-		g, err := glob.Compile(ig)
-		if err != nil {
-			onCompileErr(err, ig)
-			continue
-		}
-		includeGlobs = append(includeGlobs, g)
-	}
-	for _, eg := range exclude {
-		g, err := glob.Compile(eg)
-		if err != nil {
-			onCompileErr(err, eg)
-			continue
-		}
-		excludeGlobs = append(excludeGlobs, g)
-	}
-	return &globRepoFilter{include: includeGlobs, exclude: excludeGlobs}
-}
-
-func (c *globRepoFilter) ignoreRepo(s string) bool {
-	for _, g := range c.exclude {
-		if g.Match(s) {
-			return true
-		}
-	}
-	return false
-}
-
-func (c *globRepoFilter) includeRepo(s string) bool {
-	if len(c.include) == 0 {
-		return true
-	}
-
-	for _, g := range c.include {
-		if g.Match(s) {
-			return true
-		}
-	}
-	return false
-}
-
 // Init returns an initialized Gitlab source.
 func (s *Source) Init(ctx context.Context, name string, jobId sources.JobID, sourceId sources.SourceID, verify bool, connection *anypb.Any, concurrency int) error {
 	s.name = name
@@ -249,7 +199,7 @@ func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, tar
 	// Get all repos if not specified.
 	if len(repos) == 0 {
 		ctx.Logger().Info("no repositories configured, enumerating")
-		ignoreRepo := buildIgnorer(s.includeRepos, s.ignoreRepos, func(err error, pattern string) {
+		ignoreRepo := glob.BuildIgnorer(s.includeRepos, s.ignoreRepos, func(err error, pattern string) {
 			ctx.Logger().Error(err, "could not compile include/exclude repo glob", "glob", pattern)
 		})
 		reporter := sources.VisitorReporter{
@@ -373,7 +323,7 @@ func (s *Source) Validate(ctx context.Context) []error {
 		return errs
 	}
 
-	ignoreProject := buildIgnorer(s.includeRepos, s.ignoreRepos, func(err error, pattern string) {
+	ignoreProject := glob.BuildIgnorer(s.includeRepos, s.ignoreRepos, func(err error, pattern string) {
 		errs = append(errs, fmt.Errorf("could not compile include/exclude repo pattern %q: %w", pattern, err))
 	})
 
@@ -702,21 +652,6 @@ func (s *Source) WithScanOptions(scanOptions *git.ScanOptions) {
 	s.scanOptions = scanOptions
 }
 
-func buildIgnorer(include, exclude []string, onCompile func(err error, pattern string)) func(repo string) bool {
-
-	// compile and load globRepoFilter
-	globRepoFilter := newGlobRepoFilter(include, exclude, onCompile)
-
-	f := func(repo string) bool {
-		if !globRepoFilter.includeRepo(repo) || globRepoFilter.ignoreRepo(repo) {
-			return true
-		}
-		return false
-	}
-
-	return f
-}
-
 func normalizeRepos(repos []string) ([]string, []error) {
 	// Optimistically allocate space for all valid repositories.
 	validRepos := make([]string, 0, len(repos))
@@ -812,7 +747,7 @@ func (s *Source) Enumerate(ctx context.Context, reporter sources.UnitReporter) e
 	}
 
 	// Otherwise, enumerate all repos.
-	ignoreRepo := buildIgnorer(s.includeRepos, s.ignoreRepos, func(err error, pattern string) {
+	ignoreRepo := glob.BuildIgnorer(s.includeRepos, s.ignoreRepos, func(err error, pattern string) {
 		ctx.Logger().Error(err, "could not compile include/exclude repo glob", "glob", pattern)
 		// TODO: Handle error returned from UnitErr.
 		_ = reporter.UnitErr(ctx, fmt.Errorf("could not compile include/exclude repo glob: %w", err))
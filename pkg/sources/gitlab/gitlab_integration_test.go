@@ -14,6 +14,7 @@ import (
 	"google.golang.org/protobuf/types/known/anypb"
 
 	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common/glob"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
@@ -559,7 +560,7 @@ func TestSource_InclusionGlobbing(t *testing.T) {
 			assert.NoError(t, err)
 
 			var errs []error
-			ignoreRepo := buildIgnorer(ctx, src.includeRepos, src.ignoreRepos, func(err error, pattern string) {
+			ignoreRepo := glob.BuildIgnorer(src.includeRepos, src.ignoreRepos, func(err error, pattern string) {
 				errs = append(errs, err)
 			})
 			err = src.getAllProjectRepos(ctx, apiClient, ignoreRepo, visitor)
@@ -0,0 +1,366 @@
+package jira
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+const (
+	issuesPageSize    = 50
+	commentsPageSize  = 100
+	changelogPageSize = 100
+)
+
+type user struct {
+	AccountID    string `json:"accountId"`
+	Name         string `json:"name"`
+	DisplayName  string `json:"displayName"`
+	EmailAddress string `json:"emailAddress"`
+}
+
+// String prefers the display name of the user. Jira Cloud hides most email
+// addresses, so the name is the more reliable identifier.
+func (u user) String() string {
+	switch {
+	case u.DisplayName != "":
+		return u.DisplayName
+	case u.Name != "":
+		return u.Name
+	default:
+		return u.AccountID
+	}
+}
+
+type project struct {
+	ID   string `json:"id"`
+	Key  string `json:"key"`
+	Name string `json:"name"`
+}
+
+// richText is a rich text field. Version 2 of the API returns it as a plain
+// string, and version 3 as an Atlassian Document Format (ADF) document, whose
+// text is extracted.
+type richText string
+
+func (t *richText) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if data[0] == '"' {
+		return json.Unmarshal(data, (*string)(t))
+	}
+	var doc adfNode
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if doc.Type != "doc" {
+		return fmt.Errorf("rich text is not an ADF document: %q", doc.Type)
+	}
+	var b strings.Builder
+	doc.writeText(&b)
+	*t = richText(strings.TrimSpace(b.String()))
+	return nil
+}
+
+// adfNode is a node of an ADF document.
+type adfNode struct {
+	Type  string `json:"type"`
+	Text  string `json:"text"`
+	Attrs struct {
+		Text string `json:"text"`
+		URL  string `json:"url"`
+	} `json:"attrs"`
+	Content []adfNode `json:"content"`
+}
+
+// writeText writes the text of the node and its children, with every block
+// of text on its own line.
+func (n adfNode) writeText(b *strings.Builder) {
+	switch n.Type {
+	case "text":
+		b.WriteString(n.Text)
+	case "hardBreak":
+		b.WriteByte('\n')
+	case "mention", "emoji":
+		b.WriteString(n.Attrs.Text)
+	case "inlineCard", "blockCard", "embedCard":
+		b.WriteString(n.Attrs.URL)
+	}
+	for _, child := range n.Content {
+		child.writeText(b)
+	}
+	switch n.Type {
+	case "paragraph", "heading", "codeBlock":
+		b.WriteByte('\n')
+	}
+}
+
+type comment struct {
+	ID      string   `json:"id"`
+	Author  user     `json:"author"`
+	Body    richText `json:"body"`
+	Created string   `json:"created"`
+	Updated string   `json:"updated"`
+}
+
+type commentPage struct {
+	StartAt    int       `json:"startAt"`
+	MaxResults int       `json:"maxResults"`
+	Total      int       `json:"total"`
+	Comments   []comment `json:"comments"`
+}
+
+type attachment struct {
+	ID       string `json:"id"`
+	Filename string `json:"filename"`
+	Author   user   `json:"author"`
+	Created  string `json:"created"`
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Content  string `json:"content"`
+}
+
+type changeItem struct {
+	Field      string `json:"field"`
+	FromString string `json:"fromString"`
+	ToString   string `json:"toString"`
+}
+
+type history struct {
+	ID      string       `json:"id"`
+	Author  user         `json:"author"`
+	Created string       `json:"created"`
+	Items   []changeItem `json:"items"`
+}
+
+// changelog is the changelog embedded in an issue, which holds its first page
+// of histories.
+type changelog struct {
+	StartAt    int       `json:"startAt"`
+	MaxResults int       `json:"maxResults"`
+	Total      int       `json:"total"`
+	Histories  []history `json:"histories"`
+}
+
+// historyPage is a page of the changelog of an issue.
+type historyPage struct {
+	StartAt    int       `json:"startAt"`
+	MaxResults int       `json:"maxResults"`
+	Total      int       `json:"total"`
+	Values     []history `json:"values"`
+}
+
+// issueFields holds the fields of an issue that are scanned. Fields that are
+// not modelled, custom fields included, are kept in Other.
+type issueFields struct {
+	Summary     string       `json:"summary"`
+	Description richText     `json:"description"`
+	Reporter    user         `json:"reporter"`
+	Created     string       `json:"created"`
+	Updated     string       `json:"updated"`
+	Comment     commentPage  `json:"comment"`
+	Attachment  []attachment `json:"attachment"`
+
+	Other map[string]json.RawMessage `json:"-"`
+}
+
+func (f *issueFields) UnmarshalJSON(data []byte) error {
+	type plain issueFields
+	if err := json.Unmarshal(data, (*plain)(f)); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &f.Other)
+}
+
+type issue struct {
+	ID        string      `json:"id"`
+	Key       string      `json:"key"`
+	Fields    issueFields `json:"fields"`
+	Changelog changelog   `json:"changelog"`
+	// Names maps field IDs to their display names.
+	Names map[string]string `json:"-"`
+}
+
+// searchPage is a page of search results. Jira Data Center pages with
+// offsets, and Jira Cloud with tokens.
+type searchPage struct {
+	StartAt       int               `json:"startAt"`
+	MaxResults    int               `json:"maxResults"`
+	Total         int               `json:"total"`
+	NextPageToken string            `json:"nextPageToken"`
+	IsLast        bool              `json:"isLast"`
+	Issues        []issue           `json:"issues"`
+	Names         map[string]string `json:"names"`
+}
+
+// client is a minimal Jira REST API v2 client. Version 2 is served by both
+// Jira Cloud and Jira Data Center, and returns rich text as plain strings.
+// Jira Cloud only searches issues through the version 3 search/jql API.
+type client struct {
+	httpClient *http.Client
+	baseURL    string
+	// cloud is true when the client talks to Jira Cloud.
+	cloud bool
+	// authorize sets the credentials on a request.
+	authorize func(*http.Request)
+}
+
+func (c *client) do(ctx context.Context, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Jira API request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.authorize != nil {
+		c.authorize(req)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request to Jira API: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("unexpected status code %d from %q: %s", resp.StatusCode, req.URL.Path, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+func (c *client) get(ctx context.Context, path string, target any) error {
+	resp, err := c.do(ctx, c.baseURL+path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode Jira API response: %w", err)
+	}
+	return nil
+}
+
+// listProjects lists every project visible to the user.
+func (c *client) listProjects(ctx context.Context) ([]project, error) {
+	var projects []project
+	if err := c.get(ctx, "/rest/api/2/project", &projects); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// searchIssues visits every issue matching the JQL query, with all fields,
+// the changelog and the field names.
+func (c *client) searchIssues(ctx context.Context, jql string, visit func(issue) error) error {
+	query := url.Values{
+		"jql":        {jql},
+		"fields":     {"*all"},
+		"expand":     {"changelog,names"},
+		"maxResults": {fmt.Sprint(issuesPageSize)},
+	}
+	visitPage := func(page searchPage) error {
+		for _, is := range page.Issues {
+			is.Names = page.Names
+			if err := visit(is); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if c.cloud {
+		for {
+			var page searchPage
+			if err := c.get(ctx, "/rest/api/3/search/jql?"+query.Encode(), &page); err != nil {
+				return err
+			}
+			if err := visitPage(page); err != nil {
+				return err
+			}
+			if page.IsLast || page.NextPageToken == "" {
+				return nil
+			}
+			query.Set("nextPageToken", page.NextPageToken)
+		}
+	}
+
+	for start := 0; ; {
+		query.Set("startAt", fmt.Sprint(start))
+		var page searchPage
+		if err := c.get(ctx, "/rest/api/2/search?"+query.Encode(), &page); err != nil {
+			return err
+		}
+		if err := visitPage(page); err != nil {
+			return err
+		}
+		start += len(page.Issues)
+		if len(page.Issues) == 0 || start >= page.Total {
+			return nil
+		}
+	}
+}
+
+// listComments lists every comment of an issue. The search API only embeds
+// the first page of comments.
+func (c *client) listComments(ctx context.Context, issueKey string) ([]comment, error) {
+	var comments []comment
+	for start := 0; ; {
+		var page commentPage
+		path := fmt.Sprintf("/rest/api/2/issue/%s/comment?startAt=%d&maxResults=%d", url.PathEscape(issueKey), start, commentsPageSize)
+		if err := c.get(ctx, path, &page); err != nil {
+			return nil, err
+		}
+		comments = append(comments, page.Comments...)
+		start += len(page.Comments)
+		if len(page.Comments) == 0 || start >= page.Total {
+			return comments, nil
+		}
+	}
+}
+
+// listHistories lists every history of the changelog of an issue. The search
+// API only embeds the first page of the changelog.
+func (c *client) listHistories(ctx context.Context, issueKey string) ([]history, error) {
+	var histories []history
+	for start := 0; ; {
+		var page historyPage
+		path := fmt.Sprintf("/rest/api/2/issue/%s/changelog?startAt=%d&maxResults=%d", url.PathEscape(issueKey), start, changelogPageSize)
+		if err := c.get(ctx, path, &page); err != nil {
+			return nil, err
+		}
+		histories = append(histories, page.Values...)
+		start += len(page.Values)
+		if len(page.Values) == 0 || start >= page.Total {
+			return histories, nil
+		}
+	}
+}
+
+// userTimeZone returns the time zone of the user, in which Jira reads the
+// dates of JQL queries.
+func (c *client) userTimeZone(ctx context.Context) (*time.Location, error) {
+	var me struct {
+		TimeZone string `json:"timeZone"`
+	}
+	if err := c.get(ctx, "/rest/api/2/myself", &me); err != nil {
+		return nil, err
+	}
+	return time.LoadLocation(me.TimeZone)
+}
+
+// downloadAttachment returns the content of an attachment. The caller must
+// close the returned reader.
+func (c *client) downloadAttachment(ctx context.Context, a attachment) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, a.Content)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,353 @@
+package jira
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common/glob"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/log"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/roundtripper"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+const SourceType = sourcespb.SourceType_SOURCE_TYPE_JIRA
+
+// jqlTimeLayout is the date format accepted in JQL queries.
+const jqlTimeLayout = "2006/01/02 15:04"
+
+// Source scans the issues of a Jira Cloud or Jira Data Center instance:
+// their text fields, comments, changelog and attachments.
+//
+// Options that are not part of the connection proto are set with the With*
+// methods after Init.
+type Source struct {
+	name     string
+	sourceID sources.SourceID
+	jobID    sources.JobID
+	verify   bool
+
+	// endpoint is the Jira URL without a trailing slash.
+	endpoint     string
+	ignore       func(projectKey string) bool
+	updatedSince time.Time
+	// jqlSince is updatedSince as written in JQL queries, once known.
+	jqlSince     string
+	jqlSinceOnce sync.Once
+	client       *client
+
+	jobPool *errgroup.Group
+	sources.Progress
+	sources.CommonSourceUnitUnmarshaller
+}
+
+// Ensure the Source satisfies the interfaces at compile time.
+var _ sources.Source = (*Source)(nil)
+var _ sources.SourceUnitUnmarshaller = (*Source)(nil)
+var _ sources.SourceUnitEnumChunker = (*Source)(nil)
+
+// Type returns the type of source.
+// It is used for matching source types in configuration and job input.
+func (s *Source) Type() sourcespb.SourceType {
+	return SourceType
+}
+
+func (s *Source) SourceID() sources.SourceID {
+	return s.sourceID
+}
+
+func (s *Source) JobID() sources.JobID {
+	return s.jobID
+}
+
+// Init returns an initialized Jira source.
+func (s *Source) Init(ctx context.Context, name string, jobId sources.JobID, sourceId sources.SourceID, verify bool, connection *anypb.Any, concurrency int) error {
+	s.name = name
+	s.sourceID = sourceId
+	s.jobID = jobId
+	s.verify = verify
+	s.jobPool = &errgroup.Group{}
+	s.jobPool.SetLimit(concurrency)
+
+	var conn sourcespb.JIRA
+	if err := anypb.UnmarshalTo(connection, &conn, proto.UnmarshalOptions{}); err != nil {
+		return fmt.Errorf("error unmarshalling connection: %w", err)
+	}
+	if conn.GetEndpoint() == "" {
+		return fmt.Errorf("a Jira endpoint is required")
+	}
+	s.endpoint = strings.TrimSuffix(conn.GetEndpoint(), "/")
+
+	var authorize func(*http.Request)
+	switch cred := conn.GetCredential().(type) {
+	case *sourcespb.JIRA_BasicAuth:
+		user, password := cred.BasicAuth.GetUsername(), cred.BasicAuth.GetPassword()
+		log.RedactGlobally(password)
+		authorize = func(req *http.Request) { req.SetBasicAuth(user, password) }
+	case *sourcespb.JIRA_Token:
+		token := cred.Token
+		log.RedactGlobally(token)
+		authorize = func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) }
+	case *sourcespb.JIRA_Oauth:
+		token := cred.Oauth.GetAccessToken()
+		log.RedactGlobally(token)
+		authorize = func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) }
+	case *sourcespb.JIRA_Unauthenticated:
+	default:
+		return fmt.Errorf("invalid configuration given for source %q (%s)", name, s.Type().String())
+	}
+
+	var clientOpts []common.ClientOption
+	if conn.GetInsecureSkipVerifyTls() {
+		clientOpts = append(clientOpts, common.WithTransport(roundtripper.NewRoundTripper(nil, roundtripper.WithInsecureTLS())))
+	}
+	httpClient := common.RetryableHTTPClientTimeout(60, clientOpts...)
+	s.client = &client{httpClient: httpClient, baseURL: s.endpoint, cloud: isCloud(s.endpoint), authorize: authorize}
+
+	s.ignore = glob.BuildIgnorer(conn.GetProjects(), conn.GetIgnoreProjects(), func(err error, pattern string) {
+		ctx.Logger().Error(err, "could not compile project glob", "glob", pattern)
+	}, glob.IgnoreCase())
+	return nil
+}
+
+// WithUpdatedSince limits the scan to issues updated at or after t. It is
+// used for incremental scans.
+func (s *Source) WithUpdatedSince(t time.Time) {
+	s.updatedSince = t
+}
+
+// isCloud reports whether the endpoint is a Jira Cloud site, either directly
+// or through the Atlassian API gateway used with OAuth.
+func isCloud(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	return strings.HasSuffix(host, ".atlassian.net") || strings.HasSuffix(host, ".jira.com") || host == "api.atlassian.com"
+}
+
+// Enumerate reports every visible project that is not ignored. Units are
+// identified by project key.
+func (s *Source) Enumerate(ctx context.Context, reporter sources.UnitReporter) error {
+	projects, err := s.client.listProjects(ctx)
+	if err != nil {
+		return reporter.UnitErr(ctx, fmt.Errorf("could not list projects: %w", err))
+	}
+
+	jiraProjectsEnumerated.WithLabelValues(s.name).Set(0)
+	for _, p := range projects {
+		if s.ignore(p.Key) {
+			continue
+		}
+		if err := reporter.UnitOk(ctx, sources.CommonSourceUnit{ID: p.Key}); err != nil {
+			return err
+		}
+		jiraProjectsEnumerated.WithLabelValues(s.name).Inc()
+	}
+	return nil
+}
+
+// jql returns the query for the issues of a project, honoring the incremental
+// scan timestamp.
+func (s *Source) jql(ctx context.Context, projectKey string) string {
+	query := fmt.Sprintf("project = %q", projectKey)
+	if !s.updatedSince.IsZero() {
+		query += fmt.Sprintf(" AND updated >= %q", s.sinceInJQL(ctx))
+	}
+	return query + " ORDER BY updated ASC"
+}
+
+// sinceInJQL returns the incremental scan timestamp as written in JQL, where
+// dates are in the time zone of the user. If that time zone cannot be read,
+// the timestamp is moved back a day, which covers every time zone.
+func (s *Source) sinceInJQL(ctx context.Context) string {
+	s.jqlSinceOnce.Do(func() {
+		loc, err := s.client.userTimeZone(ctx)
+		if err != nil {
+			ctx.Logger().Error(err, "could not read the time zone of the user, scanning issues updated since a day earlier")
+			s.jqlSince = s.updatedSince.UTC().Add(-24 * time.Hour).Format(jqlTimeLayout)
+			return
+		}
+		s.jqlSince = s.updatedSince.In(loc).Format(jqlTimeLayout)
+	})
+	return s.jqlSince
+}
+
+// ChunkUnit scans every issue of the project.
+func (s *Source) ChunkUnit(ctx context.Context, unit sources.SourceUnit, reporter sources.ChunkReporter) error {
+	projectKey, _ := unit.SourceUnitID()
+	ctx = context.WithValue(ctx, "project", projectKey)
+
+	var reportErr error
+	err := s.client.searchIssues(ctx, s.jql(ctx, projectKey), func(is issue) error {
+		if reportErr = s.chunkIssue(ctx, is, reporter); reportErr != nil {
+			return reportErr
+		}
+		jiraIssuesScanned.WithLabelValues(s.name).Inc()
+		return nil
+	})
+	if reportErr != nil {
+		return reportErr
+	}
+	if err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not search issues: %w", err))
+	}
+	jiraProjectsScanned.WithLabelValues(s.name).Inc()
+	return nil
+}
+
+// chunkIssue reports the text fields, comments, changelog and attachments of
+// an issue. Only errors returned by the reporter are returned.
+func (s *Source) chunkIssue(ctx context.Context, is issue, reporter sources.ChunkReporter) error {
+	link := s.endpoint + "/browse/" + is.Key
+	f := is.Fields
+
+	report := func(location string, author user, timestamp, link, data string) error {
+		if strings.TrimSpace(data) == "" {
+			return nil
+		}
+		chunk := s.chunkSkel(is.Key, location, author, timestamp, link)
+		chunk.Data = []byte(data)
+		return reporter.ChunkOk(ctx, chunk)
+	}
+
+	if err := report("summary", f.Reporter, f.Created, link, f.Summary); err != nil {
+		return err
+	}
+	if err := report("description", f.Reporter, f.Updated, link, string(f.Description)); err != nil {
+		return err
+	}
+	for _, field := range customTextFields(f.Other) {
+		location := field.id
+		if name := is.Names[field.id]; name != "" {
+			location = fmt.Sprintf("%s (%s)", name, field.id)
+		}
+		if err := report(location, f.Reporter, f.Updated, link, field.value); err != nil {
+			return err
+		}
+	}
+
+	comments := f.Comment.Comments
+	if f.Comment.Total > len(comments) {
+		var err error
+		if comments, err = s.client.listComments(ctx, is.Key); err != nil {
+			if err := reporter.ChunkErr(ctx, fmt.Errorf("could not list comments of %s: %w", is.Key, err)); err != nil {
+				return err
+			}
+			comments = f.Comment.Comments
+		}
+	}
+	for _, c := range comments {
+		commentLink := link + "?focusedCommentId=" + c.ID
+		if err := report("comment", c.Author, c.Updated, commentLink, string(c.Body)); err != nil {
+			return err
+		}
+	}
+
+	// Secrets removed from a field are still visible in its history.
+	histories := is.Changelog.Histories
+	if is.Changelog.Total > len(histories) {
+		var err error
+		if histories, err = s.client.listHistories(ctx, is.Key); err != nil {
+			if err := reporter.ChunkErr(ctx, fmt.Errorf("could not list changelog of %s: %w", is.Key, err)); err != nil {
+				return err
+			}
+			histories = is.Changelog.Histories
+		}
+	}
+	for _, h := range histories {
+		for _, item := range h.Items {
+			data := strings.TrimSpace(item.FromString + "\n" + item.ToString)
+			if err := report("changelog: "+item.Field, h.Author, h.Created, link, data); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, a := range f.Attachment {
+		if err := s.chunkAttachment(ctx, is.Key, link, a, reporter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Source) chunkAttachment(ctx context.Context, issueKey, link string, a attachment, reporter sources.ChunkReporter) error {
+	body, err := s.client.downloadAttachment(ctx, a)
+	if err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not download attachment %q of %s: %w", a.Filename, issueKey, err))
+	}
+	defer body.Close()
+
+	chunkSkel := s.chunkSkel(issueKey, "attachment: "+a.Filename, a.Author, a.Created, link)
+	if err := handlers.HandleFile(ctx, body, &chunkSkel, reporter); err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not scan attachment %q of %s: %w", a.Filename, issueKey, err))
+	}
+	return nil
+}
+
+type textField struct {
+	id    string
+	value string
+}
+
+// customTextFields returns the custom fields that hold text, sorted by ID.
+func customTextFields(fields map[string]json.RawMessage) []textField {
+	var text []textField
+	for id, raw := range fields {
+		if !strings.HasPrefix(id, "customfield_") {
+			continue
+		}
+		var value richText
+		if err := json.Unmarshal(raw, &value); err != nil || value == "" {
+			continue
+		}
+		text = append(text, textField{id: id, value: string(value)})
+	}
+	sort.Slice(text, func(i, j int) bool { return text[i].id < text[j].id })
+	return text
+}
+
+func (s *Source) chunkSkel(issueKey, location string, author user, timestamp, link string) sources.Chunk {
+	return sources.Chunk{
+		SourceName: s.name,
+		SourceID:   s.SourceID(),
+		JobID:      s.JobID(),
+		SourceType: s.Type(),
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Jira{
+				Jira: &source_metadatapb.Jira{
+					Issue:     sanitizer.UTF8(issueKey),
+					Author:    sanitizer.UTF8(author.String()),
+					Link:      sanitizer.UTF8(link),
+					Location:  sanitizer.UTF8(location),
+					Email:     sanitizer.UTF8(author.EmailAddress),
+					Timestamp: sanitizer.UTF8(timestamp),
+				},
+			},
+		},
+		Verify: s.verify,
+	}
+}
+
+// Chunks emits chunks of bytes over a channel.
+func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ ...sources.ChunkingTarget) error {
+	jiraProjectsScanned.WithLabelValues(s.name).Set(0)
+	return sources.ChunkUnits(ctx, s, s.jobPool, &s.Progress, "Project", chunksChan)
+}
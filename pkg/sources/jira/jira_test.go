@@ -0,0 +1,229 @@
+package jira
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sourcestest"
+)
+
+// newTestServer serves canned JSON responses keyed by request path. The
+// literal "SERVER" in a response is replaced by the server URL. Responses for
+// a path followed by "?nextPageToken=next" are served as its second page.
+func newTestServer(t *testing.T, responses map[string]any) *sourcestest.Server {
+	t.Helper()
+	return sourcestest.NewServer(t, responses,
+		sourcestest.WithAuth(sourcestest.BasicAuth("me@example.com", "api-token")),
+		sourcestest.WithPages("nextPageToken", "next", nil),
+	)
+}
+
+// searches returns the query parameters of the issue searches served so far.
+func searches(srv *sourcestest.Server) []url.Values {
+	var queries []url.Values
+	for _, u := range srv.Requests() {
+		if strings.Contains(u.Path, "/search") {
+			queries = append(queries, u.Query())
+		}
+	}
+	return queries
+}
+
+func initSource(t *testing.T, endpoint string, conn *sourcespb.JIRA) *Source {
+	t.Helper()
+	conn.Endpoint = endpoint
+	conn.Credential = &sourcespb.JIRA_BasicAuth{
+		BasicAuth: &credentialspb.BasicAuth{Username: "me@example.com", Password: "api-token"},
+	}
+	anyConn, err := anypb.New(conn)
+	require.NoError(t, err)
+
+	s := &Source{}
+	require.NoError(t, s.Init(context.Background(), "test", 0, 0, false, anyConn, 1))
+	return s
+}
+
+func TestEnumerate(t *testing.T) {
+	srv := newTestServer(t, map[string]any{
+		"/rest/api/2/project": `[{"key": "SUP"}, {"key": "SEC"}, {"key": "OPS"}, {"key": "SANDBOX"}]`,
+	})
+	s := initSource(t, srv.URL+"/", &sourcespb.JIRA{
+		Projects:       []string{"s*", "ops"},
+		IgnoreProjects: []string{"sandbox"},
+	})
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+	assert.Empty(t, reporter.UnitErrs)
+	assert.Equal(t, []sources.SourceUnit{
+		sources.CommonSourceUnit{ID: "SUP"},
+		sources.CommonSourceUnit{ID: "SEC"},
+		sources.CommonSourceUnit{ID: "OPS"},
+	}, reporter.Units)
+}
+
+func TestChunkUnit(t *testing.T) {
+	srv := newTestServer(t, map[string]any{
+		"/rest/api/2/search": `{
+			"startAt": 0, "maxResults": 50, "total": 1,
+			"names": {"customfield_10010": "Steps to reproduce"},
+			"issues": [{
+				"key": "SUP-1",
+				"fields": {
+					"summary": "Login broken",
+					"description": "customer key sk_live_description",
+					"reporter": {"displayName": "Alice", "emailAddress": "alice@example.com"},
+					"created": "2024-01-01T10:00:00.000+0000",
+					"updated": "2024-01-02T10:00:00.000+0000",
+					"customfield_10010": "curl -H 'token: custom'",
+					"customfield_10011": 3,
+					"comment": {"startAt": 0, "maxResults": 1, "total": 2, "comments": [{"id": "1", "body": "first"}]},
+					"attachment": [{"filename": "env.txt", "content": "SERVER/secure/attachment/1/env.txt", "author": {"displayName": "Bob"}}]
+				},
+				"changelog": {"startAt": 0, "maxResults": 1, "total": 2, "histories": [{"author": {"displayName": "Carol"}, "created": "2024-01-03T10:00:00.000+0000",
+					"items": [{"field": "description", "fromString": "password=removed", "toString": "redacted"}]}]}
+			}]
+		}`,
+		"/rest/api/2/issue/SUP-1/changelog": `{"startAt": 0, "maxResults": 100, "total": 2, "values": [
+			{"author": {"displayName": "Carol"}, "items": [{"field": "description", "fromString": "password=removed", "toString": "redacted"}]},
+			{"author": {"displayName": "Erin"}, "items": [{"field": "environment", "fromString": "api_key=older", "toString": ""}]}
+		]}`,
+		"/rest/api/2/myself": `{"timeZone": "America/New_York"}`,
+		"/rest/api/2/issue/SUP-1/comment": `{"startAt": 0, "maxResults": 100, "total": 2, "comments": [
+			{"id": "1", "body": "first"},
+			{"id": "2", "body": "db url postgres://u:p@db", "author": {"displayName": "Dave"}}
+		]}`,
+		"/secure/attachment/1/env.txt": "AWS_SECRET=attached",
+	})
+	s := initSource(t, srv.URL, &sourcespb.JIRA{})
+	s.WithUpdatedSince(time.Date(2024, 1, 2, 15, 4, 0, 0, time.UTC))
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.ChunkUnit(context.Background(), sources.CommonSourceUnit{ID: "SUP"}, &reporter))
+	require.Empty(t, reporter.ChunkErrs)
+
+	queries := searches(srv)
+	require.Len(t, queries, 1)
+	// JQL dates are in the time zone of the user.
+	assert.Equal(t, `project = "SUP" AND updated >= "2024/01/02 10:04" ORDER BY updated ASC`, queries[0].Get("jql"))
+
+	require.Len(t, reporter.Chunks, 8)
+	byLocation := make(map[string]sources.Chunk)
+	for _, c := range reporter.Chunks {
+		byLocation[c.SourceMetadata.GetJira().GetLocation()] = c
+	}
+
+	assert.Equal(t, "customer key sk_live_description", string(byLocation["description"].Data))
+	assert.Equal(t, "alice@example.com", byLocation["description"].SourceMetadata.GetJira().GetEmail())
+	assert.Equal(t, srv.URL+"/browse/SUP-1", byLocation["description"].SourceMetadata.GetJira().GetLink())
+	assert.Equal(t, "curl -H 'token: custom'", string(byLocation["Steps to reproduce (customfield_10010)"].Data))
+	assert.Contains(t, string(byLocation["changelog: description"].Data), "password=removed")
+	assert.Equal(t, "api_key=older", string(byLocation["changelog: environment"].Data))
+	assert.Contains(t, string(byLocation["attachment: env.txt"].Data), "AWS_SECRET=attached")
+
+	comment := byLocation["comment"].SourceMetadata.GetJira()
+	assert.Equal(t, "Dave", comment.GetAuthor())
+	assert.Equal(t, srv.URL+"/browse/SUP-1?focusedCommentId=2", comment.GetLink())
+}
+
+func TestChunkUnit_UnknownTimeZone(t *testing.T) {
+	srv := newTestServer(t, map[string]any{
+		"/rest/api/2/search": `{"startAt": 0, "maxResults": 50, "total": 0, "issues": []}`,
+	})
+	s := initSource(t, srv.URL, &sourcespb.JIRA{})
+	s.WithUpdatedSince(time.Date(2024, 1, 2, 15, 4, 0, 0, time.UTC))
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.ChunkUnit(context.Background(), sources.CommonSourceUnit{ID: "SUP"}, &reporter))
+	queries := searches(srv)
+	require.Len(t, queries, 1)
+	assert.Equal(t, `project = "SUP" AND updated >= "2024/01/01 15:04" ORDER BY updated ASC`, queries[0].Get("jql"))
+}
+
+func TestChunkUnit_Cloud(t *testing.T) {
+	adf := func(text string) string {
+		return `{"type": "doc", "version": 1, "content": [
+			{"type": "paragraph", "content": [{"type": "text", "text": "` + text + `"}]},
+			{"type": "paragraph", "content": [{"type": "mention", "attrs": {"text": "@Bob"}}]}
+		]}`
+	}
+	srv := newTestServer(t, map[string]any{
+		"/rest/api/3/search/jql": `{"isLast": false, "nextPageToken": "next", "issues": [
+			{"key": "SUP-1", "fields": {"description": ` + adf("token: first-page") + `}}
+		]}`,
+		"/rest/api/3/search/jql?nextPageToken=next": `{"isLast": true, "names": {"customfield_10010": "Notes"}, "issues": [
+			{"key": "SUP-2", "fields": {"customfield_10010": ` + adf("token: custom-field") + `,
+				"comment": {"total": 1, "comments": [{"id": "5", "body": ` + adf("token: comment") + `}]}}}
+		]}`,
+	})
+	s := initSource(t, srv.URL, &sourcespb.JIRA{})
+	s.client.cloud = true
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.ChunkUnit(context.Background(), sources.CommonSourceUnit{ID: "SUP"}, &reporter))
+	require.Empty(t, reporter.ChunkErrs)
+
+	var data []string
+	for _, c := range reporter.Chunks {
+		data = append(data, string(c.Data))
+	}
+	assert.Equal(t, []string{
+		"token: first-page\n@Bob",
+		"token: custom-field\n@Bob",
+		"token: comment\n@Bob",
+	}, data)
+
+	queries := searches(srv)
+	require.Len(t, queries, 2)
+	assert.Empty(t, queries[0].Get("nextPageToken"))
+	assert.Equal(t, "next", queries[1].Get("nextPageToken"))
+	for _, q := range queries {
+		assert.Empty(t, q.Get("startAt"))
+	}
+}
+
+func TestChunkUnit_Errors(t *testing.T) {
+	const search = "/rest/api/3/search/jql"
+	const comments = "/rest/api/2/issue/SUP-1/comment"
+	sourcestest.APIErrorTest{
+		Setup: func(t *testing.T) (*sourcestest.Server, func(t *testing.T) ([]string, []error)) {
+			srv := newTestServer(t, map[string]any{
+				search: `{"isLast": false, "nextPageToken": "next", "issues": [
+					{"key": "SUP-1", "fields": {"description": "password=first", "comment": {"total": 2, "comments": []}}}
+				]}`,
+				search + "?nextPageToken=next": `{"isLast": true, "issues": [{"key": "SUP-2", "fields": {"description": "password=second"}}]}`,
+				comments:                       `{"total": 1, "comments": [{"id": "1", "body": "password=comment"}]}`,
+			})
+			s := initSource(t, srv.URL, &sourcespb.JIRA{})
+			s.client.cloud = true
+			return srv, func(t *testing.T) ([]string, []error) {
+				reporter := sourcestest.TestReporter{}
+				require.NoError(t, s.ChunkUnit(context.Background(), sources.CommonSourceUnit{ID: "SUP"}, &reporter))
+				return reporter.ChunkData(), reporter.ChunkErrs
+			}
+		},
+		First:           search,
+		LastPage:        search + "?nextPageToken=next",
+		Item:            comments,
+		All:             []string{"password=first", "password=comment", "password=second"},
+		WithoutItem:     []string{"password=first", "password=second"},
+		WithoutLastPage: []string{"password=first", "password=comment"},
+	}.Run(t)
+}
+
+func TestIsCloud(t *testing.T) {
+	assert.True(t, isCloud("https://example.atlassian.net"))
+	assert.True(t, isCloud("https://api.atlassian.com/ex/jira/1234"))
+	assert.False(t, isCloud("https://jira.example.com"))
+	assert.False(t, isCloud("http://127.0.0.1:8080"))
+}
@@ -0,0 +1,34 @@
+package jira
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+)
+
+var (
+	jiraProjectsEnumerated = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "jira_projects_enumerated",
+		Help:      "Total number of Jira projects enumerated.",
+	},
+		[]string{"source_name"})
+
+	jiraProjectsScanned = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "jira_projects_scanned",
+		Help:      "Total number of Jira projects scanned.",
+	},
+		[]string{"source_name"})
+
+	jiraIssuesScanned = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "jira_issues_scanned",
+		Help:      "Total number of Jira issues scanned.",
+	},
+		[]string{"source_name"})
+)
@@ -102,6 +102,13 @@ func (f ChunkError) Error() string {
 }
 func (f ChunkError) Unwrap() error { return f.Err }
 
+// EnumerateError is a custom error type for errors a source reports while
+// enumerating its units.
+type EnumerateError struct{ Err error }
+
+func (f EnumerateError) Error() string { return f.Err.Error() }
+func (f EnumerateError) Unwrap() error { return f.Err }
+
 // JobProgress aggregates information about a run of a Source.
 type JobProgress struct {
 	// Unique identifiers for this job.
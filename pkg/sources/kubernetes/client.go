@@ -0,0 +1,58 @@
+package kubernetes
+
+import (
+	"fmt"
+
+	"k8s.io/client-go/dynamic"
+	k8s "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/log"
+)
+
+// inClusterName is the cluster reported in the metadata of chunks when
+// trufflehog authenticates with the service account of its pod.
+const inClusterName = "in-cluster"
+
+// kubeconfigRESTConfig returns the configuration of a context of a kubeconfig,
+// or of its current context if contextName is empty, along with the name of
+// its cluster. Files referenced by the kubeconfig are read relative to the
+// working directory.
+func kubeconfigRESTConfig(data []byte, contextName string) (*rest.Config, string, error) {
+	kubeconfig, err := clientcmd.Load(data)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid kubeconfig: %w", err)
+	}
+	if contextName == "" {
+		contextName = kubeconfig.CurrentContext
+	}
+	kubeContext, ok := kubeconfig.Contexts[contextName]
+	if !ok {
+		return nil, "", fmt.Errorf("context %q not found in kubeconfig", contextName)
+	}
+
+	cfg, err := clientcmd.NewNonInteractiveClientConfig(*kubeconfig, contextName, &clientcmd.ConfigOverrides{}, nil).ClientConfig()
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid kubeconfig context %q: %w", contextName, err)
+	}
+	return cfg, kubeContext.Cluster, nil
+}
+
+// newClients returns the typed and dynamic clients of the API server described
+// by cfg.
+func newClients(cfg *rest.Config) (k8s.Interface, dynamic.Interface, error) {
+	log.RedactGlobally(cfg.BearerToken)
+	log.RedactGlobally(cfg.Password)
+	cfg.UserAgent = "trufflehog"
+
+	client, err := k8s.NewForConfig(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not create Kubernetes client: %w", err)
+	}
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not create Kubernetes client: %w", err)
+	}
+	return client, dynamicClient, nil
+}
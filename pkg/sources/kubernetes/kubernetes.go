@@ -0,0 +1,517 @@
+package kubernetes
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	k8s "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+const (
+	SourceType = sourcespb.SourceType_SOURCE_TYPE_KUBERNETES
+
+	// helmReleaseType is the type of the secrets in which Helm 3 stores
+	// releases.
+	helmReleaseType = "helm.sh/release.v1"
+
+	// listLimit is the page size of list requests.
+	listLimit = 250
+)
+
+// Source scans the objects of a Kubernetes cluster, one namespace at a time:
+// ConfigMaps, Helm releases, the env and args of pods and deployments, the
+// annotations of all of these, custom resources and, optionally, Secrets.
+type Source struct {
+	name     string
+	sourceID sources.SourceID
+	jobID    sources.JobID
+	verify   bool
+
+	client        k8s.Interface
+	dynamicClient dynamic.Interface
+	cluster       string
+	// namespaces, when not empty, limits the scan to these namespaces.
+	namespaces     []string
+	includeSecrets bool
+	resources      []schema.GroupVersionResource
+
+	jobPool *errgroup.Group
+	sources.Progress
+	sources.CommonSourceUnitUnmarshaller
+}
+
+// Ensure the Source satisfies the interfaces at compile time.
+var _ sources.Source = (*Source)(nil)
+var _ sources.SourceUnitUnmarshaller = (*Source)(nil)
+var _ sources.SourceUnitEnumChunker = (*Source)(nil)
+
+// Type returns the type of source.
+// It is used for matching source types in configuration and job input.
+func (s *Source) Type() sourcespb.SourceType {
+	return SourceType
+}
+
+func (s *Source) SourceID() sources.SourceID {
+	return s.sourceID
+}
+
+func (s *Source) JobID() sources.JobID {
+	return s.jobID
+}
+
+// Init returns an initialized Kubernetes source.
+func (s *Source) Init(_ context.Context, name string, jobId sources.JobID, sourceId sources.SourceID, verify bool, connection *anypb.Any, concurrency int) error {
+	s.name = name
+	s.sourceID = sourceId
+	s.jobID = jobId
+	s.verify = verify
+	s.jobPool = &errgroup.Group{}
+	s.jobPool.SetLimit(concurrency)
+
+	var conn sourcespb.Kubernetes
+	if err := anypb.UnmarshalTo(connection, &conn, proto.UnmarshalOptions{}); err != nil {
+		return fmt.Errorf("error unmarshalling connection: %w", err)
+	}
+
+	var (
+		cfg *rest.Config
+		err error
+	)
+	switch cred := conn.GetCredential().(type) {
+	case *sourcespb.Kubernetes_Kubeconfig:
+		cfg, s.cluster, err = kubeconfigRESTConfig([]byte(cred.Kubeconfig), conn.GetContext())
+	case *sourcespb.Kubernetes_InCluster:
+		s.cluster = inClusterName
+		if cfg, err = rest.InClusterConfig(); err != nil {
+			err = fmt.Errorf("could not use the in-cluster service account: %w", err)
+		}
+	default:
+		return fmt.Errorf("invalid configuration given for source %q (%s)", name, s.Type().String())
+	}
+	if err != nil {
+		return err
+	}
+	if s.client, s.dynamicClient, err = newClients(cfg); err != nil {
+		return err
+	}
+
+	s.namespaces = conn.GetNamespaces()
+	s.includeSecrets = conn.GetIncludeSecrets()
+	s.resources = nil
+	for _, gvr := range conn.GetResources() {
+		r, err := parseResource(gvr)
+		if err != nil {
+			return err
+		}
+		s.resources = append(s.resources, r)
+	}
+	return nil
+}
+
+// parseResource parses a GVR written as group/version/resource, such as
+// monitoring.coreos.com/v1/alertmanagers, or version/resource for the core
+// group.
+func parseResource(gvr string) (schema.GroupVersionResource, error) {
+	parts := strings.Split(gvr, "/")
+	for _, p := range parts {
+		if p == "" {
+			return schema.GroupVersionResource{}, fmt.Errorf("invalid resource %q: expected group/version/resource", gvr)
+		}
+	}
+	switch len(parts) {
+	case 2:
+		return schema.GroupVersionResource{Version: parts[0], Resource: parts[1]}, nil
+	case 3:
+		return schema.GroupVersionResource{Group: parts[0], Version: parts[1], Resource: parts[2]}, nil
+	default:
+		return schema.GroupVersionResource{}, fmt.Errorf("invalid resource %q: expected group/version/resource", gvr)
+	}
+}
+
+// resourceString formats a GVR the way it is given on the command line.
+func resourceString(gvr schema.GroupVersionResource) string {
+	if gvr.Group == "" {
+		return gvr.Version + "/" + gvr.Resource
+	}
+	return gvr.Group + "/" + gvr.Version + "/" + gvr.Resource
+}
+
+// Enumerate reports the configured namespaces or, when there are none, every
+// namespace of the cluster. Units are identified by namespace name.
+func (s *Source) Enumerate(ctx context.Context, reporter sources.UnitReporter) error {
+	kubernetesNamespacesEnumerated.WithLabelValues(s.name).Set(0)
+	report := func(namespace string) error {
+		if err := reporter.UnitOk(ctx, sources.CommonSourceUnit{ID: namespace}); err != nil {
+			return err
+		}
+		kubernetesNamespacesEnumerated.WithLabelValues(s.name).Inc()
+		return nil
+	}
+
+	if len(s.namespaces) > 0 {
+		for _, ns := range s.namespaces {
+			if err := report(ns); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	list := func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+		return s.client.CoreV1().Namespaces().List(ctx, opts)
+	}
+	err := eachListItem(ctx, list, metav1.ListOptions{}, func(obj runtime.Object) error {
+		return report(obj.(*corev1.Namespace).Name)
+	})
+	if err != nil {
+		return reporter.UnitErr(ctx, fmt.Errorf("could not list namespaces: %w", err))
+	}
+	return nil
+}
+
+// listFunc lists a page of a collection.
+type listFunc func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error)
+
+// eachListItem calls fn with every object of a collection, following continue
+// tokens.
+func eachListItem(ctx context.Context, list listFunc, opts metav1.ListOptions, fn func(runtime.Object) error) error {
+	opts.Limit = listLimit
+	for {
+		page, err := list(ctx, opts)
+		if err != nil {
+			return err
+		}
+		if err := meta.EachListItem(page, fn); err != nil {
+			return err
+		}
+		pageMeta, err := meta.ListAccessor(page)
+		if err != nil {
+			return err
+		}
+		if pageMeta.GetContinue() == "" {
+			return nil
+		}
+		opts.Continue = pageMeta.GetContinue()
+	}
+}
+
+// field is a value of an object to scan, such as the env of a container.
+type field struct {
+	name string
+	data []byte
+}
+
+// collection is a kind of object scanned in every namespace.
+type collection struct {
+	resource schema.GroupVersionResource
+	// kind is the kind of the objects, as items of typed lists do not carry
+	// it. It is empty for custom resources.
+	kind   string
+	list   func(namespace string) listFunc
+	opts   metav1.ListOptions
+	fields func(runtime.Object) []field
+}
+
+func (s *Source) collections() []collection {
+	secretOpts := metav1.ListOptions{}
+	if !s.includeSecrets {
+		// Without access to Secrets in general, only Helm releases are read.
+		secretOpts.LabelSelector = "owner=helm"
+	}
+
+	collections := []collection{
+		{
+			resource: corev1.SchemeGroupVersion.WithResource("configmaps"),
+			kind:     "ConfigMap",
+			list: func(namespace string) listFunc {
+				return func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+					return s.client.CoreV1().ConfigMaps(namespace).List(ctx, opts)
+				}
+			},
+			fields: configMapFields,
+		},
+		{
+			resource: corev1.SchemeGroupVersion.WithResource("secrets"),
+			kind:     "Secret",
+			list: func(namespace string) listFunc {
+				return func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+					return s.client.CoreV1().Secrets(namespace).List(ctx, opts)
+				}
+			},
+			opts:   secretOpts,
+			fields: s.secretFields,
+		},
+		{
+			resource: corev1.SchemeGroupVersion.WithResource("pods"),
+			kind:     "Pod",
+			list: func(namespace string) listFunc {
+				return func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+					return s.client.CoreV1().Pods(namespace).List(ctx, opts)
+				}
+			},
+			fields: podFields,
+		},
+		{
+			resource: appsv1.SchemeGroupVersion.WithResource("deployments"),
+			kind:     "Deployment",
+			list: func(namespace string) listFunc {
+				return func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+					return s.client.AppsV1().Deployments(namespace).List(ctx, opts)
+				}
+			},
+			fields: deploymentFields,
+		},
+	}
+	for _, gvr := range s.resources {
+		gvr := gvr
+		collections = append(collections, collection{
+			resource: gvr,
+			list: func(namespace string) listFunc {
+				return func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+					return s.dynamicClient.Resource(gvr).Namespace(namespace).List(ctx, opts)
+				}
+			},
+			fields: customResourceFields,
+		})
+	}
+	return collections
+}
+
+// ChunkUnit scans the objects of a namespace.
+func (s *Source) ChunkUnit(ctx context.Context, unit sources.SourceUnit, reporter sources.ChunkReporter) error {
+	namespace, _ := unit.SourceUnitID()
+	ctx = context.WithValue(ctx, "namespace", namespace)
+
+	for _, c := range s.collections() {
+		err := eachListItem(ctx, c.list(namespace), c.opts, func(obj runtime.Object) error {
+			accessor, err := meta.Accessor(obj)
+			if err != nil {
+				return err
+			}
+			kind := c.kind
+			if kind == "" {
+				kind = obj.GetObjectKind().GroupVersionKind().Kind
+			}
+			for _, f := range c.fields(obj) {
+				if err := s.chunkField(ctx, namespace, kind, accessor.GetName(), f, reporter); err != nil {
+					return err
+				}
+			}
+			kubernetesObjectsScanned.WithLabelValues(s.name).Inc()
+			return nil
+		})
+		if err != nil {
+			if err := reporter.ChunkErr(ctx, fmt.Errorf("could not list %s in namespace %q: %w", resourceString(c.resource), namespace, err)); err != nil {
+				return err
+			}
+		}
+	}
+	kubernetesNamespacesScanned.WithLabelValues(s.name).Inc()
+	return nil
+}
+
+func (s *Source) chunkField(ctx context.Context, namespace, kind, name string, f field, reporter sources.ChunkReporter) error {
+	if len(f.data) == 0 {
+		return nil
+	}
+	chunkSkel := &sources.Chunk{
+		SourceName: s.name,
+		SourceID:   s.SourceID(),
+		JobID:      s.JobID(),
+		SourceType: s.Type(),
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Kubernetes{
+				Kubernetes: &source_metadatapb.Kubernetes{
+					Cluster:   sanitizer.UTF8(s.cluster),
+					Namespace: sanitizer.UTF8(namespace),
+					Kind:      sanitizer.UTF8(kind),
+					Name:      sanitizer.UTF8(name),
+					Field:     sanitizer.UTF8(f.name),
+				},
+			},
+		},
+		Verify: s.verify,
+	}
+	if err := handlers.HandleFile(ctx, bytes.NewReader(f.data), chunkSkel, reporter); err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not scan %s %s/%s %s: %w", kind, namespace, name, f.name, err))
+	}
+	return nil
+}
+
+// annotationFields returns annotations, which include the
+// kubectl.kubernetes.io/last-applied-configuration of objects created with
+// kubectl apply.
+func annotationFields(prefix string, annotations map[string]string) []field {
+	fields := make([]field, 0, len(annotations))
+	for _, k := range sortedKeys(annotations) {
+		fields = append(fields, field{name: prefix + ".annotations[" + k + "]", data: []byte(annotations[k])})
+	}
+	return fields
+}
+
+func configMapFields(obj runtime.Object) []field {
+	cm := obj.(*corev1.ConfigMap)
+	fields := annotationFields("metadata", cm.Annotations)
+	for _, k := range sortedKeys(cm.Data) {
+		data := []byte(cm.Data[k])
+		// Helm stores releases in ConfigMaps when its storage driver is
+		// configmap.
+		if k == "release" && cm.Labels["owner"] == "helm" {
+			if release, err := decodeHelmRelease(data); err == nil {
+				data = release
+			}
+		}
+		fields = append(fields, field{name: "data." + k, data: data})
+	}
+	for _, k := range sortedKeys(cm.BinaryData) {
+		fields = append(fields, field{name: "binaryData." + k, data: cm.BinaryData[k]})
+	}
+	return fields
+}
+
+func (s *Source) secretFields(obj runtime.Object) []field {
+	secret := obj.(*corev1.Secret)
+	if secret.Type == helmReleaseType {
+		if release, err := decodeHelmRelease(secret.Data["release"]); err == nil {
+			return []field{{name: "data.release", data: release}}
+		}
+	}
+	if !s.includeSecrets {
+		return nil
+	}
+
+	fields := annotationFields("metadata", secret.Annotations)
+	for _, k := range sortedKeys(secret.Data) {
+		fields = append(fields, field{name: "data." + k, data: secret.Data[k]})
+	}
+	for _, k := range sortedKeys(secret.StringData) {
+		fields = append(fields, field{name: "stringData." + k, data: []byte(secret.StringData[k])})
+	}
+	return fields
+}
+
+func podFields(obj runtime.Object) []field {
+	pod := obj.(*corev1.Pod)
+	fields := annotationFields("metadata", pod.Annotations)
+	return append(fields, podSpecFields("spec", &pod.Spec)...)
+}
+
+func deploymentFields(obj runtime.Object) []field {
+	deployment := obj.(*appsv1.Deployment)
+	fields := annotationFields("metadata", deployment.Annotations)
+	fields = append(fields, annotationFields("spec.template.metadata", deployment.Spec.Template.Annotations)...)
+	return append(fields, podSpecFields("spec.template.spec", &deployment.Spec.Template.Spec)...)
+}
+
+// podSpecFields returns the env, command and args of the containers of a pod
+// spec. Env values set from ConfigMaps and Secrets are not resolved.
+func podSpecFields(prefix string, spec *corev1.PodSpec) []field {
+	var fields []field
+	containerFields := func(list, name string, env []corev1.EnvVar, command, args []string) {
+		path := prefix + "." + list + "[" + name + "]"
+		var vars []string
+		for _, v := range env {
+			if v.Value != "" {
+				vars = append(vars, v.Name+"="+v.Value)
+			}
+		}
+		if len(vars) > 0 {
+			fields = append(fields, field{name: path + ".env", data: []byte(strings.Join(vars, "\n"))})
+		}
+		if len(command) > 0 {
+			fields = append(fields, field{name: path + ".command", data: []byte(strings.Join(command, " "))})
+		}
+		if len(args) > 0 {
+			fields = append(fields, field{name: path + ".args", data: []byte(strings.Join(args, " "))})
+		}
+	}
+	for _, c := range spec.InitContainers {
+		containerFields("initContainers", c.Name, c.Env, c.Command, c.Args)
+	}
+	for _, c := range spec.Containers {
+		containerFields("containers", c.Name, c.Env, c.Command, c.Args)
+	}
+	for _, c := range spec.EphemeralContainers {
+		containerFields("ephemeralContainers", c.Name, c.Env, c.Command, c.Args)
+	}
+	return fields
+}
+
+// customResourceFields returns the whole object, without its managed fields,
+// as custom resources have no known layout.
+func customResourceFields(obj runtime.Object) []field {
+	u := obj.(*unstructured.Unstructured)
+	u.SetManagedFields(nil)
+	data, err := json.MarshalIndent(u.Object, "", "  ")
+	if err != nil {
+		return nil
+	}
+	return []field{{name: "", data: data}}
+}
+
+var gzipMagic = []byte{0x1f, 0x8b, 0x08}
+
+// decodeHelmRelease decodes a release as stored by Helm: the base64 encoding
+// of its gzipped JSON.
+func decodeHelmRelease(stored []byte) ([]byte, error) {
+	if len(stored) == 0 {
+		return nil, errors.New("empty release")
+	}
+	b := make([]byte, base64.StdEncoding.DecodedLen(len(stored)))
+	n, err := base64.StdEncoding.Decode(b, stored)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Helm release encoding: %w", err)
+	}
+	b = b[:n]
+	if !bytes.HasPrefix(b, gzipMagic) {
+		return b, nil
+	}
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Helm release compression: %w", err)
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Chunks emits chunks of bytes over a channel.
+func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ ...sources.ChunkingTarget) error {
+	kubernetesNamespacesScanned.WithLabelValues(s.name).Set(0)
+	kubernetesObjectsScanned.WithLabelValues(s.name).Set(0)
+	return sources.ChunkUnits(ctx, s, s.jobPool, &s.Progress, "Namespace", chunksChan)
+}
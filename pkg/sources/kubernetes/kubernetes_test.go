@@ -0,0 +1,250 @@
+package kubernetes
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sourcestest"
+)
+
+const testKubeconfig = `
+apiVersion: v1
+kind: Config
+current-context: prod
+clusters:
+- name: prod-cluster
+  cluster:
+    server: https://prod.example.com
+    insecure-skip-tls-verify: true
+- name: dev-cluster
+  cluster:
+    server: https://dev.example.com
+contexts:
+- name: prod
+  context: {cluster: prod-cluster, user: admin}
+- name: dev
+  context: {cluster: dev-cluster, user: dev}
+users:
+- name: admin
+  user: {token: test-token}
+- name: dev
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1
+      command: get-token
+      args: [--cluster, dev]
+      interactiveMode: Never
+`
+
+var databases = schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "databases"}
+
+// helmRelease returns the release field of a Helm release secret as returned
+// by the API server, after the base64 decoding of Secret data.
+func helmRelease(t *testing.T, release string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write([]byte(release))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	return []byte(base64.StdEncoding.EncodeToString(buf.Bytes()))
+}
+
+// newTestSource returns a source initialized with conn whose clients are
+// replaced by fakes serving a small cluster.
+func newTestSource(t *testing.T, conn *sourcespb.Kubernetes) *Source {
+	t.Helper()
+	conn.Credential = &sourcespb.Kubernetes_Kubeconfig{Kubeconfig: testKubeconfig}
+	connection, err := anypb.New(conn)
+	require.NoError(t, err)
+	s := &Source{}
+	require.NoError(t, s.Init(context.Background(), "test", 0, 0, false, connection, 1))
+
+	client := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "payments"}},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-config", Namespace: "payments"},
+			Data:       map[string]string{"settings.ini": "db_password = from-configmap"},
+			BinaryData: map[string][]byte{"blob": []byte("from-binary-data")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "sh.helm.release.v1.api.v1", Namespace: "payments", Labels: map[string]string{"owner": "helm"}},
+			Type:       helmReleaseType,
+			Data:       map[string][]byte{"release": helmRelease(t, `{"config":{"apiKey":"from-helm-values"}}`)},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "db-credentials", Namespace: "payments"},
+			Type:       corev1.SecretTypeOpaque,
+			Data:       map[string][]byte{"password": []byte("from-secret")},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "api-7d9f", Namespace: "payments"},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name: "api",
+				Args: []string{"--token", "from-pod-args"},
+				Env: []corev1.EnvVar{
+					{Name: "STRIPE_KEY", Value: "from-pod-env"},
+					{Name: "FROM_SECRET", ValueFrom: &corev1.EnvVarSource{}},
+				},
+			}}},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "api",
+				Namespace: "payments",
+				Annotations: map[string]string{
+					"kubectl.kubernetes.io/last-applied-configuration": `{"env":"from-last-applied"}`,
+				},
+			},
+			Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "migrate", Command: []string{"migrate", "--dsn", "from-deployment"}}},
+			}}},
+		},
+	)
+	client.PrependReactor("list", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetNamespace() != "kube-system" {
+			return false, nil, nil
+		}
+		return true, nil, apierrors.NewForbidden(corev1.Resource("secrets"), "", nil)
+	})
+
+	database := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "example.com/v1",
+		"kind":       "Database",
+		"metadata":   map[string]any{"name": "orders", "namespace": "payments"},
+		"spec":       map[string]any{"password": "from-custom-resource"},
+	}}
+	database.SetManagedFields([]metav1.ManagedFieldsEntry{{Manager: "noise"}})
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{databases: "DatabaseList"}, database)
+
+	s.client, s.dynamicClient = client, dynamicClient
+	return s
+}
+
+func TestEnumerate(t *testing.T) {
+	s := newTestSource(t, &sourcespb.Kubernetes{})
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+	require.Empty(t, reporter.UnitErrs)
+	var ids []string
+	for _, unit := range reporter.Units {
+		id, _ := unit.SourceUnitID()
+		ids = append(ids, id)
+	}
+	assert.ElementsMatch(t, []string{"default", "payments", "kube-system"}, ids)
+
+	s = newTestSource(t, &sourcespb.Kubernetes{Namespaces: []string{"payments"}})
+	reporter = sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+	require.Len(t, reporter.Units, 1)
+}
+
+// chunksByField returns the data of the chunks keyed by kind/name field.
+func chunksByField(reporter *sourcestest.TestReporter) map[string]string {
+	chunks := make(map[string]string, len(reporter.Chunks))
+	for _, c := range reporter.Chunks {
+		meta := c.SourceMetadata.GetKubernetes()
+		chunks[meta.GetKind()+"/"+meta.GetName()+" "+meta.GetField()] += string(c.Data)
+	}
+	return chunks
+}
+
+func TestChunkUnit(t *testing.T) {
+	s := newTestSource(t, &sourcespb.Kubernetes{Resources: []string{"example.com/v1/databases"}})
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.ChunkUnit(context.Background(), sources.CommonSourceUnit{ID: "payments"}, &reporter))
+	require.Empty(t, reporter.ChunkErrs)
+
+	chunks := chunksByField(&reporter)
+	assert.Equal(t, "db_password = from-configmap", chunks["ConfigMap/app-config data.settings.ini"])
+	assert.Equal(t, "from-binary-data", chunks["ConfigMap/app-config binaryData.blob"])
+	assert.Equal(t, `{"config":{"apiKey":"from-helm-values"}}`, chunks["Secret/sh.helm.release.v1.api.v1 data.release"])
+	assert.Equal(t, "STRIPE_KEY=from-pod-env", chunks["Pod/api-7d9f spec.containers[api].env"])
+	assert.Equal(t, "--token from-pod-args", chunks["Pod/api-7d9f spec.containers[api].args"])
+	assert.Equal(t, `{"env":"from-last-applied"}`, chunks["Deployment/api metadata.annotations[kubectl.kubernetes.io/last-applied-configuration]"])
+	assert.Equal(t, "migrate --dsn from-deployment", chunks["Deployment/api spec.template.spec.initContainers[migrate].command"])
+	assert.Contains(t, chunks["Database/orders "], "from-custom-resource")
+	assert.NotContains(t, chunks["Database/orders "], "managedFields")
+	for _, data := range chunks {
+		assert.NotContains(t, data, "from-secret")
+	}
+
+	meta := reporter.Chunks[0].SourceMetadata.GetKubernetes()
+	assert.Equal(t, "prod-cluster", meta.GetCluster())
+	assert.Equal(t, "payments", meta.GetNamespace())
+}
+
+func TestChunkUnit_IncludeSecrets(t *testing.T) {
+	s := newTestSource(t, &sourcespb.Kubernetes{IncludeSecrets: true})
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.ChunkUnit(context.Background(), sources.CommonSourceUnit{ID: "payments"}, &reporter))
+	require.Empty(t, reporter.ChunkErrs)
+
+	chunks := chunksByField(&reporter)
+	assert.Equal(t, "from-secret", chunks["Secret/db-credentials data.password"])
+	assert.Contains(t, chunks["Secret/sh.helm.release.v1.api.v1 data.release"], "from-helm-values")
+
+	// A forbidden collection is reported and the other ones are still scanned.
+	reporter = sourcestest.TestReporter{}
+	require.NoError(t, s.ChunkUnit(context.Background(), sources.CommonSourceUnit{ID: "kube-system"}, &reporter))
+	require.Len(t, reporter.ChunkErrs, 1)
+	assert.True(t, apierrors.IsForbidden(reporter.ChunkErrs[0]))
+}
+
+func TestParseResource(t *testing.T) {
+	r, err := parseResource("monitoring.coreos.com/v1/alertmanagers")
+	require.NoError(t, err)
+	assert.Equal(t, schema.GroupVersionResource{Group: "monitoring.coreos.com", Version: "v1", Resource: "alertmanagers"}, r)
+	assert.Equal(t, "monitoring.coreos.com/v1/alertmanagers", resourceString(r))
+
+	r, err = parseResource("v1/serviceaccounts")
+	require.NoError(t, err)
+	assert.Equal(t, schema.GroupVersionResource{Version: "v1", Resource: "serviceaccounts"}, r)
+	assert.Equal(t, "v1/serviceaccounts", resourceString(r))
+
+	for _, invalid := range []string{"databases", "a/b/c/d", "example.com//databases"} {
+		_, err := parseResource(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestKubeconfigRESTConfig(t *testing.T) {
+	cfg, cluster, err := kubeconfigRESTConfig([]byte(testKubeconfig), "")
+	require.NoError(t, err)
+	assert.Equal(t, "prod-cluster", cluster)
+	assert.Equal(t, "https://prod.example.com", cfg.Host)
+	assert.True(t, cfg.Insecure)
+	assert.Equal(t, "test-token", cfg.BearerToken)
+
+	cfg, cluster, err = kubeconfigRESTConfig([]byte(testKubeconfig), "dev")
+	require.NoError(t, err)
+	assert.Equal(t, "dev-cluster", cluster)
+	assert.Equal(t, "https://dev.example.com", cfg.Host)
+	require.NotNil(t, cfg.ExecProvider)
+	assert.Equal(t, []string{"--cluster", "dev"}, cfg.ExecProvider.Args)
+
+	_, _, err = kubeconfigRESTConfig([]byte(testKubeconfig), "staging")
+	assert.Error(t, err)
+}
@@ -0,0 +1,34 @@
+package kubernetes
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+)
+
+var (
+	kubernetesNamespacesEnumerated = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "kubernetes_namespaces_enumerated",
+		Help:      "Total number of Kubernetes namespaces enumerated.",
+	},
+		[]string{"source_name"})
+
+	kubernetesNamespacesScanned = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "kubernetes_namespaces_scanned",
+		Help:      "Total number of Kubernetes namespaces scanned.",
+	},
+		[]string{"source_name"})
+
+	kubernetesObjectsScanned = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "kubernetes_objects_scanned",
+		Help:      "Total number of Kubernetes objects scanned.",
+	},
+		[]string{"source_name"})
+)
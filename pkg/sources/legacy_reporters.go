@@ -1,6 +1,10 @@
 package sources
 
 import (
+	"fmt"
+
+	"golang.org/x/sync/errgroup"
+
 	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
 )
@@ -42,3 +46,38 @@ func (v VisitorReporter) UnitErr(ctx context.Context, err error) error {
 	}
 	return v.VisitErr(ctx, err)
 }
+
+// ChunkUnits implements Chunks for a source that enumerates units: it chunks
+// every unit of the source on pool and writes the chunks to chunksChan.
+// Progress names the unit being chunked as "<unitName>: <id>". Errors are
+// logged, as the source manager reports them when it scans with units.
+func ChunkUnits(ctx context.Context, source SourceUnitEnumChunker, pool *errgroup.Group, progress *Progress, unitName string, chunksChan chan *Chunk) error {
+	var units []SourceUnit
+	visitor := VisitorReporter{
+		VisitUnit: func(ctx context.Context, unit SourceUnit) error {
+			units = append(units, unit)
+			return ctx.Err()
+		},
+	}
+	if err := source.Enumerate(ctx, visitor); err != nil {
+		return err
+	}
+
+	reporter := ChanReporter{Ch: chunksChan}
+	for i, unit := range units {
+		pool.Go(func() error {
+			if common.IsDone(ctx) {
+				return nil
+			}
+			id, _ := unit.SourceUnitID()
+			progress.SetProgressComplete(i, len(units), fmt.Sprintf("%s: %s", unitName, id), "")
+			if err := source.ChunkUnit(ctx, unit, reporter); err != nil {
+				ctx.Logger().Error(err, "error chunking unit", "unit", id)
+			}
+			return nil
+		})
+	}
+	_ = pool.Wait()
+	progress.SetProgressComplete(len(units), len(units), "Completed scan", "")
+	return nil
+}
@@ -0,0 +1,120 @@
+package npm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+const searchPageSize = 250
+
+type npmUser struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+type packageVersion struct {
+	Version string  `json:"version"`
+	NPMUser npmUser `json:"_npmUser"`
+	Dist    struct {
+		Tarball string `json:"tarball"`
+	} `json:"dist"`
+}
+
+// packument is the registry document that describes every published version
+// of a package.
+type packument struct {
+	Name     string                    `json:"name"`
+	DistTags map[string]string         `json:"dist-tags"`
+	Versions map[string]packageVersion `json:"versions"`
+	Time     map[string]string         `json:"time"`
+}
+
+// client is a minimal npm registry client. Private registries such as
+// Verdaccio serve the same API.
+type client struct {
+	httpClient *http.Client
+	registry   string
+}
+
+func (c *client) do(ctx context.Context, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create npm registry request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request to npm registry: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("unexpected status code %d from %q: %s", resp.StatusCode, req.URL.Path, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+func (c *client) get(ctx context.Context, path string, target any) error {
+	resp, err := c.do(ctx, c.registry+path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode npm registry response: %w", err)
+	}
+	return nil
+}
+
+// getPackument returns the packument of a package. Scoped names keep their
+// "@", with the slash escaped.
+func (c *client) getPackument(ctx context.Context, name string) (packument, error) {
+	var p packument
+	err := c.get(ctx, "/"+url.PathEscape(name), &p)
+	return p, err
+}
+
+// search visits the names of every package matching a search query, such as
+// "scope:acme" or "maintainer:alice".
+func (c *client) search(ctx context.Context, text string, visit func(name string) error) error {
+	query := url.Values{"text": {text}, "size": {fmt.Sprint(searchPageSize)}}
+	for from := 0; ; {
+		query.Set("from", fmt.Sprint(from))
+		var resp struct {
+			Objects []struct {
+				Package struct {
+					Name string `json:"name"`
+				} `json:"package"`
+			} `json:"objects"`
+			Total int `json:"total"`
+		}
+		if err := c.get(ctx, "/-/v1/search?"+query.Encode(), &resp); err != nil {
+			return err
+		}
+		for _, o := range resp.Objects {
+			if err := visit(o.Package.Name); err != nil {
+				return err
+			}
+		}
+		from += len(resp.Objects)
+		if len(resp.Objects) == 0 || from >= resp.Total {
+			return nil
+		}
+	}
+}
+
+// download returns the content of a tarball. The caller must close the
+// returned reader.
+func (c *client) download(ctx context.Context, tarball string) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, tarball)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,26 @@
+package npm
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+)
+
+var (
+	npmPackagesEnumerated = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "npm_packages_enumerated",
+		Help:      "Total number of npm packages enumerated.",
+	},
+		[]string{"source_name"})
+
+	npmPackagesScanned = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "npm_packages_scanned",
+		Help:      "Total number of npm packages scanned.",
+	},
+		[]string{"source_name"})
+)
@@ -0,0 +1,257 @@
+package npm
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+const (
+	SourceType = sourcespb.SourceType_SOURCE_TYPE_NPM_UNAUTHD_PACKAGES
+
+	defaultRegistry = "https://registry.npmjs.org"
+)
+
+// Source scans the published tarballs of npm packages.
+//
+// The connection proto only holds the credential, so the packages to scan
+// and the registry are set with the With* methods after Init.
+type Source struct {
+	name     string
+	sourceID sources.SourceID
+	jobID    sources.JobID
+	verify   bool
+
+	client     *client
+	packages   []string
+	scopes     []string
+	maintainer string
+	// versions, when not empty, limits the scan to these versions or dist-tags.
+	versions []string
+
+	jobPool *errgroup.Group
+	sources.Progress
+	sources.CommonSourceUnitUnmarshaller
+}
+
+// Ensure the Source satisfies the interfaces at compile time.
+var _ sources.Source = (*Source)(nil)
+var _ sources.SourceUnitUnmarshaller = (*Source)(nil)
+var _ sources.SourceUnitEnumChunker = (*Source)(nil)
+
+// Type returns the type of source.
+// It is used for matching source types in configuration and job input.
+func (s *Source) Type() sourcespb.SourceType {
+	return SourceType
+}
+
+func (s *Source) SourceID() sources.SourceID {
+	return s.sourceID
+}
+
+func (s *Source) JobID() sources.JobID {
+	return s.jobID
+}
+
+// Init returns an initialized npm source that uses the public registry.
+func (s *Source) Init(_ context.Context, name string, jobId sources.JobID, sourceId sources.SourceID, verify bool, connection *anypb.Any, concurrency int) error {
+	s.name = name
+	s.sourceID = sourceId
+	s.jobID = jobId
+	s.verify = verify
+	s.jobPool = &errgroup.Group{}
+	s.jobPool.SetLimit(concurrency)
+
+	var conn sourcespb.NPMUnauthenticatedPackage
+	if err := anypb.UnmarshalTo(connection, &conn, proto.UnmarshalOptions{}); err != nil {
+		return fmt.Errorf("error unmarshalling connection: %w", err)
+	}
+	switch conn.GetCredential().(type) {
+	case *sourcespb.NPMUnauthenticatedPackage_Unauthenticated:
+	default:
+		return fmt.Errorf("invalid configuration given for source %q (%s)", name, s.Type().String())
+	}
+
+	s.client = &client{httpClient: common.RetryableHTTPClientTimeout(300), registry: defaultRegistry}
+	return nil
+}
+
+// WithRegistry sets the registry URL, for example a private Verdaccio instance.
+func (s *Source) WithRegistry(registry string) {
+	if registry != "" {
+		s.client.registry = strings.TrimSuffix(registry, "/")
+	}
+}
+
+// WithPackages adds packages to scan by name.
+func (s *Source) WithPackages(packages ...string) {
+	s.packages = append(s.packages, packages...)
+}
+
+// WithScopes adds every package of the given scopes, with or without the "@".
+func (s *Source) WithScopes(scopes ...string) {
+	s.scopes = append(s.scopes, scopes...)
+}
+
+// WithMaintainer adds every package maintained by the given npm user.
+func (s *Source) WithMaintainer(maintainer string) {
+	s.maintainer = maintainer
+}
+
+// WithVersions limits the scan to the given versions or dist-tags, such as
+// "latest". By default every published version is scanned.
+func (s *Source) WithVersions(versions ...string) {
+	s.versions = append(s.versions, versions...)
+}
+
+// Enumerate reports the named packages and every package found by scope and
+// maintainer searches, once each. Units are identified by package name.
+func (s *Source) Enumerate(ctx context.Context, reporter sources.UnitReporter) error {
+	seen := make(map[string]struct{})
+	report := func(name string) error {
+		if _, ok := seen[name]; ok {
+			return nil
+		}
+		seen[name] = struct{}{}
+		npmPackagesEnumerated.WithLabelValues(s.name).Inc()
+		return reporter.UnitOk(ctx, sources.CommonSourceUnit{ID: name})
+	}
+
+	npmPackagesEnumerated.WithLabelValues(s.name).Set(0)
+	for _, name := range s.packages {
+		if err := report(name); err != nil {
+			return err
+		}
+	}
+
+	var queries []string
+	for _, scope := range s.scopes {
+		queries = append(queries, "scope:"+strings.TrimPrefix(scope, "@"))
+	}
+	if s.maintainer != "" {
+		queries = append(queries, "maintainer:"+s.maintainer)
+	}
+	for _, query := range queries {
+		var reportErr error
+		err := s.client.search(ctx, query, func(name string) error {
+			reportErr = report(name)
+			return reportErr
+		})
+		if reportErr != nil {
+			return reportErr
+		}
+		if err != nil {
+			if err := reporter.UnitErr(ctx, fmt.Errorf("could not search for %q: %w", query, err)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// selectVersions returns the versions of the package to scan, oldest first
+// when publish times are known.
+func (s *Source) selectVersions(p packument) []string {
+	var versions []string
+	if len(s.versions) == 0 {
+		for v := range p.Versions {
+			versions = append(versions, v)
+		}
+	} else {
+		for _, v := range s.versions {
+			if tagged, ok := p.DistTags[v]; ok {
+				v = tagged
+			}
+			if _, ok := p.Versions[v]; ok {
+				versions = append(versions, v)
+			}
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		ti, tj := p.Time[versions[i]], p.Time[versions[j]]
+		if ti != tj {
+			return ti < tj
+		}
+		return versions[i] < versions[j]
+	})
+	return versions
+}
+
+// ChunkUnit downloads and scans the tarball of every selected version of the
+// package.
+func (s *Source) ChunkUnit(ctx context.Context, unit sources.SourceUnit, reporter sources.ChunkReporter) error {
+	name, _ := unit.SourceUnitID()
+	ctx = context.WithValue(ctx, "package", name)
+
+	p, err := s.client.getPackument(ctx, name)
+	if err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not get package %q: %w", name, err))
+	}
+
+	seen := make(map[string]struct{})
+	for _, v := range s.selectVersions(p) {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		if err := s.chunkVersion(ctx, name, p.Versions[v], reporter); err != nil {
+			return err
+		}
+	}
+	npmPackagesScanned.WithLabelValues(s.name).Inc()
+	return nil
+}
+
+func (s *Source) chunkVersion(ctx context.Context, name string, v packageVersion, reporter sources.ChunkReporter) error {
+	tarball := v.Dist.Tarball
+	if tarball == "" {
+		return nil
+	}
+	body, err := s.client.download(ctx, tarball)
+	if err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not download %s@%s: %w", name, v.Version, err))
+	}
+	defer body.Close()
+
+	chunkSkel := &sources.Chunk{
+		SourceName: s.name,
+		SourceID:   s.SourceID(),
+		JobID:      s.JobID(),
+		SourceType: s.Type(),
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Npm{
+				Npm: &source_metadatapb.NPM{
+					File:    sanitizer.UTF8(path.Base(tarball)),
+					Package: sanitizer.UTF8(name),
+					Release: sanitizer.UTF8(v.Version),
+					Link:    sanitizer.UTF8(tarball),
+					Email:   sanitizer.UTF8(v.NPMUser.Email),
+				},
+			},
+		},
+		Verify: s.verify,
+	}
+	if err := handlers.HandleFile(ctx, body, chunkSkel, reporter); err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not scan %s@%s: %w", name, v.Version, err))
+	}
+	return nil
+}
+
+// Chunks emits chunks of bytes over a channel.
+func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ ...sources.ChunkingTarget) error {
+	npmPackagesScanned.WithLabelValues(s.name).Set(0)
+	return sources.ChunkUnits(ctx, s, s.jobPool, &s.Progress, "Package", chunksChan)
+}
@@ -0,0 +1,131 @@
+package npm
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sourcestest"
+)
+
+func tarball(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content))}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func newTestSource(t *testing.T) (*Source, *httptest.Server) {
+	t.Helper()
+	tarballs := map[string][]byte{
+		"/@acme/ui/-/ui-1.0.0.tgz": tarball(t, map[string]string{"package/dist/index.js": `const key = "sk_live_v1";`}),
+		"/@acme/ui/-/ui-2.0.0.tgz": tarball(t, map[string]string{"package/dist/index.js": `const key = "sk_live_v2";`}),
+	}
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if data, ok := tarballs[r.URL.Path]; ok {
+			_, _ = w.Write(data)
+			return
+		}
+		var resp string
+		switch r.URL.EscapedPath() {
+		case "/@acme%2Fui":
+			resp = `{
+				"name": "@acme/ui",
+				"dist-tags": {"latest": "2.0.0"},
+				"time": {"1.0.0": "2023-01-01T00:00:00.000Z", "2.0.0": "2024-01-01T00:00:00.000Z"},
+				"versions": {
+					"1.0.0": {"version": "1.0.0", "_npmUser": {"email": "alice@example.com"}, "dist": {"tarball": "SERVER/@acme/ui/-/ui-1.0.0.tgz"}},
+					"2.0.0": {"version": "2.0.0", "_npmUser": {"email": "bob@example.com"}, "dist": {"tarball": "SERVER/@acme/ui/-/ui-2.0.0.tgz"}}
+				}
+			}`
+		case "/-/v1/search":
+			switch r.URL.Query().Get("text") {
+			case "scope:acme":
+				resp = `{"objects": [{"package": {"name": "@acme/ui"}}, {"package": {"name": "@acme/cli"}}], "total": 2}`
+			case "maintainer:alice":
+				resp = `{"objects": [{"package": {"name": "@acme/ui"}}, {"package": {"name": "left-pad"}}], "total": 2}`
+			}
+		}
+		if resp == "" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(strings.ReplaceAll(resp, "SERVER", srv.URL)))
+	}))
+	t.Cleanup(srv.Close)
+
+	conn, err := anypb.New(&sourcespb.NPMUnauthenticatedPackage{
+		Credential: &sourcespb.NPMUnauthenticatedPackage_Unauthenticated{Unauthenticated: &credentialspb.Unauthenticated{}},
+	})
+	require.NoError(t, err)
+	s := &Source{}
+	require.NoError(t, s.Init(context.Background(), "test", 0, 0, false, conn, 1))
+	s.WithRegistry(srv.URL + "/")
+	return s, srv
+}
+
+func TestEnumerate(t *testing.T) {
+	s, _ := newTestSource(t)
+	s.WithPackages("express")
+	s.WithScopes("@acme")
+	s.WithMaintainer("alice")
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+	assert.Empty(t, reporter.UnitErrs)
+	assert.Equal(t, []sources.SourceUnit{
+		sources.CommonSourceUnit{ID: "express"},
+		sources.CommonSourceUnit{ID: "@acme/ui"},
+		sources.CommonSourceUnit{ID: "@acme/cli"},
+		sources.CommonSourceUnit{ID: "left-pad"},
+	}, reporter.Units)
+}
+
+func TestChunkUnit(t *testing.T) {
+	t.Run("all versions", func(t *testing.T) {
+		s, srv := newTestSource(t)
+		reporter := sourcestest.TestReporter{}
+		require.NoError(t, s.ChunkUnit(context.Background(), sources.CommonSourceUnit{ID: "@acme/ui"}, &reporter))
+		require.Empty(t, reporter.ChunkErrs)
+		require.Len(t, reporter.Chunks, 2)
+
+		assert.Contains(t, string(reporter.Chunks[0].Data), "sk_live_v1")
+		npm := reporter.Chunks[0].SourceMetadata.GetNpm()
+		assert.Equal(t, "@acme/ui", npm.GetPackage())
+		assert.Equal(t, "1.0.0", npm.GetRelease())
+		assert.Equal(t, "ui-1.0.0.tgz", npm.GetFile())
+		assert.Equal(t, "alice@example.com", npm.GetEmail())
+		assert.Equal(t, srv.URL+"/@acme/ui/-/ui-1.0.0.tgz", npm.GetLink())
+		assert.Contains(t, string(reporter.Chunks[1].Data), "sk_live_v2")
+	})
+
+	t.Run("dist-tag", func(t *testing.T) {
+		s, _ := newTestSource(t)
+		s.WithVersions("latest")
+		reporter := sourcestest.TestReporter{}
+		require.NoError(t, s.ChunkUnit(context.Background(), sources.CommonSourceUnit{ID: "@acme/ui"}, &reporter))
+		require.Len(t, reporter.Chunks, 1)
+		assert.Equal(t, "2.0.0", reporter.Chunks[0].SourceMetadata.GetNpm().GetRelease())
+	})
+}
@@ -0,0 +1,255 @@
+package pypi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+// simpleJSONType is the content type of the JSON form of the Simple API
+// (PEP 691). Indexes that do not support it serve HTML (PEP 503).
+const simpleJSONType = "application/vnd.pypi.simple.v1+json"
+
+var errNotFound = errors.New("not found")
+
+// releaseFile is a distribution file of a project release: an sdist or a wheel.
+type releaseFile struct {
+	Filename string
+	URL      string
+	Version  string
+}
+
+// project holds the release files of a project and, when the index serves
+// the JSON API, the contact email of its author.
+type project struct {
+	Files []releaseFile
+	Email string
+}
+
+// client reads projects from a package index. It prefers the PyPI JSON API
+// and falls back to the Simple API, which every index, devpi included, serves.
+type client struct {
+	httpClient *http.Client
+	// indexURL is the Simple API root, such as https://pypi.org/simple.
+	indexURL string
+}
+
+func (c *client) do(ctx context.Context, rawURL, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create package index request: %w", err)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request to package index: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%q: %w", req.URL.Path, errNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("unexpected status code %d from %q: %s", resp.StatusCode, req.URL.Path, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+// normalize returns the normalized form of a project name (PEP 503).
+func normalize(name string) string {
+	return strings.ToLower(separators.ReplaceAllString(name, "-"))
+}
+
+var separators = regexp.MustCompile(`[-_.]+`)
+
+// jsonAPIURL returns the JSON API URL of a project, or an empty string if
+// the index is not laid out like PyPI.
+func (c *client) jsonAPIURL(name string) string {
+	root, ok := strings.CutSuffix(c.indexURL, "/simple")
+	if !ok {
+		return ""
+	}
+	return root + "/pypi/" + url.PathEscape(name) + "/json"
+}
+
+// getProject returns every release file of a project.
+func (c *client) getProject(ctx context.Context, name string) (project, error) {
+	if apiURL := c.jsonAPIURL(name); apiURL != "" {
+		p, err := c.getProjectJSON(ctx, apiURL)
+		if err == nil {
+			return p, nil
+		}
+		if !errors.Is(err, errNotFound) {
+			return project{}, err
+		}
+	}
+	files, err := c.getProjectSimple(ctx, name)
+	return project{Files: files}, err
+}
+
+func (c *client) getProjectJSON(ctx context.Context, apiURL string) (project, error) {
+	resp, err := c.do(ctx, apiURL, "application/json")
+	if err != nil {
+		return project{}, err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Info struct {
+			AuthorEmail     string `json:"author_email"`
+			MaintainerEmail string `json:"maintainer_email"`
+		} `json:"info"`
+		Releases map[string][]struct {
+			Filename string `json:"filename"`
+			URL      string `json:"url"`
+		} `json:"releases"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return project{}, fmt.Errorf("failed to decode package index response: %w", err)
+	}
+
+	p := project{Email: body.Info.AuthorEmail}
+	if p.Email == "" {
+		p.Email = body.Info.MaintainerEmail
+	}
+	for version, files := range body.Releases {
+		for _, f := range files {
+			p.Files = append(p.Files, releaseFile{Filename: f.Filename, URL: f.URL, Version: version})
+		}
+	}
+	sort.Slice(p.Files, func(i, j int) bool { return p.Files[i].Filename < p.Files[j].Filename })
+	return p, nil
+}
+
+func (c *client) getProjectSimple(ctx context.Context, name string) ([]releaseFile, error) {
+	pageURL := c.indexURL + "/" + normalize(name) + "/"
+	resp, err := c.do(ctx, pageURL, simpleJSONType+", text/html;q=0.1")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var links []releaseFile
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == simpleJSONType {
+		var body struct {
+			Files []struct {
+				Filename string `json:"filename"`
+				URL      string `json:"url"`
+			} `json:"files"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			return nil, fmt.Errorf("failed to decode package index response: %w", err)
+		}
+		for _, f := range body.Files {
+			links = append(links, releaseFile{Filename: f.Filename, URL: f.URL})
+		}
+	} else {
+		links = parseSimpleHTML(resp.Body)
+	}
+
+	files := make([]releaseFile, 0, len(links))
+	for _, f := range links {
+		ref, err := url.Parse(f.URL)
+		if err != nil {
+			continue
+		}
+		ref.Fragment = ""
+		f.URL = base.ResolveReference(ref).String()
+		f.Version = versionFromFilename(f.Filename)
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// parseSimpleHTML returns the file links of a Simple API project page. The
+// link text is the filename.
+func parseSimpleHTML(r io.Reader) []releaseFile {
+	z := html.NewTokenizer(r)
+	var (
+		files   []releaseFile
+		current *releaseFile
+	)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return files
+		case html.StartTagToken:
+			name, hasAttr := z.TagName()
+			if string(name) != "a" {
+				continue
+			}
+			current = &releaseFile{}
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				if string(key) == "href" {
+					current.URL = string(val)
+				}
+			}
+		case html.TextToken:
+			if current != nil {
+				current.Filename += string(z.Text())
+			}
+		case html.EndTagToken:
+			if name, _ := z.TagName(); string(name) == "a" && current != nil {
+				current.Filename = strings.TrimSpace(current.Filename)
+				if current.URL != "" && current.Filename != "" {
+					files = append(files, *current)
+				}
+				current = nil
+			}
+		}
+	}
+}
+
+var sdistExtensions = []string{".tar.gz", ".tar.bz2", ".tar.xz", ".tgz", ".zip", ".tar"}
+
+// versionFromFilename extracts the version from a wheel or sdist filename.
+// Wheels are named {name}-{version}-...whl and sdists {name}-{version}.{ext}.
+func versionFromFilename(filename string) string {
+	if base, ok := strings.CutSuffix(filename, ".whl"); ok {
+		parts := strings.Split(base, "-")
+		if len(parts) >= 2 {
+			return parts[1]
+		}
+		return ""
+	}
+	for _, ext := range sdistExtensions {
+		if base, ok := strings.CutSuffix(filename, ext); ok {
+			if i := strings.LastIndex(base, "-"); i >= 0 {
+				return base[i+1:]
+			}
+			return ""
+		}
+	}
+	return ""
+}
+
+// download returns the content of a release file. The caller must close the
+// returned reader.
+func (c *client) download(ctx context.Context, f releaseFile) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, f.URL, "")
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
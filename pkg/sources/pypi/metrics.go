@@ -0,0 +1,26 @@
+package pypi
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+)
+
+var (
+	pypiProjectsEnumerated = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "pypi_projects_enumerated",
+		Help:      "Total number of PyPI projects enumerated.",
+	},
+		[]string{"source_name"})
+
+	pypiProjectsScanned = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "pypi_projects_scanned",
+		Help:      "Total number of PyPI projects scanned.",
+	},
+		[]string{"source_name"})
+)
@@ -0,0 +1,184 @@
+package pypi
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+const (
+	SourceType = sourcespb.SourceType_SOURCE_TYPE_PYPI_UNAUTHD_PACKAGES
+
+	defaultIndexURL = "https://pypi.org/simple"
+)
+
+// Source scans the release files, sdists and wheels, of Python projects.
+//
+// The connection proto only holds the credential, so the projects to scan
+// and the index are set with the With* methods after Init.
+type Source struct {
+	name     string
+	sourceID sources.SourceID
+	jobID    sources.JobID
+	verify   bool
+
+	client   *client
+	projects []string
+	// versions, when not empty, limits the scan to these versions.
+	versions map[string]struct{}
+
+	jobPool *errgroup.Group
+	sources.Progress
+	sources.CommonSourceUnitUnmarshaller
+}
+
+// Ensure the Source satisfies the interfaces at compile time.
+var _ sources.Source = (*Source)(nil)
+var _ sources.SourceUnitUnmarshaller = (*Source)(nil)
+var _ sources.SourceUnitEnumChunker = (*Source)(nil)
+
+// Type returns the type of source.
+// It is used for matching source types in configuration and job input.
+func (s *Source) Type() sourcespb.SourceType {
+	return SourceType
+}
+
+func (s *Source) SourceID() sources.SourceID {
+	return s.sourceID
+}
+
+func (s *Source) JobID() sources.JobID {
+	return s.jobID
+}
+
+// Init returns an initialized PyPI source that uses pypi.org.
+func (s *Source) Init(_ context.Context, name string, jobId sources.JobID, sourceId sources.SourceID, verify bool, connection *anypb.Any, concurrency int) error {
+	s.name = name
+	s.sourceID = sourceId
+	s.jobID = jobId
+	s.verify = verify
+	s.jobPool = &errgroup.Group{}
+	s.jobPool.SetLimit(concurrency)
+
+	var conn sourcespb.PyPIUnauthenticatedPackage
+	if err := anypb.UnmarshalTo(connection, &conn, proto.UnmarshalOptions{}); err != nil {
+		return fmt.Errorf("error unmarshalling connection: %w", err)
+	}
+	switch conn.GetCredential().(type) {
+	case *sourcespb.PyPIUnauthenticatedPackage_Unauthenticated:
+	default:
+		return fmt.Errorf("invalid configuration given for source %q (%s)", name, s.Type().String())
+	}
+
+	s.client = &client{httpClient: common.RetryableHTTPClientTimeout(300), indexURL: defaultIndexURL}
+	return nil
+}
+
+// WithIndexURL sets the Simple API root of the index, as passed to
+// pip --index-url, for example a devpi index.
+func (s *Source) WithIndexURL(indexURL string) {
+	if indexURL != "" {
+		s.client.indexURL = strings.TrimSuffix(indexURL, "/")
+	}
+}
+
+// WithProjects adds projects to scan by name.
+func (s *Source) WithProjects(projects ...string) {
+	s.projects = append(s.projects, projects...)
+}
+
+// WithVersions limits the scan to the given versions. By default every
+// release is scanned.
+func (s *Source) WithVersions(versions ...string) {
+	if s.versions == nil {
+		s.versions = make(map[string]struct{}, len(versions))
+	}
+	for _, v := range versions {
+		s.versions[v] = struct{}{}
+	}
+}
+
+// Enumerate reports the configured projects. Units are identified by
+// project name.
+func (s *Source) Enumerate(ctx context.Context, reporter sources.UnitReporter) error {
+	pypiProjectsEnumerated.WithLabelValues(s.name).Set(0)
+	for _, p := range s.projects {
+		if err := reporter.UnitOk(ctx, sources.CommonSourceUnit{ID: p}); err != nil {
+			return err
+		}
+		pypiProjectsEnumerated.WithLabelValues(s.name).Inc()
+	}
+	return nil
+}
+
+// ChunkUnit downloads and scans every selected release file of the project.
+func (s *Source) ChunkUnit(ctx context.Context, unit sources.SourceUnit, reporter sources.ChunkReporter) error {
+	name, _ := unit.SourceUnitID()
+	ctx = context.WithValue(ctx, "project", name)
+
+	p, err := s.client.getProject(ctx, name)
+	if err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not get project %q: %w", name, err))
+	}
+
+	for _, f := range p.Files {
+		if len(s.versions) > 0 {
+			if _, ok := s.versions[f.Version]; !ok {
+				continue
+			}
+		}
+		if err := s.chunkFile(ctx, name, p.Email, f, reporter); err != nil {
+			return err
+		}
+	}
+	pypiProjectsScanned.WithLabelValues(s.name).Inc()
+	return nil
+}
+
+func (s *Source) chunkFile(ctx context.Context, name, email string, f releaseFile, reporter sources.ChunkReporter) error {
+	body, err := s.client.download(ctx, f)
+	if err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not download %s: %w", f.Filename, err))
+	}
+	defer body.Close()
+
+	chunkSkel := &sources.Chunk{
+		SourceName: s.name,
+		SourceID:   s.SourceID(),
+		JobID:      s.JobID(),
+		SourceType: s.Type(),
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Pypi{
+				Pypi: &source_metadatapb.PyPi{
+					File:    sanitizer.UTF8(f.Filename),
+					Package: sanitizer.UTF8(name),
+					Release: sanitizer.UTF8(f.Version),
+					Link:    sanitizer.UTF8(f.URL),
+					Email:   sanitizer.UTF8(email),
+				},
+			},
+		},
+		Verify: s.verify,
+	}
+	if err := handlers.HandleFile(ctx, body, chunkSkel, reporter); err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not scan %s: %w", f.Filename, err))
+	}
+	return nil
+}
+
+// Chunks emits chunks of bytes over a channel.
+func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ ...sources.ChunkingTarget) error {
+	pypiProjectsScanned.WithLabelValues(s.name).Set(0)
+	return sources.ChunkUnits(ctx, s, s.jobPool, &s.Progress, "Project", chunksChan)
+}
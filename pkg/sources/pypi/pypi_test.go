@@ -0,0 +1,145 @@
+package pypi
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sourcestest"
+)
+
+func sdist(t *testing.T, name, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content))}))
+	_, err := tw.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func wheel(t *testing.T, name, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create(name)
+	require.NoError(t, err)
+	_, err = w.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func newTestSource(t *testing.T, indexPath string) (*Source, *httptest.Server) {
+	t.Helper()
+	files := map[string][]byte{
+		"/files/acme_tools-1.0.tar.gz":               sdist(t, "acme_tools-1.0/.env", "AWS_SECRET=from-sdist"),
+		"/files/acme_tools-1.1-py3-none-any.whl":     wheel(t, "acme_tools/config.py", "TOKEN = 'from-wheel'"),
+		"/root/dev/+f/abc/internal_lib-0.2.0.tar.gz": sdist(t, "internal_lib-0.2.0/settings.py", "PASSWORD = 'from-devpi'"),
+	}
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if data, ok := files[r.URL.Path]; ok {
+			_, _ = w.Write(data)
+			return
+		}
+		var resp string
+		switch r.URL.Path {
+		case "/pypi/Acme.Tools/json":
+			resp = `{
+				"info": {"author_email": "dev@acme.example"},
+				"releases": {
+					"1.0": [{"filename": "acme_tools-1.0.tar.gz", "url": "SERVER/files/acme_tools-1.0.tar.gz"}],
+					"1.1": [{"filename": "acme_tools-1.1-py3-none-any.whl", "url": "SERVER/files/acme_tools-1.1-py3-none-any.whl"}]
+				}
+			}`
+		case "/root/dev/internal-lib/":
+			// devpi serves the Simple API as HTML.
+			w.Header().Set("Content-Type", "text/html")
+			resp = `<html><body>
+				<a href="../+f/abc/internal_lib-0.2.0.tar.gz#sha256=deadbeef">internal_lib-0.2.0.tar.gz</a><br/>
+			</body></html>`
+		}
+		if resp == "" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(strings.ReplaceAll(resp, "SERVER", srv.URL)))
+	}))
+	t.Cleanup(srv.Close)
+
+	conn, err := anypb.New(&sourcespb.PyPIUnauthenticatedPackage{
+		Credential: &sourcespb.PyPIUnauthenticatedPackage_Unauthenticated{Unauthenticated: &credentialspb.Unauthenticated{}},
+	})
+	require.NoError(t, err)
+	s := &Source{}
+	require.NoError(t, s.Init(context.Background(), "test", 0, 0, false, conn, 1))
+	s.WithIndexURL(srv.URL + indexPath)
+	return s, srv
+}
+
+func TestChunkUnit_JSONAPI(t *testing.T) {
+	s, srv := newTestSource(t, "/simple/")
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.ChunkUnit(context.Background(), sources.CommonSourceUnit{ID: "Acme.Tools"}, &reporter))
+	require.Empty(t, reporter.ChunkErrs)
+	require.Len(t, reporter.Chunks, 2)
+
+	assert.Contains(t, string(reporter.Chunks[0].Data), "AWS_SECRET=from-sdist")
+	meta := reporter.Chunks[0].SourceMetadata.GetPypi()
+	assert.Equal(t, "Acme.Tools", meta.GetPackage())
+	assert.Equal(t, "1.0", meta.GetRelease())
+	assert.Equal(t, "acme_tools-1.0.tar.gz", meta.GetFile())
+	assert.Equal(t, "dev@acme.example", meta.GetEmail())
+	assert.Equal(t, srv.URL+"/files/acme_tools-1.0.tar.gz", meta.GetLink())
+
+	assert.Contains(t, string(reporter.Chunks[1].Data), "from-wheel")
+	assert.Equal(t, "1.1", reporter.Chunks[1].SourceMetadata.GetPypi().GetRelease())
+
+	s.WithVersions("1.1")
+	reporter = sourcestest.TestReporter{}
+	require.NoError(t, s.ChunkUnit(context.Background(), sources.CommonSourceUnit{ID: "Acme.Tools"}, &reporter))
+	require.Len(t, reporter.Chunks, 1)
+	assert.Equal(t, "acme_tools-1.1-py3-none-any.whl", reporter.Chunks[0].SourceMetadata.GetPypi().GetFile())
+}
+
+func TestChunkUnit_SimpleAPI(t *testing.T) {
+	s, srv := newTestSource(t, "/root/dev")
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.ChunkUnit(context.Background(), sources.CommonSourceUnit{ID: "Internal_Lib"}, &reporter))
+	require.Empty(t, reporter.ChunkErrs)
+	require.Len(t, reporter.Chunks, 1)
+
+	assert.Contains(t, string(reporter.Chunks[0].Data), "from-devpi")
+	meta := reporter.Chunks[0].SourceMetadata.GetPypi()
+	assert.Equal(t, "0.2.0", meta.GetRelease())
+	assert.Equal(t, srv.URL+"/root/dev/+f/abc/internal_lib-0.2.0.tar.gz", meta.GetLink())
+}
+
+func TestVersionFromFilename(t *testing.T) {
+	for filename, want := range map[string]string{
+		"requests-2.31.0-py3-none-any.whl":   "2.31.0",
+		"requests-2.31.0.tar.gz":             "2.31.0",
+		"zope.interface-6.0-cp311-win32.whl": "6.0",
+		"legacy-name-1.0.zip":                "1.0",
+		"README.txt":                         "",
+	} {
+		assert.Equal(t, want, versionFromFilename(filename), filename)
+	}
+}
@@ -0,0 +1,159 @@
+package sentry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+type organization struct {
+	ID          string `json:"id"`
+	Slug        string `json:"slug"`
+	DateCreated string `json:"dateCreated"`
+}
+
+type project struct {
+	ID           string       `json:"id"`
+	Slug         string       `json:"slug"`
+	Organization organization `json:"organization"`
+}
+
+type issue struct {
+	ID        string `json:"id"`
+	ShortID   string `json:"shortId"`
+	Permalink string `json:"permalink"`
+}
+
+// entry is an interface of an event, such as its request, exception or
+// breadcrumbs. Data is decoded according to Type.
+type entry struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// event is an event with all of its data, as returned with full=true.
+type event struct {
+	ID          string          `json:"id"`
+	EventID     string          `json:"eventID"`
+	GroupID     string          `json:"groupID"`
+	DateCreated string          `json:"dateCreated"`
+	Message     string          `json:"message"`
+	Entries     []entry         `json:"entries"`
+	Context     json.RawMessage `json:"context"`
+	Contexts    json.RawMessage `json:"contexts"`
+	User        json.RawMessage `json:"user"`
+}
+
+// client is a minimal Sentry web API client. It works with sentry.io and with
+// self-hosted Sentry.
+type client struct {
+	httpClient *http.Client
+	// baseURL is the Sentry URL without a trailing slash.
+	baseURL string
+	// authorize sets the credentials on a request.
+	authorize func(*http.Request)
+}
+
+func (c *client) do(ctx context.Context, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Sentry API request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	c.authorize(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request to Sentry API: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("unexpected status code %d from %q: %s", resp.StatusCode, req.URL.Path, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+func (c *client) get(ctx context.Context, path string, target any) error {
+	_, err := c.getPage(ctx, c.baseURL+path, target)
+	return err
+}
+
+// getPage decodes the response into target and returns the URL of the next
+// page, if any.
+func (c *client) getPage(ctx context.Context, rawURL string, target any) (string, error) {
+	resp, err := c.do(ctx, rawURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return "", fmt.Errorf("failed to decode Sentry API response: %w", err)
+	}
+	return nextPageURL(resp.Header.Get("Link")), nil
+}
+
+var nextLink = regexp.MustCompile(`<([^>]+)>;\s*rel="next";\s*results="(true|false)"`)
+
+// nextPageURL returns the next page of a paginated response from its Link
+// header. Sentry always sends a next link, and marks whether it has results.
+func nextPageURL(linkHeader string) string {
+	if m := nextLink.FindStringSubmatch(linkHeader); m != nil && m[2] == "true" {
+		return m[1]
+	}
+	return ""
+}
+
+// listAll visits every item of a paginated list.
+func listAll[T any](ctx context.Context, c *client, path string, visit func(T) error) error {
+	for next := c.baseURL + path; next != ""; {
+		var page []T
+		var err error
+		if next, err = c.getPage(ctx, next, &page); err != nil {
+			return err
+		}
+		for _, item := range page {
+			if err := visit(item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// listProjects lists every project the credentials can access, in every
+// organization.
+func (c *client) listProjects(ctx context.Context) ([]project, error) {
+	var projects []project
+	err := listAll(ctx, c, "/api/0/projects/", func(p project) error {
+		projects = append(projects, p)
+		return nil
+	})
+	return projects, err
+}
+
+func (c *client) getProject(ctx context.Context, org, slug string) (project, error) {
+	var p project
+	err := c.get(ctx, fmt.Sprintf("/api/0/projects/%s/%s/", url.PathEscape(org), url.PathEscape(slug)), &p)
+	return p, err
+}
+
+// listIssues visits every issue of a project, resolved and ignored ones
+// included.
+func (c *client) listIssues(ctx context.Context, org, slug string, visit func(issue) error) error {
+	path := fmt.Sprintf("/api/0/projects/%s/%s/issues/?query=", url.PathEscape(org), url.PathEscape(slug))
+	return listAll(ctx, c, path, visit)
+}
+
+// listEvents visits every event of an issue with all of its data.
+func (c *client) listEvents(ctx context.Context, issueID string, visit func(event) error) error {
+	path := fmt.Sprintf("/api/0/issues/%s/events/?full=true", url.PathEscape(issueID))
+	return listAll(ctx, c, path, visit)
+}
@@ -0,0 +1,34 @@
+package sentry
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+)
+
+var (
+	sentryProjectsEnumerated = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "sentry_projects_enumerated",
+		Help:      "Total number of Sentry projects enumerated.",
+	},
+		[]string{"source_name"})
+
+	sentryProjectsScanned = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "sentry_projects_scanned",
+		Help:      "Total number of Sentry projects scanned.",
+	},
+		[]string{"source_name"})
+
+	sentryEventsScanned = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "sentry_events_scanned",
+		Help:      "Total number of Sentry events scanned.",
+	},
+		[]string{"source_name"})
+)
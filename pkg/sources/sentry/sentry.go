@@ -0,0 +1,363 @@
+package sentry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/log"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/roundtripper"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+const (
+	SourceType = sourcespb.SourceType_SOURCE_TYPE_SENTRY
+
+	defaultEndpoint = "https://sentry.io"
+)
+
+// Source scans the events of Sentry projects: their breadcrumbs, requests,
+// extra context, exceptions and the local variables of their stack frames.
+// Units are projects, identified by org/project.
+type Source struct {
+	name     string
+	sourceID sources.SourceID
+	jobID    sources.JobID
+	verify   bool
+
+	client *client
+	// projects holds the project slugs, or org/project, to scan. Empty means
+	// every project.
+	projects map[string]struct{}
+
+	jobPool *errgroup.Group
+	sources.Progress
+	sources.CommonSourceUnitUnmarshaller
+}
+
+// Ensure the Source satisfies the interfaces at compile time.
+var _ sources.Source = (*Source)(nil)
+var _ sources.SourceUnitUnmarshaller = (*Source)(nil)
+var _ sources.SourceUnitEnumChunker = (*Source)(nil)
+
+// Type returns the type of source.
+// It is used for matching source types in configuration and job input.
+func (s *Source) Type() sourcespb.SourceType {
+	return SourceType
+}
+
+func (s *Source) SourceID() sources.SourceID {
+	return s.sourceID
+}
+
+func (s *Source) JobID() sources.JobID {
+	return s.jobID
+}
+
+// Init returns an initialized Sentry source.
+func (s *Source) Init(ctx context.Context, name string, jobId sources.JobID, sourceId sources.SourceID, verify bool, connection *anypb.Any, concurrency int) error {
+	s.name = name
+	s.sourceID = sourceId
+	s.jobID = jobId
+	s.verify = verify
+	s.jobPool = &errgroup.Group{}
+	s.jobPool.SetLimit(concurrency)
+
+	var conn sourcespb.Sentry
+	if err := anypb.UnmarshalTo(connection, &conn, proto.UnmarshalOptions{}); err != nil {
+		return fmt.Errorf("error unmarshalling connection: %w", err)
+	}
+
+	var authorize func(*http.Request)
+	switch cred := conn.GetCredential().(type) {
+	case *sourcespb.Sentry_AuthToken:
+		token := cred.AuthToken
+		log.RedactGlobally(token)
+		authorize = func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) }
+	case *sourcespb.Sentry_ApiKey:
+		// Legacy API keys are sent as the username of basic auth.
+		key := cred.ApiKey
+		log.RedactGlobally(key)
+		authorize = func(req *http.Request) { req.SetBasicAuth(key, "") }
+	case *sourcespb.Sentry_DsnKey:
+		return fmt.Errorf("a DSN key can only send events: an auth token is required to read them")
+	default:
+		return fmt.Errorf("invalid configuration given for source %q (%s)", name, s.Type().String())
+	}
+
+	endpoint := strings.TrimSuffix(conn.GetEndpoint(), "/")
+	if endpoint == "" {
+		endpoint = defaultEndpoint
+	}
+	var clientOpts []common.ClientOption
+	if conn.GetInsecureSkipVerifyTls() {
+		clientOpts = append(clientOpts, common.WithTransport(roundtripper.NewRoundTripper(nil, roundtripper.WithInsecureTLS())))
+	}
+	httpClient := common.RetryableHTTPClientTimeout(60, clientOpts...)
+	s.client = &client{httpClient: httpClient, baseURL: endpoint, authorize: authorize}
+
+	// Projects are a comma separated list.
+	s.projects = make(map[string]struct{})
+	for _, p := range strings.Split(conn.GetProjects(), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			s.projects[p] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// wanted reports whether a project is configured to be scanned, by slug or
+// by org/project.
+func (s *Source) wanted(p project) bool {
+	if len(s.projects) == 0 {
+		return true
+	}
+	_, bySlug := s.projects[p.Slug]
+	_, byID := s.projects[p.Organization.Slug+"/"+p.Slug]
+	return bySlug || byID
+}
+
+// Enumerate reports every accessible project that is configured to be
+// scanned.
+func (s *Source) Enumerate(ctx context.Context, reporter sources.UnitReporter) error {
+	projects, err := s.client.listProjects(ctx)
+	if err != nil {
+		return reporter.UnitErr(ctx, fmt.Errorf("could not list projects: %w", err))
+	}
+
+	sentryProjectsEnumerated.WithLabelValues(s.name).Set(0)
+	for _, p := range projects {
+		if !s.wanted(p) {
+			continue
+		}
+		if err := reporter.UnitOk(ctx, sources.CommonSourceUnit{ID: p.Organization.Slug + "/" + p.Slug}); err != nil {
+			return err
+		}
+		sentryProjectsEnumerated.WithLabelValues(s.name).Inc()
+	}
+	return nil
+}
+
+// ChunkUnit scans every event of every issue of a project.
+func (s *Source) ChunkUnit(ctx context.Context, unit sources.SourceUnit, reporter sources.ChunkReporter) error {
+	id, _ := unit.SourceUnitID()
+	org, slug, ok := strings.Cut(id, "/")
+	if !ok {
+		return fmt.Errorf("invalid project %q: expected org/project", id)
+	}
+	ctx = context.WithValue(ctx, "project", id)
+
+	p, err := s.client.getProject(ctx, org, slug)
+	if err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not get project %s: %w", id, err))
+	}
+	if p.Organization.Slug == "" {
+		p.Organization.Slug = org
+	}
+
+	var reportErr error
+	err = s.client.listIssues(ctx, org, slug, func(is issue) error {
+		err := s.client.listEvents(ctx, is.ID, func(e event) error {
+			if reportErr = s.chunkEvent(ctx, p, is, e, reporter); reportErr != nil {
+				return reportErr
+			}
+			sentryEventsScanned.WithLabelValues(s.name).Inc()
+			return nil
+		})
+		if reportErr != nil {
+			return reportErr
+		}
+		if err != nil {
+			// Keep scanning the other issues.
+			if reportErr = reporter.ChunkErr(ctx, fmt.Errorf("could not list events of issue %s: %w", is.ShortID, err)); reportErr != nil {
+				return reportErr
+			}
+		}
+		return nil
+	})
+	if reportErr != nil {
+		return reportErr
+	}
+	if err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not list issues of %s: %w", id, err))
+	}
+	sentryProjectsScanned.WithLabelValues(s.name).Inc()
+	return nil
+}
+
+// chunkEvent reports the parts of an event that hold captured data.
+func (s *Source) chunkEvent(ctx context.Context, p project, is issue, e event, reporter sources.ChunkReporter) error {
+	link := fmt.Sprintf("%s/organizations/%s/issues/%s/events/%s/", s.client.baseURL, p.Organization.Slug, is.ID, e.EventID)
+	if is.Permalink != "" {
+		link = strings.TrimSuffix(is.Permalink, "/") + "/events/" + e.EventID + "/"
+	}
+
+	for _, data := range eventData(e) {
+		if strings.TrimSpace(data) == "" {
+			continue
+		}
+		chunk := sources.Chunk{
+			SourceName: s.name,
+			SourceID:   s.SourceID(),
+			JobID:      s.JobID(),
+			SourceType: s.Type(),
+			SourceMetadata: &source_metadatapb.MetaData{
+				Data: &source_metadatapb.MetaData_Sentry{
+					Sentry: &source_metadatapb.Sentry{
+						EventId:                 sanitizer.UTF8(e.EventID),
+						OrganizationId:          sanitizer.UTF8(p.Organization.ID),
+						OrganizationSlug:        sanitizer.UTF8(p.Organization.Slug),
+						OrganizationDateCreated: sanitizer.UTF8(p.Organization.DateCreated),
+						ProjectId:               sanitizer.UTF8(p.ID),
+						ProjectSlug:             sanitizer.UTF8(p.Slug),
+						IssueId:                 sanitizer.UTF8(is.ID),
+						DateCreated:             sanitizer.UTF8(e.DateCreated),
+						Link:                    sanitizer.UTF8(link),
+					},
+				},
+			},
+			Data:   []byte(data),
+			Verify: s.verify,
+		}
+		if err := reporter.ChunkOk(ctx, chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eventData returns the text of the message, breadcrumbs, request,
+// exceptions, stack frame variables and extra context of an event.
+func eventData(e event) []string {
+	data := []string{e.Message}
+	for _, en := range e.Entries {
+		switch en.Type {
+		case "breadcrumbs":
+			var crumbs struct {
+				Values []struct {
+					Category string          `json:"category"`
+					Message  string          `json:"message"`
+					Data     json.RawMessage `json:"data"`
+				} `json:"values"`
+			}
+			if json.Unmarshal(en.Data, &crumbs) != nil {
+				continue
+			}
+			var lines []string
+			for _, c := range crumbs.Values {
+				lines = append(lines, strings.TrimSpace(c.Category+" "+c.Message+" "+jsonText(c.Data)))
+			}
+			data = append(data, strings.Join(lines, "\n"))
+		case "request":
+			var req struct {
+				Method  string          `json:"method"`
+				URL     string          `json:"url"`
+				Query   json.RawMessage `json:"query"`
+				Headers json.RawMessage `json:"headers"`
+				Cookies json.RawMessage `json:"cookies"`
+				Env     json.RawMessage `json:"env"`
+				Data    json.RawMessage `json:"data"`
+			}
+			if json.Unmarshal(en.Data, &req) != nil {
+				continue
+			}
+			data = append(data,
+				strings.TrimSpace(req.Method+" "+req.URL+"\n"+pairsText(req.Query, "=")),
+				pairsText(req.Headers, ": "),
+				pairsText(req.Cookies, "="),
+				pairsText(req.Env, "="),
+				jsonText(req.Data),
+			)
+		case "exception", "threads":
+			var values struct {
+				Values []struct {
+					Value      string `json:"value"`
+					Stacktrace *struct {
+						Frames []struct {
+							Filename string          `json:"filename"`
+							Function string          `json:"function"`
+							Vars     json.RawMessage `json:"vars"`
+						} `json:"frames"`
+					} `json:"stacktrace"`
+				} `json:"values"`
+			}
+			if json.Unmarshal(en.Data, &values) != nil {
+				continue
+			}
+			for _, v := range values.Values {
+				data = append(data, v.Value)
+				if v.Stacktrace == nil {
+					continue
+				}
+				for _, f := range v.Stacktrace.Frames {
+					if vars := jsonText(f.Vars); vars != "" {
+						data = append(data, fmt.Sprintf("%s %s\n%s", f.Filename, f.Function, vars))
+					}
+				}
+			}
+		case "message":
+			var msg struct {
+				Formatted string `json:"formatted"`
+			}
+			if json.Unmarshal(en.Data, &msg) == nil && msg.Formatted != e.Message {
+				data = append(data, msg.Formatted)
+			}
+		}
+	}
+	return append(data, jsonText(e.Context), jsonText(e.Contexts))
+}
+
+// jsonText returns a JSON value as text: strings are unquoted and empty
+// values are dropped.
+func jsonText(raw json.RawMessage) string {
+	var str string
+	if json.Unmarshal(raw, &str) == nil {
+		return str
+	}
+	switch strings.TrimSpace(string(raw)) {
+	case "", "null", "{}", "[]":
+		return ""
+	}
+	return string(raw)
+}
+
+// pairsText formats the key/value pairs of a request, which Sentry sends as a
+// list of pairs, an object or a raw string, one per line.
+func pairsText(raw json.RawMessage, sep string) string {
+	var lines []string
+	var pairs [][]json.RawMessage
+	var object map[string]json.RawMessage
+	switch {
+	case json.Unmarshal(raw, &pairs) == nil:
+		for _, pair := range pairs {
+			if len(pair) == 2 {
+				lines = append(lines, jsonText(pair[0])+sep+jsonText(pair[1]))
+			}
+		}
+	case json.Unmarshal(raw, &object) == nil:
+		for key, value := range object {
+			lines = append(lines, key+sep+jsonText(value))
+		}
+		sort.Strings(lines)
+	default:
+		return jsonText(raw)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Chunks emits chunks of bytes over a channel.
+func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ ...sources.ChunkingTarget) error {
+	sentryProjectsScanned.WithLabelValues(s.name).Set(0)
+	return sources.ChunkUnits(ctx, s, s.jobPool, &s.Progress, "Project", chunksChan)
+}
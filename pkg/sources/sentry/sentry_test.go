@@ -0,0 +1,167 @@
+package sentry
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sourcestest"
+)
+
+// newTestServer serves canned JSON responses keyed by request path. Responses
+// for a path followed by "?cursor=next" are served as its second page.
+func newTestServer(t *testing.T, responses map[string]any) *sourcestest.Server {
+	t.Helper()
+	return sourcestest.NewServer(t, responses,
+		sourcestest.WithAuth(sourcestest.BearerAuth("sntrys-token")),
+		sourcestest.WithPages("cursor", "next", func(next string, more bool) http.Header {
+			prev := strings.Replace(next, "cursor=next", "cursor=prev", 1)
+			return http.Header{"Link": {fmt.Sprintf(
+				`<%s>; rel="previous"; results="false"; cursor="prev", <%s>; rel="next"; results="%t"; cursor="next"`,
+				prev, next, more)}}
+		}),
+	)
+}
+
+func initSource(t *testing.T, endpoint string, conn *sourcespb.Sentry) *Source {
+	t.Helper()
+	conn.Endpoint = endpoint
+	conn.Credential = &sourcespb.Sentry_AuthToken{AuthToken: "sntrys-token"}
+	anyConn, err := anypb.New(conn)
+	require.NoError(t, err)
+
+	s := &Source{}
+	require.NoError(t, s.Init(context.Background(), "test", 0, 0, false, anyConn, 1))
+	return s
+}
+
+func TestEnumerate(t *testing.T) {
+	srv := newTestServer(t, map[string]any{
+		"/api/0/projects/":             `[{"slug": "api", "organization": {"slug": "acme"}}, {"slug": "web", "organization": {"slug": "acme"}}]`,
+		"/api/0/projects/?cursor=next": `[{"slug": "api", "organization": {"slug": "beta"}}]`,
+	})
+	s := initSource(t, srv.URL+"/", &sourcespb.Sentry{})
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+	assert.Empty(t, reporter.UnitErrs)
+	assert.Equal(t, []sources.SourceUnit{
+		sources.CommonSourceUnit{ID: "acme/api"},
+		sources.CommonSourceUnit{ID: "acme/web"},
+		sources.CommonSourceUnit{ID: "beta/api"},
+	}, reporter.Units)
+
+	s = initSource(t, srv.URL, &sourcespb.Sentry{Projects: "web, beta/api"})
+	reporter = sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+	assert.Equal(t, []sources.SourceUnit{
+		sources.CommonSourceUnit{ID: "acme/web"},
+		sources.CommonSourceUnit{ID: "beta/api"},
+	}, reporter.Units)
+}
+
+func TestChunkUnit(t *testing.T) {
+	srv := newTestServer(t, map[string]any{
+		"/api/0/projects/acme/api/": `{"id": "11", "slug": "api", "organization": {"id": "1", "slug": "acme", "dateCreated": "2020-01-01T00:00:00Z"}}`,
+		"/api/0/projects/acme/api/issues/": `[
+			{"id": "100", "shortId": "API-1", "permalink": "https://sentry.example.com/organizations/acme/issues/100/"},
+			{"id": "101", "shortId": "API-2"}
+		]`,
+		"/api/0/issues/100/events/": `[{
+			"eventID": "e1",
+			"dateCreated": "2024-05-01T10:00:00Z",
+			"message": "payment failed",
+			"entries": [
+				{"type": "breadcrumbs", "data": {"values": [{"category": "http", "data": {"url": "https://api.stripe.com?key=from-breadcrumb"}}]}},
+				{"type": "request", "data": {
+					"method": "POST", "url": "https://shop.example.com/pay",
+					"headers": [["Authorization", "Bearer from-request-header"], ["Accept", "*/*"]],
+					"env": {"DATABASE_URL": "from-request-env"},
+					"data": {"card_token": "from-request-body"}
+				}},
+				{"type": "exception", "data": {"values": [{"value": "timeout", "stacktrace": {"frames": [
+					{"filename": "pay.py", "function": "charge", "vars": {"api_key": "'from-frame-vars'"}},
+					{"filename": "lib.py", "function": "call", "vars": null}
+				]}}]}}
+			],
+			"context": {"aws_secret": "from-extra-context"}
+		}]`,
+	})
+	s := initSource(t, srv.URL, &sourcespb.Sentry{})
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.ChunkUnit(context.Background(), sources.CommonSourceUnit{ID: "acme/api"}, &reporter))
+	// The events of the second issue are missing.
+	require.Len(t, reporter.ChunkErrs, 1)
+	assert.Contains(t, reporter.ChunkErrs[0].Error(), "API-2")
+
+	var data []string
+	for _, c := range reporter.Chunks {
+		data = append(data, string(c.Data))
+	}
+	all := strings.Join(data, "\n")
+	for _, want := range []string{
+		"payment failed",
+		"from-breadcrumb",
+		"Authorization: Bearer from-request-header",
+		"DATABASE_URL=from-request-env",
+		"from-request-body",
+		"from-frame-vars",
+		"from-extra-context",
+	} {
+		assert.Contains(t, all, want)
+	}
+	assert.Contains(t, data, "pay.py charge\n{\"api_key\": \"'from-frame-vars'\"}")
+
+	meta := reporter.Chunks[0].SourceMetadata.GetSentry()
+	assert.Equal(t, "e1", meta.GetEventId())
+	assert.Equal(t, "1", meta.GetOrganizationId())
+	assert.Equal(t, "acme", meta.GetOrganizationSlug())
+	assert.Equal(t, "11", meta.GetProjectId())
+	assert.Equal(t, "api", meta.GetProjectSlug())
+	assert.Equal(t, "100", meta.GetIssueId())
+	assert.Equal(t, "https://sentry.example.com/organizations/acme/issues/100/events/e1/", meta.GetLink())
+}
+
+func TestChunkUnit_Errors(t *testing.T) {
+	const project = "/api/0/projects/acme/api/"
+	const issues = project + "issues/"
+	sourcestest.APIErrorTest{
+		Setup: func(t *testing.T) (*sourcestest.Server, func(t *testing.T) ([]string, []error)) {
+			srv := newTestServer(t, map[string]any{
+				project:                     `{"id": "11", "slug": "api", "organization": {"id": "1", "slug": "acme"}}`,
+				issues:                      `[{"id": "100", "shortId": "API-1"}]`,
+				issues + "?cursor=next":     `[{"id": "101", "shortId": "API-2"}]`,
+				"/api/0/issues/100/events/": `[{"eventID": "e1", "message": "from-issue-100"}]`,
+				"/api/0/issues/101/events/": `[{"eventID": "e2", "message": "from-issue-101"}]`,
+			})
+			s := initSource(t, srv.URL, &sourcespb.Sentry{})
+			return srv, func(t *testing.T) ([]string, []error) {
+				reporter := sourcestest.TestReporter{}
+				require.NoError(t, s.ChunkUnit(context.Background(), sources.CommonSourceUnit{ID: "acme/api"}, &reporter))
+				return reporter.ChunkData(), reporter.ChunkErrs
+			}
+		},
+		First:           project,
+		LastPage:        issues + "?cursor=next",
+		Item:            "/api/0/issues/100/events/",
+		All:             []string{"from-issue-100", "from-issue-101"},
+		WithoutItem:     []string{"from-issue-101"},
+		WithoutLastPage: []string{"from-issue-100"},
+	}.Run(t)
+}
+
+func TestInit_DSNKey(t *testing.T) {
+	conn, err := anypb.New(&sourcespb.Sentry{Credential: &sourcespb.Sentry_DsnKey{DsnKey: "public"}})
+	require.NoError(t, err)
+	s := &Source{}
+	assert.Error(t, s.Init(context.Background(), "test", 0, 0, false, conn, 1))
+}
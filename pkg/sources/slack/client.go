@@ -0,0 +1,275 @@
+package slack
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+const (
+	defaultEndpoint = "https://slack.com/api"
+	pageSize        = 200
+
+	// maxRateLimitRetries bounds how often a single call is retried after
+	// being rate limited.
+	maxRateLimitRetries = 10
+	// defaultRetryAfter is used when a rate limited response has no
+	// Retry-After header.
+	defaultRetryAfter = 30 * time.Second
+)
+
+type channel struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	IsPrivate   bool   `json:"is_private"`
+	IsIM        bool   `json:"is_im"`
+	IsMPIM      bool   `json:"is_mpim"`
+	IsExtShared bool   `json:"is_ext_shared"`
+	Properties  struct {
+		Canvas struct {
+			FileID string `json:"file_id"`
+		} `json:"canvas"`
+	} `json:"properties"`
+}
+
+type file struct {
+	ID                 string `json:"id"`
+	Name               string `json:"name"`
+	Title              string `json:"title"`
+	User               string `json:"user"`
+	Filetype           string `json:"filetype"`
+	Mode               string `json:"mode"`
+	URLPrivateDownload string `json:"url_private_download"`
+	Permalink          string `json:"permalink"`
+}
+
+type message struct {
+	Type       string `json:"type"`
+	Subtype    string `json:"subtype"`
+	User       string `json:"user"`
+	Text       string `json:"text"`
+	TS         string `json:"ts"`
+	ThreadTS   string `json:"thread_ts"`
+	ReplyCount int    `json:"reply_count"`
+	Files      []file `json:"files"`
+	// Attachments are legacy rich message parts; bots often post secrets in them.
+	Attachments []struct {
+		Pretext  string `json:"pretext"`
+		Text     string `json:"text"`
+		Fallback string `json:"fallback"`
+	} `json:"attachments"`
+}
+
+// fullText returns the text of the message and its attachments.
+func (m message) fullText() string {
+	parts := []string{m.Text}
+	for _, a := range m.Attachments {
+		parts = append(parts, a.Pretext, a.Text, a.Fallback)
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n"))
+}
+
+type slackUser struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Profile struct {
+		Email string `json:"email"`
+	} `json:"profile"`
+}
+
+// response holds the fields common to every Web API response.
+type response struct {
+	OK               bool   `json:"ok"`
+	Error            string `json:"error"`
+	ResponseMetadata struct {
+		NextCursor string `json:"next_cursor"`
+	} `json:"response_metadata"`
+}
+
+// client is a minimal Slack Web API client that waits out rate limits.
+type client struct {
+	httpClient *http.Client
+	endpoint   string
+	token      string
+}
+
+func (c *client) do(ctx context.Context, rawURL string) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Slack API request: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+c.token)
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to make request to Slack API: %w", err)
+		}
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < maxRateLimitRetries {
+			resp.Body.Close()
+			wait := retryAfter(resp.Header.Get("Retry-After"))
+			ctx.Logger().V(2).Info("rate limited by Slack API", "url", req.URL.Path, "retry_after", wait)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(wait):
+			}
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+			return nil, fmt.Errorf("unexpected status code %d from %q: %s", resp.StatusCode, req.URL.Path, strings.TrimSpace(string(body)))
+		}
+		return resp, nil
+	}
+}
+
+// retryAfter parses the Retry-After header, which Slack sends in seconds.
+func retryAfter(header string) time.Duration {
+	seconds, err := strconv.Atoi(header)
+	if err != nil || seconds < 0 {
+		return defaultRetryAfter
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// call invokes a Web API method and decodes the response into target, which
+// must embed response.
+func (c *client) call(ctx context.Context, method string, query url.Values, target interface{ result() response }) error {
+	resp, err := c.do(ctx, c.endpoint+"/"+method+"?"+query.Encode())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode Slack API response: %w", err)
+	}
+	if r := target.result(); !r.OK {
+		return fmt.Errorf("slack API method %s failed: %s", method, r.Error)
+	}
+	return nil
+}
+
+func (r response) result() response { return r }
+
+type authTestResponse struct {
+	response
+	URL    string `json:"url"`
+	TeamID string `json:"team_id"`
+}
+
+// authTest validates the token and returns the workspace URL.
+func (c *client) authTest(ctx context.Context) (authTestResponse, error) {
+	var resp authTestResponse
+	err := c.call(ctx, "auth.test", url.Values{}, &resp)
+	return resp, err
+}
+
+// listChannels visits every conversation the token can see, archived ones
+// included.
+func (c *client) listChannels(ctx context.Context, visit func(channel) error) error {
+	query := url.Values{
+		"types":            {"public_channel,private_channel,mpim,im"},
+		"exclude_archived": {"false"},
+		"limit":            {fmt.Sprint(pageSize)},
+	}
+	for {
+		var resp struct {
+			response
+			Channels []channel `json:"channels"`
+		}
+		if err := c.call(ctx, "conversations.list", query, &resp); err != nil {
+			return err
+		}
+		for _, ch := range resp.Channels {
+			if err := visit(ch); err != nil {
+				return err
+			}
+		}
+		if resp.ResponseMetadata.NextCursor == "" {
+			return nil
+		}
+		query.Set("cursor", resp.ResponseMetadata.NextCursor)
+	}
+}
+
+// channelInfo returns a single conversation.
+func (c *client) channelInfo(ctx context.Context, channelID string) (channel, error) {
+	var resp struct {
+		response
+		Channel channel `json:"channel"`
+	}
+	err := c.call(ctx, "conversations.info", url.Values{"channel": {channelID}}, &resp)
+	return resp.Channel, err
+}
+
+// listMessages visits the messages of a conversation. With a thread
+// timestamp it visits the replies of that thread instead, parent included.
+func (c *client) listMessages(ctx context.Context, channelID, threadTS string, visit func(message) error) error {
+	method := "conversations.history"
+	query := url.Values{
+		"channel": {channelID},
+		"limit":   {fmt.Sprint(pageSize)},
+	}
+	if threadTS != "" {
+		method = "conversations.replies"
+		query.Set("ts", threadTS)
+	}
+	for {
+		var resp struct {
+			response
+			Messages []message `json:"messages"`
+		}
+		if err := c.call(ctx, method, query, &resp); err != nil {
+			return err
+		}
+		for _, m := range resp.Messages {
+			if err := visit(m); err != nil {
+				return err
+			}
+		}
+		if resp.ResponseMetadata.NextCursor == "" {
+			return nil
+		}
+		query.Set("cursor", resp.ResponseMetadata.NextCursor)
+	}
+}
+
+// fileInfo returns a single file, such as a canvas.
+func (c *client) fileInfo(ctx context.Context, fileID string) (file, error) {
+	var resp struct {
+		response
+		File file `json:"file"`
+	}
+	err := c.call(ctx, "files.info", url.Values{"file": {fileID}}, &resp)
+	return resp.File, err
+}
+
+// userInfo returns a single user.
+func (c *client) userInfo(ctx context.Context, userID string) (slackUser, error) {
+	var resp struct {
+		response
+		User slackUser `json:"user"`
+	}
+	err := c.call(ctx, "users.info", url.Values{"user": {userID}}, &resp)
+	return resp.User, err
+}
+
+// download returns the content of a private file. The caller must close the
+// returned reader.
+func (c *client) download(ctx context.Context, f file) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, f.URLPrivateDownload)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,169 @@
+package slack
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// exportUnitKind identifies units that are channel directories of an export
+// archive.
+const exportUnitKind sources.SourceUnitKind = "export_channel"
+
+// exportManifests are the files of an export archive that list its
+// conversations. Only channels.json is present in every export.
+var exportManifests = []struct {
+	file    string
+	private bool
+}{
+	{"channels.json", false},
+	{"groups.json", true},
+	{"mpims.json", true},
+	{"dms.json", true},
+}
+
+// exportIndex describes the conversations and users of an export archive.
+type exportIndex struct {
+	// channels maps the directory of each conversation to the conversation.
+	channels map[string]channel
+	// dirs are the conversation directories in a stable order.
+	dirs   []string
+	emails map[string]string
+}
+
+// loadExport reads the manifests of the export archive.
+func (s *Source) loadExport() (*exportIndex, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.export != nil {
+		return s.export, nil
+	}
+
+	r, err := zip.OpenReader(s.exportPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not open Slack export: %w", err)
+	}
+	defer r.Close()
+
+	idx := &exportIndex{channels: make(map[string]channel), emails: make(map[string]string)}
+	for _, m := range exportManifests {
+		var channels []channel
+		if err := readJSON(r, m.file, &channels); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		for _, ch := range channels {
+			ch.IsPrivate = m.private
+			// Direct messages have no name; their directory is their ID.
+			dir := ch.Name
+			if dir == "" {
+				dir = ch.ID
+			}
+			idx.channels[dir] = ch
+			idx.dirs = append(idx.dirs, dir)
+		}
+	}
+	sort.Strings(idx.dirs)
+
+	var users []slackUser
+	if err := readJSON(r, "users.json", &users); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	for _, u := range users {
+		idx.emails[u.ID] = u.Profile.Email
+	}
+
+	s.export = idx
+	return idx, nil
+}
+
+func readJSON(r *zip.ReadCloser, name string, target any) error {
+	f, err := r.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := json.NewDecoder(f).Decode(target); err != nil {
+		return fmt.Errorf("could not decode %s: %w", name, err)
+	}
+	return nil
+}
+
+func (s *Source) enumerateExport(ctx context.Context, reporter sources.UnitReporter) error {
+	idx, err := s.loadExport()
+	if err != nil {
+		return reporter.UnitErr(ctx, err)
+	}
+
+	slackChannelsEnumerated.WithLabelValues(s.name).Set(0)
+	for _, dir := range idx.dirs {
+		ch := idx.channels[dir]
+		if s.skip(ch.ID, ch.Name) {
+			continue
+		}
+		if err := reporter.UnitOk(ctx, sources.CommonSourceUnit{Kind: exportUnitKind, ID: dir}); err != nil {
+			return err
+		}
+		slackChannelsEnumerated.WithLabelValues(s.name).Inc()
+	}
+	return nil
+}
+
+// chunkExportChannel reports the messages of a conversation directory, which
+// holds one JSON file of messages per day. Thread replies are stored inline.
+// Files are not part of exports and are skipped.
+func (s *Source) chunkExportChannel(ctx context.Context, dir string, reporter sources.ChunkReporter) error {
+	ctx = context.WithValue(ctx, "channel", dir)
+	idx, err := s.loadExport()
+	if err != nil {
+		return reporter.ChunkErr(ctx, err)
+	}
+	ch := idx.channels[dir]
+
+	r, err := zip.OpenReader(s.exportPath)
+	if err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not open Slack export: %w", err))
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		if path.Dir(f.Name) != dir || !strings.HasSuffix(f.Name, ".json") {
+			continue
+		}
+		var messages []message
+		if err := readJSON(r, f.Name, &messages); err != nil {
+			if err := reporter.ChunkErr(ctx, err); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, m := range messages {
+			text := m.fullText()
+			if text == "" {
+				continue
+			}
+			location := "message"
+			if m.ThreadTS != "" && m.ThreadTS != m.TS {
+				location = "thread reply"
+			}
+			chunk := s.chunkSkel(ch, m, idx.emails[m.User], "", location, f.Name)
+			chunk.Data = []byte(text)
+			if err := reporter.ChunkOk(ctx, chunk); err != nil {
+				return err
+			}
+			slackMessagesScanned.WithLabelValues(s.name).Inc()
+		}
+	}
+	slackChannelsScanned.WithLabelValues(s.name).Inc()
+	return nil
+}
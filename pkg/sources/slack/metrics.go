@@ -0,0 +1,34 @@
+package slack
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+)
+
+var (
+	slackChannelsEnumerated = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "slack_channels_enumerated",
+		Help:      "Total number of Slack channels enumerated.",
+	},
+		[]string{"source_name"})
+
+	slackChannelsScanned = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "slack_channels_scanned",
+		Help:      "Total number of Slack channels scanned.",
+	},
+		[]string{"source_name"})
+
+	slackMessagesScanned = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "slack_messages_scanned",
+		Help:      "Total number of Slack messages scanned.",
+	},
+		[]string{"source_name"})
+)
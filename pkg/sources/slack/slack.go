@@ -0,0 +1,424 @@
+package slack
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/log"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+const SourceType = sourcespb.SourceType_SOURCE_TYPE_SLACK
+
+// Source scans a Slack workspace through the Web API: channel history,
+// thread replies, shared files and channel canvases. It can also scan an
+// export archive without any token.
+//
+// Options that are not part of the connection proto are set with the With*
+// methods after Init.
+type Source struct {
+	name     string
+	sourceID sources.SourceID
+	jobID    sources.JobID
+	verify   bool
+
+	// client is nil when only an export archive is scanned.
+	client *client
+	// channels, when not empty, limits the scan to these channel names or IDs.
+	channels []string
+	ignore   map[string]struct{}
+	// exportPath is the path of a Slack export ZIP archive.
+	exportPath string
+	export     *exportIndex
+
+	mu           sync.Mutex
+	workspaceURL string
+	channelCache map[string]channel
+	emails       map[string]string
+
+	jobPool *errgroup.Group
+	sources.Progress
+	sources.CommonSourceUnitUnmarshaller
+}
+
+// Ensure the Source satisfies the interfaces at compile time.
+var _ sources.Source = (*Source)(nil)
+var _ sources.SourceUnitUnmarshaller = (*Source)(nil)
+var _ sources.SourceUnitEnumChunker = (*Source)(nil)
+
+// Type returns the type of source.
+// It is used for matching source types in configuration and job input.
+func (s *Source) Type() sourcespb.SourceType {
+	return SourceType
+}
+
+func (s *Source) SourceID() sources.SourceID {
+	return s.sourceID
+}
+
+func (s *Source) JobID() sources.JobID {
+	return s.jobID
+}
+
+// Init returns an initialized Slack source. A token is only required when
+// the workspace is scanned through the Web API.
+func (s *Source) Init(_ context.Context, name string, jobId sources.JobID, sourceId sources.SourceID, verify bool, connection *anypb.Any, concurrency int) error {
+	s.name = name
+	s.sourceID = sourceId
+	s.jobID = jobId
+	s.verify = verify
+	s.jobPool = &errgroup.Group{}
+	s.jobPool.SetLimit(concurrency)
+	s.channelCache = make(map[string]channel)
+	s.emails = make(map[string]string)
+
+	var conn sourcespb.Slack
+	if err := anypb.UnmarshalTo(connection, &conn, proto.UnmarshalOptions{}); err != nil {
+		return fmt.Errorf("error unmarshalling connection: %w", err)
+	}
+
+	s.channels = conn.GetChannels()
+	s.ignore = make(map[string]struct{}, len(conn.GetIgnoreList()))
+	for _, c := range conn.GetIgnoreList() {
+		s.ignore[strings.TrimPrefix(c, "#")] = struct{}{}
+	}
+
+	var token string
+	switch cred := conn.GetCredential().(type) {
+	case *sourcespb.Slack_Token:
+		token = cred.Token
+	case *sourcespb.Slack_Tokens:
+		// A user token sees every conversation the user is in, a bot token
+		// only those the bot was added to.
+		token = cred.Tokens.GetClientToken()
+		if token == "" {
+			token = cred.Tokens.GetBotToken()
+		}
+	case nil:
+	default:
+		return fmt.Errorf("invalid configuration given for source %q (%s)", name, s.Type().String())
+	}
+	if token == "" {
+		return nil
+	}
+	log.RedactGlobally(token)
+
+	endpoint := strings.TrimSuffix(conn.GetEndpoint(), "/")
+	if endpoint == "" {
+		endpoint = defaultEndpoint
+	}
+	s.client = &client{
+		httpClient: common.SaneHttpClientTimeOut(60 * time.Second),
+		endpoint:   endpoint,
+		token:      token,
+	}
+	return nil
+}
+
+// WithExportArchive scans the Slack export ZIP archive at path instead of
+// the Web API.
+func (s *Source) WithExportArchive(path string) {
+	s.exportPath = path
+}
+
+// skip reports whether a channel is excluded by the channel and ignore lists.
+func (s *Source) skip(id, name string) bool {
+	if _, ok := s.ignore[id]; ok {
+		return true
+	}
+	if _, ok := s.ignore[name]; ok {
+		return true
+	}
+	if len(s.channels) == 0 {
+		return false
+	}
+	for _, c := range s.channels {
+		c = strings.TrimPrefix(c, "#")
+		if c == id || c == name {
+			return false
+		}
+	}
+	return true
+}
+
+// Enumerate reports every channel that is not skipped. Web API units are
+// identified by channel ID, export units by their directory in the archive.
+func (s *Source) Enumerate(ctx context.Context, reporter sources.UnitReporter) error {
+	if s.exportPath != "" {
+		return s.enumerateExport(ctx, reporter)
+	}
+	if s.client == nil {
+		return fmt.Errorf("a Slack token or an export archive is required")
+	}
+
+	if err := s.loadWorkspaceURL(ctx); err != nil {
+		return reporter.UnitErr(ctx, err)
+	}
+
+	slackChannelsEnumerated.WithLabelValues(s.name).Set(0)
+	var reportErr error
+	err := s.client.listChannels(ctx, func(ch channel) error {
+		if s.skip(ch.ID, ch.Name) {
+			return nil
+		}
+		s.mu.Lock()
+		s.channelCache[ch.ID] = ch
+		s.mu.Unlock()
+		if reportErr = reporter.UnitOk(ctx, sources.CommonSourceUnit{ID: ch.ID}); reportErr != nil {
+			return reportErr
+		}
+		slackChannelsEnumerated.WithLabelValues(s.name).Inc()
+		return nil
+	})
+	if reportErr != nil {
+		return reportErr
+	}
+	if err != nil {
+		return reporter.UnitErr(ctx, fmt.Errorf("could not list channels: %w", err))
+	}
+	return nil
+}
+
+func (s *Source) loadWorkspaceURL(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.workspaceURL != "" {
+		return nil
+	}
+	auth, err := s.client.authTest(ctx)
+	if err != nil {
+		return fmt.Errorf("could not validate Slack token: %w", err)
+	}
+	s.workspaceURL = auth.URL
+	if !strings.HasSuffix(s.workspaceURL, "/") {
+		s.workspaceURL += "/"
+	}
+	return nil
+}
+
+// channel returns a channel seen during enumeration, or looks it up.
+func (s *Source) channel(ctx context.Context, id string) (channel, error) {
+	s.mu.Lock()
+	ch, ok := s.channelCache[id]
+	s.mu.Unlock()
+	if ok {
+		return ch, nil
+	}
+	return s.client.channelInfo(ctx, id)
+}
+
+// email returns the email address of a user, or an empty string if it is
+// not visible to the token. Lookups are cached.
+func (s *Source) email(ctx context.Context, userID string) string {
+	if userID == "" {
+		return ""
+	}
+	s.mu.Lock()
+	email, ok := s.emails[userID]
+	s.mu.Unlock()
+	if ok {
+		return email
+	}
+	u, err := s.client.userInfo(ctx, userID)
+	if err != nil {
+		ctx.Logger().V(2).Info("could not look up Slack user", "user", userID, "error", err)
+	}
+	s.mu.Lock()
+	s.emails[userID] = u.Profile.Email
+	s.mu.Unlock()
+	return u.Profile.Email
+}
+
+// ChunkUnit scans the messages, thread replies, files and canvas of a channel.
+func (s *Source) ChunkUnit(ctx context.Context, unit sources.SourceUnit, reporter sources.ChunkReporter) error {
+	id, kind := unit.SourceUnitID()
+	if kind == exportUnitKind {
+		return s.chunkExportChannel(ctx, id, reporter)
+	}
+	if s.client == nil {
+		return fmt.Errorf("a Slack token is required to scan channel %s", id)
+	}
+	ctx = context.WithValue(ctx, "channel", id)
+
+	if err := s.loadWorkspaceURL(ctx); err != nil {
+		return reporter.ChunkErr(ctx, err)
+	}
+	ch, err := s.channel(ctx, id)
+	if err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not get channel: %w", err))
+	}
+
+	var reportErr error
+	err = s.client.listMessages(ctx, ch.ID, "", func(m message) error {
+		if reportErr = s.chunkMessage(ctx, ch, m, reporter); reportErr != nil {
+			return reportErr
+		}
+		if m.ReplyCount > 0 && m.ThreadTS == m.TS {
+			reportErr = s.chunkThread(ctx, ch, m.TS, reporter)
+		}
+		return reportErr
+	})
+	if reportErr != nil {
+		return reportErr
+	}
+	if err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not list messages: %w", err))
+	}
+
+	if canvasID := ch.Properties.Canvas.FileID; canvasID != "" {
+		canvas, err := s.client.fileInfo(ctx, canvasID)
+		if err != nil {
+			if err := reporter.ChunkErr(ctx, fmt.Errorf("could not get canvas: %w", err)); err != nil {
+				return err
+			}
+		} else if err := s.chunkFile(ctx, ch, message{User: canvas.User}, canvas, "canvas", reporter); err != nil {
+			return err
+		}
+	}
+	slackChannelsScanned.WithLabelValues(s.name).Inc()
+	return nil
+}
+
+// chunkThread reports the replies of a thread; the parent was already reported.
+func (s *Source) chunkThread(ctx context.Context, ch channel, threadTS string, reporter sources.ChunkReporter) error {
+	var reportErr error
+	err := s.client.listMessages(ctx, ch.ID, threadTS, func(m message) error {
+		if m.TS == threadTS {
+			return nil
+		}
+		reportErr = s.chunkMessage(ctx, ch, m, reporter)
+		return reportErr
+	})
+	if reportErr != nil {
+		return reportErr
+	}
+	if err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not list replies of thread %s: %w", threadTS, err))
+	}
+	return nil
+}
+
+// chunkMessage reports the text and files of a message. Only errors returned
+// by the reporter are returned.
+func (s *Source) chunkMessage(ctx context.Context, ch channel, m message, reporter sources.ChunkReporter) error {
+	if text := m.fullText(); text != "" {
+		location := "message"
+		if m.ThreadTS != "" && m.ThreadTS != m.TS {
+			location = "thread reply"
+		}
+		chunk := s.chunkSkel(ch, m, s.email(ctx, m.User), s.permalink(ch.ID, m), location, "")
+		chunk.Data = []byte(text)
+		if err := reporter.ChunkOk(ctx, chunk); err != nil {
+			return err
+		}
+	}
+	slackMessagesScanned.WithLabelValues(s.name).Inc()
+
+	for _, f := range m.Files {
+		if err := s.chunkFile(ctx, ch, m, f, "file", reporter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Source) chunkFile(ctx context.Context, ch channel, m message, f file, location string, reporter sources.ChunkReporter) error {
+	// External and tombstoned files have no content hosted by Slack.
+	if f.URLPrivateDownload == "" || f.Mode == "external" || f.Mode == "tombstone" || f.Mode == "hidden_by_limit" {
+		return nil
+	}
+	body, err := s.client.download(ctx, f)
+	if err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not download file %q: %w", f.Name, err))
+	}
+	defer body.Close()
+
+	link := f.Permalink
+	if link == "" {
+		link = s.permalink(ch.ID, m)
+	}
+	chunkSkel := s.chunkSkel(ch, m, s.email(ctx, f.User), link, location, f.Name)
+	if err := handlers.HandleFile(ctx, body, &chunkSkel, reporter); err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("could not scan file %q: %w", f.Name, err))
+	}
+	return nil
+}
+
+// permalink builds the link to a message without calling chat.getPermalink
+// for every message.
+func (s *Source) permalink(channelID string, m message) string {
+	if s.workspaceURL == "" || m.TS == "" {
+		return ""
+	}
+	link := s.workspaceURL + "archives/" + channelID + "/p" + strings.Replace(m.TS, ".", "", 1)
+	if m.ThreadTS != "" && m.ThreadTS != m.TS {
+		link += "?thread_ts=" + m.ThreadTS + "&cid=" + channelID
+	}
+	return link
+}
+
+// visibility maps the kind of conversation to a result visibility.
+func visibility(ch channel) source_metadatapb.Visibility {
+	switch {
+	case ch.IsExtShared:
+		return source_metadatapb.Visibility_shared
+	case ch.IsPrivate, ch.IsIM, ch.IsMPIM:
+		return source_metadatapb.Visibility_private
+	default:
+		return source_metadatapb.Visibility_public
+	}
+}
+
+// formatTS converts a Slack message timestamp to RFC 3339.
+func formatTS(ts string) string {
+	seconds, _, _ := strings.Cut(ts, ".")
+	unix, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil {
+		return ts
+	}
+	return time.Unix(unix, 0).UTC().Format(time.RFC3339)
+}
+
+func (s *Source) chunkSkel(ch channel, m message, email, link, location, fileName string) sources.Chunk {
+	return sources.Chunk{
+		SourceName: s.name,
+		SourceID:   s.SourceID(),
+		JobID:      s.JobID(),
+		SourceType: s.Type(),
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Slack{
+				Slack: &source_metadatapb.Slack{
+					ChannelId:   ch.ID,
+					ChannelName: sanitizer.UTF8(ch.Name),
+					Timestamp:   formatTS(m.TS),
+					UserId:      m.User,
+					Link:        link,
+					File:        sanitizer.UTF8(fileName),
+					Email:       sanitizer.UTF8(email),
+					Visibility:  visibility(ch),
+					Location:    location,
+				},
+			},
+		},
+		Verify: s.verify,
+	}
+}
+
+// Chunks emits chunks of bytes over a channel.
+func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ ...sources.ChunkingTarget) error {
+	slackChannelsScanned.WithLabelValues(s.name).Set(0)
+	return sources.ChunkUnits(ctx, s, s.jobPool, &s.Progress, "Channel", chunksChan)
+}
@@ -125,7 +125,8 @@ func (s *SourceManager) GetIDs(ctx context.Context, sourceName string, kind sour
 
 // EnumerateAndScan blocks until a resource is available to run the source, then
 // asynchronously runs it. Error information is stored and accessible via the
-// JobProgressRef as it becomes available.
+// JobProgressRef as it becomes available. A source that is an io.Closer is
+// closed once it has run.
 func (s *SourceManager) EnumerateAndScan(ctx context.Context, sourceName string, source Source, targets ...ChunkingTarget) (JobProgressRef, error) {
 	sourceID, jobID := source.SourceID(), source.JobID()
 	// Do preflight checks before waiting on the pool.
@@ -159,6 +160,9 @@ func (s *SourceManager) EnumerateAndScan(ctx context.Context, sourceName string,
 		)
 		defer common.Recover(ctx)
 		defer cancel(nil)
+		if closer, ok := source.(io.Closer); ok {
+			defer closer.Close()
+		}
 		if err := s.run(ctx, source, progress, targets...); err != nil {
 			select {
 			case s.firstErr <- err:
@@ -655,9 +659,9 @@ func (s *mgrUnitReporter) UnitOk(ctx context.Context, unit SourceUnit) error {
 }
 
 // UnitErr implements the UnitReporter interface by recording the error in the
-// report.
+// report as an enumeration error.
 func (s *mgrUnitReporter) UnitErr(ctx context.Context, err error) error {
-	s.report.ReportError(err)
+	s.report.ReportError(EnumerateError{err})
 	return nil
 }
 
@@ -2,11 +2,13 @@ package sources
 
 import (
 	"sync"
+	"time"
 
 	"google.golang.org/protobuf/types/known/anypb"
 
 	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/gitstate"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
 )
@@ -45,6 +47,35 @@ type Chunk struct {
 	Verify bool
 }
 
+// FileOffset is the location of the start of a Chunk's data within a file.
+type FileOffset struct {
+	// Byte is the offset of the first byte of the chunk.
+	Byte int64
+	// Lines is the number of lines that precede the chunk.
+	Lines int64
+	// Column is the number of bytes between the start of the line the chunk
+	// starts on and the start of the chunk.
+	Column int64
+}
+
+// WithFileOffset returns metadata that holds the data of md along with the
+// location of a chunk's data within the file it was read from. The offset is
+// carried by the metadata rather than the Chunk to keep Chunk small. md isn't
+// modified, so it can be shared by every chunk of a file.
+func WithFileOffset(md *source_metadatapb.MetaData, offset FileOffset) *source_metadatapb.MetaData {
+	if md == nil {
+		return nil
+	}
+	return &source_metadatapb.MetaData{
+		Data: md.Data,
+		FileOffset: &source_metadatapb.FileOffset{
+			Byte:   offset.Byte,
+			Lines:  offset.Lines,
+			Column: offset.Column,
+		},
+	}
+}
+
 // ChunkingTarget specifies criteria for a targeted chunking process.
 // Instead of collecting data indiscriminately, this struct allows the caller
 // to specify particular subsets of data they're interested in. This becomes
@@ -175,6 +206,22 @@ type DockerConfig struct {
 	BearerToken string
 	// UseDockerKeychain determines whether to use the Docker keychain.
 	UseDockerKeychain bool
+	// Platform selects the image of multi-platform indexes, such as linux/arm64.
+	Platform string
+	// ContainerdRoot is the root directory of containerd, for containerd:// images.
+	ContainerdRoot string
+	// ContainerdNamespace is the containerd namespace of containerd:// images.
+	ContainerdNamespace string
+	// Registry, when set, is enumerated for images through its catalog.
+	Registry string
+	// IncludeRepos and ExcludeRepos are globs that select the repositories of Registry.
+	IncludeRepos []string
+	ExcludeRepos []string
+	// IncludeTags and ExcludeTags are globs that select the tags of Registry.
+	IncludeTags []string
+	ExcludeTags []string
+	// MaxTags limits the scan to the most recently created tags of each repository.
+	MaxTags int
 }
 
 // GCSConfig defines the optional configuration for a GCS source.
@@ -225,6 +272,12 @@ type GitConfig struct {
 	ExcludeGlobs string
 	// SkipBinaries allows skipping binary files from the scan.
 	SkipBinaries bool
+	// ScanUnreachable enables scanning stashes, reflogs, notes, hidden refs
+	// such as refs/pull/*, and dangling objects.
+	ScanUnreachable bool
+	// State, if set, makes the scan incremental: only commits added since the
+	// last scan recorded in it are scanned.
+	State *gitstate.Store
 }
 
 // GithubConfig defines the optional configuration for a github source.
@@ -261,6 +314,13 @@ type GithubConfig struct {
 	IncludeWikis bool
 	// CommentsTimeframeDays indicates how many days of comments to include in the scan.
 	CommentsTimeframeDays uint32
+	// IncludeActionsLogs indicates whether to include GitHub Actions workflow run logs in the scan.
+	IncludeActionsLogs bool
+	// IncludeArtifacts indicates whether to include GitHub Actions workflow run artifacts in the scan.
+	IncludeArtifacts bool
+	// State, if set, makes the scan incremental: only commits added since the
+	// last scan recorded in it are scanned.
+	State *gitstate.Store
 }
 
 // GitHubExperimentalConfig defines the optional configuration for an experimental GitHub source.
@@ -293,6 +353,297 @@ type GitlabConfig struct {
 	IncludeRepos []string
 	// ExcludeRepos is a list of repositories to exclude from the scan.
 	ExcludeRepos []string
+	// State, if set, makes the scan incremental: only commits added since the
+	// last scan recorded in it are scanned.
+	State *gitstate.Store
+}
+
+// BitbucketConfig defines the optional configuration for a Bitbucket source.
+type BitbucketConfig struct {
+	// Endpoint is the Bitbucket Data Center URL. Empty means Bitbucket Cloud.
+	Endpoint string
+	// Token is an access token to use to authenticate with the source.
+	Token string
+	// OAuthToken is an OAuth access token to use to authenticate with the source.
+	OAuthToken string
+	// Username and Password are basic auth credentials, e.g. an app password.
+	Username, Password string
+	// Repos is the list of repositories to scan.
+	Repos []string
+	// Workspaces is the list of Cloud workspaces or Data Center projects to enumerate.
+	Workspaces []string
+	// Projects limits the scan to repositories in the given project keys.
+	Projects []string
+	// IncludeRepos is a list of repositories to include in the scan.
+	IncludeRepos []string
+	// ExcludeRepos is a list of repositories to exclude from the scan.
+	ExcludeRepos []string
+	// IncludePRComments indicates whether to scan pull request descriptions and comments.
+	IncludePRComments bool
+	// Filter is the filter to use to scan the source.
+	Filter *common.Filter
+	// SkipBinaries allows skipping binary files from the scan.
+	SkipBinaries bool
+}
+
+// AzureReposConfig defines the optional configuration for an Azure Repos source.
+type AzureReposConfig struct {
+	// Endpoint is the Azure DevOps Server URL. Empty means Azure DevOps Services.
+	Endpoint string
+	// Token is a personal access token to use to authenticate with the source.
+	Token string
+	// OAuthToken is an OAuth access token to use to authenticate with the source.
+	OAuthToken string
+	// Repos is the list of repositories to scan.
+	Repos []string
+	// Organizations is the list of organizations (or collections) to enumerate.
+	Organizations []string
+	// Projects is the list of projects to enumerate.
+	Projects []string
+	// IncludeForks indicates whether to include forked repositories in the scan.
+	IncludeForks bool
+	// IncludeRepos is a list of repositories to include in the scan.
+	IncludeRepos []string
+	// ExcludeRepos is a list of repositories to exclude from the scan.
+	ExcludeRepos []string
+	// IncludeProjects is a list of projects to include in the scan.
+	IncludeProjects []string
+	// ExcludeProjects is a list of projects to exclude from the scan.
+	ExcludeProjects []string
+	// IncludePRThreads indicates whether to scan pull request descriptions and threads.
+	IncludePRThreads bool
+	// IncludeWikis indicates whether to scan project wiki repositories.
+	IncludeWikis bool
+	// Filter is the filter to use to scan the source.
+	Filter *common.Filter
+	// SkipBinaries allows skipping binary files from the scan.
+	SkipBinaries bool
+}
+
+// GerritConfig defines the optional configuration for a Gerrit source.
+type GerritConfig struct {
+	// Endpoint is the Gerrit server URL.
+	Endpoint string
+	// Username is the username to authenticate with. Empty means unauthenticated.
+	Username string
+	// Password is the HTTP password of the user.
+	Password string
+	// Projects is the list of projects to scan. Empty means every project.
+	Projects []string
+	// Filter is the filter to use to scan the source.
+	Filter *common.Filter
+	// SkipBinaries allows skipping binary files from the scan.
+	SkipBinaries bool
+}
+
+// JiraConfig defines the optional configuration for a Jira source.
+type JiraConfig struct {
+	// Endpoint is the Jira Cloud or Jira Data Center URL.
+	Endpoint string
+	// Username is the account email (Cloud) or username (Data Center) used
+	// with Token for basic authentication.
+	Username string
+	// Token is an API token when Username is set, otherwise a personal access token.
+	Token string
+	// OAuthToken is an OAuth access token to use to authenticate with the source.
+	OAuthToken string
+	// Projects is a list of project keys or globs to scan. Empty means every project.
+	Projects []string
+	// IgnoreProjects is a list of project keys or globs to skip.
+	IgnoreProjects []string
+	// UpdatedSince limits the scan to issues updated at or after this time.
+	UpdatedSince time.Time
+	// InsecureSkipVerifyTLS disables TLS certificate verification.
+	InsecureSkipVerifyTLS bool
+}
+
+// ConfluenceConfig defines the optional configuration for a Confluence source.
+type ConfluenceConfig struct {
+	// Endpoint is the Confluence Data Center URL, or the /wiki URL of a
+	// Confluence Cloud site.
+	Endpoint string
+	// Username is the account email (Cloud) or username (Data Center) used
+	// with Token for basic authentication.
+	Username string
+	// Token is an API token when Username is set, otherwise a personal access token.
+	Token string
+	// SpacesScope is the type of spaces to scan when Spaces is empty: all,
+	// global or personal.
+	SpacesScope string
+	// Spaces is the list of space keys to scan.
+	Spaces []string
+	// IgnoreSpaces is a list of space keys or globs to skip.
+	IgnoreSpaces []string
+	// IncludeAttachments indicates whether to scan page attachments.
+	IncludeAttachments bool
+	// SkipHistory indicates whether to scan only the current version of pages.
+	SkipHistory bool
+	// InsecureSkipVerifyTLS disables TLS certificate verification.
+	InsecureSkipVerifyTLS bool
+}
+
+// SlackConfig defines the optional configuration for a Slack source.
+type SlackConfig struct {
+	// Endpoint is the Slack Web API URL. Empty means https://slack.com/api.
+	Endpoint string
+	// Token is a Slack user or bot token.
+	Token string
+	// Channels is the list of channel names or IDs to scan. Empty means every channel.
+	Channels []string
+	// IgnoreChannels is the list of channel names or IDs to skip.
+	IgnoreChannels []string
+	// ExportPath is the path of a Slack export ZIP archive to scan instead of the Web API.
+	ExportPath string
+}
+
+// NPMConfig defines the optional configuration for an npm source.
+type NPMConfig struct {
+	// Registry is the npm registry URL. Empty means the public registry.
+	Registry string
+	// Packages is the list of package names to scan.
+	Packages []string
+	// Scopes is the list of scopes whose packages are scanned.
+	Scopes []string
+	// Maintainer is an npm user whose packages are scanned.
+	Maintainer string
+	// Versions limits the scan to these versions or dist-tags. Empty means every version.
+	Versions []string
+}
+
+// PyPIConfig defines the optional configuration for a PyPI source.
+type PyPIConfig struct {
+	// IndexURL is the Simple API root of the package index. Empty means pypi.org.
+	IndexURL string
+	// Projects is the list of projects to scan.
+	Projects []string
+	// Versions limits the scan to these versions. Empty means every release.
+	Versions []string
+}
+
+// ArtifactoryConfig defines the optional configuration for a JFrog Artifactory source.
+type ArtifactoryConfig struct {
+	// Endpoint is the Artifactory URL, including the /artifactory context path.
+	Endpoint string
+	// Username and Password are used for basic authentication.
+	Username string
+	Password string
+	// AccessToken is used for bearer authentication when no username is set.
+	AccessToken string
+	// Repositories is the list of repository keys or globs to scan. Empty means all.
+	Repositories []string
+	// IncludePaths and ExcludePaths are artifact path globs.
+	IncludePaths []string
+	ExcludePaths []string
+	// MaxSize is the maximum artifact size to scan. Zero means no limit.
+	MaxSize int64
+	// ModifiedSince limits the scan to artifacts modified after this time.
+	ModifiedSince time.Time
+}
+
+// AzureStorageConfig defines the optional configuration for an Azure Storage source.
+type AzureStorageConfig struct {
+	// ConnectionString is a storage account connection string.
+	ConnectionString string
+	// Account is the storage account name.
+	Account string
+	// AccountKey is used for Shared Key authentication with Account.
+	AccountKey string
+	// TenantID, ClientID and ClientCertificate, the path of a PEM file with
+	// the certificate and its private key, identify a service principal.
+	TenantID          string
+	ClientID          string
+	ClientCertificate string
+	// Endpoint overrides the blob endpoint, for example for Azurite.
+	Endpoint string
+	// Containers is the list of containers to scan. Empty means all.
+	Containers []string
+	// IgnoreContainers is a list of container globs to skip.
+	IgnoreContainers []string
+	// Prefix limits the scan to blobs whose name starts with it.
+	Prefix string
+	// IncludeBlobs and ExcludeBlobs are blob name globs.
+	IncludeBlobs []string
+	ExcludeBlobs []string
+	// MaxObjectSize is the maximum blob size to scan. Zero means no limit.
+	MaxObjectSize int64
+	// Concurrency is the number of blobs scanned concurrently.
+	Concurrency int
+	// IncludeSnapshots and IncludeVersions also scan blob snapshots and
+	// previous versions.
+	IncludeSnapshots bool
+	IncludeVersions  bool
+}
+
+// KubernetesConfig defines the optional configuration for a Kubernetes source.
+type KubernetesConfig struct {
+	// Kubeconfig is the path of the kubeconfig file. Empty means
+	// ~/.kube/config.
+	Kubeconfig string
+	// Context is the kubeconfig context to use. Empty means the current one.
+	Context string
+	// InCluster uses the service account of the pod trufflehog runs in
+	// instead of a kubeconfig.
+	InCluster bool
+	// Namespaces is the list of namespaces to scan. Empty means all.
+	Namespaces []string
+	// IncludeSecrets also scans the decoded data of Secrets.
+	IncludeSecrets bool
+	// Resources is a list of custom resources to scan, as group/version/resource.
+	Resources []string
+}
+
+// DatabaseConfig defines the optional configuration for a database source.
+type DatabaseConfig struct {
+	// Driver is the type of the database: postgres or mysql.
+	Driver string
+	// ConnectionString is a postgres:// URL or a MySQL DSN.
+	ConnectionString string
+	// IncludeTables limits the scan to tables matching these globs of
+	// schema.table. Empty means every table.
+	IncludeTables []string
+	// ExcludeTables skips tables matching these globs of schema.table.
+	ExcludeTables []string
+	// SampleRows is the maximum number of rows scanned per table. Zero means
+	// every row.
+	SampleRows int64
+	// BatchSize is the number of rows read per query.
+	BatchSize int64
+	// UpdatedColumn is the column holding the last modification time of rows.
+	UpdatedColumn string
+	// UpdatedSince limits the scan to rows whose UpdatedColumn is after it.
+	UpdatedSince time.Time
+}
+
+// BuildkiteConfig defines the optional configuration for a Buildkite source.
+type BuildkiteConfig struct {
+	// Token is the Buildkite API access token.
+	Token string
+	// Organizations are the slugs of the organizations to scan. Empty means
+	// every organization the token can access.
+	Organizations []string
+	// Pipelines limits the scan to pipelines matching these globs of
+	// org/pipeline. Empty means every pipeline.
+	Pipelines []string
+	// IgnorePipelines skips pipelines matching these globs of org/pipeline.
+	IgnorePipelines []string
+	// CreatedSince limits the scan to builds created after it.
+	CreatedSince time.Time
+	// SkipArtifacts disables the download of build artifacts.
+	SkipArtifacts bool
+}
+
+// SentryConfig defines the optional configuration for a Sentry source.
+type SentryConfig struct {
+	// Endpoint is the Sentry URL, sentry.io or a self-hosted instance.
+	Endpoint string
+	// AuthToken is a Sentry auth token with the event:read, project:read
+	// and org:read scopes.
+	AuthToken string
+	// Projects are the slugs, or org/project, of the projects to scan. Empty
+	// means every project the token can access.
+	Projects []string
+	// InsecureSkipVerifyTLS disables TLS certificate verification.
+	InsecureSkipVerifyTLS bool
 }
 
 // FilesystemConfig defines the optional configuration for a filesystem source.
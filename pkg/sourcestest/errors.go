@@ -0,0 +1,89 @@
+package sourcestest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// APIErrorTest checks how a source handles a failing API, given a scan that
+// reads two pages of a listing and an item listed on the first page.
+type APIErrorTest struct {
+	// Setup starts a server for the full scan and returns the scan, which
+	// returns the data and errors the source reports.
+	Setup func(t *testing.T) (*Server, func(t *testing.T) ([]string, []error))
+	// First is the request key of the first request of the scan, LastPage of
+	// the last page of the listing and Item of the item.
+	First, LastPage, Item string
+	// All is the data of the full scan, WithoutItem the data when the item is
+	// forbidden and WithoutLastPage the data when the last page is truncated.
+	All, WithoutItem, WithoutLastPage []string
+}
+
+// Run checks that a rejected credential stops the scan with an error, that a
+// forbidden item is reported and skipped, that a rate limited page is
+// retried and that a truncated last page is reported.
+func (a APIErrorTest) Run(t *testing.T) {
+	t.Helper()
+	tests := []struct {
+		name    string
+		key     string
+		failure *Failure
+		body    string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:    "unauthorized",
+			key:     a.First,
+			failure: &Failure{Status: http.StatusUnauthorized},
+			wantErr: "401",
+		},
+		{
+			name:    "forbidden item",
+			key:     a.Item,
+			failure: &Failure{Status: http.StatusForbidden},
+			want:    a.WithoutItem,
+			wantErr: "status code 403",
+		},
+		{
+			name: "rate limited",
+			key:  a.LastPage,
+			failure: &Failure{
+				Status: http.StatusTooManyRequests,
+				Header: http.Header{"Retry-After": {"0"}},
+				Times:  1,
+			},
+			want: a.All,
+		},
+		{
+			name:    "truncated last page",
+			key:     a.LastPage,
+			body:    `{"trunc`,
+			want:    a.WithoutLastPage,
+			wantErr: "failed to decode",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, scan := a.Setup(t)
+			if tt.failure != nil {
+				srv.Fail(tt.key, *tt.failure)
+			} else {
+				srv.Responses[tt.key] = tt.body
+			}
+
+			data, errs := scan(t)
+			assert.Equal(t, tt.want, data)
+			if tt.wantErr == "" {
+				assert.Empty(t, errs)
+				return
+			}
+			require.Len(t, errs, 1)
+			assert.ErrorContains(t, errs[0], tt.wantErr)
+		})
+	}
+}
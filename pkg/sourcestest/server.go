@@ -0,0 +1,197 @@
+package sourcestest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Server is an HTTP server that answers requests with canned responses, for
+// testing sources against a fake API.
+type Server struct {
+	*httptest.Server
+
+	// Responses maps a request key, by default the request path, to its
+	// response. A string is written as is, with the literal "SERVER" replaced
+	// by the server URL; anything else is encoded as JSON. Responses may be
+	// added once the server is running, but not while it serves requests.
+	Responses map[string]any
+
+	authorized func(*http.Request) bool
+	key        func(*http.Request) string
+	prefix     string
+	pageParam  string
+	pageValue  string
+	pageHeader func(next string, more bool) http.Header
+	routes     map[string]http.HandlerFunc
+
+	mu       sync.Mutex
+	requests []*url.URL
+	failures map[string][]Failure
+}
+
+// Failure is a response served in place of a canned response.
+type Failure struct {
+	Status int
+	Header http.Header
+	// Times is how many requests fail. Zero fails every request.
+	Times int
+}
+
+// ServerOption configures a Server.
+type ServerOption func(*Server)
+
+// WithAuth rejects requests for which authorized returns false with 401
+// Unauthorized.
+func WithAuth(authorized func(*http.Request) bool) ServerOption {
+	return func(s *Server) { s.authorized = authorized }
+}
+
+// BearerAuth accepts requests that carry the bearer token.
+func BearerAuth(token string) func(*http.Request) bool {
+	return func(r *http.Request) bool { return r.Header.Get("Authorization") == "Bearer "+token }
+}
+
+// BasicAuth accepts requests that carry the username and password.
+func BasicAuth(username, password string) func(*http.Request) bool {
+	return func(r *http.Request) bool {
+		user, pass, _ := r.BasicAuth()
+		return user == username && pass == password
+	}
+}
+
+// WithKey looks responses up by key(r), falling back to the request path.
+func WithKey(key func(*http.Request) string) ServerOption {
+	return func(s *Server) { s.key = key }
+}
+
+// WithPrefix writes prefix before every response.
+func WithPrefix(prefix string) ServerOption {
+	return func(s *Server) { s.prefix = prefix }
+}
+
+// WithPages serves the response keyed by "<path>?<param>=<value>" as the
+// second page of <path>. Every response gets the headers returned by header,
+// given the URL of the second page and whether it is still to come.
+func WithPages(param, value string, header func(next string, more bool) http.Header) ServerOption {
+	return func(s *Server) {
+		s.pageParam, s.pageValue, s.pageHeader = param, value, header
+	}
+}
+
+// WithRoute serves path with handler, without authorization.
+func WithRoute(path string, handler http.HandlerFunc) ServerOption {
+	return func(s *Server) { s.routes[path] = handler }
+}
+
+// NewServer starts a Server that is closed when the test finishes.
+func NewServer(t *testing.T, responses map[string]any, opts ...ServerOption) *Server {
+	t.Helper()
+	if responses == nil {
+		responses = make(map[string]any)
+	}
+	s := &Server{
+		Responses: responses,
+		key:       func(r *http.Request) string { return r.URL.Path },
+		routes:    make(map[string]http.HandlerFunc),
+		failures:  make(map[string][]Failure),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.Server = httptest.NewServer(s)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Fail serves failure for the requests with key, ahead of its canned
+// response. Failures for the same key are served in the order they are added.
+func (s *Server) Fail(key string, failure Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[key] = append(s.failures[key], failure)
+}
+
+// Requests returns the URLs of the authorized requests served so far.
+func (s *Server) Requests() []*url.URL {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*url.URL(nil), s.requests...)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if handler, ok := s.routes[r.URL.Path]; ok {
+		handler(w, r)
+		return
+	}
+	if s.authorized != nil && !s.authorized(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	key := s.key(r)
+	nextKey := ""
+	if s.pageParam != "" {
+		if r.URL.Query().Get(s.pageParam) == s.pageValue {
+			key = r.URL.Path + "?" + s.pageParam + "=" + s.pageValue
+		} else {
+			nextKey = r.URL.Path + "?" + s.pageParam + "=" + s.pageValue
+		}
+	}
+
+	if failure, ok := s.record(r, key); ok {
+		for name, values := range failure.Header {
+			w.Header()[name] = values
+		}
+		w.WriteHeader(failure.Status)
+		return
+	}
+
+	resp, ok := s.Responses[key]
+	if !ok {
+		resp, ok = s.Responses[r.URL.Path]
+	}
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if s.pageHeader != nil {
+		_, more := s.Responses[nextKey]
+		next := s.URL + r.URL.Path + "?" + s.pageParam + "=" + s.pageValue
+		for name, values := range s.pageHeader(next, more) {
+			w.Header()[name] = values
+		}
+	}
+
+	_, _ = w.Write([]byte(s.prefix))
+	if body, isString := resp.(string); isString {
+		_, _ = w.Write([]byte(strings.ReplaceAll(body, "SERVER", s.URL)))
+		return
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// record notes the request and returns the failure to serve for key, if any.
+func (s *Server) record(r *http.Request, key string) (Failure, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u := *r.URL
+	s.requests = append(s.requests, &u)
+
+	queue := s.failures[key]
+	if len(queue) == 0 {
+		return Failure{}, false
+	}
+	failure := queue[0]
+	if failure.Times > 0 {
+		queue[0].Times--
+		if queue[0].Times == 0 {
+			s.failures[key] = queue[1:]
+		}
+	}
+	return failure, true
+}
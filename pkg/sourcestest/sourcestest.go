@@ -2,6 +2,7 @@ package sourcestest
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
@@ -18,31 +19,53 @@ var (
 )
 
 // TestReporter is a helper struct that implements both UnitReporter and
-// ChunkReporter by simply recording the values passed in the methods.
+// ChunkReporter by simply recording the values passed in the methods. It is
+// safe for concurrent use.
 type TestReporter struct {
 	Units     []sources.SourceUnit
 	UnitErrs  []error
 	Chunks    []sources.Chunk
 	ChunkErrs []error
+
+	mu sync.Mutex
 }
 
 func (t *TestReporter) UnitOk(_ context.Context, unit sources.SourceUnit) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	t.Units = append(t.Units, unit)
 	return nil
 }
 func (t *TestReporter) UnitErr(_ context.Context, err error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	t.UnitErrs = append(t.UnitErrs, err)
 	return nil
 }
 func (t *TestReporter) ChunkOk(_ context.Context, chunk sources.Chunk) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	t.Chunks = append(t.Chunks, chunk)
 	return nil
 }
 func (t *TestReporter) ChunkErr(_ context.Context, err error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	t.ChunkErrs = append(t.ChunkErrs, err)
 	return nil
 }
 
+// ChunkData returns the data of the chunks reported so far as strings.
+func (t *TestReporter) ChunkData() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var data []string
+	for _, c := range t.Chunks {
+		data = append(data, string(c.Data))
+	}
+	return data
+}
+
 // ErrReporter implements UnitReporter and ChunkReporter but always returns an
 // error.
 type ErrReporter struct{}